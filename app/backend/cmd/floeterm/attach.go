@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/coder/websocket"
+	"golang.org/x/term"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// Terminal attachment from the command line: `floeterm attach <session-id>`
+// puts the local TTY into raw mode and bridges it over the livev1 websocket,
+// propagating window size changes via SIGWINCH — the feel of `tmux attach`
+// against a floeterm server. Ctrl-] detaches without touching the session.
+
+// attachDetachByte is the local escape (Ctrl-], as in telnet) that detaches
+// the CLI while leaving the session running.
+const attachDetachByte = 0x1d
+
+func runAttachCommand(args []string) int {
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	serverURL := fs.String("server", "", "base URL of the running server (default: derived from the addr setting)")
+	token := fs.String("token", "", "bearer token (default: auth-token from environment or config)")
+	configPath := fs.String("config", "", "config file to read the server address and token from")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: floeterm attach [flags] <session-id>")
+		return 2
+	}
+	sessionID := fs.Arg(0)
+
+	client, err := newAPIClient(*configPath, *serverURL, *token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dialURL := "ws" + strings.TrimPrefix(client.base, "http") + "/ws"
+	if client.token != "" {
+		dialURL += "?token=" + url.QueryEscape(client.token)
+	}
+	conn, _, err := websocket.Dial(ctx, dialURL, &websocket.DialOptions{
+		Subprotocols: []string{"floeterm.v1"},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to %s: %v\n", client.base, err)
+		return 1
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(16 << 20)
+
+	stdinFD := int(os.Stdin.Fd())
+	cols, rows := uint32(80), uint32(24)
+	if w, h, err := term.GetSize(stdinFD); err == nil && w > 0 && h > 0 {
+		cols, rows = uint32(w), uint32(h)
+	}
+
+	idBuf := make([]byte, 8)
+	if _, err := rand.Read(idBuf); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to mint connection id: %v\n", err)
+		return 1
+	}
+
+	// The websocket allows one concurrent writer; input, resize and the
+	// attach frame all funnel through writeFrame.
+	var writeMu sync.Mutex
+	writeFrame := func(frame []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.Write(ctx, websocket.MessageBinary, frame)
+	}
+
+	attachFrame, err := livev1.EncodeAttach(livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             cols,
+		Rows:             rows,
+		SessionID:        sessionID,
+		ConnectionID:     "cli-" + hex.EncodeToString(idBuf),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := writeFrame(attachFrame); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to attach: %v\n", err)
+		return 1
+	}
+
+	interactive := term.IsTerminal(stdinFD)
+	if interactive {
+		fmt.Fprintln(os.Stderr, "attached; press Ctrl-] to detach")
+		oldState, err := term.MakeRaw(stdinFD)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to set raw mode: %v\n", err)
+			return 1
+		}
+		defer term.Restore(stdinFD, oldState)
+	}
+
+	// Input pump: local keystrokes become Input frames until the escape byte.
+	var sequence uint64
+	go func() {
+		defer cancel()
+		buf := make([]byte, 4096)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				data := buf[:n]
+				if interactive {
+					if escape := bytes.IndexByte(data, attachDetachByte); escape >= 0 {
+						data = data[:escape]
+					}
+				}
+				if len(data) > 0 {
+					sequence++
+					frame, err := livev1.EncodeInput(livev1.Input{Sequence: sequence, Data: data})
+					if err != nil || writeFrame(frame) != nil {
+						return
+					}
+				}
+				if len(data) < n {
+					return // escape byte seen: detach
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// Resize pump: forward SIGWINCH as Resize frames.
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	go func() {
+		for range winch {
+			w, h, err := term.GetSize(stdinFD)
+			if err != nil || w <= 0 || h <= 0 {
+				continue
+			}
+			sequence++
+			frame, err := livev1.EncodeResize(livev1.Resize{Sequence: sequence, Cols: uint32(w), Rows: uint32(h)})
+			if err != nil || writeFrame(frame) != nil {
+				return
+			}
+		}
+	}()
+
+	decoder := livev1.NewDecoder()
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintln(os.Stderr, "\r\ndetached")
+				return 0
+			}
+			fmt.Fprintf(os.Stderr, "\r\nconnection closed: %v\n", err)
+			return 1
+		}
+		frames, err := decoder.Push(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\r\nprotocol error: %v\n", err)
+			return 1
+		}
+		for _, frame := range frames {
+			switch frame.Type {
+			case livev1.FrameOutputBatch:
+				batch, err := livev1.DecodeOutputBatch(frame)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "\r\nprotocol error: %v\n", err)
+					return 1
+				}
+				for _, record := range batch.Records {
+					if _, err := os.Stdout.Write(record.Data); err != nil {
+						return 1
+					}
+				}
+			case livev1.FrameSessionClosed:
+				fmt.Fprintln(os.Stderr, "\r\nsession closed")
+				return 0
+			case livev1.FrameError:
+				// Error payload: uint16 code, then a length-prefixed message.
+				message := "protocol error"
+				if len(frame.Payload) > 4 {
+					message = string(frame.Payload[4:])
+				}
+				fmt.Fprintf(os.Stderr, "\r\nserver error: %s\n", message)
+				return 1
+			default:
+				// Attached, resize acks, geometry and presence frames need no
+				// action here; the local TTY is the source of truth for size.
+			}
+		}
+	}
+}
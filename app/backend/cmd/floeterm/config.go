@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Configuration file support: -config floeterm.yaml reads a flat YAML
+// document whose keys are exactly the flag names, so every current and
+// future flag is automatically configurable without a second schema:
+//
+//	addr: :8443
+//	auth-token: secret
+//	tls-cert: /etc/floeterm/cert.pem
+//	ratelimit-input-bps: 65536
+//
+// Only the flat `key: value` subset of YAML is supported (comments and
+// quoted values included); that keeps the parser dependency-free, matching
+// how the server hand-rolls its msgpack codec. Precedence, highest first:
+// flags given on the command line, FLOETERM_* environment variables
+// (FLOETERM_AUTH_TOKEN overrides auth-token), then the config file.
+
+// loadConfigValues parses the flat YAML config file into flag-name keys.
+func loadConfigValues(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected `key: value`, got %q", i+1, trimmed)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid key %q", i+1, key)
+		}
+		if len(value) >= 2 {
+			if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+				value = value[1 : len(value)-1]
+			}
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", i+1, key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// envVarForFlag maps a flag name to its override variable: FLOETERM_ plus the
+// flag name uppercased with dashes as underscores.
+func envVarForFlag(name string) string {
+	return "FLOETERM_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyConfig layers the config file and FLOETERM_* environment variables
+// under any flags given explicitly on the command line. It must run after
+// flags.Parse so explicit flags can be told apart from defaults.
+func applyConfig(flags *flag.FlagSet, configPath string) error {
+	var fileValues map[string]string
+	if configPath != "" {
+		var err error
+		fileValues, err = loadConfigValues(configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	known := make(map[string]bool)
+	explicit := make(map[string]bool)
+	flags.VisitAll(func(f *flag.Flag) { known[f.Name] = true })
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for key := range fileValues {
+		if !known[key] || key == "config" {
+			return fmt.Errorf("unknown config key %q", key)
+		}
+	}
+
+	var applyErr error
+	flags.VisitAll(func(f *flag.Flag) {
+		if applyErr != nil || explicit[f.Name] || f.Name == "config" {
+			return
+		}
+		if value, ok := os.LookupEnv(envVarForFlag(f.Name)); ok {
+			if err := flags.Set(f.Name, value); err != nil {
+				applyErr = fmt.Errorf("invalid %s: %w", envVarForFlag(f.Name), err)
+			}
+			return
+		}
+		if value, ok := fileValues[f.Name]; ok {
+			if err := flags.Set(f.Name, value); err != nil {
+				applyErr = fmt.Errorf("invalid config key %q: %w", f.Name, err)
+			}
+		}
+	})
+	return applyErr
+}
+
+// runConfigCommand implements `floeterm config validate [file]`: it parses
+// the file, checks every key against the flag surface and every value against
+// its flag's parser, and reports without starting the server.
+func runConfigCommand(flags *flag.FlagSet, args []string) int {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "usage: floeterm config validate [file]")
+		return 2
+	}
+	path := "floeterm.yaml"
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	values, err := loadConfigValues(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	known := make(map[string]bool)
+	flags.VisitAll(func(f *flag.Flag) { known[f.Name] = true })
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] || key == "config" {
+			fmt.Fprintf(os.Stderr, "unknown config key %q\n", key)
+			return 1
+		}
+		// The process exits after validation, so setting the real flags is
+		// harmless and reuses each flag's own value parser.
+		if err := flags.Set(key, values[key]); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config key %q: %v\n", key, err)
+			return 1
+		}
+	}
+	fmt.Printf("%s: %d settings OK\n", path, len(values))
+	return 0
+}
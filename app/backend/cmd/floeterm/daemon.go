@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Background mode: `floeterm serve -daemon [-pidfile path]` re-executes the
+// binary detached from the controlling terminal and records its pid, and
+// `floeterm stop` reads the pidfile and terminates that process. This covers
+// quick setups that have no service manager wired up; anything long-lived
+// should still run under one.
+
+// daemonEnvVar marks the re-executed child so it does not detach again.
+const daemonEnvVar = "FLOETERM_DAEMONIZED"
+
+func defaultPidfilePath() string {
+	return filepath.Join(os.TempDir(), "floeterm.pid")
+}
+
+// daemonize re-executes the current command line in a new session with stdio
+// on /dev/null, writes the child's pid, reports it, and exits the foreground
+// process. It never returns.
+func daemonize(pidfile string) {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot resolve executable path: %v\n", err)
+		os.Exit(1)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot open %s: %v\n", os.DevNull, err)
+		os.Exit(1)
+	}
+	defer devNull.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), daemonEnvVar+"=1")
+	cmd.Stdin = devNull
+	cmd.Stdout = devNull
+	cmd.Stderr = devNull
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start background process: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writePidfile(pidfile, cmd.Process.Pid); err != nil {
+		_ = cmd.Process.Kill()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("floeterm running in background (pid %d, pidfile %s)\n", cmd.Process.Pid, pidfile)
+	os.Exit(0)
+}
+
+// writePidfile records pid, refusing to clobber a pidfile whose process is
+// still alive. A stale pidfile left by a crash is replaced silently.
+func writePidfile(pidfile string, pid int) error {
+	if existing, err := readPidfile(pidfile); err == nil && processAlive(existing) {
+		return fmt.Errorf("floeterm already running (pid %d per %s); use `floeterm stop` first", existing, pidfile)
+	}
+	if err := os.WriteFile(pidfile, []byte(strconv.Itoa(pid)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	return nil
+}
+
+func readPidfile(pidfile string) (int, error) {
+	data, err := os.ReadFile(pidfile)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("pidfile %s does not contain a pid", pidfile)
+	}
+	return pid, nil
+}
+
+// processAlive reports whether a process with the pid exists. Signal 0
+// performs the existence check without delivering anything; EPERM still means
+// the process is there.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// runStopCommand implements `floeterm stop [-pidfile path]`: signal the
+// recorded process with SIGTERM and wait for it to exit.
+func runStopCommand(args []string) int {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	pidfile := fs.String("pidfile", defaultPidfilePath(), "pidfile written by the running server")
+	fs.Parse(args)
+
+	pid, err := readPidfile(*pidfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read pidfile %s: %v\n", *pidfile, err)
+		return 1
+	}
+	if !processAlive(pid) {
+		fmt.Fprintf(os.Stderr, "no process with pid %d; removing stale pidfile\n", pid)
+		_ = os.Remove(*pidfile)
+		return 1
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to signal pid %d: %v\n", pid, err)
+		return 1
+	}
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if !processAlive(pid) {
+			// The server removes its own pidfile on a clean shutdown; clear
+			// it here too in case it was killed mid-write.
+			_ = os.Remove(*pidfile)
+			fmt.Printf("floeterm stopped (pid %d)\n", pid)
+			return 0
+		}
+	}
+	fmt.Fprintf(os.Stderr, "pid %d did not exit within 10s\n", pid)
+	return 1
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/creack/pty"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// runDoctorCommand implements `floeterm doctor`: local environment checks for
+// the handful of things most support issues boil down to — shell resolution,
+// /etc/passwd access, PTY allocation, shell-init directory writability,
+// terminfo presence and static dir resolution.
+func runDoctorCommand(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	staticDir := fs.String("static", "", "static dir to check instead of auto-detecting one")
+	_ = fs.Parse(args)
+
+	failures := 0
+	report := func(status string, name string, detail string, hint string) {
+		if status == "FAIL" {
+			failures++
+		}
+		fmt.Printf("%-16s %-4s %s\n", name, status, detail)
+		if status != "ok" && hint != "" {
+			fmt.Printf("%-16s      hint: %s\n", "", hint)
+		}
+	}
+
+	shell := terminal.DefaultShellResolver{}.ResolveShell(terminal.NopLogger{})
+	if info, err := os.Stat(shell); err != nil {
+		report("FAIL", "shell", fmt.Sprintf("%s: %v", shell, err),
+			"set $SHELL to an installed shell or start the server with -shell")
+	} else if info.Mode()&0111 == 0 {
+		report("FAIL", "shell", shell+": not executable",
+			"set $SHELL to an installed shell or start the server with -shell")
+	} else {
+		report("ok", "shell", shell, "")
+	}
+
+	if f, err := os.Open("/etc/passwd"); err != nil {
+		report("warn", "passwd", fmt.Sprintf("%v", err),
+			"shell lookup falls back to $SHELL and common shell paths")
+	} else {
+		f.Close()
+		report("ok", "passwd", "/etc/passwd readable", "")
+	}
+
+	if ptmx, tty, err := pty.Open(); err != nil {
+		report("FAIL", "pty", fmt.Sprintf("%v", err),
+			"check that /dev/ptmx exists and devpts is mounted")
+	} else {
+		tty.Close()
+		ptmx.Close()
+		report("ok", "pty", "PTY pair allocated", "")
+	}
+
+	initDir := terminal.DefaultShellInitBaseDir()
+	if err := os.MkdirAll(initDir, 0o755); err != nil {
+		report("FAIL", "shell-init", fmt.Sprintf("%s: %v", initDir, err),
+			"shell integration needs a writable init directory; fix permissions or HOME")
+	} else if probe, err := os.CreateTemp(initDir, ".doctor-*"); err != nil {
+		report("FAIL", "shell-init", fmt.Sprintf("%s: %v", initDir, err),
+			"shell integration needs a writable init directory; fix permissions or HOME")
+	} else {
+		probe.Close()
+		os.Remove(probe.Name())
+		report("ok", "shell-init", initDir+" writable", "")
+	}
+
+	if dir := findTerminfoDir(); dir != "" {
+		report("ok", "terminfo", dir, "")
+	} else {
+		report("warn", "terminfo", "no terminfo database found",
+			"install ncurses terminfo so full-screen programs render correctly")
+	}
+
+	dist := *staticDir
+	if dist == "" {
+		dist = findDefaultStaticDir()
+	}
+	if dist == "" {
+		report("warn", "static", "no app/web dist found (API-only mode)",
+			"run `make app-web-build` or pass -static to serve the web UI")
+	} else if info, err := os.Stat(filepath.Join(dist, "index.html")); err != nil || info.IsDir() {
+		report("warn", "static", dist+": missing index.html",
+			"run `make app-web-build` or pass -static to serve the web UI")
+	} else {
+		report("ok", "static", dist, "")
+	}
+
+	if failures > 0 {
+		fmt.Printf("\n%d check(s) failed\n", failures)
+		return 1
+	}
+	return 0
+}
+
+// findTerminfoDir returns the first terminfo database location that exists,
+// following the usual curses search order.
+func findTerminfoDir() string {
+	candidates := []string{os.Getenv("TERMINFO")}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		candidates = append(candidates, filepath.Join(home, ".terminfo"))
+	}
+	candidates = append(candidates,
+		"/usr/share/terminfo",
+		"/lib/terminfo",
+		"/etc/terminfo",
+		"/usr/local/share/terminfo",
+	)
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
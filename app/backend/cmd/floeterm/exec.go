@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// Scripted orchestration of live terminals: `floeterm exec <session-id> --
+// <command>` types the command into the target session as if a user pressed
+// the keys, and with -wait watches the shell's command lifecycle markers (the
+// foregroundCommand phase the session already tracks) until the command
+// finishes. One-shot commands that need no session belong on /api/exec.
+
+func runExecCommand(args []string) int {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	serverURL := fs.String("server", "", "base URL of the running server (default: derived from the addr setting)")
+	token := fs.String("token", "", "bearer token (default: auth-token from environment or config)")
+	configPath := fs.String("config", "", "config file to read the server address and token from")
+	wait := fs.Bool("wait", false, "wait for the shell's command-finished marker before returning")
+	waitTimeout := fs.Duration("wait-timeout", 60*time.Second, "how long -wait watches for the command to finish")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "usage: floeterm exec [flags] <session-id> -- <command>")
+		return 2
+	}
+	sessionID := fs.Arg(0)
+	commandArgs := fs.Args()[1:]
+	// Flag parsing stops at the session id, so the conventional "--" before
+	// the command is still present; drop it rather than typing it.
+	if commandArgs[0] == "--" {
+		commandArgs = commandArgs[1:]
+	}
+	command := strings.Join(commandArgs, " ")
+	if strings.TrimSpace(command) == "" {
+		fmt.Fprintln(os.Stderr, "usage: floeterm exec [flags] <session-id> -- <command>")
+		return 2
+	}
+
+	client, err := newAPIClient(*configPath, *serverURL, *token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var startRevision uint64
+	if *wait {
+		info, err := fetchSession(client, sessionID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		startRevision = info.ForegroundCommand.Revision
+	}
+
+	if err := typeIntoSession(client, sessionID, command+"\r"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !*wait {
+		return 0
+	}
+
+	// The lifecycle markers come from the shell integration (command start
+	// flips the phase to running, command end back to idle, each bumping the
+	// revision), so "finished" is an idle phase on a newer revision.
+	sawRunning := false
+	for deadline := time.Now().Add(*waitTimeout); time.Now().Before(deadline); time.Sleep(200 * time.Millisecond) {
+		info, err := fetchSession(client, sessionID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		phase := info.ForegroundCommand.Phase
+		revision := info.ForegroundCommand.Revision
+		if revision > startRevision && phase == "running" {
+			sawRunning = true
+		}
+		if phase == "idle" && (sawRunning || revision > startRevision) {
+			return 0
+		}
+	}
+	fmt.Fprintf(os.Stderr, "timed out after %s waiting for the command to finish\n", *waitTimeout)
+	return 1
+}
+
+// typeIntoSession attaches over the livev1 websocket just long enough to
+// deliver text as terminal input, then detaches cleanly.
+func typeIntoSession(client *apiClient, sessionID, text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dialURL := "ws" + strings.TrimPrefix(client.base, "http") + "/ws"
+	if client.token != "" {
+		dialURL += "?token=" + url.QueryEscape(client.token)
+	}
+	conn, _, err := websocket.Dial(ctx, dialURL, &websocket.DialOptions{
+		Subprotocols: []string{"floeterm.v1"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", client.base, err)
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(16 << 20)
+
+	idBuf := make([]byte, 8)
+	if _, err := rand.Read(idBuf); err != nil {
+		return fmt.Errorf("failed to mint connection id: %w", err)
+	}
+	attachFrame, err := livev1.EncodeAttach(livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        sessionID,
+		ConnectionID:     "exec-" + hex.EncodeToString(idBuf),
+	})
+	if err != nil {
+		return err
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, attachFrame); err != nil {
+		return fmt.Errorf("failed to attach: %w", err)
+	}
+
+	// Wait for the attach confirmation so a rejected attach surfaces as an
+	// error instead of input silently going nowhere.
+	decoder := livev1.NewDecoder()
+attached:
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return fmt.Errorf("attach failed: %w", err)
+		}
+		frames, err := decoder.Push(data)
+		if err != nil {
+			return err
+		}
+		for _, frame := range frames {
+			switch frame.Type {
+			case livev1.FrameAttached:
+				break attached
+			case livev1.FrameError:
+				message := "attach rejected"
+				if len(frame.Payload) > 4 {
+					message = string(frame.Payload[4:])
+				}
+				return fmt.Errorf("attach failed: %s", message)
+			}
+		}
+	}
+
+	inputFrame, err := livev1.EncodeInput(livev1.Input{Sequence: 1, Data: []byte(text)})
+	if err != nil {
+		return err
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, inputFrame); err != nil {
+		return fmt.Errorf("failed to send input: %w", err)
+	}
+	detachFrame, err := livev1.EncodeFrame(livev1.Frame{Type: livev1.FrameDetach})
+	if err != nil {
+		return err
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, detachFrame); err != nil {
+		return fmt.Errorf("failed to detach: %w", err)
+	}
+	return conn.Close(websocket.StatusNormalClosure, "")
+}
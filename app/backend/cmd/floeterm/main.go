@@ -1,29 +1,287 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/floegence/floeterm/app/backend/internal/grpcapi"
 	"github.com/floegence/floeterm/app/backend/internal/server"
 	terminal "github.com/floegence/floeterm/terminal-go"
 )
 
 func main() {
+	// Sandboxed sessions re-exec this binary to apply Landlock/seccomp
+	// between fork and exec; the helper invocation never returns.
+	terminal.MaybeRunSandboxHelper()
+	// Remote SSH sessions re-exec this binary as the PTY child that bridges
+	// to the remote machine; likewise never returns when invoked as such.
+	server.MaybeRunSSHRemoteHelper()
+	// Docker-backed sessions re-exec this binary as the PTY child that
+	// bridges to an exec TTY in the container.
+	server.MaybeRunDockerExecHelper()
+	// Tmux-backed sessions re-exec this binary as the PTY child that bridges
+	// to a pane over tmux control mode.
+	server.MaybeRunTmuxPaneHelper()
+
 	var addr string
 	var staticDir string
+	var basePath string
 	var logLevel string
+	var logFormat string
 	var performanceDiagnostics bool
-	flag.StringVar(&addr, "addr", ":8080", "HTTP listen address")
+	var authToken string
+	var authTokenFile string
+	var basicAuthUser string
+	var basicAuthHash string
+	var authUsersSpec string
+	var acmeDomain string
+	var acmeCacheDir string
+	var acmeEmail string
+	var allowedOrigins string
+	var grpcAddr string
+	var tlsCert string
+	var tlsKey string
+	var tlsClientCA string
+	var rateLimitInputBPS int
+	var rateLimitRequestsPerMinute int
+	var rateLimitWSConnectsPerMinute int
+	var rateLimitRouteOverrides string
+	var maxWSPerIP int
+	var maxSessionsPerIP int
+	var trustedProxies string
+	var wsCompression string
+	var wsCompressionThreshold int
+	var wsPingInterval time.Duration
+	var wsPingTimeout time.Duration
+	var historyChunks int
+	var historyBytes int64
+	var maxSessions int
+	var idleTimeout time.Duration
+	var inputWindow time.Duration
+	var shellOverride string
+	var commandOverride string
+	var envDeny string
+	var envAllow string
+	var isolate string
+	var chrootDir string
+	var chrootHome bool
+	var sandboxRO string
+	var sandboxRW string
+	var sandboxDenySyscalls bool
+	var allowCommands string
+	var denyCommands string
+	var csp string
+	var frameAncestors string
+	var referrerPolicy string
+	var noSecurityHeaders bool
+	var readonly bool
+	var listenLAN bool
+	var traceSpans bool
+	var traceFile string
+	var webhookURL string
+	var webhookSecret string
+	var webhookEvents string
+	var redisBridgeAddr string
+	var redisBridgePassword string
+	var redisBridgePrefix string
+	var auditLogPath string
+	var sshAddr string
+	var sshHostKeyPath string
+	var sshAuthorizedKeys string
+	var tunnelProvider string
+	var exitWhenIdle time.Duration
+	var configPath string
+	var openBrowser bool
+	var daemonMode bool
+	var pidfilePath string
+	flag.StringVar(&configPath, "config", "", "flat YAML config file whose keys are flag names; flags override it, FLOETERM_* env vars sit in between")
+	flag.BoolVar(&openBrowser, "open", false, "open the local access URL in the default browser once the server is listening")
+	flag.BoolVar(&daemonMode, "daemon", false, "detach from the terminal and run in the background (see `floeterm stop`)")
+	flag.StringVar(&pidfilePath, "pidfile", "", "write the server pid to this file (default with -daemon: $TMPDIR/floeterm.pid)")
+	flag.StringVar(&addr, "addr", "", "HTTP listen address (default 127.0.0.1:8080, or :8080 with -listen-lan)")
+	flag.BoolVar(&listenLAN, "listen-lan", false, "listen on all interfaces instead of loopback only (requires authentication)")
+	flag.BoolVar(&listenLAN, "allow-remote", false, "alias for -listen-lan")
 	flag.StringVar(&staticDir, "static", "", "path to app/web dist directory")
+	flag.StringVar(&basePath, "base-path", "", "mount all routes under this URL prefix (e.g. /floeterm) for reverse-proxy subpath deployments")
 	flag.StringVar(&logLevel, "log-level", "info", "log level: debug|info|warn|error")
+	flag.StringVar(&logFormat, "log-format", "text", "log format: text|json")
 	flag.BoolVar(&performanceDiagnostics, "performance-diagnostics", false, "enable loopback performance diagnostics endpoint")
-	flag.Parse()
+	var noAuth bool
+	var authPAM bool
+	flag.StringVar(&authToken, "auth-token", "", "bearer token required on /api and /ws requests")
+	flag.BoolVar(&noAuth, "no-auth", false, "serve without authentication instead of generating a startup token")
+	flag.BoolVar(&authPAM, "auth-pam", false, "authenticate with local system accounts (username/password via PAM) and run each session as the authenticated user; requires root")
+	flag.StringVar(&authTokenFile, "auth-token-file", "", "file containing the bearer token (alternative to -auth-token)")
+	flag.StringVar(&basicAuthUser, "basic-auth-user", "", "username for HTTP basic auth (requires -basic-auth-password-hash)")
+	flag.StringVar(&basicAuthHash, "basic-auth-password-hash", "", "bcrypt hash of the basic auth password")
+	flag.StringVar(&authUsersSpec, "auth-users", "", "comma-separated per-user tokens: name:token[:admin],...")
+	flag.StringVar(&acmeDomain, "acme-domain", "", "serve HTTPS with a Let's Encrypt certificate for this domain")
+	flag.StringVar(&acmeCacheDir, "acme-cache-dir", "", "directory for cached ACME certificates (default: user cache dir)")
+	flag.StringVar(&acmeEmail, "acme-email", "", "contact email for the ACME account (optional)")
+	flag.StringVar(&grpcAddr, "grpc-addr", "", "serve the gRPC API on this address (e.g. :9090); disabled when empty")
+	flag.StringVar(&allowedOrigins, "allowed-origins", "", "comma-separated origin host patterns allowed to open websockets in addition to same-origin (e.g. *.example.com)")
+	flag.StringVar(&tlsCert, "tls-cert", "", "serve HTTPS with this certificate file (requires -tls-key)")
+	flag.StringVar(&tlsKey, "tls-key", "", "private key file for -tls-cert")
+	flag.StringVar(&tlsClientCA, "tls-client-ca", "", "require client certificates signed by this CA file; the cert CN becomes the user identity")
+	flag.IntVar(&rateLimitInputBPS, "ratelimit-input-bps", 0, "cap terminal input to this many bytes per second across all sessions (0 = unlimited)")
+	flag.IntVar(&rateLimitRequestsPerMinute, "ratelimit-requests-per-minute", 0, "cap /api requests per minute (0 = unlimited)")
+	flag.IntVar(&rateLimitWSConnectsPerMinute, "ratelimit-ws-connects-per-minute", 0, "cap /ws handshakes per minute (0 = unlimited)")
+	flag.StringVar(&rateLimitRouteOverrides, "ratelimit-route-overrides", "", "comma-separated per-route request limits: prefix=per-minute,... (e.g. /api/exec=10)")
+	flag.IntVar(&maxWSPerIP, "max-ws-per-ip", 0, "cap simultaneous websocket connections per remote IP (0 = unlimited)")
+	flag.IntVar(&maxSessionsPerIP, "max-sessions-per-ip", 0, "cap simultaneous sessions per remote IP (0 = unlimited)")
+	flag.StringVar(&trustedProxies, "trusted-proxies", "", "comma-separated proxy IPs/CIDRs whose X-Forwarded-For headers are trusted for the client IP (e.g. 10.0.0.0/8)")
+	flag.StringVar(&wsCompression, "ws-compression", "disabled", "websocket compression mode: disabled|context-takeover|no-context-takeover")
+	flag.IntVar(&wsCompressionThreshold, "ws-compression-threshold", 0, "minimum websocket message size in bytes before compression applies (0 = library default)")
+	flag.DurationVar(&wsPingInterval, "ws-ping-interval", 0, "interval between server keepalive pings on websockets (0 = 30s default, negative disables)")
+	flag.DurationVar(&wsPingTimeout, "ws-ping-timeout", 0, "how long to wait for a pong before closing the connection (0 = 10s default)")
+	flag.IntVar(&historyChunks, "history-chunks", 0, "cap retained history chunks per session (0 = library default)")
+	flag.Int64Var(&historyBytes, "history-bytes", 0, "cap retained history bytes per session (0 = unlimited)")
+	flag.IntVar(&maxSessions, "max-sessions", 0, "cap concurrent sessions across all clients (0 = unlimited)")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 0, "delete sessions with no attached clients and no activity for this long (0 = never)")
+	flag.DurationVar(&inputWindow, "input-window", 0, "window after an input write during which output is attributed to that connection for echo suppression (0 = 50ms default)")
+	flag.StringVar(&shellOverride, "shell", "", "force every session to launch this shell instead of auto-detecting one")
+	flag.StringVar(&commandOverride, "command", "", "force every session to run exactly this command (quoted argv, e.g. \"bash -c 'tmux attach'\"); overrides -shell")
+	flag.BoolVar(&readonly, "readonly", false, "reject all input, resize, create and delete requests; history and live output stay viewable")
+	flag.DurationVar(&exitWhenIdle, "exit-when-idle", 0, "exit after all sessions are closed and no clients have connected for this long (0 = never)")
+	flag.StringVar(&envDeny, "env-deny", "", "comma-separated env var patterns stripped from session environments (e.g. AWS_*,*_TOKEN,SSH_AUTH_SOCK)")
+	flag.StringVar(&envAllow, "env-allow", "", "comma-separated env var patterns; when set, only matching vars reach sessions (-env-deny still applies)")
+	flag.StringVar(&isolate, "isolate", "", "comma-separated Linux namespaces for session processes: mount,pid,ipc,tmp (tmp mounts a private tmpfs over /tmp)")
+	flag.StringVar(&chrootDir, "chroot", "", "chroot every session into this directory tree; it must contain the shell and its dependencies (requires root)")
+	flag.BoolVar(&chrootHome, "chroot-home", false, "chroot every session into its user's home directory (requires root; the home must contain a usable shell)")
+	flag.StringVar(&sandboxRO, "sandbox-ro", "", "comma-separated paths sessions may read and execute but not modify (Landlock; everything unlisted is denied)")
+	flag.StringVar(&sandboxRW, "sandbox-rw", "", "comma-separated paths sessions may read and write (Landlock; everything unlisted is denied)")
+	flag.BoolVar(&sandboxDenySyscalls, "sandbox-deny-syscalls", false, "block dangerous syscalls (ptrace, mount, module loading, ...) in sessions via seccomp")
+	flag.StringVar(&allowCommands, "allow-commands", "", "comma-separated executable globs; when set, sessions and /api/exec may only run matching commands (e.g. bash,/usr/bin/htop)")
+	flag.StringVar(&denyCommands, "deny-commands", "", "comma-separated executable globs rejected for sessions and /api/exec")
+	flag.StringVar(&csp, "csp", "", "override the Content-Security-Policy header (frame-ancestors is managed separately)")
+	flag.StringVar(&frameAncestors, "frame-ancestors", "", "CSP sources allowed to embed the UI (default 'none')")
+	flag.StringVar(&referrerPolicy, "referrer-policy", "", "override the Referrer-Policy header (default no-referrer)")
+	flag.BoolVar(&traceSpans, "trace", false, "emit OpenTelemetry spans for API, websocket and attach/replay paths as JSON lines on stderr")
+	flag.StringVar(&traceFile, "trace-file", "", "write trace spans to this file instead of stderr (implies -trace)")
+	flag.StringVar(&webhookURL, "webhook", "", "POST session lifecycle, command and bell events to this URL as JSON")
+	flag.StringVar(&webhookSecret, "webhook-secret", "", "sign webhook bodies with this HMAC-SHA256 secret (X-Floeterm-Signature header)")
+	flag.StringVar(&webhookEvents, "webhook-events", "", "comma-separated webhook event filter: session.created,session.closed,session.exited,command.finished,activity,bell (empty = all)")
+	flag.StringVar(&redisBridgeAddr, "redis-bridge", "", "mirror session events to Redis pub/sub at this host:port and consume remote input, for multi-replica deployments")
+	flag.StringVar(&redisBridgePassword, "redis-bridge-password", "", "AUTH password for -redis-bridge")
+	flag.StringVar(&redisBridgePrefix, "redis-bridge-prefix", "", "channel prefix for -redis-bridge (default floeterm)")
+	flag.StringVar(&auditLogPath, "audit-log", "", "append audit events (auth, session lifecycle, input batches, admin ops) to this JSONL file; the /api/audit/events stream works regardless")
+	flag.StringVar(&sshAddr, "ssh-addr", "", "serve sessions over an embedded SSH server on this address (e.g. :2222); requires -ssh-authorized-keys")
+	flag.StringVar(&sshHostKeyPath, "ssh-host-key", "", "PEM host key file for -ssh-addr (default: generated under the user config dir on first use)")
+	flag.StringVar(&sshAuthorizedKeys, "ssh-authorized-keys", "", "authorized_keys file whose keys may connect to -ssh-addr")
+	flag.StringVar(&tunnelProvider, "tunnel", "", "expose the server through an outbound tunnel and print the public HTTPS URL: cloudflared, or a localhost.run-style SSH relay host (incompatible with -no-auth)")
+	flag.BoolVar(&noSecurityHeaders, "no-security-headers", false, "do not set security headers; useful when a fronting proxy manages them")
+	// `floeterm serve [flags]` is the explicit spelling of the default mode;
+	// other leading words select subcommands with their own flags.
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "serve" {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "version" {
+		os.Exit(runVersionCommand())
+	}
+	if len(args) > 0 && args[0] == "doctor" {
+		os.Exit(runDoctorCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "stop" {
+		os.Exit(runStopCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "sessions" {
+		os.Exit(runSessionsCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "attach" {
+		os.Exit(runAttachCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "exec" {
+		os.Exit(runExecCommand(args[1:]))
+	}
+	flag.CommandLine.Parse(args)
+
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfigCommand(flag.CommandLine, flag.Args()[1:]))
+	}
+
+	if err := applyConfig(flag.CommandLine, configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+
+	authConfigured := authToken != "" || authTokenFile != "" || basicAuthUser != "" ||
+		authUsersSpec != "" || tlsClientCA != "" || authPAM
+
+	// Out of the box the server binds loopback only; anything wider needs the
+	// explicit -listen-lan opt-in plus working authentication. -acme-domain
+	// counts as the opt-in since it only makes sense for a reachable host.
+	if addr == "" {
+		addr = server.DefaultListenAddr
+		if listenLAN {
+			addr = ":8080"
+		}
+	}
+	if err := server.ValidateListenAddr(addr, listenLAN || acmeDomain != "", authConfigured || !noAuth); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// A tunnel makes the server reachable from the whole internet, so it
+	// gets the same auth requirement as a non-loopback bind. The generated
+	// startup token counts: it is what makes one-flag sharing safe.
+	if tunnelProvider != "" && noAuth {
+		fmt.Fprintln(os.Stderr, "refusing -tunnel with -no-auth: the public URL would hand out shells to anyone who finds it")
+		os.Exit(2)
+	}
+
+	// With no credentials configured, generate a per-startup token rather
+	// than handing a shell to anyone who can reach the port. The tokenized
+	// URL is printed the way Jupyter does it; -no-auth opts out explicitly.
+	if !authConfigured && !noAuth {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to generate access token: %v\n", err)
+			os.Exit(1)
+		}
+		authToken = hex.EncodeToString(buf)
+		// A daemonized child inherits the token through the environment
+		// override instead of generating a different one.
+		os.Setenv(envVarForFlag("auth-token"), authToken)
+		scheme := "http"
+		if acmeDomain != "" || tlsCert != "" {
+			scheme = "https"
+		}
+		fmt.Fprintf(os.Stderr, "no auth configured; generated access token (disable with -no-auth)\n")
+		if url := displayLocalAccessURL(addr, scheme); url != "" {
+			fmt.Fprintf(os.Stderr, "access URL: %s/?token=%s\n", url, authToken)
+		}
+	}
+
+	if daemonMode && pidfilePath == "" {
+		pidfilePath = defaultPidfilePath()
+	}
+	if daemonMode && os.Getenv(daemonEnvVar) == "" {
+		daemonize(pidfilePath)
+	}
 
 	if staticDir == "" {
 		staticDir = resolveDefaultStaticDir()
@@ -44,42 +302,647 @@ func main() {
 		level = terminal.LogInfo
 	}
 
-	logger := terminal.NewStdLogger(level)
+	authUsers, err := parseAuthUsers(authUsersSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -auth-users: %v\n", err)
+		os.Exit(2)
+	}
+
+	routeOverrides, err := parseRouteOverrides(rateLimitRouteOverrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -ratelimit-route-overrides: %v\n", err)
+		os.Exit(2)
+	}
+
+	var logger terminal.Logger
+	switch strings.ToLower(strings.TrimSpace(logFormat)) {
+	case "", "text":
+		logger = terminal.NewStdLogger(level)
+	case "json":
+		logger = terminal.NewJSONLogger(level)
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -log-format: %q (want text or json)\n", logFormat)
+		os.Exit(2)
+	}
+
+	if pidfilePath != "" {
+		// In daemon mode the foreground parent already recorded our pid.
+		if os.Getenv(daemonEnvVar) == "" {
+			if err := writePidfile(pidfilePath, os.Getpid()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		defer os.Remove(pidfilePath)
+	}
+
+	// SIGINT/SIGTERM cancel this context; the serve paths drain their HTTP
+	// server and return so the deferred teardown (gRPC stop, manager cleanup,
+	// pidfile removal) runs instead of being skipped by an immediate exit.
+	ctx, shutdown := context.WithCancel(context.Background())
+	watchShutdownSignals(shutdown, logger)
+
+	managerCfg := terminal.ManagerConfig{
+		Logger:                 logger,
+		HistoryBufferMaxChunks: historyChunks,
+		HistoryBufferMaxBytes:  historyBytes,
+		MaxSessions:            maxSessions,
+		SessionIdleTimeout:     idleTimeout,
+		EchoAttributionWindow:  inputWindow,
+		ShellArgsProvider: terminal.DefaultShellArgsProvider{
+			EnableCommandLifecycle: true,
+		},
+		ShellInitWriter: terminal.DefaultShellInitWriter{
+			EnableCommandLifecycle: true,
+		},
+		// Keep UI responsiveness high.
+		InitialResizeSuppressDuration: 200 * time.Millisecond,
+		ResizeSuppressDuration:        150 * time.Millisecond,
+	}
+	if isolate != "" {
+		iso, err := parseIsolation(isolate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -isolate: %v\n", err)
+			os.Exit(2)
+		}
+		managerCfg.Isolation = iso
+	}
+	managerCfg.Isolation.ChrootDir = chrootDir
+	managerCfg.Isolation.ChrootHome = chrootHome
+	if sandboxRO != "" || sandboxRW != "" || sandboxDenySyscalls {
+		managerCfg.Sandbox = &terminal.SandboxProfile{
+			ReadOnlyPaths:         splitCommaList(sandboxRO),
+			ReadWritePaths:        splitCommaList(sandboxRW),
+			DenyDangerousSyscalls: sandboxDenySyscalls,
+		}
+	}
+	var commandPolicy terminal.CommandPolicy
+	if allowCommands != "" || denyCommands != "" {
+		commandPolicy = terminal.RuleCommandPolicy{
+			Allow: commandRulesFromGlobs(splitCommaList(allowCommands)),
+			Deny:  commandRulesFromGlobs(splitCommaList(denyCommands)),
+		}
+		managerCfg.CommandPolicy = commandPolicy
+	}
+	if envDeny != "" || envAllow != "" {
+		managerCfg.EnvProvider = terminal.FilteredEnvProvider{
+			Allow: splitCommaList(envAllow),
+			Deny:  splitCommaList(envDeny),
+		}
+	}
+	if shellOverride != "" {
+		managerCfg.ShellResolver = terminal.FixedShellResolver{Shell: shellOverride}
+	}
+	if commandOverride != "" {
+		words, err := splitCommandWords(commandOverride)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -command: %v\n", err)
+			os.Exit(2)
+		}
+		managerCfg.ShellResolver = terminal.FixedShellResolver{Shell: words[0]}
+		managerCfg.ShellArgsProvider = terminal.FixedShellArgsProvider{Args: words[1:]}
+	}
+
+	tracing := server.TracingConfig{Enabled: traceSpans || traceFile != ""}
+	if traceFile != "" {
+		spanOut, err := os.OpenFile(traceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open trace file: %v\n", err)
+			os.Exit(2)
+		}
+		defer spanOut.Close()
+		tracing.Exporter = server.NewWriterSpanExporter(spanOut)
+	}
+
+	var auditSink io.Writer
+	if auditLogPath != "" {
+		auditOut, err := os.OpenFile(auditLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open audit log: %v\n", err)
+			os.Exit(2)
+		}
+		defer auditOut.Close()
+		auditSink = auditOut
+	}
+
+	var sshConfig server.SSHConfig
+	if sshAddr != "" {
+		if sshAuthorizedKeys == "" {
+			fmt.Fprintln(os.Stderr, "-ssh-addr requires -ssh-authorized-keys: a key-less SSH listener would hand out shells to anyone")
+			os.Exit(2)
+		}
+		authorizedKeys, err := os.ReadFile(sshAuthorizedKeys)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read authorized keys: %v\n", err)
+			os.Exit(2)
+		}
+		hostKey, err := loadOrCreateSSHHostKey(sshHostKeyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load SSH host key: %v\n", err)
+			os.Exit(2)
+		}
+		sshConfig = server.SSHConfig{
+			Addr:           sshAddr,
+			HostKeyPEM:     hostKey,
+			AuthorizedKeys: authorizedKeys,
+		}
+	}
+
+	var webhooks []server.WebhookConfig
+	if webhookURL != "" {
+		webhooks = append(webhooks, server.WebhookConfig{
+			URL:    webhookURL,
+			Secret: webhookSecret,
+			Events: splitCommaList(webhookEvents),
+		})
+	}
+
 	srv := server.New(server.Config{
 		StaticDir:                    staticDir,
+		BasePath:                     basePath,
 		EnablePerformanceDiagnostics: performanceDiagnostics,
-		ManagerConfig: terminal.ManagerConfig{
-			Logger: logger,
-			ShellArgsProvider: terminal.DefaultShellArgsProvider{
-				EnableCommandLifecycle: true,
-			},
-			ShellInitWriter: terminal.DefaultShellInitWriter{
-				EnableCommandLifecycle: true,
-			},
-			// Keep UI responsiveness high.
-			InitialResizeSuppressDuration: 200 * time.Millisecond,
-			ResizeSuppressDuration:        150 * time.Millisecond,
+		AuthToken:                    authToken,
+		AuthTokenFile:                authTokenFile,
+		BasicAuthUsername:            basicAuthUser,
+		BasicAuthPasswordHash:        basicAuthHash,
+		AuthUsers:                    authUsers,
+		ClientCertAuth:               tlsClientCA != "",
+		PAMAuth:                      authPAM,
+		AllowedOrigins:               splitCommaList(allowedOrigins),
+		RateLimit: server.RateLimitConfig{
+			InputBytesPerSecond:    rateLimitInputBPS,
+			RequestsPerMinute:      rateLimitRequestsPerMinute,
+			WSConnectsPerMinute:    rateLimitWSConnectsPerMinute,
+			RouteRequestsPerMinute: routeOverrides,
+		},
+		MaxWSPerIP:       maxWSPerIP,
+		MaxSessionsPerIP: maxSessionsPerIP,
+		ReadOnly:         readonly,
+		Tracing:          tracing,
+		Webhooks:         webhooks,
+		AuditLog:         auditSink,
+		RedisBridge: server.RedisBridgeConfig{
+			Addr:          redisBridgeAddr,
+			Password:      redisBridgePassword,
+			ChannelPrefix: redisBridgePrefix,
+		},
+		SSH:           sshConfig,
+		BuildInfo:     resolveBuildInfo(),
+		CommandPolicy: commandPolicy,
+		SecurityHeaders: server.SecurityHeadersConfig{
+			Disable:               noSecurityHeaders,
+			ContentSecurityPolicy: csp,
+			FrameAncestors:        frameAncestors,
+			ReferrerPolicy:        referrerPolicy,
+		},
+		TrustedProxies: splitCommaList(trustedProxies),
+		WSCompression: server.WSCompressionConfig{
+			Mode:           wsCompression,
+			ThresholdBytes: wsCompressionThreshold,
 		},
+		WSPingInterval: wsPingInterval,
+		WSPingTimeout:  wsPingTimeout,
+		ManagerConfig:  managerCfg,
 	})
-	defer srv.Close()
+	defer func() {
+		// Manager cleanup signals every shell process; give it a bounded
+		// window so a stuck PTY cannot wedge shutdown forever.
+		done := make(chan struct{})
+		go func() {
+			srv.Close()
+			close(done)
+		}()
+		select {
+		case <-done:
+			logger.Info("shutdown complete")
+		case <-time.After(shutdownGracePeriod):
+			logger.Warn("session cleanup timed out, exiting anyway")
+		}
+	}()
+
+	if exitWhenIdle > 0 {
+		go watchIdleExit(ctx, shutdown, srv.Manager(), exitWhenIdle, logger)
+	}
 
 	logger.Info("floeterm server listening", "addr", addr)
 	if staticDir != "" {
 		logger.Info("serving web", "staticDir", staticDir)
-		if url := displayLocalAccessURL(addr); url != "" {
+		if url := displayLocalAccessURL(addr, "http"); url != "" {
 			logger.Info("open in browser", "url", url)
 		}
 	} else {
 		logger.Info("no static dir configured; API only")
 	}
 
-	if err := http.ListenAndServe(addr, srv.Handler()); err != nil {
+	if grpcAddr != "" {
+		// The gRPC listener can only check token credentials, so it reuses the
+		// server's effective token (flag, file, or the generated one). If auth
+		// is configured purely through non-token schemes there is nothing it
+		// could verify — refuse to open an unauthenticated side door.
+		grpcToken := srv.AuthToken()
+		if grpcToken == "" && !noAuth {
+			fmt.Fprintln(os.Stderr, "refusing -grpc-addr: the configured auth has no bearer token the gRPC listener can check; add -auth-token or -auth-token-file")
+			os.Exit(2)
+		}
+		grpcServer := grpcapi.NewServer(
+			grpcapi.NewService(srv.Manager(), srv.LiveBackend()),
+			grpcapi.Options{Token: grpcToken},
+		)
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			logger.Error("failed to listen for grpc", "addr", grpcAddr, "error", err)
+			os.Exit(1)
+		}
+		logger.Info("floeterm grpc listening", "addr", grpcAddr)
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				logger.Error("grpc server exited", "error", err)
+			}
+		}()
+		defer grpcServer.GracefulStop()
+	}
+
+	if tunnelProvider != "" {
+		tunnel, err := server.StartTunnel(ctx, tunnelProvider, server.TunnelTargetAddr(addr), logger)
+		if err != nil {
+			logger.Error("failed to start tunnel", "provider", tunnelProvider, "error", err)
+			os.Exit(1)
+		}
+		defer tunnel.Close()
+		go func() {
+			urlCtx, cancelURL := context.WithTimeout(ctx, time.Minute)
+			defer cancelURL()
+			url, err := tunnel.URL(urlCtx)
+			if err != nil {
+				logger.Error("tunnel did not report a public URL", "provider", tunnelProvider, "error", err)
+				return
+			}
+			logger.Info("public tunnel URL", "url", url)
+			fmt.Fprintf(os.Stderr, "public tunnel URL: %s\n", url)
+		}()
+	}
+
+	if acmeDomain != "" {
+		if openBrowser {
+			openInBrowser("https://"+acmeDomain, logger)
+		}
+		if err := serveWithACME(ctx, addr, srv.Handler(), acmeDomain, acmeCacheDir, acmeEmail, logger); err != nil {
+			logger.Error("https server exited", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if tlsCert != "" || tlsKey != "" {
+		if openBrowser {
+			if url := displayLocalAccessURL(addr, "https"); url != "" {
+				openInBrowser(url, logger)
+			}
+		}
+		if err := serveWithTLS(ctx, addr, srv.Handler(), tlsCert, tlsKey, tlsClientCA, logger); err != nil {
+			logger.Error("https server exited", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if tlsClientCA != "" {
+		fmt.Fprintln(os.Stderr, "-tls-client-ca requires -tls-cert and -tls-key")
+		os.Exit(2)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Error("failed to listen", "addr", addr, "error", err)
+		os.Exit(1)
+	}
+	if openBrowser {
+		if url := displayLocalAccessURL(addr, "http"); url != "" {
+			openInBrowser(url, logger)
+		}
+	}
+	httpServer := &http.Server{Handler: srv.Handler()}
+	shutdownOnDone(ctx, httpServer, logger)
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Error("http server exited", "error", err)
 		os.Exit(1)
 	}
 }
 
-func displayLocalAccessURL(addr string) string {
+// shutdownGracePeriod bounds both HTTP connection draining and terminal
+// manager cleanup during shutdown.
+const shutdownGracePeriod = 10 * time.Second
+
+// watchShutdownSignals cancels the root context on the first SIGINT or
+// SIGTERM, so serve loops can drain instead of the process exiting mid-flight.
+func watchShutdownSignals(shutdown context.CancelFunc, logger terminal.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logger.Info("shutting down", "signal", sig.String())
+		shutdown()
+	}()
+}
+
+// watchIdleExit cancels the root context once the server has had no sessions
+// and no connected clients for the full -exit-when-idle window, so ad-hoc
+// launches (e.g. by an IDE plugin) do not outlive their use.
+func watchIdleExit(ctx context.Context, shutdown context.CancelFunc, manager *terminal.Manager, window time.Duration, logger terminal.Logger) {
+	tick := window / 4
+	if tick < 50*time.Millisecond {
+		tick = 50 * time.Millisecond
+	}
+	if tick > 5*time.Second {
+		tick = 5 * time.Second
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	idleSince := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			diag := manager.GetDiagnostics()
+			if diag.SessionCount > 0 || diag.ConnectionCount > 0 || diag.LiveAttachmentCount > 0 {
+				idleSince = time.Now()
+				continue
+			}
+			if time.Since(idleSince) >= window {
+				logger.Info("no sessions or clients for the idle window, shutting down", "window", window.String())
+				shutdown()
+				return
+			}
+		}
+	}
+}
+
+// shutdownOnDone drains the HTTP server once ctx is cancelled. Hijacked
+// connections (websockets) do not drain, so after the grace period the
+// remaining connections are closed outright.
+func shutdownOnDone(ctx context.Context, httpServer *http.Server, logger terminal.Logger) {
+	go func() {
+		<-ctx.Done()
+		drainCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+		defer cancel()
+		if err := httpServer.Shutdown(drainCtx); err != nil {
+			logger.Warn("closing remaining connections after grace period", "error", err)
+			_ = httpServer.Close()
+		}
+	}()
+}
+
+// serveWithACME serves the handler over HTTPS with certificates obtained and
+// renewed automatically via Let's Encrypt. A plain HTTP listener on port 80
+// answers http-01 challenges and redirects everything else to HTTPS.
+func serveWithACME(ctx context.Context, addr string, handler http.Handler, domain, cacheDir, email string, logger terminal.Logger) error {
+	if cacheDir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("cannot determine ACME cache dir: %w", err)
+		}
+		cacheDir = filepath.Join(base, "floeterm", "autocert")
+	}
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return fmt.Errorf("cannot create ACME cache dir: %w", err)
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domain),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+	}
+
+	// The default -addr targets plain HTTP; switch to the HTTPS port unless
+	// the operator chose one explicitly.
+	if addr == ":8080" || addr == server.DefaultListenAddr {
+		addr = ":443"
+	}
+
+	go func() {
+		if err := http.ListenAndServe(":80", manager.HTTPHandler(nil)); err != nil {
+			logger.Warn("ACME challenge listener exited", "error", err)
+		}
+	}()
+
+	httpsServer := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: manager.TLSConfig(),
+	}
+	shutdownOnDone(ctx, httpsServer, logger)
+	logger.Info("serving HTTPS via ACME", "domain", domain, "addr", addr, "cacheDir", cacheDir)
+	if err := httpsServer.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveWithTLS serves the handler over HTTPS with an operator-provided
+// certificate. When a client CA is configured, every connection must present a
+// certificate signed by it; the server maps the certificate's common name to
+// the caller's identity.
+func serveWithTLS(ctx context.Context, addr string, handler http.Handler, certFile, keyFile, clientCAFile string, logger terminal.Logger) error {
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("both -tls-cert and -tls-key are required for HTTPS")
+	}
+
+	tlsConfig := &tls.Config{}
+	if clientCAFile != "" {
+		caPEM, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return fmt.Errorf("cannot read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in client CA file %s", clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	httpsServer := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	shutdownOnDone(ctx, httpsServer, logger)
+	logger.Info("serving HTTPS", "addr", addr, "clientCerts", clientCAFile != "")
+	if err := httpsServer.ListenAndServeTLS(certFile, keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// parseAuthUsers parses the -auth-users flag: "name:token" entries separated
+// by commas, with an optional trailing ":admin" role.
+func parseAuthUsers(spec string) ([]server.AuthUser, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	var users []server.AuthUser
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("entry %q must be name:token[:admin]", entry)
+		}
+		user := server.AuthUser{Name: parts[0], Token: parts[1]}
+		if len(parts) == 3 {
+			if parts[2] != "admin" {
+				return nil, fmt.Errorf("entry %q has unknown role %q", entry, parts[2])
+			}
+			user.Admin = true
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// parseIsolation parses the -isolate flag into namespace settings.
+func parseIsolation(spec string) (terminal.SessionIsolation, error) {
+	var iso terminal.SessionIsolation
+	for _, part := range splitCommaList(spec) {
+		switch part {
+		case "mount":
+			iso.NewMountNamespace = true
+		case "pid":
+			iso.NewPIDNamespace = true
+		case "ipc":
+			iso.NewIPCNamespace = true
+		case "tmp":
+			iso.PrivateTmp = true
+		default:
+			return terminal.SessionIsolation{}, fmt.Errorf("unknown namespace %q (want mount, pid, ipc or tmp)", part)
+		}
+	}
+	return iso, nil
+}
+
+// commandRulesFromGlobs turns -allow-commands/-deny-commands globs into
+// executable-only policy rules (any arguments accepted).
+func commandRulesFromGlobs(globs []string) []terminal.CommandRule {
+	rules := make([]terminal.CommandRule, 0, len(globs))
+	for _, glob := range globs {
+		rules = append(rules, terminal.CommandRule{Path: glob})
+	}
+	return rules
+}
+
+// splitCommaList splits a comma-separated flag value, trimming whitespace and
+// dropping empty entries.
+func splitCommaList(spec string) []string {
+	var items []string
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitCommandWords splits the -command flag into an argv, honouring single
+// and double quotes the way a shell would (without any expansion).
+func splitCommandWords(spec string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inWord := false
+	quote := byte(0)
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("command must not be empty")
+	}
+	return words, nil
+}
+
+// parseRouteOverrides parses the -ratelimit-route-overrides flag:
+// "prefix=per-minute" entries separated by commas.
+func parseRouteOverrides(spec string) (map[string]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	overrides := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		prefix, limit, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("entry %q must be /prefix=per-minute", entry)
+		}
+		perMinute, err := strconv.Atoi(limit)
+		if err != nil || perMinute <= 0 {
+			return nil, fmt.Errorf("entry %q has invalid per-minute limit %q", entry, limit)
+		}
+		overrides[prefix] = perMinute
+	}
+	return overrides, nil
+}
+
+// loadOrCreateSSHHostKey reads the PEM host key at path, generating and
+// persisting an ed25519 key on first use so the server identity survives
+// restarts. An empty path defaults to the user config dir.
+func loadOrCreateSSHHostKey(path string) ([]byte, error) {
+	if path == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve config dir for the default host key path: %w", err)
+		}
+		path = filepath.Join(configDir, "floeterm", "ssh_host_key")
+	}
+	if pemBytes, err := os.ReadFile(path); err == nil {
+		return pemBytes, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate host key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "floeterm host key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode host key: %w", err)
+	}
+	pemBytes := pem.EncodeToMemory(block)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, pemBytes, 0o600); err != nil {
+		return nil, err
+	}
+	return pemBytes, nil
+}
+
+func displayLocalAccessURL(addr, scheme string) string {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
 		return ""
@@ -92,10 +955,37 @@ func displayLocalAccessURL(addr string) string {
 		host = "localhost"
 	}
 
-	return "http://" + net.JoinHostPort(host, port)
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// openInBrowser launches the user's default browser at url via the platform
+// opener. Browsing is a convenience, so failures are logged and ignored.
+func openInBrowser(url string, logger terminal.Logger) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		logger.Warn("failed to open browser", "url", url, "error", err)
+		return
+	}
+	go func() { _ = cmd.Wait() }()
 }
 
 func resolveDefaultStaticDir() string {
+	dir := findDefaultStaticDir()
+	if dir == "" {
+		fmt.Fprintln(os.Stderr, "warning: could not find app/web dist (run `make app-web-build` and pass -static)")
+	}
+	return dir
+}
+
+func findDefaultStaticDir() string {
 	candidates := []string{
 		filepath.Join("..", "web", "dist"),
 		filepath.Join("app", "web", "dist"),
@@ -109,6 +999,5 @@ func resolveDefaultStaticDir() string {
 			return candidate
 		}
 	}
-	fmt.Fprintln(os.Stderr, "warning: could not find app/web dist (run `make app-web-build` and pass -static)")
 	return ""
 }
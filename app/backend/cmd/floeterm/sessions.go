@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Session management from the command line: `floeterm sessions <verb>` talks
+// to a running server's HTTP API so scripts and power users can manage
+// terminals without the web UI. The server address and token resolve the same
+// way the server's own flags do: explicit flag, then FLOETERM_* environment
+// variable, then the config file.
+
+// apiClient is a thin JSON client for the server's /api endpoints.
+type apiClient struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func (c *apiClient) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// cliSessionInfo is the subset of the session listing the CLI uses.
+type cliSessionInfo struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	WorkingDir        string `json:"workingDir"`
+	IsActive          bool   `json:"isActive"`
+	LastActiveAtMs    int64  `json:"lastActiveAtMs"`
+	ForegroundCommand struct {
+		Phase       string `json:"phase"`
+		DisplayName string `json:"displayName"`
+		Revision    uint64 `json:"revision"`
+	} `json:"foregroundCommand"`
+}
+
+// fetchSession finds one session in the listing; the API has no single-session
+// GET, but the list is cheap.
+func fetchSession(client *apiClient, sessionID string) (cliSessionInfo, error) {
+	var sessions []cliSessionInfo
+	if err := client.do(http.MethodGet, "/api/sessions", nil, &sessions); err != nil {
+		return cliSessionInfo{}, err
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return session, nil
+		}
+	}
+	return cliSessionInfo{}, fmt.Errorf("session %s not found", sessionID)
+}
+
+// newAPIClient resolves the server base URL and token with the usual
+// precedence: explicit flag, FLOETERM_* environment variable, config file.
+func newAPIClient(configPath, serverURL, token string) (*apiClient, error) {
+	var fileValues map[string]string
+	if configPath != "" {
+		var err error
+		fileValues, err = loadConfigValues(configPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fromEnvOrFile := func(key string) string {
+		if value, ok := os.LookupEnv(envVarForFlag(key)); ok {
+			return value
+		}
+		return fileValues[key]
+	}
+
+	if serverURL == "" {
+		addr := fromEnvOrFile("addr")
+		if addr == "" {
+			addr = ":8080"
+		}
+		serverURL = displayLocalAccessURL(addr, "http")
+		if serverURL == "" {
+			return nil, fmt.Errorf("cannot derive a server URL from addr %q; pass -server", addr)
+		}
+	}
+	if token == "" {
+		token = fromEnvOrFile("auth-token")
+	}
+	if token == "" {
+		if tokenFile := fromEnvOrFile("auth-token-file"); tokenFile != "" {
+			data, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read auth token file: %w", err)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	return &apiClient{
+		base:  strings.TrimSuffix(serverURL, "/"),
+		token: token,
+		http:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// runSessionsCommand implements
+// `floeterm sessions [-server URL] [-token T] [-config file] <list|new|rm|rename>`.
+func runSessionsCommand(args []string) int {
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	serverURL := fs.String("server", "", "base URL of the running server (default: derived from the addr setting)")
+	token := fs.String("token", "", "bearer token (default: auth-token from environment or config)")
+	configPath := fs.String("config", "", "config file to read the server address and token from")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: floeterm sessions [flags] list|new|rm|rename ...")
+		return 2
+	}
+
+	client, err := newAPIClient(*configPath, *serverURL, *token)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	verb, rest := fs.Arg(0), fs.Args()[1:]
+	switch verb {
+	case "list":
+		return sessionsList(client)
+	case "new":
+		return sessionsNew(client, rest)
+	case "rm":
+		return sessionsRemove(client, rest)
+	case "rename":
+		return sessionsRename(client, rest)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown sessions verb %q (want list|new|rm|rename)\n", verb)
+		return 2
+	}
+}
+
+func sessionsList(client *apiClient) int {
+	var sessions []cliSessionInfo
+	if err := client.do(http.MethodGet, "/api/sessions", nil, &sessions); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 2, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tACTIVE\tLAST ACTIVE\tWORKING DIR")
+	for _, session := range sessions {
+		lastActive := "-"
+		if session.LastActiveAtMs > 0 {
+			lastActive = time.UnixMilli(session.LastActiveAtMs).Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n",
+			session.ID, session.Name, session.IsActive, lastActive, session.WorkingDir)
+	}
+	tw.Flush()
+	return 0
+}
+
+func sessionsNew(client *apiClient, args []string) int {
+	fs := flag.NewFlagSet("sessions new", flag.ExitOnError)
+	workingDir := fs.String("workdir", "", "working directory for the new session")
+	profile := fs.String("profile", "", "session profile to create from (see /api/profiles)")
+	fs.Parse(args)
+
+	request := map[string]string{
+		"name":       fs.Arg(0),
+		"workingDir": *workingDir,
+		"profile":    *profile,
+	}
+	var created cliSessionInfo
+	if err := client.do(http.MethodPost, "/api/sessions", request, &created); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%s\t%s\n", created.ID, created.Name)
+	return 0
+}
+
+func sessionsRemove(client *apiClient, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: floeterm sessions rm <session-id> ...")
+		return 2
+	}
+	status := 0
+	for _, sessionID := range args {
+		if err := client.do(http.MethodDelete, "/api/sessions/"+sessionID, nil, nil); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func sessionsRename(client *apiClient, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: floeterm sessions rename <session-id> <new-name>")
+		return 2
+	}
+	request := map[string]string{"name": args[1]}
+	if err := client.do(http.MethodPatch, "/api/sessions/"+args[0], request, nil); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/floegence/floeterm/app/backend/internal/server"
+)
+
+// version, commit and buildDate are injected by release builds via
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+// Without injection the commit and date fall back to the module build info
+// Go embeds for VCS checkouts.
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// resolveBuildInfo combines the ldflags-injected values with the embedded
+// module build info and the running toolchain version.
+func resolveBuildInfo() server.BuildInfo {
+	info := server.BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// runVersionCommand implements `floeterm version`.
+func runVersionCommand() int {
+	info := resolveBuildInfo()
+	fmt.Printf("floeterm %s\n", info.Version)
+	fmt.Printf("commit: %s\n", info.Commit)
+	fmt.Printf("built: %s\n", info.BuildDate)
+	fmt.Printf("go: %s\n", info.GoVersion)
+	return 0
+}
@@ -0,0 +1,37 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodec marshals gRPC messages as JSON so the service needs no generated
+// protobuf code. Clients select it with grpc.CallContentSubtype(JSONCodecName)
+// after importing this package; see terminal.proto for the schema.
+type jsonCodec struct{}
+
+// JSONCodecName is the content-subtype the server speaks.
+const JSONCodecName = "json"
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal grpc message: %w", err)
+	}
+	return data, nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal grpc message: %w", err)
+	}
+	return nil
+}
+
+func (jsonCodec) Name() string { return JSONCodecName }
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
@@ -0,0 +1,94 @@
+package grpcapi
+
+// Message types mirrored from terminal.proto. Fields marshal as JSON; []byte
+// fields round-trip as base64 strings, matching the REST API's history
+// encoding.
+
+type Empty struct{}
+
+type CreateSessionRequest struct {
+	Name        string `json:"name,omitempty"`
+	WorkingDir  string `json:"workingDir,omitempty"`
+	PathPrepend string `json:"pathPrepend,omitempty"`
+}
+
+type SessionInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name,omitempty"`
+	WorkingDir     string `json:"workingDir,omitempty"`
+	CreatedAtMs    int64  `json:"createdAtMs"`
+	LastActiveAtMs int64  `json:"lastActiveAtMs"`
+	IsActive       bool   `json:"isActive"`
+}
+
+type ListSessionsRequest struct{}
+
+type ListSessionsResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+type DeleteSessionRequest struct {
+	SessionID string `json:"sessionId"`
+}
+
+type AttachRequest struct {
+	SessionID string `json:"sessionId"`
+	Cols      uint32 `json:"cols,omitempty"`
+	Rows      uint32 `json:"rows,omitempty"`
+}
+
+// AttachEvent is a tagged union; Type selects which fields are meaningful.
+type AttachEvent struct {
+	Type         string `json:"type"`
+	ConnectionID string `json:"connectionId,omitempty"`
+	Data         []byte `json:"data,omitempty"`
+	Sequence     uint64 `json:"sequence,omitempty"`
+	TimestampMs  int64  `json:"timestampMs,omitempty"`
+	Cols         uint32 `json:"cols,omitempty"`
+	Rows         uint32 `json:"rows,omitempty"`
+}
+
+const (
+	AttachEventAttached = "attached"
+	AttachEventOutput   = "output"
+	AttachEventGeometry = "geometry"
+	AttachEventClosed   = "closed"
+)
+
+type WriteRequest struct {
+	SessionID    string `json:"sessionId"`
+	ConnectionID string `json:"connectionId"`
+	Data         []byte `json:"data"`
+}
+
+type ResizeRequest struct {
+	SessionID    string `json:"sessionId"`
+	ConnectionID string `json:"connectionId"`
+	Cols         uint32 `json:"cols"`
+	Rows         uint32 `json:"rows"`
+}
+
+type Geometry struct {
+	Generation uint64 `json:"generation"`
+	Cols       uint32 `json:"cols"`
+	Rows       uint32 `json:"rows"`
+}
+
+type HistoryRequest struct {
+	SessionID string `json:"sessionId"`
+	StartSeq  int64  `json:"startSeq,omitempty"`
+	EndSeq    int64  `json:"endSeq,omitempty"`
+	MaxBytes  int64  `json:"maxBytes,omitempty"`
+}
+
+type HistoryChunk struct {
+	Sequence    int64  `json:"sequence"`
+	Data        []byte `json:"data"`
+	TimestampMs int64  `json:"timestampMs"`
+}
+
+type HistoryResponse struct {
+	Chunks            []HistoryChunk `json:"chunks"`
+	NextStartSequence int64          `json:"nextStartSequence"`
+	HasMore           bool           `json:"hasMore"`
+}
@@ -0,0 +1,277 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+const (
+	defaultAttachCols = 80
+	defaultAttachRows = 24
+
+	defaultHistoryBytes = int64(512 * 1024)
+	maxHistoryBytes     = int64(1 << 20)
+	maxHistoryChunks    = 256
+
+	attachEventBuffer = 1024
+)
+
+// TerminalServer is the service interface behind the floeterm.v1.Terminal
+// gRPC service; see terminal.proto for the wire schema.
+type TerminalServer interface {
+	CreateSession(context.Context, *CreateSessionRequest) (*SessionInfo, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	DeleteSession(context.Context, *DeleteSessionRequest) (*Empty, error)
+	Attach(*AttachRequest, TerminalAttachStream) error
+	Write(context.Context, *WriteRequest) (*Empty, error)
+	Resize(context.Context, *ResizeRequest) (*Geometry, error)
+	History(context.Context, *HistoryRequest) (*HistoryResponse, error)
+}
+
+// TerminalAttachStream is the server side of the Attach event stream.
+type TerminalAttachStream interface {
+	Send(*AttachEvent) error
+	Context() context.Context
+}
+
+// Service implements TerminalServer on top of a terminal manager and the
+// livev1 backend used by the websocket protocol, so both transports share
+// attach, input and resize semantics.
+type Service struct {
+	manager *terminal.Manager
+	backend livev1.Backend
+}
+
+func NewService(manager *terminal.Manager, backend livev1.Backend) *Service {
+	return &Service{manager: manager, backend: backend}
+}
+
+func (s *Service) CreateSession(ctx context.Context, req *CreateSessionRequest) (*SessionInfo, error) {
+	session, err := s.manager.CreateSessionWithOptions(terminal.CreateSessionOptions{
+		Name:        req.Name,
+		WorkingDir:  req.WorkingDir,
+		PathPrepend: req.PathPrepend,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	info := toSessionInfo(session.ToSessionInfo())
+	return &info, nil
+}
+
+func (s *Service) ListSessions(ctx context.Context, _ *ListSessionsRequest) (*ListSessionsResponse, error) {
+	sessions := s.manager.ListSessions()
+	out := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, toSessionInfo(session.ToSessionInfo()))
+	}
+	return &ListSessionsResponse{Sessions: out}, nil
+}
+
+func (s *Service) DeleteSession(ctx context.Context, req *DeleteSessionRequest) (*Empty, error) {
+	if err := s.manager.DeleteSession(req.SessionID); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &Empty{}, nil
+}
+
+func (s *Service) Attach(req *AttachRequest, stream TerminalAttachStream) error {
+	cols, rows := req.Cols, req.Rows
+	if cols == 0 {
+		cols = defaultAttachCols
+	}
+	if rows == 0 {
+		rows = defaultAttachRows
+	}
+	connectionID, err := newConnectionID()
+	if err != nil {
+		return status.Error(codes.Internal, "failed to mint connection id")
+	}
+
+	// Subscriber callbacks run on the session's broadcast path; they block on
+	// the buffered channel (mirroring the websocket queue's backpressure) and
+	// bail out once the stream is gone.
+	events := make(chan *AttachEvent, attachEventBuffer)
+	closed := make(chan struct{})
+	var closeOnce sync.Once
+	markClosed := func() { closeOnce.Do(func() { close(closed) }) }
+	send := func(event *AttachEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-closed:
+			return false
+		case <-stream.Context().Done():
+			return false
+		}
+	}
+
+	attachment := livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             cols,
+		Rows:             rows,
+		SessionID:        req.SessionID,
+		ConnectionID:     connectionID,
+	}
+	attached, detach, err := s.backend.Attach(stream.Context(), attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			return send(&AttachEvent{
+				Type:        AttachEventOutput,
+				Data:        append([]byte(nil), record.Data...),
+				Sequence:    record.Sequence,
+				TimestampMs: int64(record.TimestampMs),
+				Cols:        record.Cols,
+				Rows:        record.Rows,
+			})
+		},
+		OnGeometry: func(geometry livev1.EffectiveGeometry) bool {
+			return send(&AttachEvent{
+				Type: AttachEventGeometry,
+				Cols: geometry.Cols,
+				Rows: geometry.Rows,
+			})
+		},
+		OnSessionClosed: markClosed,
+		OnSuperseded:    markClosed,
+	})
+	if err != nil {
+		return attachError(err)
+	}
+	if detach != nil {
+		defer detach()
+	}
+
+	if err := stream.Send(&AttachEvent{
+		Type:         AttachEventAttached,
+		ConnectionID: connectionID,
+		Cols:         attached.Cols,
+		Rows:         attached.Rows,
+	}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-closed:
+			_ = stream.Send(&AttachEvent{Type: AttachEventClosed})
+			return nil
+		case event := <-events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Service) Write(ctx context.Context, req *WriteRequest) (*Empty, error) {
+	if req.ConnectionID == "" {
+		return nil, status.Error(codes.InvalidArgument, "connectionId is required")
+	}
+	err := s.backend.WriteInput(ctx,
+		livev1.Attach{SessionID: req.SessionID, ConnectionID: req.ConnectionID},
+		livev1.Input{Data: req.Data})
+	if err != nil {
+		return nil, attachError(err)
+	}
+	return &Empty{}, nil
+}
+
+func (s *Service) Resize(ctx context.Context, req *ResizeRequest) (*Geometry, error) {
+	if req.ConnectionID == "" {
+		return nil, status.Error(codes.InvalidArgument, "connectionId is required")
+	}
+	geometry, err := s.backend.Resize(ctx,
+		livev1.Attach{SessionID: req.SessionID, ConnectionID: req.ConnectionID},
+		livev1.Resize{Cols: req.Cols, Rows: req.Rows})
+	if err != nil {
+		return nil, attachError(err)
+	}
+	return &Geometry{Generation: geometry.Generation, Cols: geometry.Cols, Rows: geometry.Rows}, nil
+}
+
+func (s *Service) History(ctx context.Context, req *HistoryRequest) (*HistoryResponse, error) {
+	session, ok := s.manager.GetSession(req.SessionID)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	maxBytes := req.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultHistoryBytes
+	}
+	if maxBytes > maxHistoryBytes {
+		return nil, status.Error(codes.InvalidArgument, "invalid maxBytes")
+	}
+	endSeq := req.EndSeq
+	if endSeq == 0 {
+		endSeq = -1
+	}
+
+	page, err := session.GetHistoryPage(terminal.HistoryPageOptions{
+		StartSeq:    req.StartSeq,
+		EndSeq:      endSeq,
+		LimitChunks: maxHistoryChunks,
+		MaxBytes:    int(maxBytes),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	chunks := make([]HistoryChunk, 0, len(page.Chunks))
+	for _, chunk := range page.Chunks {
+		chunks = append(chunks, HistoryChunk{
+			Sequence:    chunk.Sequence,
+			Data:        chunk.Data,
+			TimestampMs: chunk.Timestamp,
+		})
+	}
+	return &HistoryResponse{
+		Chunks:            chunks,
+		NextStartSequence: page.NextStartSeq,
+		HasMore:           page.HasMore,
+	}, nil
+}
+
+func toSessionInfo(info terminal.TerminalSessionInfo) SessionInfo {
+	return SessionInfo{
+		ID:             info.ID,
+		Name:           info.Name,
+		WorkingDir:     info.WorkingDir,
+		CreatedAtMs:    info.CreatedAt,
+		LastActiveAtMs: info.LastActive,
+		IsActive:       info.IsActive,
+	}
+}
+
+// attachError maps livev1 backend errors onto gRPC status codes.
+func attachError(err error) error {
+	switch {
+	case errors.Is(err, livev1.ErrSessionNotFound):
+		return status.Error(codes.NotFound, "session not found")
+	case errors.Is(err, livev1.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, "permission denied")
+	case errors.Is(err, livev1.ErrActivationFailed):
+		return status.Error(codes.FailedPrecondition, "session activation failed")
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func newConnectionID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "grpc-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,157 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+type fixedShellResolver struct{ shell string }
+
+func (r fixedShellResolver) ResolveShell(terminal.Logger) string { return r.shell }
+
+type fixedShellArgsProvider struct{ args []string }
+
+func (p fixedShellArgsProvider) GetShellArgs(string, string) ([]string, []string) { return p.args, nil }
+
+func startTestGRPCServer(t *testing.T, options Options) *grpc.ClientConn {
+	t.Helper()
+	manager := terminal.NewManager(terminal.ManagerConfig{
+		Logger:            terminal.NopLogger{},
+		ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+	})
+	backend := livev1.NewManagerBackend(manager, livev1.ManagerBackendOptions{})
+	server := NewServer(NewService(manager, backend), options)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.NewClient(listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(JSONCodecName)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+		manager.Cleanup()
+	})
+	return conn
+}
+
+func TestGRPCSessionLifecycle(t *testing.T) {
+	conn := startTestGRPCServer(t, Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var created SessionInfo
+	err := conn.Invoke(ctx, "/floeterm.v1.Terminal/CreateSession",
+		&CreateSessionRequest{Name: "grpc"}, &created)
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if created.ID == "" || created.Name != "grpc" {
+		t.Fatalf("unexpected session info: %+v", created)
+	}
+
+	var listed ListSessionsResponse
+	if err := conn.Invoke(ctx, "/floeterm.v1.Terminal/ListSessions", &ListSessionsRequest{}, &listed); err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(listed.Sessions) != 1 || listed.Sessions[0].ID != created.ID {
+		t.Fatalf("unexpected listing: %+v", listed)
+	}
+
+	if err := conn.Invoke(ctx, "/floeterm.v1.Terminal/DeleteSession",
+		&DeleteSessionRequest{SessionID: created.ID}, &Empty{}); err != nil {
+		t.Fatalf("DeleteSession failed: %v", err)
+	}
+}
+
+func TestGRPCAttachStreamsOutput(t *testing.T) {
+	conn := startTestGRPCServer(t, Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var created SessionInfo
+	if err := conn.Invoke(ctx, "/floeterm.v1.Terminal/CreateSession",
+		&CreateSessionRequest{}, &created); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	desc := &grpc.StreamDesc{StreamName: "Attach", ServerStreams: true}
+	stream, err := conn.NewStream(ctx, desc, "/floeterm.v1.Terminal/Attach")
+	if err != nil {
+		t.Fatalf("Attach stream failed: %v", err)
+	}
+	if err := stream.SendMsg(&AttachRequest{SessionID: created.ID, Cols: 100, Rows: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+
+	var attached AttachEvent
+	if err := stream.RecvMsg(&attached); err != nil {
+		t.Fatalf("attached event failed: %v", err)
+	}
+	if attached.Type != AttachEventAttached || attached.ConnectionID == "" {
+		t.Fatalf("unexpected first event: %+v", attached)
+	}
+
+	err = conn.Invoke(ctx, "/floeterm.v1.Terminal/Write", &WriteRequest{
+		SessionID:    created.ID,
+		ConnectionID: attached.ConnectionID,
+		Data:         []byte("hello grpc\n"),
+	}, &Empty{})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for echoed output")
+		default:
+		}
+		var event AttachEvent
+		if err := stream.RecvMsg(&event); err != nil {
+			t.Fatalf("output event failed: %v", err)
+		}
+		if event.Type == AttachEventOutput && bytes.Contains(event.Data, []byte("hello grpc")) {
+			return
+		}
+	}
+}
+
+func TestGRPCTokenAuth(t *testing.T) {
+	conn := startTestGRPCServer(t, Options{Token: "secret"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var listed ListSessionsResponse
+	err := conn.Invoke(ctx, "/floeterm.v1.Terminal/ListSessions", &ListSessionsRequest{}, &listed)
+	if err == nil {
+		t.Fatal("expected unauthenticated call to fail")
+	}
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	if err := conn.Invoke(authCtx, "/floeterm.v1.Terminal/ListSessions", &ListSessionsRequest{}, &listed); err != nil {
+		t.Fatalf("authenticated call failed: %v", err)
+	}
+}
@@ -2,7 +2,6 @@ package server
 
 import (
 	"bytes"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -10,6 +9,8 @@ import (
 	"runtime"
 	"runtime/pprof"
 	"strings"
+	"syscall"
+	"time"
 
 	terminal "github.com/floegence/floeterm/terminal-go"
 )
@@ -27,9 +28,17 @@ type apiSessionInfo struct {
 	ID                string                   `json:"id"`
 	Name              string                   `json:"name"`
 	WorkingDir        string                   `json:"workingDir"`
+	Owner             string                   `json:"owner,omitempty"`
+	Tags              []string                 `json:"tags,omitempty"`
+	Notes             string                   `json:"notes,omitempty"`
+	NameLocked        bool                     `json:"nameLocked"`
+	ResizePolicy      string                   `json:"resizePolicy"`
 	CreatedAtMs       int64                    `json:"createdAtMs"`
 	LastActiveAtMs    int64                    `json:"lastActiveAtMs"`
 	IsActive          bool                     `json:"isActive"`
+	ExitCode          *int                     `json:"exitCode,omitempty"`
+	ExitedAtMs        int64                    `json:"exitedAtMs,omitempty"`
+	TerminationReason string                   `json:"terminationReason,omitempty"`
 	ForegroundCommand apiForegroundCommandInfo `json:"foregroundCommand"`
 	OutputActivity    apiOutputActivityInfo    `json:"outputActivity"`
 }
@@ -48,14 +57,44 @@ type apiOutputActivityInfo struct {
 }
 
 type createSessionRequest struct {
-	Name       string `json:"name"`
-	WorkingDir string `json:"workingDir"`
+	Name        string `json:"name"`
+	WorkingDir  string `json:"workingDir"`
+	PathPrepend string `json:"pathPrepend"`
+	// IdempotencyKey makes the create safe to retry: as long as the session
+	// created under this key is still alive, repeated posts return it instead
+	// of creating a duplicate. Keys are scoped to the calling user.
+	IdempotencyKey string `json:"idempotencyKey"`
+	// Profile names a session template (see /api/profiles) supplying shell,
+	// argv, environment, working directory, dimensions and tags. Explicit
+	// fields on this request win over the profile's defaults.
+	Profile string `json:"profile"`
+	// SSH backs the session with a remote PTY on another machine instead of
+	// a local shell, turning this server into a jump host. Wins over the
+	// profile's SSH spec when both are set.
+	SSH *RemoteSSHSpec `json:"ssh,omitempty"`
+	// Docker backs the session with an exec TTY inside a container. Wins
+	// over the profile's Docker spec when both are set; mutually exclusive
+	// with SSH.
+	Docker *DockerExecSpec `json:"docker,omitempty"`
+	// Tmux backs the session with an existing tmux pane (see
+	// /api/tmux/sessions for targets); mutually exclusive with SSH and
+	// Docker.
+	Tmux *TmuxPaneSpec `json:"tmux,omitempty"`
 }
 
 type renameSessionRequest struct {
 	NewName string `json:"newName"`
 }
 
+// patchSessionRequest carries a partial metadata update; absent fields are
+// left untouched, which is why every field is a pointer.
+type patchSessionRequest struct {
+	Name       *string   `json:"name"`
+	Tags       *[]string `json:"tags"`
+	Notes      *string   `json:"notes"`
+	NameLocked *bool     `json:"nameLocked"`
+}
+
 type historyChunk struct {
 	Sequence    int64  `json:"sequence"`
 	DataBase64  string `json:"data"`
@@ -66,6 +105,7 @@ type historyPageResponse struct {
 	Chunks                 []historyChunk `json:"chunks"`
 	FirstRetainedSequence  int64          `json:"firstRetainedSequence"`
 	NextStartSequence      int64          `json:"nextStartSequence"`
+	NextSeq                int64          `json:"nextSeq"`
 	HasMore                bool           `json:"hasMore"`
 	CoveredThroughSequence int64          `json:"coveredThroughSequence"`
 	SnapshotEndSequence    int64          `json:"snapshotEndSequence"`
@@ -76,18 +116,50 @@ type historyPageResponse struct {
 }
 
 type sessionStatsResponse struct {
-	History historyStats `json:"history"`
+	History         historyStats  `json:"history"`
+	IO              ioStats       `json:"io"`
+	ConnectionCount int           `json:"connectionCount"`
+	Process         *processStats `json:"process,omitempty"`
+	UptimeMs        int64         `json:"uptimeMs"`
+	IsActive        bool          `json:"isActive"`
+	LastExit        *lastExitInfo `json:"lastExit,omitempty"`
 }
 
 type historyStats struct {
-	TotalBytes int64 `json:"totalBytes"`
+	TotalBytes        int64 `json:"totalBytes"`
+	TotalChunks       int   `json:"totalChunks"`
+	UsedChunks        int   `json:"usedChunks"`
+	WriteCount        int64 `json:"writeCount"`
+	MemoryUsage       int64 `json:"memoryUsage"`
+	OldestTimestampMs int64 `json:"oldestTimestampMs"`
+	NewestTimestampMs int64 `json:"newestTimestampMs"`
+}
+
+type ioStats struct {
+	InputBytes  int64 `json:"inputBytes"`
+	OutputBytes int64 `json:"outputBytes"`
+}
+
+type processStats struct {
+	PID        int     `json:"pid"`
+	CPUSeconds float64 `json:"cpuSeconds"`
+	RSSBytes   int64   `json:"rssBytes"`
+}
+
+type lastExitInfo struct {
+	Error string `json:"error"`
 }
 
 func toAPISessionInfo(info terminal.TerminalSessionInfo) apiSessionInfo {
-	return apiSessionInfo{
+	out := apiSessionInfo{
 		ID:             info.ID,
 		Name:           info.Name,
 		WorkingDir:     info.WorkingDir,
+		Owner:          info.Owner,
+		Tags:           info.Tags,
+		Notes:          info.Notes,
+		NameLocked:     info.NameLocked,
+		ResizePolicy:   info.ResizePolicy,
 		CreatedAtMs:    info.CreatedAt,
 		LastActiveAtMs: info.LastActive,
 		IsActive:       info.IsActive,
@@ -103,6 +175,13 @@ func toAPISessionInfo(info terminal.TerminalSessionInfo) apiSessionInfo {
 			UpdatedAtMs: info.OutputActivity.UpdatedAt,
 		},
 	}
+	if info.LastExit != nil {
+		exitCode := info.LastExit.ExitCode
+		out.ExitCode = &exitCode
+		out.ExitedAtMs = info.LastExit.ExitedAt
+		out.TerminationReason = string(info.LastExit.Reason)
+	}
+	return out
 }
 
 func (s *Server) handlePerformanceRuntime(w http.ResponseWriter, r *http.Request) {
@@ -147,10 +226,15 @@ func (s *Server) handlePerformanceGoroutines(w http.ResponseWriter, r *http.Requ
 func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		id := identityFromContext(r.Context())
 		sessions := s.manager.ListSessions()
 		out := make([]apiSessionInfo, 0, len(sessions))
 		for _, session := range sessions {
-			out = append(out, toAPISessionInfo(session.ToSessionInfo()))
+			info := session.ToSessionInfo()
+			if !id.canAccess(info.Owner) {
+				continue
+			}
+			out = append(out, toAPISessionInfo(info))
 		}
 		writeJSON(w, http.StatusOK, out)
 		return
@@ -158,7 +242,7 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		var req createSessionRequest
 		if r.Body != nil {
-			if err := readJSON(w, r, &req, maxJSONBodyBytesDefault); err != nil && !errors.Is(err, io.EOF) {
+			if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil && !errors.Is(err, io.EOF) {
 				var httpErr *httpError
 				if errors.As(err, &httpErr) {
 					http.Error(w, httpErr.message, httpErr.status)
@@ -169,11 +253,112 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		session, err := s.manager.CreateSession(req.Name, req.WorkingDir)
+		idemKey := strings.TrimSpace(req.IdempotencyKey)
+		if len(idemKey) > maxIdempotencyKeyBytes {
+			http.Error(w, "idempotency key too long", http.StatusBadRequest)
+			return
+		}
+		owner := identityFromContext(r.Context()).name
+		if idemKey != "" {
+			if session, ok := s.lookupIdempotentSession(owner, idemKey); ok {
+				writeJSON(w, http.StatusOK, toAPISessionInfo(session.ToSessionInfo()))
+				return
+			}
+		}
+
+		opts := terminal.CreateSessionOptions{
+			Name:        req.Name,
+			WorkingDir:  req.WorkingDir,
+			PathPrepend: req.PathPrepend,
+			Owner:       owner,
+		}
+		if s.pamAuth != nil && owner != "" {
+			// PAM-authenticated callers get their sessions under their own
+			// local account, not the server's.
+			opts.RunAsUser = owner
+		}
+		var profile Profile
+		if req.Profile != "" {
+			var ok bool
+			profile, ok = s.profiles.get(req.Profile)
+			if !ok {
+				http.Error(w, fmt.Sprintf("unknown profile %q", req.Profile), http.StatusBadRequest)
+				return
+			}
+			applyProfile(&opts, profile)
+		}
+		sshSpec := req.SSH
+		if sshSpec == nil {
+			sshSpec = profile.SSH
+		}
+		dockerSpec := req.Docker
+		if dockerSpec == nil {
+			dockerSpec = profile.Docker
+		}
+		backends := 0
+		for _, set := range []bool{sshSpec != nil, dockerSpec != nil, req.Tmux != nil} {
+			if set {
+				backends++
+			}
+		}
+		if backends > 1 {
+			http.Error(w, "ssh, docker and tmux backends are mutually exclusive", http.StatusBadRequest)
+			return
+		}
+		switch {
+		case sshSpec != nil:
+			if err := applyRemoteSSH(&opts, *sshSpec); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case dockerSpec != nil:
+			if err := applyDockerExec(&opts, *dockerSpec); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case req.Tmux != nil:
+			if err := applyTmuxPane(&opts, *req.Tmux); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ip := s.clientIP(r)
+		if !s.ipLimits.acquireSession(ip) {
+			s.metrics.IncCounter(metricRateLimitRejected, 1)
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many sessions from this address", http.StatusTooManyRequests)
+			return
+		}
+
+		session, err := s.manager.CreateSessionWithOptions(opts)
 		if err != nil {
+			s.ipLimits.releaseSessionSlot(ip)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if len(profile.Tags) > 0 {
+			tags := append([]string(nil), profile.Tags...)
+			_ = s.manager.UpdateSessionMetadata(session.ID, terminal.SessionMetadataUpdate{Tags: &tags})
+		}
+		if profile.Cols > 0 && profile.Rows > 0 {
+			_ = session.SetResizePolicy(terminal.ResizePolicy{
+				Mode: terminal.ResizePolicyFixed,
+				Cols: profile.Cols,
+				Rows: profile.Rows,
+			})
+		}
+		s.ipLimits.bindSession(session.ID, ip)
+		if idemKey != "" {
+			s.rememberIdempotentSession(owner, idemKey, session.ID)
+		}
+		s.audit.record(auditEvent{
+			Type:      auditSessionCreate,
+			User:      owner,
+			RemoteIP:  ip,
+			SessionID: session.ID,
+			Detail:    session.GetName(),
+		})
 
 		writeJSON(w, http.StatusOK, toAPISessionInfo(session.ToSessionInfo()))
 		return
@@ -184,6 +369,43 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// lookupIdempotentSession returns the live session previously created under
+// the caller's idempotency key, if any. Stale entries (session already gone)
+// are dropped so the key can be reused.
+func (s *Server) lookupIdempotentSession(owner, key string) (*terminal.Session, bool) {
+	mapKey := owner + "\x00" + key
+	s.idemMu.Lock()
+	sessionID, ok := s.idemSessions[mapKey]
+	s.idemMu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		s.idemMu.Lock()
+		delete(s.idemSessions, mapKey)
+		s.idemMu.Unlock()
+		return nil, false
+	}
+	return session, true
+}
+
+func (s *Server) rememberIdempotentSession(owner, key, sessionID string) {
+	s.idemMu.Lock()
+	defer s.idemMu.Unlock()
+	s.idemSessions[owner+"\x00"+key] = sessionID
+}
+
+func (s *Server) forgetIdempotentSession(sessionID string) {
+	s.idemMu.Lock()
+	defer s.idemMu.Unlock()
+	for key, id := range s.idemSessions {
+		if id == sessionID {
+			delete(s.idemSessions, key)
+		}
+	}
+}
+
 func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
 	path = strings.TrimPrefix(path, "/")
@@ -199,26 +421,47 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 		action = parts[1]
 	}
 
+	// Hide sessions owned by other users; responding 404 avoids leaking
+	// session IDs across tenants.
+	if session, ok := s.manager.GetSession(sessionID); ok {
+		if !identityFromContext(r.Context()).canAccess(session.Owner) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+	}
+
 	switch action {
 	case "":
-		if r.Method != http.MethodDelete {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		switch r.Method {
+		case http.MethodDelete:
+			if err := s.manager.DeleteSession(sessionID); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			s.audit.record(auditEvent{
+				Type:      auditSessionDelete,
+				User:      identityFromContext(r.Context()).name,
+				RemoteIP:  s.clientIP(r),
+				SessionID: sessionID,
+			})
+			w.WriteHeader(http.StatusNoContent)
 			return
-		}
-		if err := s.manager.DeleteSession(sessionID); err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+		case http.MethodPatch:
+			s.handleSessionPatch(w, r, sessionID)
+			return
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
-		w.WriteHeader(http.StatusNoContent)
-		return
 
+	// Deprecated: superseded by PATCH /api/sessions/{id}; kept for older clients.
 	case "rename":
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 		var req renameSessionRequest
-		if err := readJSON(w, r, &req, maxJSONBodyBytesDefault); err != nil {
+		if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil {
 			var httpErr *httpError
 			if errors.As(err, &httpErr) {
 				http.Error(w, httpErr.message, httpErr.status)
@@ -231,78 +474,39 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid payload", http.StatusBadRequest)
 			return
 		}
+		oldName := ""
+		if session, ok := s.manager.GetSession(sessionID); ok {
+			oldName = session.GetName()
+		}
 		if err := s.manager.RenameSession(sessionID, req.NewName); err != nil {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		s.publishRenamed(sessionID, oldName, req.NewName)
+		s.audit.record(auditEvent{
+			Type:      auditSessionRename,
+			User:      identityFromContext(r.Context()).name,
+			RemoteIP:  s.clientIP(r),
+			SessionID: sessionID,
+			Detail:    req.NewName,
+		})
 		w.WriteHeader(http.StatusNoContent)
 		return
 
 	case "history":
-		if r.Method != http.MethodGet {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		startSeq, err := parseIntQuery(r.URL.Query(), "startSeq", 0)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		endSeq, err := parseIntQuery(r.URL.Query(), "endSeq", -1)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		historyGeneration, err := parseIntQuery(r.URL.Query(), "historyGeneration", 0)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		maxBytes, err := parseIntQuery(r.URL.Query(), "maxBytes", defaultHistoryPageBytes)
-		if err != nil || maxBytes <= 0 || maxBytes > maxHistoryPageBytes {
-			http.Error(w, "invalid maxBytes", http.StatusBadRequest)
-			return
-		}
-
-		session, ok := s.manager.GetSession(sessionID)
-		if !ok {
-			http.Error(w, "session not found", http.StatusNotFound)
-			return
-		}
-
-		page, err := session.GetHistoryPage(terminal.HistoryPageOptions{
-			StartSeq:          startSeq,
-			EndSeq:            endSeq,
-			HistoryGeneration: historyGeneration,
-			LimitChunks:       maxHistoryPageChunks,
-			MaxBytes:          int(maxBytes),
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if len(parts) > 2 && parts[2] == "export" {
+			s.handleHistoryExport(w, r, sessionID)
 			return
 		}
+		s.handleSessionHistory(w, r, sessionID)
+		return
 
-		out := make([]historyChunk, 0, len(page.Chunks))
-		for _, chunk := range page.Chunks {
-			out = append(out, historyChunk{
-				Sequence:    chunk.Sequence,
-				DataBase64:  base64.StdEncoding.EncodeToString(chunk.Data),
-				TimestampMs: chunk.Timestamp,
-			})
-		}
+	case "transfer":
+		s.handleSessionTransfer(w, r, sessionID, parts)
+		return
 
-		writeJSON(w, http.StatusOK, historyPageResponse{
-			Chunks:                 out,
-			FirstRetainedSequence:  page.FirstRetainedSequence,
-			NextStartSequence:      page.NextStartSeq,
-			HasMore:                page.HasMore,
-			CoveredThroughSequence: page.CoveredThroughSequence,
-			SnapshotEndSequence:    page.SnapshotEndSequence,
-			HistoryGeneration:      page.HistoryGeneration,
-			HistoryReset:           page.HistoryReset,
-			HistoryTruncated:       page.HistoryTruncated,
-			TotalBytes:             page.TotalBytes,
-		})
+	case "images":
+		s.handleSessionImages(w, r, sessionID)
 		return
 
 	case "stats":
@@ -323,11 +527,65 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		writeJSON(w, http.StatusOK, sessionStatsResponse{
+		inputBytes, outputBytes := session.GetIOStats()
+		response := sessionStatsResponse{
 			History: historyStats{
-				TotalBytes: stats.TotalBytes,
+				TotalBytes:        stats.TotalBytes,
+				TotalChunks:       stats.TotalChunks,
+				UsedChunks:        stats.UsedChunks,
+				WriteCount:        stats.WriteCount,
+				MemoryUsage:       stats.MemoryUsage,
+				OldestTimestampMs: stats.OldestTimestamp,
+				NewestTimestampMs: stats.NewestTimestamp,
 			},
-		})
+			IO:              ioStats{InputBytes: inputBytes, OutputBytes: outputBytes},
+			ConnectionCount: session.ConnectionCount(),
+			UptimeMs:        time.Since(session.CreatedAt).Milliseconds(),
+			IsActive:        session.IsActive(),
+		}
+		if pid := session.ProcessID(); pid > 0 {
+			if cpuSeconds, rssBytes, err := readProcessUsage(pid); err == nil {
+				response.Process = &processStats{PID: pid, CPUSeconds: cpuSeconds, RSSBytes: rssBytes}
+			} else {
+				response.Process = &processStats{PID: pid}
+			}
+		}
+		if exitErr := session.ExitError(); exitErr != nil {
+			response.LastExit = &lastExitInfo{Error: exitErr.Error()}
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+
+	case "files":
+		s.handleSessionFiles(w, r, sessionID)
+		return
+
+	case "fs":
+		s.handleSessionFS(w, r, sessionID)
+		return
+
+	case "screen":
+		s.handleSessionScreen(w, r, sessionID)
+		return
+
+	case "signal":
+		s.handleSessionSignal(w, r, sessionID)
+		return
+
+	case "resize-policy":
+		s.handleSessionResizePolicy(w, r, sessionID)
+		return
+
+	case "share":
+		s.handleSessionShare(w, r, sessionID)
+		return
+
+	case "ticket":
+		s.handleSessionTicket(w, r, sessionID)
+		return
+
+	case "events":
+		s.handleSessionEvents(w, r, sessionID)
 		return
 
 	case "clear":
@@ -347,3 +605,236 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+type screenResponse struct {
+	Lines []terminal.ScreenLine `json:"lines"`
+	Rows  int                   `json:"rows"`
+	Cols  int                   `json:"cols"`
+}
+
+// handleSessionScreen returns the current rendered screen contents so
+// lightweight clients and previews can show a terminal thumbnail without
+// attaching a websocket.
+func (s *Server) handleSessionScreen(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rows, err := parseIntQuery(r.URL.Query(), "rows", 24)
+	if err != nil || rows <= 0 || rows > maxScreenRows {
+		http.Error(w, "invalid rows", http.StatusBadRequest)
+		return
+	}
+	cols, err := parseIntQuery(r.URL.Query(), "cols", 80)
+	if err != nil || cols <= 0 || cols > maxScreenCols {
+		http.Error(w, "invalid cols", http.StatusBadRequest)
+		return
+	}
+
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	chunks, err := session.GetHistoryFromSequence(1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, screenResponse{
+		Lines: terminal.RenderScreen(chunks, int(rows), int(cols)),
+		Rows:  int(rows),
+		Cols:  int(cols),
+	})
+}
+
+type signalSessionRequest struct {
+	Signal string `json:"signal"`
+}
+
+// allowedSignals is the set of signals clients may deliver to a session's
+// process group. Anything outside this list is rejected rather than passed
+// through, so the endpoint cannot be used for exotic signals like SIGSTOP on
+// the shell's whole session.
+var allowedSignals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// handleSessionSignal delivers an allowlisted signal to the PTY process
+// group, so clients can interrupt a runaway command even when the terminal no
+// longer responds to ^C bytes.
+func (s *Server) handleSessionSignal(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req signalSessionRequest
+	if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.message, httpErr.status)
+			return
+		}
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	sig, ok := allowedSignals[strings.ToUpper(strings.TrimSpace(req.Signal))]
+	if !ok {
+		http.Error(w, "unsupported signal", http.StatusBadRequest)
+		return
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if err := session.SignalProcessGroup(sig); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type resizePolicyRequest struct {
+	Policy string `json:"policy"`
+}
+
+// handleSessionResizePolicy switches how the session reconciles the shared
+// PTY size ("min", "max", "lastWriter" or "fixed:WxH"), so a briefly attached
+// small viewport does not have to shrink everyone else's terminal.
+func (s *Server) handleSessionResizePolicy(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req resizePolicyRequest
+	if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.message, httpErr.status)
+			return
+		}
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := terminal.ParseResizePolicy(strings.TrimSpace(req.Policy))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if err := session.SetResizePolicy(policy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPISessionInfo(session.ToSessionInfo()))
+}
+
+// handleSessionPatch applies a partial metadata update (name, tags, notes,
+// name lock) in one call. Absent fields keep their current values.
+func (s *Server) handleSessionPatch(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var req patchSessionRequest
+	if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.message, httpErr.status)
+			return
+		}
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	update := terminal.SessionMetadataUpdate{NameLocked: req.NameLocked}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			http.Error(w, "invalid name: must not be empty", http.StatusBadRequest)
+			return
+		}
+		if len(name) > maxSessionNameBytes {
+			http.Error(w, "invalid name: too long", http.StatusBadRequest)
+			return
+		}
+		update.Name = &name
+	}
+	if req.Tags != nil {
+		if len(*req.Tags) > maxSessionTags {
+			http.Error(w, "invalid tags: too many", http.StatusBadRequest)
+			return
+		}
+		tags := make([]string, 0, len(*req.Tags))
+		for _, tag := range *req.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				http.Error(w, "invalid tags: must not contain empty tags", http.StatusBadRequest)
+				return
+			}
+			if len(tag) > maxSessionTagBytes {
+				http.Error(w, "invalid tags: tag too long", http.StatusBadRequest)
+				return
+			}
+			tags = append(tags, tag)
+		}
+		update.Tags = &tags
+	}
+	if req.Notes != nil {
+		if len(*req.Notes) > maxSessionNotesBytes {
+			http.Error(w, "invalid notes: too long", http.StatusBadRequest)
+			return
+		}
+		update.Notes = req.Notes
+	}
+
+	oldName := ""
+	if update.Name != nil {
+		if current, ok := s.manager.GetSession(sessionID); ok {
+			oldName = current.GetName()
+		}
+	}
+	if err := s.manager.UpdateSessionMetadata(sessionID, update); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if update.Name != nil {
+		s.publishRenamed(sessionID, oldName, *update.Name)
+	}
+	writeJSON(w, http.StatusOK, toAPISessionInfo(session.ToSessionInfo()))
+}
+
+// publishRenamed surfaces an explicit API rename on the lifecycle stream.
+// Automatic working-directory renames already flow through
+// OnTerminalNameChanged; the manager emits no event for explicit renames.
+func (s *Server) publishRenamed(sessionID, oldName, newName string) {
+	workingDir := ""
+	owner := ""
+	if session, ok := s.manager.GetSession(sessionID); ok {
+		workingDir = session.GetWorkingDir()
+		owner = session.Owner
+	}
+	s.events.publishLifecycle(sessionLifecycleEvent{
+		Type:       "renamed",
+		SessionID:  sessionID,
+		OldName:    oldName,
+		NewName:    newName,
+		WorkingDir: workingDir,
+		owner:      owner,
+	})
+}
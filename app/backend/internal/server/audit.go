@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// The audit log records security-relevant operations — authentication
+// attempts, session lifecycle, input batches and admin operations — as
+// structured events. SIEM pipelines can consume them live from the
+// authenticated /api/audit/events SSE stream or from a JSONL file sink,
+// rather than scraping text logs.
+
+// Audit event types.
+const (
+	auditAuthSuccess   = "auth.success"
+	auditAuthFailure   = "auth.failure"
+	auditSessionCreate = "session.create"
+	auditSessionDelete = "session.delete"
+	auditSessionRename = "session.rename"
+	auditInput         = "input"
+	auditExec          = "exec"
+	auditProfileCreate = "profile.create"
+	auditProfileUpdate = "profile.update"
+	auditProfileDelete = "profile.delete"
+)
+
+const auditSubscriberBuffer = 256
+
+// auditEvent is one audit record. Input events carry only byte counts, never
+// keystroke content.
+type auditEvent struct {
+	TimestampMs int64  `json:"timestampMs"`
+	Type        string `json:"type"`
+	User        string `json:"user,omitempty"`
+	RemoteIP    string `json:"remoteIp,omitempty"`
+	SessionID   string `json:"sessionId,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	Bytes       int    `json:"bytes,omitempty"`
+}
+
+type auditSubscriber struct {
+	lines chan []byte
+}
+
+// auditLog serializes events to the optional JSONL sink and fans them out to
+// SSE subscribers. Slow subscribers drop events rather than stall request
+// handling; the file sink never drops.
+type auditLog struct {
+	mu          sync.Mutex
+	sink        io.Writer
+	subscribers map[*auditSubscriber]struct{}
+}
+
+func newAuditLog(sink io.Writer) *auditLog {
+	return &auditLog{
+		sink:        sink,
+		subscribers: make(map[*auditSubscriber]struct{}),
+	}
+}
+
+func (a *auditLog) record(event auditEvent) {
+	if event.TimestampMs == 0 {
+		event.TimestampMs = time.Now().UnixMilli()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.sink == nil && len(a.subscribers) == 0 {
+		return
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if a.sink != nil {
+		a.sink.Write(append(line, '\n'))
+	}
+	for sub := range a.subscribers {
+		select {
+		case sub.lines <- line:
+		default:
+		}
+	}
+}
+
+func (a *auditLog) subscribe() *auditSubscriber {
+	sub := &auditSubscriber{lines: make(chan []byte, auditSubscriberBuffer)}
+	a.mu.Lock()
+	a.subscribers[sub] = struct{}{}
+	a.mu.Unlock()
+	return sub
+}
+
+func (a *auditLog) unsubscribe(sub *auditSubscriber) {
+	a.mu.Lock()
+	delete(a.subscribers, sub)
+	a.mu.Unlock()
+}
+
+// handleAuditEvents streams audit records as server-sent events. Named
+// non-admin identities are rejected: audit data spans all tenants.
+func (s *Server) handleAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if id := identityFromContext(r.Context()); !id.admin && id.name != "" {
+		http.Error(w, "admin access required", http.StatusForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := s.audit.subscribe()
+	defer s.audit.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case line := <-sub.lines:
+			if _, err := w.Write([]byte("event: audit\ndata: " + string(line) + "\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// auditBackend records input batches written through the live protocol. Like
+// the other backend wrappers it forwards optional capabilities explicitly.
+type auditBackend struct {
+	livev1.Backend
+	audit *auditLog
+}
+
+func (b auditBackend) WriteInput(ctx context.Context, attachment livev1.Attach, input livev1.Input) error {
+	err := b.Backend.WriteInput(ctx, attachment, input)
+	if err == nil {
+		b.audit.record(auditEvent{
+			Type:      auditInput,
+			SessionID: attachment.SessionID,
+			Detail:    attachment.ConnectionID,
+			Bytes:     len(input.Data),
+		})
+	}
+	return err
+}
+
+// ReplayOutput forwards the reconnect catch-up capability, which struct
+// embedding does not promote for optional interfaces.
+func (b auditBackend) ReplayOutput(ctx context.Context, attachment livev1.Attach, afterSequence uint64) ([]livev1.OutputRecord, error) {
+	if replayer, ok := b.Backend.(livev1.OutputReplayer); ok {
+		return replayer.ReplayOutput(ctx, attachment, afterSequence)
+	}
+	return nil, nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// syncBuffer is a goroutine-safe JSONL sink for tests.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) events(t *testing.T) []auditEvent {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var events []auditEvent
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event auditEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid audit line %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func auditEventTypes(events []auditEvent) map[string]auditEvent {
+	out := make(map[string]auditEvent, len(events))
+	for _, event := range events {
+		out[event.Type] = event
+	}
+	return out
+}
+
+func TestAuditSinkRecordsAuthAndLifecycle(t *testing.T) {
+	sink := &syncBuffer{}
+	srv := New(Config{
+		AuthToken: "audit-token",
+		AuditLog:  sink,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	// Unauthenticated request: auth.failure.
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status=%d, want 401", resp.StatusCode)
+	}
+
+	// Authenticated create and delete: auth.success + lifecycle events.
+	req, _ := http.NewRequest(http.MethodPost, httpSrv.URL+"/api/sessions", strings.NewReader(`{"name":"audited"}`))
+	req.Header.Set("Authorization", "Bearer audit-token")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+created.ID, nil)
+	req.Header.Set("Authorization", "Bearer audit-token")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	events := auditEventTypes(sink.events(t))
+	if _, ok := events[auditAuthFailure]; !ok {
+		t.Fatalf("no auth.failure event, got %v", events)
+	}
+	if _, ok := events[auditAuthSuccess]; !ok {
+		t.Fatalf("no auth.success event, got %v", events)
+	}
+	create, ok := events[auditSessionCreate]
+	if !ok || create.SessionID != created.ID || create.Detail != "audited" {
+		t.Fatalf("session.create event = %+v", create)
+	}
+	if deleted, ok := events[auditSessionDelete]; !ok || deleted.SessionID != created.ID {
+		t.Fatalf("session.delete event = %+v", deleted)
+	}
+}
+
+func TestAuditStreamRejectsNonAdmin(t *testing.T) {
+	srv := New(Config{
+		AuthUsers: []AuthUser{{Name: "viewer", Token: "viewer-token"}},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, httpSrv.URL+"/api/audit/events", nil)
+	req.Header.Set("Authorization", "Bearer viewer-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status=%d, want 403", resp.StatusCode)
+	}
+}
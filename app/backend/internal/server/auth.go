@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// resolveAuthToken returns the effective bearer token from the configuration.
+// An explicit token wins over a token file; an empty result disables auth.
+func resolveAuthToken(cfg Config) (string, error) {
+	if cfg.AuthToken != "" {
+		return cfg.AuthToken, nil
+	}
+	if cfg.AuthTokenFile == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(cfg.AuthTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read auth token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.New("auth token file is empty")
+	}
+	return token, nil
+}
+
+// withAuth enforces authentication on protected requests. Bearer tokens cover
+// /api and /ws; static SPA assets stay reachable so the frontend can render
+// its login prompt. Basic auth additionally covers the SPA routes since the
+// browser handles the credential prompt itself. When auth is disabled the
+// handler chain is returned unchanged; when the token could not be loaded the
+// server fails closed.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Share tokens authenticate on their own but are limited to view-only
+		// access to their session, regardless of other configured auth.
+		if grant, ok := s.lookupShareGrant(requestToken(r)); ok {
+			if !shareRequestAllowed(r, grant.sessionID) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "share token is view-only"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withShareScope(r.Context(), grant)))
+			return
+		}
+		// ttyd and gotty clients cannot set headers and send their credential
+		// as the first protocol message instead; the handlers enforce it.
+		if r.URL.Path == "/ws" && r.URL.Query().Get("mode") == "ttyd" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/gotty/") && strings.HasSuffix(r.URL.Path, "/ws") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Attach tickets authenticate exactly one websocket handshake; the
+		// mint call already went through regular auth.
+		if r.URL.Path == "/ws" || r.URL.Path == "/ws/rpc" {
+			if token := r.URL.Query().Get("ticket"); token != "" {
+				ticket, ok := s.consumeAttachTicket(token)
+				if !ok {
+					writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or expired ticket"})
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(withTicketScope(r.Context(), ticket)))
+				return
+			}
+		}
+		if s.authToken == "" && s.basicAuthUser == "" && len(s.authUsers) == 0 && !s.clientCertAuth && s.pamAuth == nil && s.authErr == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		protected := isProtectedPath(r.URL.Path) || s.basicAuthUser != "" || s.pamAuth != nil
+		if !protected {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.authErr != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "authentication misconfigured"})
+			return
+		}
+		if id, ok := s.authenticate(r); ok {
+			s.audit.record(auditEvent{
+				Type:     auditAuthSuccess,
+				User:     id.name,
+				RemoteIP: s.clientIP(r),
+				Path:     r.URL.Path,
+			})
+			next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), id)))
+			return
+		}
+		s.audit.record(auditEvent{
+			Type:     auditAuthFailure,
+			RemoteIP: s.clientIP(r),
+			Path:     r.URL.Path,
+		})
+		if s.basicAuthUser != "" || s.pamAuth != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="floeterm"`)
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	})
+}
+
+// authenticate resolves the caller's identity from any configured credential.
+// Single-token and basic auth callers act as admins to preserve the
+// single-tenant behaviour; per-user tokens carry their configured role.
+func (s *Server) authenticate(r *http.Request) (identity, bool) {
+	// A verified client certificate is authentication by itself; the listener
+	// already checked the signature against the configured CA.
+	if s.clientCertAuth && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		name := r.TLS.PeerCertificates[0].Subject.CommonName
+		if name != "" {
+			id := identity{name: name}
+			for _, user := range s.authUsers {
+				if user.Name == name {
+					id.admin = user.Admin
+				}
+			}
+			return id, true
+		}
+	}
+
+	token := requestToken(r)
+	for _, user := range s.authUsers {
+		if tokenMatches(token, user.Token) {
+			return identity{name: user.Name, admin: user.Admin}, true
+		}
+	}
+	if s.authToken != "" && tokenMatches(token, s.authToken) {
+		return identity{admin: true}, true
+	}
+	if s.basicAuthUser != "" && s.basicAuthMatches(r) {
+		return identity{name: s.basicAuthUser, admin: true}, true
+	}
+	// PAM callers are plain users, never admins: each account only sees and
+	// controls its own sessions.
+	if s.pamAuth != nil {
+		if user, pass, ok := r.BasicAuth(); ok && s.pamAuth.verify(user, pass) {
+			return identity{name: user}, true
+		}
+	}
+	return identity{}, false
+}
+
+// identity describes the authenticated caller. The zero value means "no auth
+// configured" and is treated as an admin for backwards compatibility.
+type identity struct {
+	name  string
+	admin bool
+}
+
+// canAccess reports whether the caller may operate on a session with the
+// given owner. Unowned sessions, admin callers and anonymous (auth disabled)
+// callers always pass.
+func (id identity) canAccess(owner string) bool {
+	if owner == "" || id.admin || id.name == "" {
+		return true
+	}
+	return id.name == owner
+}
+
+type identityContextKey struct{}
+
+func withIdentity(ctx context.Context, id identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+func identityFromContext(ctx context.Context) identity {
+	id, _ := ctx.Value(identityContextKey{}).(identity)
+	return id
+}
+
+// basicAuthMatches verifies the request's basic auth credentials against the
+// configured username and bcrypt password hash.
+func (s *Server) basicAuthMatches(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userSum := sha256.Sum256([]byte(user))
+	wantSum := sha256.Sum256([]byte(s.basicAuthUser))
+	if subtle.ConstantTimeCompare(userSum[:], wantSum[:]) != 1 {
+		// Still burn a bcrypt comparison so username probing is not faster.
+		_ = bcrypt.CompareHashAndPassword([]byte(s.basicAuthHash), []byte(pass))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.basicAuthHash), []byte(pass)) == nil
+}
+
+func isProtectedPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/api" ||
+		strings.HasPrefix(path, "/ws/") || path == "/ws" ||
+		strings.HasPrefix(path, "/gotty/") ||
+		path == "/metrics"
+}
+
+// requestToken extracts the caller's token from the Authorization header or,
+// for WebSocket clients that cannot set headers, the token query parameter.
+func requestToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+// tokenMatches compares tokens in constant time regardless of length.
+func tokenMatches(got, want string) bool {
+	if got == "" {
+		return false
+	}
+	gotSum := sha256.Sum256([]byte(got))
+	wantSum := sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(gotSum[:], wantSum[:]) == 1
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newAuthTestServer(t *testing.T, cfg Config) *httptest.Server {
+	t.Helper()
+	cfg.ManagerConfig = terminal.ManagerConfig{
+		Logger:            terminal.NopLogger{},
+		ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+	}
+	srv := New(cfg)
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	return httpSrv
+}
+
+func TestAuthTokenProtectsAPIRequests(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{AuthToken: "secret"})
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated status=%d, want 401", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, httpSrv.URL+"/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("authenticated status=%d, want 200", resp.StatusCode)
+	}
+	var sessions []apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+
+	// WebSocket clients cannot set headers; the token query parameter works.
+	resp, err = http.Get(httpSrv.URL + "/api/sessions?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("query token status=%d, want 200", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, httpSrv.URL+"/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong token status=%d, want 401", resp.StatusCode)
+	}
+}
+
+func TestAuthTokenFromFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("filetoken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := newAuthTestServer(t, Config{AuthTokenFile: tokenFile})
+
+	req, _ := http.NewRequest(http.MethodGet, httpSrv.URL+"/api/sessions", nil)
+	req.Header.Set("Authorization", "Bearer filetoken")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("file token status=%d, want 200", resp.StatusCode)
+	}
+}
+
+func TestAuthFailsClosedWhenTokenFileMissing(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{AuthTokenFile: "/nonexistent/token"})
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d, want 503", resp.StatusCode)
+	}
+}
+
+func TestBasicAuthProtectsAPIAndSPA(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staticDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(staticDir, "index.html"), []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := newAuthTestServer(t, Config{
+		StaticDir:             staticDir,
+		BasicAuthUsername:     "admin",
+		BasicAuthPasswordHash: string(hash),
+	})
+
+	for _, path := range []string{"/api/sessions", "/"} {
+		resp, err := http.Get(httpSrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s unauthenticated status=%d, want 401", path, resp.StatusCode)
+		}
+		if got := resp.Header.Get("WWW-Authenticate"); got != `Basic realm="floeterm"` {
+			t.Fatalf("WWW-Authenticate=%q", got)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, httpSrv.URL+path, nil)
+		req.SetBasicAuth("admin", "hunter2")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s authenticated status=%d, want 200", path, resp.StatusCode)
+		}
+
+		req, _ = http.NewRequest(http.MethodGet, httpSrv.URL+path, nil)
+		req.SetBasicAuth("admin", "wrong")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s wrong password status=%d, want 401", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestClientCertAuthMapsCommonName(t *testing.T) {
+	srv := New(Config{
+		ClientCertAuth: true,
+		AuthUsers:      []AuthUser{{Name: "root", Token: "root-token", Admin: true}},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	defer srv.Close()
+	handler := srv.Handler()
+
+	// The TLS listener has already verified the certificate chain by the time
+	// the handler runs, so the test injects the connection state directly.
+	withCert := func(name string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/sessions", nil)
+		if name != "" {
+			req.TLS = &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: name}}},
+			}
+		}
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, withCert(""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no cert status=%d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, withCert("alice"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cert status=%d, want 200", rec.Code)
+	}
+
+	if id, ok := srv.authenticate(withCert("alice")); !ok || id.name != "alice" || id.admin {
+		t.Fatalf("identity=%+v ok=%v, want non-admin alice", id, ok)
+	}
+	if id, ok := srv.authenticate(withCert("root")); !ok || !id.admin {
+		t.Fatalf("identity=%+v ok=%v, want admin root", id, ok)
+	}
+}
+
+func TestSessionOwnershipEnforcement(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{
+		AuthUsers: []AuthUser{
+			{Name: "alice", Token: "alice-token"},
+			{Name: "bob", Token: "bob-token"},
+			{Name: "root", Token: "root-token", Admin: true},
+		},
+	})
+
+	do := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req, _ := http.NewRequest(method, httpSrv.URL+path, reader)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// alice creates a session owned by her.
+	resp := do(http.MethodPost, "/api/sessions", "alice-token", `{"name":"mine"}`)
+	var created apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if created.Owner != "alice" {
+		t.Fatalf("owner=%q, want alice", created.Owner)
+	}
+
+	// bob cannot see it in listings.
+	resp = do(http.MethodGet, "/api/sessions", "bob-token", "")
+	var bobSessions []apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&bobSessions); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(bobSessions) != 0 {
+		t.Fatalf("bob sees %d sessions, want 0", len(bobSessions))
+	}
+
+	// bob cannot delete it; the session is hidden as a 404.
+	resp = do(http.MethodDelete, "/api/sessions/"+created.ID, "bob-token", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("bob delete status=%d, want 404", resp.StatusCode)
+	}
+
+	// the admin sees and may delete it.
+	resp = do(http.MethodGet, "/api/sessions", "root-token", "")
+	var adminSessions []apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&adminSessions); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(adminSessions) != 1 {
+		t.Fatalf("admin sees %d sessions, want 1", len(adminSessions))
+	}
+	resp = do(http.MethodDelete, "/api/sessions/"+created.ID, "root-token", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("admin delete status=%d, want 204", resp.StatusCode)
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// normalizeBasePath canonicalizes a mount prefix to either "" (serve at the
+// root) or "/prefix" with a leading slash and no trailing slash, so the rest
+// of the server can concatenate it without special cases.
+func normalizeBasePath(raw string) string {
+	path := strings.TrimSpace(raw)
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+// mountUnder serves the handler below a URL prefix, as when floeterm sits
+// behind a reverse proxy at a subpath. The prefix is stripped before the
+// inner handler runs so route matching, auth and rate limiting keep working
+// on the unprefixed paths; requests outside the prefix are not ours.
+func mountUnder(basePath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == basePath {
+			http.Redirect(w, r, basePath+"/", http.StatusMovedPermanently)
+			return
+		}
+		rest, ok := strings.CutPrefix(r.URL.Path, basePath+"/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/" + rest
+		next.ServeHTTP(w, r2)
+	})
+}
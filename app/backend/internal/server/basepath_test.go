@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestNormalizeBasePath(t *testing.T) {
+	cases := map[string]string{
+		"":            "",
+		"/":           "",
+		"floeterm":    "/floeterm",
+		"/floeterm":   "/floeterm",
+		"/floeterm/":  "/floeterm",
+		" /a/b/ ":     "/a/b",
+		"//floeterm/": "/floeterm",
+	}
+	for raw, want := range cases {
+		if got := normalizeBasePath(raw); got != want {
+			t.Fatalf("normalizeBasePath(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestBasePathMountsAllRoutes(t *testing.T) {
+	staticDir := t.TempDir()
+	index := "<!DOCTYPE html>\n<html>\n<head>\n<title>floeterm</title>\n</head>\n<body></body>\n</html>\n"
+	if err := os.WriteFile(filepath.Join(staticDir, "index.html"), []byte(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New(Config{
+		BasePath:  "/floeterm/",
+		StaticDir: staticDir,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+
+	// API routes answer under the prefix and only there.
+	resp, err := http.Get(httpSrv.URL + "/floeterm/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sessions []apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("prefixed api status=%d, want 200", resp.StatusCode)
+	}
+
+	resp, err = http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unprefixed api status=%d, want 404", resp.StatusCode)
+	}
+
+	// The bare prefix redirects to the SPA root below it.
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err = client.Get(httpSrv.URL + "/floeterm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "/floeterm/" {
+		t.Fatalf("bare prefix status=%d location=%q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// The SPA entrypoint gets a <base> tag so its relative assets resolve
+	// under the prefix.
+	resp, err = http.Get(httpSrv.URL + "/floeterm/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `<base href="/floeterm/">`) {
+		t.Fatalf("index.html missing base tag: %s", body)
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/coder/websocket"
+)
+
+// WSCompressionConfig enables permessage-deflate on the websocket endpoints.
+// Text-heavy output (logs, build output) compresses well for remote users;
+// LAN deployments can leave it off and skip the CPU cost. The zero value
+// disables compression. The deflate level is fixed by the websocket
+// implementation; mode and threshold are the negotiable knobs.
+type WSCompressionConfig struct {
+	// Mode selects the negotiation mode: "" or "disabled" turns compression
+	// off, "context-takeover" reuses the 32 KiB sliding window across
+	// messages (best ratio, more memory per connection) and
+	// "no-context-takeover" compresses each message independently.
+	Mode string
+
+	// ThresholdBytes is the minimum message size before compression is
+	// applied; smaller messages go out uncompressed. Zero keeps the
+	// per-mode defaults (128 bytes with context takeover, 512 without).
+	ThresholdBytes int
+}
+
+// parseWSCompression maps the string mode onto the websocket library's
+// enum. An unknown mode is an error so a typo does not silently change the
+// deployment's bandwidth profile.
+func parseWSCompression(cfg WSCompressionConfig) (websocket.CompressionMode, int, error) {
+	switch cfg.Mode {
+	case "", "disabled":
+		return websocket.CompressionDisabled, 0, nil
+	case "context-takeover":
+		return websocket.CompressionContextTakeover, cfg.ThresholdBytes, nil
+	case "no-context-takeover":
+		return websocket.CompressionNoContextTakeover, cfg.ThresholdBytes, nil
+	default:
+		return websocket.CompressionDisabled, 0, fmt.Errorf("invalid websocket compression mode %q", cfg.Mode)
+	}
+}
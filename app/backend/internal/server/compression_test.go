@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestParseWSCompression(t *testing.T) {
+	cases := []struct {
+		mode    string
+		want    websocket.CompressionMode
+		wantErr bool
+	}{
+		{mode: "", want: websocket.CompressionDisabled},
+		{mode: "disabled", want: websocket.CompressionDisabled},
+		{mode: "context-takeover", want: websocket.CompressionContextTakeover},
+		{mode: "no-context-takeover", want: websocket.CompressionNoContextTakeover},
+		{mode: "fastest", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, _, err := parseWSCompression(WSCompressionConfig{Mode: tc.mode, ThresholdBytes: 256})
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("mode %q: expected error", tc.mode)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("mode %q: got %v err=%v", tc.mode, got, err)
+		}
+	}
+}
+
+func TestWebSocketCompressionStillServesRPC(t *testing.T) {
+	srv := New(Config{
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+		WSCompression: WSCompressionConfig{Mode: "no-context-takeover", ThresholdBytes: 64},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	created := createTestSession(t, httpSrv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/rpc", &websocket.DialOptions{
+		CompressionMode: websocket.CompressionNoContextTakeover,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+
+	resp := rpcCall(t, ctx, conn, 1, "attach", rpcAttachParams{SessionID: created.ID, Cols: 80, Rows: 24})
+	if resp.Error != nil {
+		t.Fatalf("attach failed: %+v", resp.Error)
+	}
+	// Push enough repetitive text past the threshold to exercise the deflate
+	// path in both directions.
+	payload := bytes.Repeat([]byte("compressible "), 64)
+	resp = rpcCall(t, ctx, conn, 2, "input", rpcInputParams{Data: append(payload, '\n')})
+	if resp.Error != nil {
+		t.Fatalf("input failed: %+v", resp.Error)
+	}
+
+	deadline := time.After(10 * time.Second)
+	var echoed []byte
+	for !bytes.Contains(echoed, payload) {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for echoed output")
+		default:
+		}
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var note struct {
+			Method string                `json:"method"`
+			Params rpcOutputNotification `json:"params"`
+		}
+		if err := json.Unmarshal(data, &note); err != nil {
+			continue
+		}
+		if note.Method == "output" {
+			echoed = append(echoed, note.Params.Data...)
+		}
+	}
+}
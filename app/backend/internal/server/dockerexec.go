@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// Docker-backed sessions drop users straight into a container: the session's
+// PTY child is this binary re-exec'd as a small Docker API client that runs
+// `docker exec -it` against the engine socket and bridges the TTY stream.
+// Like the remote SSH backend, the bridge is an ordinary PTY child, so
+// history, reconnects, resizes and exit codes behave exactly like a local
+// session. The engine API is spoken directly over the unix socket with the
+// standard library — the handful of calls needed (exec create, start with a
+// hijacked connection, resize, inspect) do not justify a client dependency.
+
+// DockerExecSpec describes a session that execs into a container.
+type DockerExecSpec struct {
+	// Container is the target container name or ID. Required.
+	Container string `json:"container"`
+	// Cmd is the command to exec; empty defaults to /bin/sh.
+	Cmd []string `json:"cmd,omitempty"`
+	// User runs the exec as this user (user, user:group, uid). Empty keeps
+	// the container's default.
+	User string `json:"user,omitempty"`
+	// WorkingDir sets the working directory inside the container.
+	WorkingDir string `json:"workingDir,omitempty"`
+	// Socket is the engine socket path; empty defaults to
+	// /var/run/docker.sock.
+	Socket string `json:"socket,omitempty"`
+}
+
+func (spec DockerExecSpec) validate() error {
+	if spec.Container == "" {
+		return errors.New("docker container must not be empty")
+	}
+	return nil
+}
+
+// dockerExecHelperArg is the hidden argv[1] marker for the docker exec
+// re-exec.
+const dockerExecHelperArg = "__floeterm-docker-exec"
+
+const defaultDockerSocket = "/var/run/docker.sock"
+
+// applyDockerExec points the session's shell at the docker exec helper.
+func applyDockerExec(opts *terminal.CreateSessionOptions, spec DockerExecSpec) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate own binary for docker re-exec: %w", err)
+	}
+	payload, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("cannot encode docker spec: %w", err)
+	}
+	opts.Shell = self
+	opts.ShellArgs = []string{dockerExecHelperArg, string(payload)}
+	return nil
+}
+
+// MaybeRunDockerExecHelper handles the hidden docker exec invocation:
+// argv = [self, marker, spec-json]. When the marker is present it bridges
+// stdio to an exec TTY in the container and exits with the exec's status,
+// never returning; otherwise it does nothing. Call it at the top of main.
+func MaybeRunDockerExecHelper() {
+	if len(os.Args) < 3 || os.Args[1] != dockerExecHelperArg {
+		return
+	}
+	var spec DockerExecSpec
+	if err := json.Unmarshal([]byte(os.Args[2]), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "docker helper: invalid spec: %v\r\n", err)
+		os.Exit(125)
+	}
+	code, err := runDockerExec(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "docker helper: %v\r\n", err)
+		os.Exit(255)
+	}
+	os.Exit(code)
+}
+
+// dockerAPI is the minimal engine client: JSON calls over the unix socket.
+type dockerAPI struct {
+	socket string
+	client *http.Client
+}
+
+func newDockerAPI(socket string) *dockerAPI {
+	if socket == "" {
+		socket = defaultDockerSocket
+	}
+	return &dockerAPI{
+		socket: socket,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			},
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// call performs a JSON request against the engine and decodes the response
+// into out when non-nil.
+func (d *dockerAPI) call(method, path string, body any, out any) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, "http://docker"+path, payload)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("engine request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("engine returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// runDockerExec creates a TTY exec in the container, attaches over a hijacked
+// connection and bridges stdio until the exec ends. SIGWINCH — delivered when
+// the session PTY resizes — is forwarded as an exec resize.
+func runDockerExec(spec DockerExecSpec) (int, error) {
+	api := newDockerAPI(spec.Socket)
+
+	cmd := spec.Cmd
+	if len(cmd) == 0 {
+		cmd = []string{"/bin/sh"}
+	}
+	termName := os.Getenv("TERM")
+	if termName == "" {
+		termName = "xterm-256color"
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := api.call(http.MethodPost, "/containers/"+url.PathEscape(spec.Container)+"/exec", map[string]any{
+		"AttachStdin":  true,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Tty":          true,
+		"Cmd":          cmd,
+		"User":         spec.User,
+		"WorkingDir":   spec.WorkingDir,
+		"Env":          []string{"TERM=" + termName},
+	}, &created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec in %s: %w", spec.Container, err)
+	}
+
+	if cols, rows, err := term.GetSize(int(os.Stdin.Fd())); err == nil && cols > 0 && rows > 0 {
+		_ = api.resizeExec(created.ID, cols, rows)
+	}
+
+	conn, reader, err := api.startExecHijacked(created.ID)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	go func() {
+		for range winch {
+			if cols, rows, err := term.GetSize(int(os.Stdin.Fd())); err == nil && cols > 0 && rows > 0 {
+				_ = api.resizeExec(created.ID, cols, rows)
+			}
+		}
+	}()
+
+	// Input pump; the exec ends when the output copy below sees EOF.
+	go func() {
+		_, _ = io.Copy(conn, os.Stdin)
+	}()
+	_, _ = io.Copy(os.Stdout, reader)
+
+	var inspected struct {
+		ExitCode int  `json:"ExitCode"`
+		Running  bool `json:"Running"`
+	}
+	if err := api.call(http.MethodGet, "/exec/"+created.ID+"/json", nil, &inspected); err != nil {
+		return 0, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	return inspected.ExitCode, nil
+}
+
+func (d *dockerAPI) resizeExec(execID string, cols, rows int) error {
+	path := "/exec/" + execID + "/resize?w=" + strconv.Itoa(cols) + "&h=" + strconv.Itoa(rows)
+	return d.call(http.MethodPost, path, nil, nil)
+}
+
+// startExecHijacked starts the exec and upgrades the connection to the raw
+// TTY stream. The engine speaks plain HTTP/1.1 here, so the upgrade is
+// written by hand: net/http cannot hand back the connection after a 101.
+func (d *dockerAPI) startExecHijacked(execID string) (net.Conn, io.Reader, error) {
+	conn, err := net.Dial("unix", d.socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reach engine socket: %w", err)
+	}
+	body := `{"Detach":false,"Tty":true}`
+	request := "POST /exec/" + execID + "/start HTTP/1.1\r\n" +
+		"Host: docker\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: tcp\r\n" +
+		"Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" +
+		body
+	if _, err := conn.Write([]byte(request)); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to start exec: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to read exec start response: %w", err)
+	}
+	if !bytes.Contains([]byte(status), []byte(" 101 ")) && !bytes.Contains([]byte(status), []byte(" 200 ")) {
+		conn.Close()
+		return nil, nil, fmt.Errorf("engine refused exec start: %s", bytes.TrimSpace([]byte(status)))
+	}
+	// Skip the remaining headers; the raw stream begins after the blank line.
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("failed to read exec start headers: %w", err)
+		}
+		if line == "\r\n" || line == "\n" {
+			break
+		}
+	}
+	return conn, reader, nil
+}
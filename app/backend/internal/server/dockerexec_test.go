@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// fakeDockerEngine serves the handful of engine API calls the exec bridge
+// uses on a unix socket, echoing the hijacked TTY stream back.
+func fakeDockerEngine(t *testing.T) (string, *[]string) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var resizes []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/app/exec", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "exec-1"})
+	})
+	mux.HandleFunc("/exec/exec-1/resize", func(w http.ResponseWriter, r *http.Request) {
+		resizes = append(resizes, r.URL.RawQuery)
+	})
+	mux.HandleFunc("/exec/exec-1/start", func(w http.ResponseWriter, r *http.Request) {
+		conn, bufrw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		// The request body is still unread on the hijacked connection.
+		io.CopyN(io.Discard, bufrw, r.ContentLength)
+		bufrw.WriteString("HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		bufrw.Flush()
+		line, err := bufrw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		bufrw.WriteString(line)
+		bufrw.Flush()
+	})
+	mux.HandleFunc("/exec/exec-1/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"ExitCode": 7, "Running": false})
+	})
+	go http.Serve(listener, mux)
+	return socket, &resizes
+}
+
+func TestDockerAPIExecLifecycle(t *testing.T) {
+	socket, resizes := fakeDockerEngine(t)
+	api := newDockerAPI(socket)
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := api.call(http.MethodPost, "/containers/app/exec", map[string]any{"Tty": true}, &created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "exec-1" {
+		t.Fatalf("exec id = %q", created.ID)
+	}
+
+	if err := api.resizeExec(created.ID, 100, 30); err != nil {
+		t.Fatal(err)
+	}
+	if len(*resizes) != 1 || (*resizes)[0] != "w=100&h=30" {
+		t.Fatalf("resizes = %v", *resizes)
+	}
+
+	conn, reader, err := api.startExecHijacked(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		t.Fatal(err)
+	}
+	echoed, err := bufio.NewReader(reader).ReadString('\n')
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if echoed != "ping\n" {
+		t.Fatalf("echoed %q, want %q", echoed, "ping\n")
+	}
+
+	var inspected struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := api.call(http.MethodGet, "/exec/exec-1/json", nil, &inspected); err != nil {
+		t.Fatal(err)
+	}
+	if inspected.ExitCode != 7 {
+		t.Fatalf("exit code = %d, want 7", inspected.ExitCode)
+	}
+}
+
+func TestApplyDockerExecBuildsHelperInvocation(t *testing.T) {
+	spec := DockerExecSpec{Container: "app", Cmd: []string{"/bin/bash"}, User: "deploy"}
+	var opts terminal.CreateSessionOptions
+	if err := applyDockerExec(&opts, spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.ShellArgs) != 2 || opts.ShellArgs[0] != dockerExecHelperArg {
+		t.Fatalf("shell args = %v, want [%s <spec-json>]", opts.ShellArgs, dockerExecHelperArg)
+	}
+	var decoded DockerExecSpec
+	if err := json.Unmarshal([]byte(opts.ShellArgs[1]), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Container != "app" || decoded.User != "deploy" || strings.Join(decoded.Cmd, " ") != "/bin/bash" {
+		t.Fatalf("round-tripped spec = %+v", decoded)
+	}
+
+	if err := applyDockerExec(&opts, DockerExecSpec{}); err == nil {
+		t.Fatal("empty container accepted")
+	}
+}
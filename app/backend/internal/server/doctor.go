@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+)
+
+type doctorShellProbe struct {
+	Shell              string `json:"shell"`
+	ShellType          string `json:"shellType"`
+	OK                 bool   `json:"ok"`
+	RCSourced          bool   `json:"rcSourced"`
+	PathPrependApplied bool   `json:"pathPrependApplied"`
+	ZdotdirRestored    bool   `json:"zdotdirRestored"`
+	Error              string `json:"error,omitempty"`
+}
+
+type doctorResponse struct {
+	DefaultShell string             `json:"defaultShell"`
+	Probes       []doctorShellProbe `json:"probes"`
+}
+
+// handleServerDoctor probes shell integration health. By default it checks
+// the shell new sessions would use; additional shells can be requested with
+// repeated ?shell= query parameters.
+func (s *Server) handleServerDoctor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	defaultShell := s.manager.DefaultShell()
+	shells := r.URL.Query()["shell"]
+	if len(shells) == 0 {
+		shells = []string{defaultShell}
+	}
+
+	probes := make([]doctorShellProbe, 0, len(shells))
+	for _, shell := range shells {
+		result := s.manager.ProbeShellContext(r.Context(), shell)
+		probes = append(probes, doctorShellProbe{
+			Shell:              result.Shell,
+			ShellType:          result.ShellType,
+			OK:                 result.OK,
+			RCSourced:          result.RCSourced,
+			PathPrependApplied: result.PathPrependApplied,
+			ZdotdirRestored:    result.ZdotdirRestored,
+			Error:              result.Error,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, doctorResponse{
+		DefaultShell: defaultShell,
+		Probes:       probes,
+	})
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/coder/websocket"
+)
+
+// The /ws/events endpoint streams server-wide session lifecycle events as JSON
+// text messages, so the web UI's session list can update in real time instead
+// of polling GET /api/sessions. It carries no terminal output; per-session
+// data streams stay on /ws, /ws/rpc and the SSE endpoint.
+//
+// The first message is always {"type":"ready"}. Everything published after it
+// is guaranteed to reach the subscriber (modulo slow-reader drops), so clients
+// should fetch the session list once after seeing it and apply events on top.
+
+// sessionLifecycleEvent is one message on the /ws/events stream. Type is one
+// of "ready", "created", "renamed" or "closed"; renamed also covers the
+// automatic renames that follow working-directory changes, and closed carries
+// the exit details when the shell actually ran.
+type sessionLifecycleEvent struct {
+	Type              string `json:"type"`
+	SessionID         string `json:"sessionId,omitempty"`
+	Name              string `json:"name,omitempty"`
+	OldName           string `json:"oldName,omitempty"`
+	NewName           string `json:"newName,omitempty"`
+	WorkingDir        string `json:"workingDir,omitempty"`
+	CreatedAtMs       int64  `json:"createdAtMs,omitempty"`
+	ExitCode          *int   `json:"exitCode,omitempty"`
+	ExitedAtMs        int64  `json:"exitedAtMs,omitempty"`
+	TerminationReason string `json:"terminationReason,omitempty"`
+
+	// owner is the session's owner, used to filter the stream against the
+	// subscriber's identity the same way GET /api/sessions filters its list.
+	// It stays out of the wire format; unexported fields are never marshalled.
+	owner string
+}
+
+type lifecycleSubscriber struct {
+	events chan sessionLifecycleEvent
+}
+
+func (f *eventFanout) subscribeLifecycle() *lifecycleSubscriber {
+	sub := &lifecycleSubscriber{events: make(chan sessionLifecycleEvent, sseSubscriberBuffer)}
+	f.mu.Lock()
+	f.lifecycle[sub] = struct{}{}
+	f.mu.Unlock()
+	return sub
+}
+
+func (f *eventFanout) unsubscribeLifecycle(sub *lifecycleSubscriber) {
+	f.mu.Lock()
+	delete(f.lifecycle, sub)
+	f.mu.Unlock()
+}
+
+func (f *eventFanout) publishLifecycle(event sessionLifecycleEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.lifecycle {
+		select {
+		case sub.events <- event:
+		default:
+			f.metrics.IncCounter(metricBroadcastDrops, 1)
+		}
+	}
+}
+
+func (s *Server) handleEventsWS(w http.ResponseWriter, r *http.Request) {
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		OriginPatterns:       s.allowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.Close(websocket.StatusInternalError, "event stream failed")
+
+	// Events for sessions the caller does not own are withheld, mirroring the
+	// GET /api/sessions ownership filter.
+	id := identityFromContext(r.Context())
+
+	// The stream is write-only; CloseRead keeps control frames flowing and
+	// cancels the context when the peer goes away.
+	ctx := conn.CloseRead(r.Context())
+	s.startWSKeepalive(ctx, conn)
+
+	sub := s.events.subscribeLifecycle()
+	defer s.events.unsubscribeLifecycle(sub)
+
+	writeEvent := func(event sessionLifecycleEvent) bool {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return true
+		}
+		return conn.Write(ctx, websocket.MessageText, payload) == nil
+	}
+	if !writeEvent(sessionLifecycleEvent{Type: "ready"}) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-sub.events:
+			if !id.canAccess(event.owner) {
+				continue
+			}
+			if !writeEvent(event) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func readLifecycleEvent(t *testing.T, ctx context.Context, conn *websocket.Conn) sessionLifecycleEvent {
+	t.Helper()
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event sessionLifecycleEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("invalid event %q: %v", data, err)
+	}
+	return event
+}
+
+func TestEventsWebSocketStreamsSessionLifecycle(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// Events published after the ready marker are guaranteed to arrive, so
+	// everything below can be asserted in order.
+	if event := readLifecycleEvent(t, ctx, conn); event.Type != "ready" {
+		t.Fatalf("first event = %+v, want ready", event)
+	}
+
+	session := createTestSession(t, httpSrv.URL)
+	event := readLifecycleEvent(t, ctx, conn)
+	if event.Type != "created" || event.SessionID != session.ID {
+		t.Fatalf("created event = %+v", event)
+	}
+	if event.Name == "" || event.CreatedAtMs == 0 {
+		t.Fatalf("created event missing metadata: %+v", event)
+	}
+
+	body, err := json.Marshal(renameSessionRequest{NewName: "observed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(httpSrv.URL+"/api/sessions/"+session.ID+"/rename", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("rename status=%d", resp.StatusCode)
+	}
+	event = readLifecycleEvent(t, ctx, conn)
+	if event.Type != "renamed" || event.SessionID != session.ID || event.NewName != "observed" {
+		t.Fatalf("renamed event = %+v", event)
+	}
+	if event.OldName != session.Name {
+		t.Fatalf("renamed old name = %q, want %q", event.OldName, session.Name)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+session.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	event = readLifecycleEvent(t, ctx, conn)
+	if event.Type != "closed" || event.SessionID != session.ID {
+		t.Fatalf("closed event = %+v", event)
+	}
+}
+
+func TestEventsWebSocketFiltersByOwnership(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{
+		AuthUsers: []AuthUser{
+			{Name: "alice", Token: "alice-token"},
+			{Name: "bob", Token: "bob-token"},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	dial := func(token string) *websocket.Conn {
+		t.Helper()
+		conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/events?token="+token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event := readLifecycleEvent(t, ctx, conn); event.Type != "ready" {
+			t.Fatalf("first event = %+v, want ready", event)
+		}
+		return conn
+	}
+	aliceConn := dial("alice-token")
+	defer aliceConn.Close(websocket.StatusNormalClosure, "")
+	bobConn := dial("bob-token")
+	defer bobConn.Close(websocket.StatusNormalClosure, "")
+
+	createSession := func(token string) apiSessionInfo {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, httpSrv.URL+"/api/sessions", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var created apiSessionInfo
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatal(err)
+		}
+		return created
+	}
+
+	aliceSession := createSession("alice-token")
+	if event := readLifecycleEvent(t, ctx, aliceConn); event.Type != "created" || event.SessionID != aliceSession.ID {
+		t.Fatalf("alice created event = %+v", event)
+	}
+
+	// bob's stream must skip alice's session entirely: the first event he sees
+	// after ready is his own, just as GET /api/sessions would not list hers.
+	bobSession := createSession("bob-token")
+	if event := readLifecycleEvent(t, ctx, bobConn); event.Type != "created" || event.SessionID != bobSession.ID {
+		t.Fatalf("bob's first event = %+v, want his own created event", event)
+	}
+
+	// And symmetrically: alice skips bob's created event, so her next event is
+	// the closure of her own session.
+	req, _ := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+aliceSession.ID, nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if event := readLifecycleEvent(t, ctx, aliceConn); event.Type != "closed" || event.SessionID != aliceSession.ID {
+		t.Fatalf("alice's next event = %+v, want her own closed event", event)
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/creack/pty"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// The /api/exec endpoint runs a one-shot command and returns its captured
+// output and exit code without creating a persistent session, so automation
+// can use the backend for "run this and show me the result" flows.
+
+const (
+	defaultExecTimeout = 30 * time.Second
+	maxExecTimeout     = 5 * time.Minute
+	maxExecOutputBytes = 1 << 20
+)
+
+type execRequest struct {
+	Command    string   `json:"command"`
+	Args       []string `json:"args"`
+	WorkingDir string   `json:"workingDir"`
+	TimeoutMs  int64    `json:"timeoutMs"`
+	// PTY runs the command on a pseudo-terminal so it behaves as if
+	// interactive (line discipline, color output). Default is a plain pipe.
+	PTY bool `json:"pty"`
+}
+
+type execResponse struct {
+	OutputBase64    string `json:"output"`
+	OutputTruncated bool   `json:"outputTruncated,omitempty"`
+	ExitCode        int    `json:"exitCode"`
+	TimedOut        bool   `json:"timedOut,omitempty"`
+	DurationMs      int64  `json:"durationMs"`
+}
+
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req execRequest
+	if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.message, httpErr.status)
+			return
+		}
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Command) == "" {
+		http.Error(w, "invalid command: must not be empty", http.StatusBadRequest)
+		return
+	}
+	if s.commandPolicy != nil {
+		launch := terminal.ShellLaunchContext{WorkingDir: req.WorkingDir}
+		if err := s.commandPolicy.AuthorizeCommand(launch, req.Command, req.Args); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	timeout := defaultExecTimeout
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+		if timeout > maxExecTimeout {
+			timeout = maxExecTimeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	s.audit.record(auditEvent{
+		Type:     auditExec,
+		User:     identityFromContext(r.Context()).name,
+		RemoteIP: s.clientIP(r),
+		Detail:   req.Command,
+	})
+
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, req.Command, req.Args...)
+	cmd.Dir = req.WorkingDir
+
+	var output []byte
+	var truncated bool
+	var runErr error
+	if req.PTY {
+		output, truncated, runErr = runExecOnPTY(cmd)
+	} else {
+		output, truncated, runErr = runExecOnPipe(cmd)
+	}
+	if runErr != nil && cmd.ProcessState == nil {
+		// The command never started (bad path, permission); report that
+		// rather than a synthetic exit code.
+		http.Error(w, runErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, execResponse{
+		OutputBase64:    base64.StdEncoding.EncodeToString(output),
+		OutputTruncated: truncated,
+		ExitCode:        execExitCode(runErr),
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+		DurationMs:      time.Since(start).Milliseconds(),
+	})
+}
+
+func runExecOnPipe(cmd *exec.Cmd) ([]byte, bool, error) {
+	output, err := cmd.CombinedOutput()
+	truncated := false
+	if len(output) > maxExecOutputBytes {
+		output = output[:maxExecOutputBytes]
+		truncated = true
+	}
+	return output, truncated, err
+}
+
+func runExecOnPTY(cmd *exec.Cmd) ([]byte, bool, error) {
+	ptyFile, err := pty.Start(cmd)
+	if err != nil {
+		return nil, false, err
+	}
+	defer ptyFile.Close()
+
+	output := make([]byte, 0, 4096)
+	truncated := false
+	buf := make([]byte, 4096)
+	for {
+		n, readErr := ptyFile.Read(buf)
+		if n > 0 {
+			if len(output)+n > maxExecOutputBytes {
+				n = maxExecOutputBytes - len(output)
+				truncated = true
+			}
+			output = append(output, buf[:n]...)
+		}
+		// PTY reads end with EIO rather than EOF when the child exits.
+		if readErr != nil || truncated {
+			break
+		}
+	}
+	if truncated && cmd.Process != nil {
+		// Nobody is reading the PTY anymore; kill the child so Wait cannot
+		// block behind a full terminal buffer.
+		_ = cmd.Process.Kill()
+	}
+	return output, truncated, cmd.Wait()
+}
+
+// execExitCode derives the exit code from a run error; -1 means the process
+// was killed by a signal (including the exec timeout) or the status is
+// unknown.
+func execExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
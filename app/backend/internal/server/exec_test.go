@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func execCommand(t *testing.T, baseURL, body string) (int, execResponse) {
+	t.Helper()
+	resp, err := http.Post(baseURL+"/api/exec", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out execResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode, out
+}
+
+func TestExecRunsOneShotCommand(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	status, result := execCommand(t, httpSrv.URL, `{"command":"/bin/sh","args":["-c","echo exec-ok; exit 3"]}`)
+	if status != http.StatusOK {
+		t.Fatalf("exec status=%d, want 200", status)
+	}
+	output, err := base64.StdEncoding.DecodeString(result.OutputBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(output, []byte("exec-ok")) {
+		t.Fatalf("output=%q, want to contain exec-ok", output)
+	}
+	if result.ExitCode != 3 {
+		t.Fatalf("exit code=%d, want 3", result.ExitCode)
+	}
+	if result.TimedOut {
+		t.Fatal("unexpected timeout")
+	}
+
+	// No persistent session may be left behind.
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var sessions []apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("sessions=%d, want 0", len(sessions))
+	}
+}
+
+func TestExecOnPTYCapturesTerminalOutput(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	status, result := execCommand(t, httpSrv.URL, `{"command":"/bin/sh","args":["-c","test -t 1 && echo is-a-tty"],"pty":true}`)
+	if status != http.StatusOK {
+		t.Fatalf("exec status=%d, want 200", status)
+	}
+	output, err := base64.StdEncoding.DecodeString(result.OutputBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(output, []byte("is-a-tty")) {
+		t.Fatalf("output=%q, want to contain is-a-tty", output)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("exit code=%d, want 0", result.ExitCode)
+	}
+}
+
+func TestExecEnforcesTimeoutAndValidation(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	status, result := execCommand(t, httpSrv.URL, `{"command":"/bin/sh","args":["-c","sleep 10"],"timeoutMs":100}`)
+	if status != http.StatusOK {
+		t.Fatalf("exec status=%d, want 200", status)
+	}
+	if !result.TimedOut {
+		t.Fatal("expected timeout to be reported")
+	}
+	if result.ExitCode != -1 {
+		t.Fatalf("exit code=%d, want -1 after kill", result.ExitCode)
+	}
+
+	status, _ = execCommand(t, httpSrv.URL, `{"command":"  "}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("blank command status=%d, want 400", status)
+	}
+	status, _ = execCommand(t, httpSrv.URL, `{"command":"/no/such/binary"}`)
+	if status != http.StatusBadRequest {
+		t.Fatalf("missing binary status=%d, want 400", status)
+	}
+}
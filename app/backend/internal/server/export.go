@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// handleHistoryExport renders a session's filtered history as a downloadable
+// transcript. Formats are negotiated via ?format=; html is the default.
+func (s *Server) handleHistoryExport(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	chunks, err := session.GetHistoryFromSequence(1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "", "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(terminal.ExportHistoryHTML(chunks, session.GetName())))
+	case "text", "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(terminal.ExportHistoryText(chunks)))
+	default:
+		http.Error(w, "unsupported format", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The /api/sessions/{id}/files endpoint accepts multipart uploads and writes
+// them into the session's current working directory, so a file dropped onto
+// the web terminal lands where the shell is, like an scp into the cwd.
+
+type uploadedFileInfo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type uploadFilesResponse struct {
+	Files []uploadedFileInfo `json:"files"`
+}
+
+func (s *Server) handleSessionFiles(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	workingDir := session.GetWorkingDir()
+	if strings.TrimSpace(workingDir) == "" {
+		http.Error(w, "session has no working directory", http.StatusConflict)
+		return
+	}
+	if info, err := os.Stat(workingDir); err != nil || !info.IsDir() {
+		http.Error(w, "session working directory is unavailable", http.StatusConflict)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes+s.maxJSONBodyBytes)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "expected multipart upload", http.StatusBadRequest)
+		return
+	}
+
+	var files []uploadedFileInfo
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "invalid multipart payload", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			continue
+		}
+
+		// Uploads may carry path components (or traversal attempts) in the
+		// filename; only the base name is honored and it must stay inside
+		// the working directory.
+		name := filepath.Base(filepath.Clean(part.FileName()))
+		if name == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+			http.Error(w, "invalid file name", http.StatusBadRequest)
+			return
+		}
+		destination := filepath.Join(workingDir, name)
+
+		size, err := writeUploadedFile(destination, part)
+		if err != nil {
+			if errors.Is(err, errUploadTooLarge) {
+				http.Error(w, fmt.Sprintf("file exceeds %d bytes", int64(maxUploadBytes)), http.StatusRequestEntityTooLarge)
+				return
+			}
+			s.logger.Error("Failed to store uploaded file", "sessionID", sessionID, "name", name, "error", err)
+			http.Error(w, "failed to store file", http.StatusInternalServerError)
+			return
+		}
+		files = append(files, uploadedFileInfo{Name: name, Path: destination, Size: size})
+	}
+
+	if len(files) == 0 {
+		http.Error(w, "no file parts in upload", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, uploadFilesResponse{Files: files})
+}
+
+var errUploadTooLarge = errors.New("uploaded file too large")
+
+func writeUploadedFile(destination string, src io.Reader) (int64, error) {
+	out, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(out, io.LimitReader(src, maxUploadBytes+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > maxUploadBytes {
+		err = errUploadTooLarge
+	}
+	if err != nil {
+		_ = os.Remove(destination)
+		return 0, err
+	}
+	return size, nil
+}
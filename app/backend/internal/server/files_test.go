@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createTestSessionInDir(t *testing.T, baseURL, dir string) apiSessionInfo {
+	t.Helper()
+	body, err := json.Marshal(createSessionRequest{WorkingDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(baseURL+"/api/sessions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+	var created apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func uploadFile(t *testing.T, baseURL, sessionID, filename string, content []byte) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(baseURL+"/api/sessions/"+sessionID+"/files", writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestUploadWritesFileIntoSessionWorkingDir(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	dir := t.TempDir()
+	created := createTestSessionInDir(t, httpSrv.URL, dir)
+
+	resp := uploadFile(t, httpSrv.URL, created.ID, "notes.txt", []byte("uploaded contents"))
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload status=%d, want 200", resp.StatusCode)
+	}
+	var result uploadFilesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Name != "notes.txt" || result.Files[0].Size != int64(len("uploaded contents")) {
+		t.Fatalf("result=%+v", result)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != "uploaded contents" {
+		t.Fatalf("written=%q", written)
+	}
+}
+
+func TestUploadSanitizesTraversalFilenames(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "cwd")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	created := createTestSessionInDir(t, httpSrv.URL, dir)
+
+	resp := uploadFile(t, httpSrv.URL, created.ID, "../escape.txt", []byte("x"))
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload status=%d, want 200", resp.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("traversal filename escaped the working directory")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); err != nil {
+		t.Fatalf("sanitized upload missing: %v", err)
+	}
+}
+
+func TestUploadRejectsNonMultipartAndMissingParts(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSessionInDir(t, httpSrv.URL, t.TempDir())
+
+	resp, err := http.Post(httpSrv.URL+"/api/sessions/"+created.ID+"/files", "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("non-multipart status=%d, want 400", resp.StatusCode)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("other", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post(httpSrv.URL+"/api/sessions/"+created.ID+"/files", writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("no file parts status=%d, want 400", resp.StatusCode)
+	}
+}
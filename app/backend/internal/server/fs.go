@@ -0,0 +1,95 @@
+package server
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The /api/sessions/{id}/fs endpoint lists a directory relative to the
+// session's current working directory, which follows `cd` through the
+// existing workdir tracking. It powers a file-picker sidebar in the web UI.
+
+type fsEntry struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // "file", "dir", "symlink" or "other"
+	Size    int64  `json:"size"`
+	MtimeMs int64  `json:"mtimeMs"`
+}
+
+type fsListResponse struct {
+	Root      string    `json:"root"`
+	Path      string    `json:"path"`
+	Entries   []fsEntry `json:"entries"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+func (s *Server) handleSessionFS(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	root := session.GetWorkingDir()
+	if strings.TrimSpace(root) == "" {
+		http.Error(w, "session has no working directory", http.StatusConflict)
+		return
+	}
+
+	relPath := r.URL.Query().Get("path")
+	if relPath == "" {
+		relPath = "."
+	}
+	relPath = filepath.Clean(relPath)
+	// The listing is rooted at the session cwd; absolute paths and anything
+	// climbing above the root are rejected rather than resolved.
+	if filepath.IsAbs(relPath) || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		http.Error(w, "invalid path", http.StatusBadRequest)
+		return
+	}
+	target := filepath.Join(root, relPath)
+
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "directory not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to read directory", http.StatusInternalServerError)
+		return
+	}
+
+	response := fsListResponse{Root: root, Path: relPath, Entries: make([]fsEntry, 0, len(entries))}
+	for _, entry := range entries {
+		if len(response.Entries) >= maxFSEntries {
+			response.Truncated = true
+			break
+		}
+		item := fsEntry{Name: entry.Name(), Type: fsEntryType(entry.Type())}
+		if info, err := entry.Info(); err == nil {
+			item.Size = info.Size()
+			item.MtimeMs = info.ModTime().UnixMilli()
+		}
+		response.Entries = append(response.Entries, item)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func fsEntryType(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func listFS(t *testing.T, baseURL, sessionID, path string) (int, fsListResponse) {
+	t.Helper()
+	endpoint := baseURL + "/api/sessions/" + sessionID + "/fs"
+	if path != "" {
+		endpoint += "?path=" + url.QueryEscape(path)
+	}
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out fsListResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode, out
+}
+
+func TestFSListsSessionWorkingDirectory(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "readme.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	created := createTestSessionInDir(t, httpSrv.URL, dir)
+
+	status, listing := listFS(t, httpSrv.URL, created.ID, "")
+	if status != http.StatusOK {
+		t.Fatalf("fs status=%d, want 200", status)
+	}
+	if listing.Root != dir || listing.Path != "." {
+		t.Fatalf("root=%q path=%q", listing.Root, listing.Path)
+	}
+	types := map[string]string{}
+	sizes := map[string]int64{}
+	for _, entry := range listing.Entries {
+		types[entry.Name] = entry.Type
+		sizes[entry.Name] = entry.Size
+		if entry.MtimeMs <= 0 {
+			t.Fatalf("entry %q has no mtime", entry.Name)
+		}
+	}
+	if types["readme.md"] != "file" || sizes["readme.md"] != int64(len("hello")) {
+		t.Fatalf("readme entry=%v/%v", types["readme.md"], sizes["readme.md"])
+	}
+	if types["sub"] != "dir" {
+		t.Fatalf("sub entry type=%q, want dir", types["sub"])
+	}
+
+	status, listing = listFS(t, httpSrv.URL, created.ID, "sub")
+	if status != http.StatusOK || len(listing.Entries) != 0 {
+		t.Fatalf("subdir status=%d entries=%d", status, len(listing.Entries))
+	}
+}
+
+func TestFSRejectsEscapingPaths(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSessionInDir(t, httpSrv.URL, t.TempDir())
+
+	for _, path := range []string{"..", "../x", "/etc", "sub/../../x"} {
+		status, _ := listFS(t, httpSrv.URL, created.ID, path)
+		if status != http.StatusBadRequest {
+			t.Fatalf("path %q status=%d, want 400", path, status)
+		}
+	}
+	if status, _ := listFS(t, httpSrv.URL, created.ID, "missing"); status != http.StatusNotFound {
+		t.Fatalf("missing dir status=%d, want 404", status)
+	}
+}
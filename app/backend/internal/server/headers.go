@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+)
+
+// defaultContentSecurityPolicy suits the bundled SPA: same-origin assets,
+// inline styles for the terminal renderer, and websocket connections back to
+// the server. frame-ancestors is appended separately from the configuration.
+const defaultContentSecurityPolicy = "default-src 'self'; img-src 'self' data: blob:; style-src 'self' 'unsafe-inline'; connect-src 'self' ws: wss:"
+
+// SecurityHeadersConfig controls the security headers stamped onto every
+// response. A terminal UI is a prime clickjacking and XSS-amplification
+// target, so the defaults are strict; deployments that embed the UI or manage
+// headers at a fronting proxy can loosen or disable them.
+type SecurityHeadersConfig struct {
+	// Disable turns the header middleware off entirely.
+	Disable bool
+	// ContentSecurityPolicy replaces the default policy. frame-ancestors is
+	// always appended from FrameAncestors, so it should not appear here.
+	ContentSecurityPolicy string
+	// FrameAncestors lists who may embed the UI (CSP source syntax). The
+	// default "'none'" forbids all framing.
+	FrameAncestors string
+	// ReferrerPolicy sets the Referrer-Policy header. Defaults to
+	// "no-referrer" so tokenized URLs never leak through navigation.
+	ReferrerPolicy string
+}
+
+func (c SecurityHeadersConfig) withDefaults() SecurityHeadersConfig {
+	if c.ContentSecurityPolicy == "" {
+		c.ContentSecurityPolicy = defaultContentSecurityPolicy
+	}
+	if c.FrameAncestors == "" {
+		c.FrameAncestors = "'none'"
+	}
+	if c.ReferrerPolicy == "" {
+		c.ReferrerPolicy = "no-referrer"
+	}
+	return c
+}
+
+// withSecurityHeaders stamps the configured security headers onto every
+// response, SPA and API alike, including error responses from the inner
+// middleware.
+func (s *Server) withSecurityHeaders(next http.Handler) http.Handler {
+	if s.securityHeaders.Disable {
+		return next
+	}
+	cfg := s.securityHeaders.withDefaults()
+	csp := cfg.ContentSecurityPolicy + "; frame-ancestors " + cfg.FrameAncestors
+	// X-Frame-Options only speaks "deny" and "same origin"; for anything more
+	// nuanced the CSP frame-ancestors directive is authoritative on its own.
+	frameOptions := ""
+	switch cfg.FrameAncestors {
+	case "'none'":
+		frameOptions = "DENY"
+	case "'self'":
+		frameOptions = "SAMEORIGIN"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Content-Security-Policy", csp)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		if frameOptions != "" {
+			header.Set("X-Frame-Options", frameOptions)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestSecurityHeadersDefaults(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	csp := resp.Header.Get("Content-Security-Policy")
+	if !strings.Contains(csp, "default-src 'self'") || !strings.Contains(csp, "frame-ancestors 'none'") {
+		t.Fatalf("unexpected CSP: %q", csp)
+	}
+	if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("X-Content-Type-Options = %q", got)
+	}
+	if got := resp.Header.Get("X-Frame-Options"); got != "DENY" {
+		t.Fatalf("X-Frame-Options = %q", got)
+	}
+	if got := resp.Header.Get("Referrer-Policy"); got != "no-referrer" {
+		t.Fatalf("Referrer-Policy = %q", got)
+	}
+}
+
+func TestSecurityHeadersConfigurable(t *testing.T) {
+	srv := New(Config{
+		SecurityHeaders: SecurityHeadersConfig{FrameAncestors: "'self'"},
+		ManagerConfig:   terminal.ManagerConfig{Logger: terminal.NopLogger{}},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Frame-Options"); got != "SAMEORIGIN" {
+		t.Fatalf("X-Frame-Options = %q", got)
+	}
+	if csp := resp.Header.Get("Content-Security-Policy"); !strings.Contains(csp, "frame-ancestors 'self'") {
+		t.Fatalf("unexpected CSP: %q", csp)
+	}
+
+	disabled := New(Config{
+		SecurityHeaders: SecurityHeadersConfig{Disable: true},
+		ManagerConfig:   terminal.ManagerConfig{Logger: terminal.NopLogger{}},
+	})
+	disabledSrv := httptest.NewServer(disabled.Handler())
+	defer disabledSrv.Close()
+	defer disabled.Close()
+
+	resp, err = http.Get(disabledSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Content-Security-Policy"); got != "" {
+		t.Fatalf("expected no CSP when disabled, got %q", got)
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// historyPageMeta is the cursor metadata written after the streamed chunk
+// array. NextSeq is the position to pass as sinceSeq on the next request;
+// NextStartSequence is its older alias.
+type historyPageMeta struct {
+	FirstRetainedSequence  int64 `json:"firstRetainedSequence"`
+	NextStartSequence      int64 `json:"nextStartSequence"`
+	NextSeq                int64 `json:"nextSeq"`
+	HasMore                bool  `json:"hasMore"`
+	CoveredThroughSequence int64 `json:"coveredThroughSequence"`
+	SnapshotEndSequence    int64 `json:"snapshotEndSequence"`
+	HistoryGeneration      int64 `json:"historyGeneration"`
+	HistoryReset           bool  `json:"historyReset"`
+	HistoryTruncated       bool  `json:"historyTruncated"`
+	TotalBytes             int64 `json:"totalBytes"`
+}
+
+// handleSessionHistory serves history pages. `sinceSeq` (alias `startSeq`)
+// positions the cursor and `limit` caps the number of chunks; when the limit
+// spans several internal ring-buffer pages the response body is streamed
+// page by page, so deep histories never materialize in server memory at
+// once. The response stays a single JSON object with the chunk array first
+// and the cursor metadata after it.
+func (s *Server) handleSessionHistory(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	_, span := s.tracer.Start(r.Context(), "history.read",
+		trace.WithAttributes(attribute.String("session.id", sessionID)))
+	defer span.End()
+	query := r.URL.Query()
+	startSeq, err := parseIntQuery(query, "startSeq", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if sinceSeq, err := parseIntQuery(query, "sinceSeq", startSeq); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else {
+		startSeq = sinceSeq
+	}
+	endSeq, err := parseIntQuery(query, "endSeq", -1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	historyGeneration, err := parseIntQuery(query, "historyGeneration", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxBytes, err := parseIntQuery(query, "maxBytes", defaultHistoryPageBytes)
+	if err != nil || maxBytes <= 0 || maxBytes > maxHistoryPageBytes {
+		http.Error(w, "invalid maxBytes", http.StatusBadRequest)
+		return
+	}
+	limit, err := parseIntQuery(query, "limit", 0)
+	if err != nil || limit < 0 || limit > maxHistoryLimitChunks {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	// Without an explicit limit the handler keeps its single-page behavior:
+	// one bounded read, cursor in the metadata.
+	singlePage := limit == 0
+
+	w.Header().Set("Content-Type", "application/json")
+	flusher, _ := w.(http.Flusher)
+
+	var meta historyPageMeta
+	written := int64(0)
+	cursor := startSeq
+	started := false
+	for {
+		pageLimit := int64(maxHistoryPageChunks)
+		if !singlePage && limit-written < pageLimit {
+			pageLimit = limit - written
+		}
+		page, err := session.GetHistoryPage(terminal.HistoryPageOptions{
+			StartSeq:          cursor,
+			EndSeq:            endSeq,
+			HistoryGeneration: historyGeneration,
+			LimitChunks:       int(pageLimit),
+			MaxBytes:          int(maxBytes),
+		})
+		if err != nil {
+			if !started {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		if !started {
+			started = true
+			if _, err := w.Write([]byte(`{"chunks":[`)); err != nil {
+				return
+			}
+		}
+		for _, chunk := range page.Chunks {
+			encoded, err := json.Marshal(historyChunk{
+				Sequence:    chunk.Sequence,
+				DataBase64:  base64.StdEncoding.EncodeToString(chunk.Data),
+				TimestampMs: chunk.Timestamp,
+			})
+			if err != nil {
+				return
+			}
+			if written > 0 {
+				if _, err := w.Write([]byte(",")); err != nil {
+					return
+				}
+			}
+			if _, err := w.Write(encoded); err != nil {
+				return
+			}
+			written++
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		cursor = page.NextStartSeq
+		meta = historyPageMeta{
+			FirstRetainedSequence:  page.FirstRetainedSequence,
+			NextStartSequence:      page.NextStartSeq,
+			NextSeq:                page.NextStartSeq,
+			HasMore:                page.HasMore,
+			CoveredThroughSequence: page.CoveredThroughSequence,
+			SnapshotEndSequence:    page.SnapshotEndSequence,
+			HistoryGeneration:      page.HistoryGeneration,
+			HistoryReset:           page.HistoryReset,
+			HistoryTruncated:       page.HistoryTruncated,
+			TotalBytes:             page.TotalBytes,
+		}
+		if singlePage || !page.HasMore || written >= limit || len(page.Chunks) == 0 {
+			break
+		}
+	}
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	// Splice the metadata fields into the already-open response object.
+	if _, err := w.Write([]byte("],")); err != nil {
+		return
+	}
+	if _, err := w.Write(metaJSON[1:]); err != nil {
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// handleSessionImages extracts the inline images (Sixel, iTerm2 OSC 1337)
+// found in a session's history so the web renderer can fetch them decoded
+// instead of re-parsing escape sequences client side. `startSeq` and
+// `endSeq` bound the scanned sequence range with the same semantics as the
+// history endpoint.
+func (s *Server) handleSessionImages(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	startSeq, err := parseIntQuery(query, "startSeq", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	endSeq, err := parseIntQuery(query, "endSeq", -1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	// Image sequences span chunks, so the whole requested range is collected
+	// before extraction. The ring buffer bounds how much that can be.
+	var chunks []terminal.TerminalDataChunk
+	cursor := startSeq
+	for {
+		page, err := session.GetHistoryPage(terminal.HistoryPageOptions{
+			StartSeq:    cursor,
+			EndSeq:      endSeq,
+			LimitChunks: maxHistoryPageChunks,
+			MaxBytes:    int(maxHistoryPageBytes),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		chunks = append(chunks, page.Chunks...)
+		if !page.HasMore || len(page.Chunks) == 0 {
+			break
+		}
+		cursor = page.NextStartSeq
+	}
+
+	images := terminal.ExtractInlineImages(chunks)
+	if images == nil {
+		images = []terminal.InlineImage{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"images": images})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestSessionImagesEndpoint(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions/" + created.ID + "/images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d, want 200", resp.StatusCode)
+	}
+	var body struct {
+		Images []terminal.InlineImage `json:"images"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Images) != 0 {
+		t.Fatalf("fresh session reported images: %+v", body.Images)
+	}
+
+	missing, err := http.Get(httpSrv.URL + "/api/sessions/no-such-session/images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("status=%d, want 404", missing.StatusCode)
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"net/http"
+)
+
+// BuildInfo identifies the running server binary so clients can detect
+// backend/frontend version mismatches. The fields are supplied by the main
+// package, which injects them at build time via -ldflags.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// handleServerInfo reports the build identity of the running server.
+func (s *Server) handleServerInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.buildInfo)
+}
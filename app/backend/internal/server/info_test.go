@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestServerInfoReportsBuildIdentity(t *testing.T) {
+	srv := New(Config{
+		BuildInfo: BuildInfo{
+			Version:   "1.2.3",
+			Commit:    "abcdef1",
+			BuildDate: "2026-01-02T03:04:05Z",
+			GoVersion: "go1.99",
+		},
+		ManagerConfig: terminal.ManagerConfig{Logger: terminal.NopLogger{}},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	resp, err := http.Get(httpSrv.URL + "/api/server/info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d, want 200", resp.StatusCode)
+	}
+	var info BuildInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "1.2.3" || info.Commit != "abcdef1" || info.GoVersion != "go1.99" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, httpSrv.URL+"/api/server/info", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status=%d, want 405", resp.StatusCode)
+	}
+}
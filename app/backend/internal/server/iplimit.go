@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ipLimiter caps simultaneous websockets and sessions per remote IP so a
+// misbehaving client or scanner cannot exhaust the server's file descriptors
+// and PTYs. Unlike the token buckets in ratelimit.go these are concurrency
+// limits: closing a connection or session frees its slot immediately. All
+// methods are safe on a nil receiver, which is how the limits stay off by
+// default.
+type ipLimiter struct {
+	maxWS       int
+	maxSessions int
+
+	mu        sync.Mutex
+	ws        map[string]int
+	sessions  map[string]int
+	sessionIP map[string]string
+}
+
+func newIPLimiter(maxWS, maxSessions int) *ipLimiter {
+	if maxWS <= 0 && maxSessions <= 0 {
+		return nil
+	}
+	return &ipLimiter{
+		maxWS:       maxWS,
+		maxSessions: maxSessions,
+		ws:          make(map[string]int),
+		sessions:    make(map[string]int),
+		sessionIP:   make(map[string]string),
+	}
+}
+
+// acquireWS reserves a websocket slot for ip. The returned release must be
+// called when the connection ends; it is safe to call more than once.
+func (l *ipLimiter) acquireWS(ip string) (func(), bool) {
+	if l == nil || l.maxWS <= 0 || ip == "" {
+		return func() {}, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ws[ip] >= l.maxWS {
+		return nil, false
+	}
+	l.ws[ip]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.ws[ip] <= 1 {
+				delete(l.ws, ip)
+			} else {
+				l.ws[ip]--
+			}
+		})
+	}, true
+}
+
+// acquireSession reserves a session slot for ip ahead of creation. A
+// successful create must be followed by bindSession; a failed one by
+// releaseSessionSlot.
+func (l *ipLimiter) acquireSession(ip string) bool {
+	if l == nil || l.maxSessions <= 0 || ip == "" {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sessions[ip] >= l.maxSessions {
+		return false
+	}
+	l.sessions[ip]++
+	return true
+}
+
+func (l *ipLimiter) releaseSessionSlot(ip string) {
+	if l == nil || l.maxSessions <= 0 || ip == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sessions[ip] <= 1 {
+		delete(l.sessions, ip)
+	} else {
+		l.sessions[ip]--
+	}
+}
+
+// bindSession ties a reserved slot to the created session so the slot is
+// freed when the session closes.
+func (l *ipLimiter) bindSession(sessionID, ip string) {
+	if l == nil || l.maxSessions <= 0 || ip == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sessionIP[sessionID] = ip
+}
+
+// releaseSession frees the slot held by sessionID, if any. It is wired into
+// the event fanout's session-closed path so deletes and natural shell exits
+// both return the slot.
+func (l *ipLimiter) releaseSession(sessionID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	ip, ok := l.sessionIP[sessionID]
+	delete(l.sessionIP, sessionID)
+	l.mu.Unlock()
+	if ok {
+		l.releaseSessionSlot(ip)
+	}
+}
+
+// remoteIPHost extracts the connection's remote IP, dropping the port.
+func remoteIPHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func newIPLimitedTestServer(t *testing.T, maxWS, maxSessions int) *httptest.Server {
+	t.Helper()
+	srv := New(Config{
+		MaxWSPerIP:       maxWS,
+		MaxSessionsPerIP: maxSessions,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	return httpSrv
+}
+
+func TestPerIPSessionLimitFreesSlotOnDelete(t *testing.T) {
+	httpSrv := newIPLimitedTestServer(t, 0, 2)
+
+	create := func(t *testing.T) (int, apiSessionInfo) {
+		t.Helper()
+		resp, err := http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var info apiSessionInfo
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && resp.Header.Get("Retry-After") == "" {
+			t.Fatal("429 response missing Retry-After")
+		}
+		return resp.StatusCode, info
+	}
+
+	status, first := create(t)
+	if status != http.StatusOK {
+		t.Fatalf("first create status=%d, want 200", status)
+	}
+	if status, _ = create(t); status != http.StatusOK {
+		t.Fatalf("second create status=%d, want 200", status)
+	}
+	if status, _ = create(t); status != http.StatusTooManyRequests {
+		t.Fatalf("third create status=%d, want 429", status)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+first.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteResp.Body.Close()
+	if deleteResp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status=%d, want 204", deleteResp.StatusCode)
+	}
+
+	if status, _ = create(t); status != http.StatusOK {
+		t.Fatalf("create after delete status=%d, want 200", status)
+	}
+}
+
+func TestPerIPWebsocketLimit(t *testing.T) {
+	httpSrv := newIPLimitedTestServer(t, 1, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/ws"
+
+	first, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatalf("first ws dial failed: %v", err)
+	}
+	defer first.CloseNow()
+
+	if conn, resp, err := websocket.Dial(ctx, wsURL, nil); err == nil {
+		conn.CloseNow()
+		t.Fatal("second ws dial succeeded, want rejection")
+	} else if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second ws dial error=%v, want 429 response", err)
+	}
+
+	first.Close(websocket.StatusNormalClosure, "done")
+	// The slot is released when the first connection's handler returns, which
+	// can lag the close frame slightly; retry briefly.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, _, err := websocket.Dial(ctx, wsURL, nil)
+		if err == nil {
+			conn.CloseNow()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ws slot was not released: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWSKeepaliveClosesDeadConnection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dead := make(chan struct{})
+	// A half-open peer never answers: the ping blocks until its deadline.
+	ping := func(pingCtx context.Context) error {
+		<-pingCtx.Done()
+		return pingCtx.Err()
+	}
+	go wsKeepalive(ctx, ping, 5*time.Millisecond, 5*time.Millisecond, func() { close(dead) })
+
+	select {
+	case <-dead:
+	case <-time.After(2 * time.Second):
+		t.Fatal("keepalive never reported the dead connection")
+	}
+}
+
+func TestWSKeepaliveLeavesHealthyConnectionAlone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var pings atomic.Int64
+	var died atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wsKeepalive(ctx, func(context.Context) error {
+			pings.Add(1)
+			return nil
+		}, time.Millisecond, time.Second, func() { died.Store(true) })
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pings.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if pings.Load() < 3 {
+		t.Fatalf("pings = %d, want at least 3", pings.Load())
+	}
+
+	// Cancellation (the handler returning) stops the loop without treating
+	// the connection as dead.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("keepalive did not stop on context cancellation")
+	}
+	if died.Load() {
+		t.Fatal("healthy connection was reported dead")
+	}
+}
+
+func TestWSKeepalivePingErrorAfterCancelIsNotDead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var died atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wsKeepalive(ctx, func(context.Context) error {
+			cancel()
+			return errors.New("use of closed network connection")
+		}, time.Millisecond, time.Second, func() { died.Store(true) })
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("keepalive did not stop")
+	}
+	if died.Load() {
+		t.Fatal("connection torn down by the handler was reported dead")
+	}
+}
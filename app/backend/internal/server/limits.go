@@ -2,8 +2,34 @@ package server
 
 const maxJSONBodyBytesDefault = int64(1 << 20) // 1 MiB
 
+// Default websocket per-message read limits, overridable via
+// Config.MaxInputBytes.
+const (
+	defaultWSReadLimitBytes  = int64(8 * 1024 * 1024)
+	defaultRPCReadLimitBytes = int64(1 << 20)
+)
+
 const (
 	defaultHistoryPageBytes = int64(512 * 1024)
 	maxHistoryPageBytes     = int64(1 << 20)
 	maxHistoryPageChunks    = 256
+	maxHistoryLimitChunks   = 4096
+)
+
+const maxUploadBytes = int64(256 << 20) // 256 MiB
+
+const maxFSEntries = 2000
+
+const (
+	maxScreenRows = 500
+	maxScreenCols = 1000
+)
+
+const maxIdempotencyKeyBytes = 128
+
+const (
+	maxSessionNameBytes  = 256
+	maxSessionTags       = 16
+	maxSessionTagBytes   = 64
+	maxSessionNotesBytes = 4096
 )
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+	"net"
+)
+
+// DefaultListenAddr is the loopback address used when no listen address is
+// configured, so out-of-the-box installs do not expose shells to the network.
+const DefaultListenAddr = "127.0.0.1:8080"
+
+// ValidateListenAddr enforces the remote-exposure opt-in: binding anything
+// beyond loopback requires allowRemote, and additionally requires that some
+// form of authentication is configured.
+func ValidateListenAddr(addr string, allowRemote bool, authConfigured bool) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if isLoopbackHost(host) {
+		return nil
+	}
+	if !allowRemote {
+		return fmt.Errorf("refusing to listen on %s: binding beyond loopback requires the explicit -listen-lan opt-in", addr)
+	}
+	if !authConfigured {
+		return fmt.Errorf("refusing to listen on %s without authentication: configure a token or basic auth", addr)
+	}
+	return nil
+}
+
+// isLoopbackHost reports whether the host part of a listen address binds only
+// the loopback interface. Empty hosts and wildcard addresses bind every
+// interface and therefore do not count.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
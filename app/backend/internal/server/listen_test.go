@@ -0,0 +1,37 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateListenAddr(t *testing.T) {
+	cases := []struct {
+		addr           string
+		allowRemote    bool
+		authConfigured bool
+		wantErr        string
+	}{
+		{addr: "127.0.0.1:8080"},
+		{addr: "localhost:8080"},
+		{addr: "[::1]:8080"},
+		{addr: ":8080", wantErr: "-listen-lan"},
+		{addr: "0.0.0.0:8080", wantErr: "-listen-lan"},
+		{addr: "10.0.0.5:8080", wantErr: "-listen-lan"},
+		{addr: ":8080", allowRemote: true, wantErr: "authentication"},
+		{addr: ":8080", allowRemote: true, authConfigured: true},
+		{addr: "no-port", wantErr: "invalid listen address"},
+	}
+	for _, tc := range cases {
+		err := ValidateListenAddr(tc.addr, tc.allowRemote, tc.authConfigured)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Fatalf("ValidateListenAddr(%q, %v, %v) = %v, want nil", tc.addr, tc.allowRemote, tc.authConfigured, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("ValidateListenAddr(%q, %v, %v) = %v, want error containing %q", tc.addr, tc.allowRemote, tc.authConfigured, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// Server-side counter names reported through the same Metrics hook that
+// terminal-go uses for its own counters.
+const (
+	// metricBroadcastDrops counts SSE events discarded because a subscriber's
+	// buffer was full (slow consumer).
+	metricBroadcastDrops = "sse_events_dropped_total"
+	// metricRateLimitRejected counts requests rejected by request throttling.
+	metricRateLimitRejected = "ratelimit_rejected_total"
+	// metricWebhookDeliveries counts webhook events accepted by an endpoint.
+	metricWebhookDeliveries = "webhook_deliveries_total"
+	// metricWebhookFailures counts webhook events dropped or rejected.
+	metricWebhookFailures = "webhook_failures_total"
+)
+
+// histogramTotals is the running sum and observation count of a pushed
+// histogram. The exposition format emits them as a quantile-less summary,
+// which keeps the handler free of bucket configuration.
+type histogramTotals struct {
+	count float64
+	sum   float64
+}
+
+// serverMetrics accumulates counters, gauges and histograms pushed by
+// terminal-go and the server itself for the /metrics endpoint. Point-in-time
+// figures the library does not push (active sessions, attached ws clients,
+// history memory) are read from the manager's diagnostics at scrape time.
+type serverMetrics struct {
+	mu         sync.Mutex
+	counters   map[string]float64
+	gauges     map[string]float64
+	histograms map[string]histogramTotals
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		counters: map[string]float64{
+			terminal.MetricSessionsCreated: 0,
+			terminal.MetricSessionsFailed:  0,
+			terminal.MetricPTYReadBytes:    0,
+			terminal.MetricPTYWrittenBytes: 0,
+			terminal.MetricHistoryChunks:   0,
+			terminal.MetricPTYResizes:      0,
+			terminal.MetricEchoDedupHits:   0,
+			metricBroadcastDrops:           0,
+			metricRateLimitRejected:        0,
+			metricWebhookDeliveries:        0,
+			metricWebhookFailures:          0,
+		},
+		gauges:     map[string]float64{},
+		histograms: map[string]histogramTotals{},
+	}
+}
+
+func (m *serverMetrics) IncCounter(name string, delta float64) {
+	m.mu.Lock()
+	m.counters[name] += delta
+	m.mu.Unlock()
+}
+
+func (m *serverMetrics) SetGauge(name string, value float64) {
+	m.mu.Lock()
+	m.gauges[name] = value
+	m.mu.Unlock()
+}
+
+func (m *serverMetrics) ObserveHistogram(name string, value float64) {
+	m.mu.Lock()
+	totals := m.histograms[name]
+	totals.count++
+	totals.sum += value
+	m.histograms[name] = totals
+	m.mu.Unlock()
+}
+
+func (m *serverMetrics) snapshot() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]float64, len(m.counters))
+	for name, value := range m.counters {
+		out[name] = value
+	}
+	return out
+}
+
+func (m *serverMetrics) snapshotGauges() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]float64, len(m.gauges))
+	for name, value := range m.gauges {
+		out[name] = value
+	}
+	return out
+}
+
+func (m *serverMetrics) snapshotHistograms() map[string]histogramTotals {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]histogramTotals, len(m.histograms))
+	for name, totals := range m.histograms {
+		out[name] = totals
+	}
+	return out
+}
+
+var metricHelp = map[string]string{
+	terminal.MetricSessionsCreated:   "Total terminal sessions created.",
+	terminal.MetricSessionsFailed:    "Total session starts that failed to launch a PTY.",
+	terminal.MetricPTYReadBytes:      "Total bytes read from PTYs (terminal output).",
+	terminal.MetricPTYWrittenBytes:   "Total bytes written to PTYs (terminal input).",
+	terminal.MetricHistoryChunks:     "Total chunks written to history ring buffers.",
+	terminal.MetricPTYResizes:        "Total PTY resizes applied.",
+	terminal.MetricEchoDedupHits:     "Total output events attributed to a connection's own input for echo dedup.",
+	metricBroadcastDrops:             "Total SSE events dropped because a subscriber was too slow.",
+	metricRateLimitRejected:          "Total requests rejected by rate limiting.",
+	metricWebhookDeliveries:          "Total webhook events accepted by an endpoint.",
+	metricWebhookFailures:            "Total webhook events dropped or rejected.",
+	terminal.MetricOpenSessions:      "Current number of sessions held by the manager.",
+	terminal.MetricPTYReadChunkBytes: "Size of individual PTY read chunks in bytes.",
+}
+
+// handleMetrics serves counters and gauges in the Prometheus text exposition
+// format. The format is simple enough that writing it by hand keeps the
+// Prometheus client library out of the dependency tree.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var b strings.Builder
+	counters := s.metrics.snapshot()
+	gauges := s.metrics.snapshotGauges()
+	histograms := s.metrics.snapshotHistograms()
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeMetric(&b, "floeterm_"+name, "counter", metricHelp[name], counters[name])
+	}
+	names = names[:0]
+	for name := range gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeMetric(&b, "floeterm_"+name, "gauge", metricHelp[name], gauges[name])
+	}
+	names = names[:0]
+	for name := range histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeHistogram(&b, "floeterm_"+name, metricHelp[name], histograms[name])
+	}
+
+	diagnostics := s.manager.GetDiagnostics()
+	writeMetric(&b, "floeterm_sessions", "gauge",
+		"Current number of sessions.", float64(diagnostics.SessionCount))
+	writeMetric(&b, "floeterm_sessions_active", "gauge",
+		"Current number of sessions with a running PTY.", float64(diagnostics.ActiveSessionCount))
+	writeMetric(&b, "floeterm_ws_clients", "gauge",
+		"Current number of attached websocket clients.", float64(diagnostics.LiveAttachmentCount))
+	writeMetric(&b, "floeterm_history_bytes", "gauge",
+		"Bytes of terminal history held in ring buffers.", float64(diagnostics.HistoryBytes))
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func writeMetric(b *strings.Builder, name, kind, help string, value float64) {
+	if help != "" {
+		fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+	fmt.Fprintf(b, "%s %g\n", name, value)
+}
+
+func writeHistogram(b *strings.Builder, name, help string, totals histogramTotals) {
+	if help != "" {
+		fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	}
+	fmt.Fprintf(b, "# TYPE %s summary\n", name)
+	fmt.Fprintf(b, "%s_sum %g\n", name, totals.sum)
+	fmt.Fprintf(b, "%s_count %g\n", name, totals.count)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrapeMetrics(t *testing.T, baseURL string) map[string]float64 {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("metrics status=%d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("metrics content type=%q", ct)
+	}
+
+	values := map[string]float64{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, raw, ok := strings.Cut(line, " ")
+		if !ok {
+			t.Fatalf("malformed metrics line %q", line)
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			t.Fatalf("malformed metrics value %q: %v", line, err)
+		}
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestMetricsEndpointExportsCountersAndGauges(t *testing.T) {
+	srv, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Manager().ActivateSessionContext(ctx, created.ID, 80, 24); err != nil {
+		t.Fatalf("activate session: %v", err)
+	}
+	session, ok := srv.Manager().GetSession(created.ID)
+	if !ok {
+		t.Fatal("session not found after create")
+	}
+	if err := session.WriteData("METRICS_PROBE\n"); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	// Wait for the PTY echo so the read-bytes counter has moved.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, outputBytes := session.GetIOStats()
+		if outputBytes > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for PTY output")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	values := scrapeMetrics(t, httpSrv.URL)
+	if got := values["floeterm_sessions_created_total"]; got < 1 {
+		t.Fatalf("sessions_created_total=%v, want >= 1", got)
+	}
+	if got := values["floeterm_pty_written_bytes_total"]; got <= 0 {
+		t.Fatalf("pty_written_bytes_total=%v, want > 0", got)
+	}
+	if got := values["floeterm_pty_read_bytes_total"]; got <= 0 {
+		t.Fatalf("pty_read_bytes_total=%v, want > 0", got)
+	}
+	if got := values["floeterm_sessions"]; got != 1 {
+		t.Fatalf("sessions gauge=%v, want 1", got)
+	}
+	if got := values["floeterm_sessions_active"]; got != 1 {
+		t.Fatalf("sessions_active gauge=%v, want 1", got)
+	}
+	// Counters that have not fired yet must still be present at zero so
+	// dashboards can rely on the full series set.
+	for _, name := range []string{
+		"floeterm_session_starts_failed_total",
+		"floeterm_sse_events_dropped_total",
+		"floeterm_ratelimit_rejected_total",
+		"floeterm_ws_clients",
+		"floeterm_history_bytes",
+	} {
+		if _, ok := values[name]; !ok {
+			t.Fatalf("metric %s missing from scrape", name)
+		}
+	}
+}
+
+func TestMetricsEndpointRequiresAuthWhenConfigured(t *testing.T) {
+	srv := New(Config{AuthToken: "metrics-secret"})
+	t.Cleanup(srv.Close)
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated metrics status=%d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer metrics-secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("authenticated metrics status=%d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,530 @@
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Minimal MessagePack codec for the /ws/rpc envelope, so native clients can
+// negotiate a compact binary encoding instead of JSON at high output rates.
+// Field names on the wire are the same json-tag names the JSON encoding uses;
+// the message shapes are documented in protocol/rpc_msgpack_v1.json. Only the
+// types the RPC surface needs are supported: nil, bool, integers, floats,
+// strings, byte slices, arrays, string-keyed maps and structs.
+
+var errInvalidMsgpack = errors.New("invalid msgpack payload")
+
+func marshalMsgpack(v any) ([]byte, error) {
+	return appendMsgpackValue(nil, reflect.ValueOf(v))
+}
+
+func unmarshalMsgpack(data []byte, dst any) error {
+	value, rest, err := readMsgpackValue(data)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errInvalidMsgpack
+	}
+	return assignMsgpackInto(value, dst)
+}
+
+// assignMsgpackInto sets an already-decoded generic value into a typed
+// destination, used when part of a message was captured as `any` first.
+func assignMsgpackInto(value any, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("msgpack unmarshal target must be a non-nil pointer")
+	}
+	return assignMsgpack(value, rv.Elem())
+}
+
+func appendMsgpackValue(buf []byte, rv reflect.Value) ([]byte, error) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return append(buf, 0xc0), nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return append(buf, 0xc0), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendMsgpackInt(buf, rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return appendMsgpackUint(buf, rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(rv.Float())), nil
+	case reflect.String:
+		return appendMsgpackString(buf, rv.String()), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return appendMsgpackBytes(buf, rv.Bytes()), nil
+		}
+		buf = appendMsgpackArrayHeader(buf, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			var err error
+			buf, err = appendMsgpackValue(buf, rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("msgpack maps require string keys, got %s", rv.Type().Key())
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, key := range rv.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		buf = appendMsgpackMapHeader(buf, len(keys))
+		for _, key := range keys {
+			buf = appendMsgpackString(buf, key)
+			var err error
+			buf, err = appendMsgpackValue(buf, rv.MapIndex(reflect.ValueOf(key)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case reflect.Struct:
+		return appendMsgpackStruct(buf, rv)
+	default:
+		return nil, fmt.Errorf("msgpack cannot encode %s", rv.Kind())
+	}
+}
+
+func appendMsgpackStruct(buf []byte, rv reflect.Value) ([]byte, error) {
+	type member struct {
+		name  string
+		value reflect.Value
+	}
+	members := make([]member, 0, rv.NumField())
+	structType := rv.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitEmpty := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		value := rv.Field(i)
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		members = append(members, member{name: name, value: value})
+	}
+	buf = appendMsgpackMapHeader(buf, len(members))
+	for _, m := range members {
+		buf = appendMsgpackString(buf, m.name)
+		var err error
+		buf, err = appendMsgpackValue(buf, m.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// jsonFieldName resolves a struct field's wire name from its json tag so both
+// encodings stay field-compatible.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+func appendMsgpackInt(buf []byte, n int64) []byte {
+	if n >= 0 {
+		return appendMsgpackUint(buf, uint64(n))
+	}
+	switch {
+	case n >= -32:
+		return append(buf, byte(n))
+	case n >= math.MinInt8:
+		return append(buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(n))
+	}
+}
+
+func appendMsgpackUint(buf []byte, n uint64) []byte {
+	switch {
+	case n < 0x80:
+		return append(buf, byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), n)
+	}
+}
+
+func appendMsgpackString(buf []byte, s string) []byte {
+	switch {
+	case len(s) < 32:
+		buf = append(buf, 0xa0|byte(len(s)))
+	case len(s) <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(len(s)))
+	case len(s) <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(len(s)))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(len(s)))
+	}
+	return append(buf, s...)
+}
+
+func appendMsgpackBytes(buf []byte, data []byte) []byte {
+	switch {
+	case len(data) <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(len(data)))
+	case len(data) <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xc5), uint16(len(data)))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xc6), uint32(len(data)))
+	}
+	return append(buf, data...)
+}
+
+func appendMsgpackArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+// readMsgpackValue decodes one value, returning it as nil, bool, int64,
+// uint64, float64, string, []byte, []any or map[string]any plus the
+// remaining bytes.
+func readMsgpackValue(data []byte) (any, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, errInvalidMsgpack
+	}
+	marker := data[0]
+	rest := data[1:]
+	switch {
+	case marker < 0x80: // positive fixint
+		return int64(marker), rest, nil
+	case marker >= 0xe0: // negative fixint
+		return int64(int8(marker)), rest, nil
+	case marker >= 0xa0 && marker <= 0xbf: // fixstr
+		return readMsgpackRaw(rest, int(marker&0x1f), true)
+	case marker >= 0x90 && marker <= 0x9f: // fixarray
+		return readMsgpackArray(rest, int(marker&0x0f))
+	case marker >= 0x80 && marker <= 0x8f: // fixmap
+		return readMsgpackMap(rest, int(marker&0x0f))
+	}
+	switch marker {
+	case 0xc0:
+		return nil, rest, nil
+	case 0xc2:
+		return false, rest, nil
+	case 0xc3:
+		return true, rest, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		size := 1 << (marker - 0xcc)
+		if len(rest) < size {
+			return nil, nil, errInvalidMsgpack
+		}
+		var n uint64
+		for _, b := range rest[:size] {
+			n = n<<8 | uint64(b)
+		}
+		return int64OrUint64(n), rest[size:], nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (marker - 0xd0)
+		if len(rest) < size {
+			return nil, nil, errInvalidMsgpack
+		}
+		var n uint64
+		for _, b := range rest[:size] {
+			n = n<<8 | uint64(b)
+		}
+		shift := uint(64 - size*8)
+		return int64(n<<shift) >> shift, rest[size:], nil
+	case 0xca:
+		if len(rest) < 4 {
+			return nil, nil, errInvalidMsgpack
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(rest))), rest[4:], nil
+	case 0xcb:
+		if len(rest) < 8 {
+			return nil, nil, errInvalidMsgpack
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(rest)), rest[8:], nil
+	case 0xd9, 0xda, 0xdb:
+		size, next, err := readMsgpackLength(rest, 1<<(marker-0xd9))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackRaw(next, size, true)
+	case 0xc4, 0xc5, 0xc6:
+		size, next, err := readMsgpackLength(rest, 1<<(marker-0xc4))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackRaw(next, size, false)
+	case 0xdc, 0xdd:
+		size, next, err := readMsgpackLength(rest, 2<<(marker-0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackArray(next, size)
+	case 0xde, 0xdf:
+		size, next, err := readMsgpackLength(rest, 2<<(marker-0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgpackMap(next, size)
+	default:
+		return nil, nil, errInvalidMsgpack
+	}
+}
+
+func int64OrUint64(n uint64) any {
+	if n <= math.MaxInt64 {
+		return int64(n)
+	}
+	return n
+}
+
+func readMsgpackLength(data []byte, size int) (int, []byte, error) {
+	if len(data) < size {
+		return 0, nil, errInvalidMsgpack
+	}
+	var n uint64
+	for _, b := range data[:size] {
+		n = n<<8 | uint64(b)
+	}
+	if n > math.MaxInt32 {
+		return 0, nil, errInvalidMsgpack
+	}
+	return int(n), data[size:], nil
+}
+
+func readMsgpackRaw(data []byte, size int, asString bool) (any, []byte, error) {
+	if size < 0 || len(data) < size {
+		return nil, nil, errInvalidMsgpack
+	}
+	if asString {
+		return string(data[:size]), data[size:], nil
+	}
+	return append([]byte(nil), data[:size]...), data[size:], nil
+}
+
+func readMsgpackArray(data []byte, count int) (any, []byte, error) {
+	// Every element occupies at least one byte, so a count beyond the
+	// remaining input is invalid; rejecting it here keeps a wire-controlled
+	// header from forcing a huge preallocation before anything is decoded.
+	if count > len(data) {
+		return nil, nil, errInvalidMsgpack
+	}
+	values := make([]any, 0, count)
+	for range count {
+		value, rest, err := readMsgpackValue(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, value)
+		data = rest
+	}
+	return values, data, nil
+}
+
+func readMsgpackMap(data []byte, count int) (any, []byte, error) {
+	// Each entry is a key plus a value, at least two bytes; same bound as
+	// readMsgpackArray against allocation-forcing headers.
+	if count > len(data)/2 {
+		return nil, nil, errInvalidMsgpack
+	}
+	values := make(map[string]any, count)
+	for range count {
+		key, rest, err := readMsgpackValue(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, nil, errInvalidMsgpack
+		}
+		value, rest, err := readMsgpackValue(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[name] = value
+		data = rest
+	}
+	return values, data, nil
+}
+
+// assignMsgpack sets a decoded generic value into a typed destination,
+// matching struct fields by their json-tag names.
+func assignMsgpack(value any, dst reflect.Value) error {
+	if value == nil {
+		dst.SetZero()
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assignMsgpack(value, dst.Elem())
+	}
+	if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 {
+		dst.Set(reflect.ValueOf(value))
+		return nil
+	}
+	switch src := value.(type) {
+	case bool:
+		if dst.Kind() != reflect.Bool {
+			return assignMsgpackError(value, dst)
+		}
+		dst.SetBool(src)
+	case int64:
+		return assignMsgpackNumber(dst, src, src >= 0, uint64(max(src, 0)), float64(src))
+	case uint64:
+		return assignMsgpackNumber(dst, int64(src), true, src, float64(src))
+	case float64:
+		if dst.Kind() != reflect.Float32 && dst.Kind() != reflect.Float64 {
+			return assignMsgpackError(value, dst)
+		}
+		dst.SetFloat(src)
+	case string:
+		switch {
+		case dst.Kind() == reflect.String:
+			dst.SetString(src)
+		case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8:
+			dst.SetBytes([]byte(src))
+		default:
+			return assignMsgpackError(value, dst)
+		}
+	case []byte:
+		if dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(src)
+			return nil
+		}
+		if dst.Kind() == reflect.String {
+			dst.SetString(string(src))
+			return nil
+		}
+		return assignMsgpackError(value, dst)
+	case []any:
+		if dst.Kind() != reflect.Slice {
+			return assignMsgpackError(value, dst)
+		}
+		out := reflect.MakeSlice(dst.Type(), len(src), len(src))
+		for i, item := range src {
+			if err := assignMsgpack(item, out.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+	case map[string]any:
+		return assignMsgpackMap(src, dst)
+	default:
+		return assignMsgpackError(value, dst)
+	}
+	return nil
+}
+
+func assignMsgpackNumber(dst reflect.Value, signed int64, fitsUnsigned bool, unsigned uint64, float float64) error {
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		dst.SetInt(signed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !fitsUnsigned {
+			return fmt.Errorf("msgpack: negative value for %s", dst.Type())
+		}
+		dst.SetUint(unsigned)
+	case reflect.Float32, reflect.Float64:
+		dst.SetFloat(float)
+	default:
+		return fmt.Errorf("msgpack: cannot assign number to %s", dst.Type())
+	}
+	return nil
+}
+
+func assignMsgpackMap(src map[string]any, dst reflect.Value) error {
+	switch dst.Kind() {
+	case reflect.Struct:
+		structType := dst.Type()
+		for i := 0; i < structType.NumField(); i++ {
+			field := structType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _ := jsonFieldName(field)
+			value, ok := src[name]
+			if !ok {
+				continue
+			}
+			if err := assignMsgpack(value, dst.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if dst.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("msgpack: cannot assign map to %s", dst.Type())
+		}
+		out := reflect.MakeMapWithSize(dst.Type(), len(src))
+		for key, value := range src {
+			item := reflect.New(dst.Type().Elem()).Elem()
+			if err := assignMsgpack(value, item); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(key), item)
+		}
+		dst.Set(out)
+		return nil
+	default:
+		return assignMsgpackError(src, dst)
+	}
+}
+
+func assignMsgpackError(value any, dst reflect.Value) error {
+	return fmt.Errorf("msgpack: cannot assign %T to %s", value, dst.Type())
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// PAM-backed local-account authentication (shellinabox-style): basic-auth
+// credentials are verified against the system's accounts and each session
+// then runs as the authenticated user. Verification delegates to PAM's own
+// setuid helper, unix_chkpwd(8), so no cgo binding against libpam is needed;
+// the server must run as root both for the helper to check arbitrary accounts
+// and for the subsequent credential switch when spawning sessions.
+
+// pamVerifyTimeout bounds one helper invocation. unix_chkpwd deliberately
+// stalls on failure, so this has to exceed its fail delay.
+const pamVerifyTimeout = 10 * time.Second
+
+// pamCacheTTL is how long a successful verification is remembered. Browsers
+// resend basic-auth credentials on every request; re-running the helper each
+// time would add its latency to every API call.
+const pamCacheTTL = 5 * time.Minute
+
+var pamHelperPaths = []string{"/sbin/unix_chkpwd", "/usr/sbin/unix_chkpwd"}
+
+// pamAuthenticator verifies local account passwords and caches successes.
+type pamAuthenticator struct {
+	mu    sync.Mutex
+	cache map[string]time.Time // sha256(user NUL password) -> expiry
+}
+
+func newPAMAuthenticator() *pamAuthenticator {
+	return &pamAuthenticator{cache: make(map[string]time.Time)}
+}
+
+// verify reports whether the username/password pair matches a local account.
+func (a *pamAuthenticator) verify(username, password string) bool {
+	if username == "" || password == "" {
+		return false
+	}
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	now := time.Now()
+
+	a.mu.Lock()
+	expiry, cached := a.cache[string(key[:])]
+	if cached && now.Before(expiry) {
+		a.mu.Unlock()
+		return true
+	}
+	delete(a.cache, string(key[:]))
+	a.mu.Unlock()
+
+	if err := runPAMHelper(username, password); err != nil {
+		return false
+	}
+
+	a.mu.Lock()
+	for cacheKey, cacheExpiry := range a.cache {
+		if now.After(cacheExpiry) {
+			delete(a.cache, cacheKey)
+		}
+	}
+	a.cache[string(key[:])] = now.Add(pamCacheTTL)
+	a.mu.Unlock()
+	return true
+}
+
+// runPAMHelper invokes unix_chkpwd, which reads the NUL-terminated password
+// from stdin and exits zero when it matches the account.
+func runPAMHelper(username, password string) error {
+	helper, err := findPAMHelper()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pamVerifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, helper, username, "nullok")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open helper stdin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", helper, err)
+	}
+	_, writeErr := stdin.Write(append([]byte(password), 0))
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("password verification failed: %w", err)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to send password to helper: %w", writeErr)
+	}
+	return nil
+}
+
+func findPAMHelper() (string, error) {
+	for _, path := range pamHelperPaths {
+		if _, err := exec.LookPath(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("unix_chkpwd helper not found (is PAM installed?)")
+}
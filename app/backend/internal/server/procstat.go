@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readProcessUsage reports CPU time and resident set size for a PID from
+// /proc. On platforms or kernels without procfs it returns an error and the
+// stats endpoint simply omits process figures.
+func readProcessUsage(pid int) (cpuSeconds float64, rssBytes int64, err error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The comm field (2nd) may contain spaces; everything after the closing
+	// paren is space-separated. utime and stime are fields 14 and 15.
+	closing := strings.LastIndexByte(string(stat), ')')
+	if closing < 0 {
+		return 0, 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(string(stat[closing+1:]))
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	utime, err := strconv.ParseInt(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	stime, err := strconv.ParseInt(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	const clockTicksPerSecond = 100 // USER_HZ on every supported platform
+	cpuSeconds = float64(utime+stime) / clockTicksPerSecond
+
+	statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	statmFields := strings.Fields(string(statm))
+	if len(statmFields) < 2 {
+		return 0, 0, fmt.Errorf("malformed statm for pid %d", pid)
+	}
+	rssPages, err := strconv.ParseInt(statmFields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	rssBytes = rssPages * int64(os.Getpagesize())
+	return cpuSeconds, rssBytes, nil
+}
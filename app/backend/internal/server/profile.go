@@ -0,0 +1,268 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// Profiles are named session templates ("Dev server", "Python REPL") that
+// bundle shell, argv, environment, working directory, dimensions and tags so
+// a session can be created from one name instead of repeating the details on
+// every create call.
+
+// Profile is a reusable session template. Empty fields fall back to the
+// manager's configured defaults.
+type Profile struct {
+	// Name identifies the profile and is the handle session creates refer to.
+	Name string `json:"name"`
+	// Shell overrides the manager's shell resolver, e.g. "/usr/bin/python3".
+	Shell string `json:"shell,omitempty"`
+	// Args overrides the shell argument provider. Null keeps the provider's
+	// choice; an empty array runs the shell bare.
+	Args []string `json:"args,omitempty"`
+	// Env entries are appended last to the session environment, so they win
+	// over provider defaults.
+	Env map[string]string `json:"env,omitempty"`
+	// WorkingDir is the default working directory; an explicit directory on
+	// the create request still wins.
+	WorkingDir string `json:"workingDir,omitempty"`
+	// Cols and Rows, when both set, pin the PTY via a fixed resize policy.
+	Cols int `json:"cols,omitempty"`
+	Rows int `json:"rows,omitempty"`
+	// Tags are copied onto sessions created from this profile.
+	Tags []string `json:"tags,omitempty"`
+	// Sandbox confines sessions created from this profile with Landlock path
+	// rules and an optional seccomp denylist (Linux only); useful for
+	// untrusted playground terminals. Null keeps the server default.
+	Sandbox *terminal.SandboxProfile `json:"sandbox,omitempty"`
+	// SSH backs sessions created from this profile with a remote PTY on
+	// another machine instead of a local shell ("jump host profiles").
+	SSH *RemoteSSHSpec `json:"ssh,omitempty"`
+	// Docker backs sessions created from this profile with an exec TTY
+	// inside a container.
+	Docker *DockerExecSpec `json:"docker,omitempty"`
+}
+
+// validate rejects profiles the server could never apply cleanly.
+func (p Profile) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("profile name must not be empty")
+	}
+	if strings.Contains(p.Name, "/") {
+		return errors.New("profile name must not contain '/'")
+	}
+	if (p.Cols != 0) != (p.Rows != 0) {
+		return errors.New("cols and rows must be set together")
+	}
+	if p.Cols != 0 {
+		policy := terminal.ResizePolicy{Mode: terminal.ResizePolicyFixed, Cols: p.Cols, Rows: p.Rows}
+		if _, err := terminal.ParseResizePolicy(policy.String()); err != nil {
+			return err
+		}
+	}
+	for key := range p.Env {
+		if key == "" || strings.Contains(key, "=") {
+			return fmt.Errorf("invalid env key %q", key)
+		}
+	}
+	if p.SSH != nil {
+		if err := p.SSH.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Docker != nil {
+		if p.SSH != nil {
+			return errors.New("ssh and docker backends are mutually exclusive")
+		}
+		if err := p.Docker.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileStore holds the named profiles, seeded from the configuration and
+// mutable through the CRUD API.
+type profileStore struct {
+	mu       sync.Mutex
+	profiles map[string]Profile
+}
+
+func newProfileStore(seed []Profile) *profileStore {
+	store := &profileStore{profiles: make(map[string]Profile)}
+	for _, profile := range seed {
+		store.profiles[profile.Name] = profile
+	}
+	return store
+}
+
+func (ps *profileStore) get(name string) (Profile, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	profile, ok := ps.profiles[name]
+	return profile, ok
+}
+
+func (ps *profileStore) list() []Profile {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	out := make([]Profile, 0, len(ps.profiles))
+	for _, profile := range ps.profiles {
+		out = append(out, profile)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// put stores a profile; when mustBeNew is set an existing name is an error so
+// POST cannot silently overwrite.
+func (ps *profileStore) put(profile Profile, mustBeNew bool) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, exists := ps.profiles[profile.Name]; exists && mustBeNew {
+		return fmt.Errorf("profile %q already exists", profile.Name)
+	}
+	ps.profiles[profile.Name] = profile
+	return nil
+}
+
+func (ps *profileStore) delete(name string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.profiles[name]; !ok {
+		return false
+	}
+	delete(ps.profiles, name)
+	return true
+}
+
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.profiles.list())
+
+	case http.MethodPost:
+		profile, ok := s.readProfile(w, r)
+		if !ok {
+			return
+		}
+		if err := s.profiles.put(profile, true); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		s.audit.record(auditEvent{
+			Type:     auditProfileCreate,
+			User:     identityFromContext(r.Context()).name,
+			RemoteIP: s.clientIP(r),
+			Detail:   profile.Name,
+		})
+		writeJSON(w, http.StatusOK, profile)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleProfileByName(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/profiles/")
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, ok := s.profiles.get(name)
+		if !ok {
+			http.Error(w, "profile not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, profile)
+
+	case http.MethodPut:
+		profile, ok := s.readProfile(w, r)
+		if !ok {
+			return
+		}
+		if profile.Name != name {
+			http.Error(w, "profile name does not match URL", http.StatusBadRequest)
+			return
+		}
+		_ = s.profiles.put(profile, false)
+		s.audit.record(auditEvent{
+			Type:     auditProfileUpdate,
+			User:     identityFromContext(r.Context()).name,
+			RemoteIP: s.clientIP(r),
+			Detail:   profile.Name,
+		})
+		writeJSON(w, http.StatusOK, profile)
+
+	case http.MethodDelete:
+		if !s.profiles.delete(name) {
+			http.Error(w, "profile not found", http.StatusNotFound)
+			return
+		}
+		s.audit.record(auditEvent{
+			Type:     auditProfileDelete,
+			User:     identityFromContext(r.Context()).name,
+			RemoteIP: s.clientIP(r),
+			Detail:   name,
+		})
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// readProfile decodes and validates a profile body, writing the error
+// response itself when the payload is unusable.
+func (s *Server) readProfile(w http.ResponseWriter, r *http.Request) (Profile, bool) {
+	var profile Profile
+	if err := readJSON(w, r, &profile, s.maxJSONBodyBytes); err != nil {
+		var httpErr *httpError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.message, httpErr.status)
+			return Profile{}, false
+		}
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return Profile{}, false
+	}
+	if err := profile.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return Profile{}, false
+	}
+	return profile, true
+}
+
+// applyProfile folds a profile into session create options. Explicit request
+// values win over the profile's defaults.
+func applyProfile(opts *terminal.CreateSessionOptions, profile Profile) {
+	if opts.WorkingDir == "" {
+		opts.WorkingDir = profile.WorkingDir
+	}
+	opts.Shell = profile.Shell
+	if profile.Args != nil {
+		opts.ShellArgs = append([]string(nil), profile.Args...)
+	}
+	if len(profile.Env) > 0 {
+		keys := make([]string, 0, len(profile.Env))
+		for key := range profile.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			opts.ExtraEnv = append(opts.ExtraEnv, key+"="+profile.Env[key])
+		}
+	}
+	if profile.Sandbox != nil {
+		sandbox := *profile.Sandbox
+		opts.Sandbox = &sandbox
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func newProfileTestServer(t *testing.T, seed []Profile) (*Server, *httptest.Server) {
+	t.Helper()
+	srv := New(Config{
+		Profiles: seed,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	return srv, httpSrv
+}
+
+func TestProfileCRUD(t *testing.T) {
+	_, httpSrv := newProfileTestServer(t, nil)
+
+	body := `{"name":"dev","shell":"/bin/bash","args":["-l"],"env":{"NODE_ENV":"development"},"workingDir":"/tmp","tags":["dev"]}`
+	resp, err := http.Post(httpSrv.URL+"/api/profiles", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create status=%d, want 200", resp.StatusCode)
+	}
+
+	// Duplicate names conflict rather than overwrite.
+	resp, err = http.Post(httpSrv.URL+"/api/profiles", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("duplicate create status=%d, want 409", resp.StatusCode)
+	}
+
+	resp, err = http.Get(httpSrv.URL + "/api/profiles/dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Profile
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Shell != "/bin/bash" || got.Env["NODE_ENV"] != "development" || got.WorkingDir != "/tmp" {
+		t.Fatalf("profile = %+v", got)
+	}
+
+	put, err := http.NewRequest(http.MethodPut, httpSrv.URL+"/api/profiles/dev", bytes.NewBufferString(`{"name":"dev","shell":"/bin/zsh"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.DefaultClient.Do(put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update status=%d, want 200", resp.StatusCode)
+	}
+
+	resp, err = http.Get(httpSrv.URL + "/api/profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []Profile
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(list) != 1 || list[0].Shell != "/bin/zsh" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	del, err := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/profiles/dev", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.DefaultClient.Do(del)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status=%d, want 204", resp.StatusCode)
+	}
+	resp, err = http.Get(httpSrv.URL + "/api/profiles/dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get after delete status=%d, want 404", resp.StatusCode)
+	}
+}
+
+func TestProfileValidation(t *testing.T) {
+	_, httpSrv := newProfileTestServer(t, nil)
+
+	for _, body := range []string{
+		`{"name":""}`,
+		`{"name":"a/b"}`,
+		`{"name":"odd","cols":120}`,
+		`{"name":"tiny","cols":5,"rows":2}`,
+		`{"name":"env","env":{"BAD=KEY":"x"}}`,
+	} {
+		resp, err := http.Post(httpSrv.URL+"/api/profiles", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("create %s status=%d, want 400", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestCreateSessionFromProfile(t *testing.T) {
+	_, httpSrv := newProfileTestServer(t, []Profile{{
+		Name:       "repl",
+		WorkingDir: "/tmp",
+		Cols:       120,
+		Rows:       40,
+		Tags:       []string{"python", "repl"},
+	}})
+
+	resp, err := http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(`{"profile":"repl"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if created.WorkingDir != "/tmp" {
+		t.Fatalf("workingDir = %q, want /tmp", created.WorkingDir)
+	}
+	if len(created.Tags) != 2 || created.Tags[0] != "python" {
+		t.Fatalf("tags = %v", created.Tags)
+	}
+	if created.ResizePolicy != "fixed:120x40" {
+		t.Fatalf("resizePolicy = %q, want fixed:120x40", created.ResizePolicy)
+	}
+
+	// Explicit request values beat the profile's defaults.
+	resp, err = http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(`{"profile":"repl","workingDir":"/"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var explicit apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&explicit); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if explicit.WorkingDir != "/" {
+		t.Fatalf("workingDir = %q, want /", explicit.WorkingDir)
+	}
+
+	resp, err = http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(`{"profile":"missing"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown profile status=%d, want 400", resp.StatusCode)
+	}
+}
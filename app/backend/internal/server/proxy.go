@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// trustedProxies is the set of reverse-proxy addresses whose forwarding
+// headers the server believes. Without it every client behind a proxy would
+// share the proxy's IP for per-IP limits.
+type trustedProxies struct {
+	nets []*net.IPNet
+}
+
+// parseTrustedProxies builds the set from CIDR entries; bare IPs are treated
+// as single-host networks. An empty list yields nil, meaning no proxy is
+// trusted and forwarding headers are ignored.
+func parseTrustedProxies(cidrs []string) (*trustedProxies, error) {
+	if len(cidrs) == 0 {
+		return nil, nil
+	}
+	tp := &trustedProxies{}
+	for _, entry := range cidrs {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		tp.nets = append(tp.nets, ipNet)
+	}
+	if len(tp.nets) == 0 {
+		return nil, nil
+	}
+	return tp, nil
+}
+
+func (tp *trustedProxies) contains(ip net.IP) bool {
+	if tp == nil || ip == nil {
+		return false
+	}
+	for _, ipNet := range tp.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP resolves the request's client address for per-IP limits and logs.
+// When the connection comes from a trusted proxy, the rightmost untrusted hop
+// of X-Forwarded-For wins (rightmost because clients can forge the left end),
+// then X-Real-IP; otherwise the socket address is used as-is.
+func (s *Server) clientIP(r *http.Request) string {
+	host := remoteIPHost(r)
+	if !s.proxies.contains(net.ParseIP(host)) {
+		return host
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			ip := net.ParseIP(hop)
+			if ip == nil {
+				break
+			}
+			if !s.proxies.contains(ip) {
+				return hop
+			}
+		}
+	}
+	if real := strings.TrimSpace(r.Header.Get("X-Real-IP")); real != "" && net.ParseIP(real) != nil {
+		return real
+	}
+	return host
+}
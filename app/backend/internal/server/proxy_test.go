@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func newProxyTestServer(t *testing.T, cidrs []string) *Server {
+	t.Helper()
+	srv := New(Config{
+		TrustedProxies: cidrs,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func proxyRequest(remoteAddr, xff, realIP string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "/api/sessions", nil)
+	r.RemoteAddr = remoteAddr
+	if xff != "" {
+		r.Header.Set("X-Forwarded-For", xff)
+	}
+	if realIP != "" {
+		r.Header.Set("X-Real-IP", realIP)
+	}
+	return r
+}
+
+func TestClientIPBelievesTrustedProxiesOnly(t *testing.T) {
+	srv := newProxyTestServer(t, []string{"10.0.0.0/8", "127.0.0.1"})
+
+	// Direct connections keep the socket address even with forged headers.
+	if got := srv.clientIP(proxyRequest("203.0.113.9:4567", "1.2.3.4", "")); got != "203.0.113.9" {
+		t.Fatalf("direct clientIP = %q, want 203.0.113.9", got)
+	}
+
+	// Behind a trusted proxy the forwarded address wins.
+	if got := srv.clientIP(proxyRequest("10.1.2.3:80", "198.51.100.7", "")); got != "198.51.100.7" {
+		t.Fatalf("proxied clientIP = %q, want 198.51.100.7", got)
+	}
+
+	// The rightmost untrusted hop wins; client-controlled left entries and
+	// intermediate trusted proxies are skipped.
+	if got := srv.clientIP(proxyRequest("10.1.2.3:80", "6.6.6.6, 198.51.100.7, 10.9.9.9", "")); got != "198.51.100.7" {
+		t.Fatalf("multi-hop clientIP = %q, want 198.51.100.7", got)
+	}
+
+	// X-Real-IP is the fallback when X-Forwarded-For is absent.
+	if got := srv.clientIP(proxyRequest("127.0.0.1:9999", "", "198.51.100.8")); got != "198.51.100.8" {
+		t.Fatalf("x-real-ip clientIP = %q, want 198.51.100.8", got)
+	}
+
+	// Garbage headers fall back to the socket address.
+	if got := srv.clientIP(proxyRequest("10.1.2.3:80", "not-an-ip", "")); got != "10.1.2.3" {
+		t.Fatalf("garbage header clientIP = %q, want 10.1.2.3", got)
+	}
+}
+
+func TestTrustedProxyMisconfigurationFailsClosed(t *testing.T) {
+	srv := newProxyTestServer(t, []string{"10.0.0.0/33"})
+	if srv.authErr == nil {
+		t.Fatal("invalid trusted proxy CIDR did not set authErr")
+	}
+}
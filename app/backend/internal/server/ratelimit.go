@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// RateLimitConfig bounds request and input throughput with server-wide token
+// buckets. The zero value leaves every limiter off. Rejected HTTP requests
+// get a standard 429 with a Retry-After header.
+type RateLimitConfig struct {
+	// InputBytesPerSecond caps terminal input written by live clients across
+	// all sessions. Excess input fails the write rather than queueing.
+	InputBytesPerSecond int
+
+	// RequestsPerMinute caps /api requests.
+	RequestsPerMinute int
+
+	// WSConnectsPerMinute caps /ws handshake attempts.
+	WSConnectsPerMinute int
+
+	// RouteRequestsPerMinute overrides RequestsPerMinute for requests whose
+	// path starts with the given prefix; the longest matching prefix wins.
+	RouteRequestsPerMinute map[string]int
+}
+
+// tokenBucket is a minimal token bucket: rate tokens per second, up to burst
+// banked. Per-minute limits bank a full minute's allowance so short spikes
+// are fine and only sustained overuse is rejected.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take consumes n tokens when available; otherwise it reports how long the
+// caller should wait before retrying. Requests larger than the burst are
+// charged the full burst so a single oversized write cannot wedge forever.
+func (b *tokenBucket) take(n float64) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > b.burst {
+		n = b.burst
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens >= n {
+		b.tokens -= n
+		return true, 0
+	}
+	return false, time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+type routeLimit struct {
+	prefix string
+	bucket *tokenBucket
+}
+
+type rateLimiter struct {
+	requests   *tokenBucket
+	wsConnects *tokenBucket
+	inputBytes *tokenBucket
+	// routes is sorted by descending prefix length so the most specific
+	// override wins.
+	routes []routeLimit
+}
+
+// newRateLimiter returns nil when no limit is configured, which turns the
+// middleware into a pass-through.
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{}
+	if cfg.RequestsPerMinute > 0 {
+		l.requests = newTokenBucket(float64(cfg.RequestsPerMinute)/60, float64(cfg.RequestsPerMinute))
+	}
+	if cfg.WSConnectsPerMinute > 0 {
+		l.wsConnects = newTokenBucket(float64(cfg.WSConnectsPerMinute)/60, float64(cfg.WSConnectsPerMinute))
+	}
+	if cfg.InputBytesPerSecond > 0 {
+		l.inputBytes = newTokenBucket(float64(cfg.InputBytesPerSecond), float64(cfg.InputBytesPerSecond))
+	}
+	for prefix, perMinute := range cfg.RouteRequestsPerMinute {
+		if prefix == "" || perMinute <= 0 {
+			continue
+		}
+		l.routes = append(l.routes, routeLimit{prefix: prefix, bucket: newTokenBucket(float64(perMinute)/60, float64(perMinute))})
+	}
+	sort.Slice(l.routes, func(i, j int) bool { return len(l.routes[i].prefix) > len(l.routes[j].prefix) })
+
+	if l.requests == nil && l.wsConnects == nil && l.inputBytes == nil && len(l.routes) == 0 {
+		return nil
+	}
+	return l
+}
+
+// fill reports the bucket's configured rate and burst alongside the current
+// token count, applying the same refill take would so the reading is fresh.
+func (b *tokenBucket) fill() (rate, burst, tokens float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	return b.rate, b.burst, b.tokens
+}
+
+// stats reports every configured bucket for the /api/server/stats endpoint.
+// A nil limiter (no limits configured) reports nothing.
+func (l *rateLimiter) stats() []rateLimitStats {
+	if l == nil {
+		return nil
+	}
+	var out []rateLimitStats
+	add := func(name string, bucket *tokenBucket) {
+		if bucket == nil {
+			return
+		}
+		rate, burst, tokens := bucket.fill()
+		out = append(out, rateLimitStats{Name: name, RatePerSecond: rate, Burst: burst, AvailableTokens: tokens})
+	}
+	add("requests", l.requests)
+	add("wsConnects", l.wsConnects)
+	add("inputBytes", l.inputBytes)
+	for _, route := range l.routes {
+		add("route:"+route.prefix, route.bucket)
+	}
+	return out
+}
+
+// bucketForPath returns the bucket that governs the request path, or nil when
+// the path is not rate limited (static assets, /metrics).
+func (l *rateLimiter) bucketForPath(path string) *tokenBucket {
+	for _, route := range l.routes {
+		if strings.HasPrefix(path, route.prefix) {
+			return route.bucket
+		}
+	}
+	if path == "/ws" || strings.HasPrefix(path, "/ws/") {
+		return l.wsConnects
+	}
+	if path == "/api" || strings.HasPrefix(path, "/api/") {
+		return l.requests
+	}
+	return nil
+}
+
+func (s *Server) withRateLimit(next http.Handler) http.Handler {
+	if s.rateLimiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket := s.rateLimiter.bucketForPath(r.URL.Path)
+		if bucket != nil {
+			if ok, wait := bucket.take(1); !ok {
+				s.metrics.IncCounter(metricRateLimitRejected, 1)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func retryAfterSeconds(wait time.Duration) int {
+	secs := int(math.Ceil(wait.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// rateLimitedBackend fails live input once the configured input budget is
+// exhausted instead of letting one client saturate the PTYs.
+type rateLimitedBackend struct {
+	livev1.Backend
+	bucket  *tokenBucket
+	metrics *serverMetrics
+}
+
+func (b rateLimitedBackend) WriteInput(ctx context.Context, attachment livev1.Attach, input livev1.Input) error {
+	if ok, _ := b.bucket.take(float64(len(input.Data))); !ok {
+		b.metrics.IncCounter(metricRateLimitRejected, 1)
+		return errInputRateLimited
+	}
+	return b.Backend.WriteInput(ctx, attachment, input)
+}
+
+// ReplayOutput forwards the reconnect catch-up capability, which struct
+// embedding does not promote for optional interfaces.
+func (b rateLimitedBackend) ReplayOutput(ctx context.Context, attachment livev1.Attach, afterSequence uint64) ([]livev1.OutputRecord, error) {
+	if replayer, ok := b.Backend.(livev1.OutputReplayer); ok {
+		return replayer.ReplayOutput(ctx, attachment, afterSequence)
+	}
+	return nil, nil
+}
+
+var errInputRateLimited = errors.New("input rate limit exceeded")
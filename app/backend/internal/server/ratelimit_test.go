@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func newRateLimitedTestServer(t *testing.T, cfg RateLimitConfig) (*Server, *httptest.Server) {
+	t.Helper()
+	srv := New(Config{
+		RateLimit: cfg,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	return srv, httpSrv
+}
+
+func TestRateLimitRejectsAPIRequestsWithRetryAfter(t *testing.T) {
+	srv, httpSrv := newRateLimitedTestServer(t, RateLimitConfig{RequestsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(httpSrv.URL + "/api/sessions")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d status=%d, want 200", i+1, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("over-limit status=%d, want 429", resp.StatusCode)
+	}
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || retryAfter < 1 {
+		t.Fatalf("Retry-After=%q, want a positive integer", resp.Header.Get("Retry-After"))
+	}
+	if got := srv.metrics.snapshot()[metricRateLimitRejected]; got != 1 {
+		t.Fatalf("%s=%v, want 1", metricRateLimitRejected, got)
+	}
+
+	// /metrics and static paths are not governed by the request limiter.
+	resp, err = http.Get(httpSrv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("metrics status=%d, want 200", resp.StatusCode)
+	}
+}
+
+func TestRateLimitPerRouteOverrideBeatsDefault(t *testing.T) {
+	_, httpSrv := newRateLimitedTestServer(t, RateLimitConfig{
+		RequestsPerMinute:      100,
+		RouteRequestsPerMinute: map[string]int{"/api/exec": 1},
+	})
+
+	status := func(t *testing.T, path string) int {
+		t.Helper()
+		resp, err := http.Get(httpSrv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The first /api/exec request consumes the override's budget (the GET is
+	// rejected by the handler, but it still passed the limiter).
+	if got := status(t, "/api/exec"); got == http.StatusTooManyRequests {
+		t.Fatalf("first /api/exec request was rate limited")
+	}
+	if got := status(t, "/api/exec"); got != http.StatusTooManyRequests {
+		t.Fatalf("second /api/exec status=%d, want 429", got)
+	}
+	// Other API routes still use the roomy default limit.
+	if got := status(t, "/api/sessions"); got != http.StatusOK {
+		t.Fatalf("/api/sessions status=%d, want 200", got)
+	}
+}
+
+func TestRateLimitCapsWSConnects(t *testing.T) {
+	_, httpSrv := newRateLimitedTestServer(t, RateLimitConfig{WSConnectsPerMinute: 1})
+
+	// Plain GETs without upgrade headers still pass through the connect
+	// limiter before the handler rejects them.
+	resp, err := http.Get(httpSrv.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.Fatal("first ws connect was rate limited")
+	}
+	resp, err = http.Get(httpSrv.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second ws connect status=%d, want 429", resp.StatusCode)
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	bucket := newTokenBucket(1000, 10)
+	if ok, _ := bucket.take(10); !ok {
+		t.Fatal("initial burst was not available")
+	}
+	ok, wait := bucket.take(10)
+	if ok {
+		t.Fatal("empty bucket granted tokens")
+	}
+	if wait <= 0 {
+		t.Fatalf("wait=%v, want positive", wait)
+	}
+	waitForBucket(t, bucket, 10)
+
+	// A request larger than the burst is charged the burst instead of being
+	// unsatisfiable forever.
+	if ok, _ := newTokenBucket(1000, 10).take(10000); !ok {
+		t.Fatal("oversized request was not clamped to the burst")
+	}
+}
+
+func waitForBucket(t *testing.T, bucket *tokenBucket, n float64) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		if ok, wait := bucket.take(n); ok {
+			return
+		} else if wait > 0 {
+			// Sleeping the reported wait keeps the test fast and exercises
+			// the Retry-After math.
+			time.Sleep(wait)
+		}
+	}
+	t.Fatal("bucket never refilled")
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var errReadOnlyServer = errors.New("server is read-only")
+
+// withReadOnly rejects mutating API requests when the server was configured
+// read-only, so a build or deploy terminal can be broadcast to an audience
+// that can watch history and live output but cannot create, delete or drive
+// sessions. Websocket handshakes pass through untouched: attaching stays
+// possible and the view-only backend rejects input and resize per frame.
+func (s *Server) withReadOnly(next http.Handler) http.Handler {
+	if !s.readOnly {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": errReadOnlyServer.Error()})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+func newReadOnlyTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	srv := New(Config{
+		ReadOnly: true,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	return srv, httpSrv
+}
+
+func TestReadOnlyServerRejectsMutatingAPIRequests(t *testing.T) {
+	srv, httpSrv := newReadOnlyTestServer(t)
+
+	session, err := srv.Manager().CreateSession("broadcast", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reads stay available.
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list status=%d, want 200", resp.StatusCode)
+	}
+	resp, err = http.Get(httpSrv.URL + "/api/sessions/" + session.ID + "/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("history status=%d, want 200", resp.StatusCode)
+	}
+
+	// Creates and deletes are rejected.
+	resp, err = http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("create status=%d, want 403", resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+session.ID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("delete status=%d, want 403", resp.StatusCode)
+	}
+}
+
+func TestReadOnlyServerRejectsLiveInput(t *testing.T) {
+	srv, httpSrv := newReadOnlyTestServer(t)
+
+	session, err := srv.Manager().CreateSession("broadcast", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn := attachLiveTestConnection(t, ctx, httpSrv.URL, session.ID, "viewer")
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+
+	input, err := livev1.EncodeInput(livev1.Input{Sequence: 1, Data: []byte("rm -rf /\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, input); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		frame := readLiveFrame(t, ctx, conn)
+		if frame.Type == livev1.FrameError {
+			return
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// The Redis bridge lets several floeterm replicas behind a load balancer
+// share one session's traffic: the node hosting a session publishes its
+// output and lifecycle events to Redis pub/sub channels, and consumes input
+// published by other nodes. The protocol surface used (PUBLISH, PSUBSCRIBE)
+// is small enough that a hand-written RESP client keeps a Redis driver out
+// of the dependency tree, the same stance the /metrics handler takes towards
+// the Prometheus client.
+//
+// Channels, relative to the configured prefix:
+//
+//	<prefix>.out.<sessionID>   output chunks from the hosting node
+//	<prefix>.lifecycle         created/closed events from the hosting node
+//	<prefix>.in.<sessionID>    input for the hosting node to write to the PTY
+
+const (
+	redisBridgeDialTimeout    = 5 * time.Second
+	redisBridgeRetryInterval  = 2 * time.Second
+	redisBridgePublishTimeout = 5 * time.Second
+	redisBridgeQueueSize      = 256
+)
+
+// RedisBridgeConfig configures the replica bridge; a zero Addr disables it.
+type RedisBridgeConfig struct {
+	// Addr is the Redis host:port.
+	Addr string
+	// Password authenticates with AUTH when set.
+	Password string
+	// ChannelPrefix namespaces the bridge's channels; default "floeterm".
+	ChannelPrefix string
+}
+
+// bridgeOutputEvent is the JSON payload on <prefix>.out.<sessionID>.
+type bridgeOutputEvent struct {
+	DataBase64  string `json:"data"`
+	Sequence    int64  `json:"sequence"`
+	TimestampMs int64  `json:"timestampMs"`
+}
+
+// bridgeLifecycleEvent is the JSON payload on <prefix>.lifecycle.
+type bridgeLifecycleEvent struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name,omitempty"`
+}
+
+// bridgeInputEvent is the JSON payload on <prefix>.in.<sessionID>.
+type bridgeInputEvent struct {
+	DataBase64 string `json:"data"`
+}
+
+// bridgePublish is one queued PUBLISH for the worker goroutine.
+type bridgePublish struct {
+	channel string
+	body    []byte
+}
+
+type redisBridge struct {
+	addr     string
+	password string
+	prefix   string
+	manager  *terminal.Manager
+	logger   terminal.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// queue decouples event callbacks from network I/O: they run on the PTY
+	// read path, so publishing happens on a single worker with drop-on-full,
+	// the same stance the webhook dispatcher takes.
+	queue chan bridgePublish
+
+	// pubMu serializes writes on the publish connection; pubConn is
+	// redialled on error.
+	pubMu   sync.Mutex
+	pubConn net.Conn
+	pubR    *bufio.Reader
+
+	subs []*terminal.EventSubscription
+}
+
+func newRedisBridge(cfg RedisBridgeConfig, manager *terminal.Manager, logger terminal.Logger) *redisBridge {
+	prefix := cfg.ChannelPrefix
+	if prefix == "" {
+		prefix = "floeterm"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &redisBridge{
+		addr:     cfg.Addr,
+		password: cfg.Password,
+		prefix:   prefix,
+		manager:  manager,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		queue:    make(chan bridgePublish, redisBridgeQueueSize),
+	}
+
+	events := manager.Events()
+	b.subs = append(b.subs,
+		events.OnData(func(sessionID string, event terminal.TerminalOutputEvent) {
+			b.enqueueJSON(prefix+".out."+sessionID, bridgeOutputEvent{
+				DataBase64:  base64.StdEncoding.EncodeToString(event.Data),
+				Sequence:    event.Sequence,
+				TimestampMs: event.TimestampMs,
+			})
+		}),
+		events.OnSessionCreated(func(session *terminal.Session) {
+			b.enqueueJSON(prefix+".lifecycle", bridgeLifecycleEvent{
+				Type:      "created",
+				SessionID: session.ID,
+				Name:      session.GetName(),
+			})
+		}),
+		events.OnSessionClosed(func(sessionID string) {
+			b.enqueueJSON(prefix+".lifecycle", bridgeLifecycleEvent{
+				Type:      "closed",
+				SessionID: sessionID,
+			})
+		}),
+	)
+
+	b.wg.Add(2)
+	go b.publishLoop()
+	go b.consumeInput()
+	return b
+}
+
+func (b *redisBridge) stop() {
+	for _, sub := range b.subs {
+		sub.Cancel()
+	}
+	b.cancel()
+	b.pubMu.Lock()
+	if b.pubConn != nil {
+		b.pubConn.Close()
+		b.pubConn = nil
+	}
+	b.pubMu.Unlock()
+	b.wg.Wait()
+}
+
+func (b *redisBridge) dial() (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", b.addr, redisBridgeDialTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+	if b.password != "" {
+		if err := writeRESPCommand(conn, "AUTH", b.password); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		if _, err := readRESPReply(reader); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	return conn, reader, nil
+}
+
+// enqueueJSON marshals the payload and hands it to the publish worker. The
+// event callbacks calling this run on the PTY read path and must return
+// quickly, so a full queue drops the event: the bridge is a best-effort
+// mirror, the ring buffer remains the source of truth on the hosting node.
+func (b *redisBridge) enqueueJSON(channel string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	select {
+	case b.queue <- bridgePublish{channel: channel, body: body}:
+	default:
+		b.logger.Warn("Redis bridge publish queue full, dropping event", "channel", channel)
+	}
+}
+
+// publishLoop drains the queue on a single goroutine so slow or unreachable
+// Redis costs queued events, never session output latency.
+func (b *redisBridge) publishLoop() {
+	defer b.wg.Done()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case p := <-b.queue:
+			b.publish(p.channel, p.body)
+		}
+	}
+}
+
+// publish sends one PUBLISH, dropping the event on error.
+func (b *redisBridge) publish(channel string, body []byte) {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	if b.pubConn == nil {
+		conn, reader, err := b.dial()
+		if err != nil {
+			b.logger.Warn("Redis bridge publish connection failed", "addr", b.addr, "error", err)
+			return
+		}
+		b.pubConn = conn
+		b.pubR = reader
+	}
+	b.pubConn.SetDeadline(time.Now().Add(redisBridgePublishTimeout))
+	if err := writeRESPCommand(b.pubConn, "PUBLISH", channel, string(body)); err == nil {
+		_, err = readRESPReply(b.pubR)
+		if err == nil {
+			return
+		}
+	}
+	// Drop the connection on any error; the next publish redials.
+	b.pubConn.Close()
+	b.pubConn = nil
+	b.pubR = nil
+}
+
+// consumeInput subscribes to <prefix>.in.* and writes payloads for locally
+// hosted sessions into their PTYs, reconnecting until the bridge stops.
+func (b *redisBridge) consumeInput() {
+	defer b.wg.Done()
+	pattern := b.prefix + ".in.*"
+	for b.ctx.Err() == nil {
+		conn, reader, err := b.dial()
+		if err != nil {
+			b.logger.Warn("Redis bridge subscribe connection failed", "addr", b.addr, "error", err)
+			select {
+			case <-b.ctx.Done():
+				return
+			case <-time.After(redisBridgeRetryInterval):
+			}
+			continue
+		}
+		// Close the connection when the bridge stops so the blocking read
+		// below unblocks.
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-b.ctx.Done():
+				conn.Close()
+			case <-done:
+			}
+		}()
+		b.readInputMessages(conn, reader, pattern)
+		close(done)
+		conn.Close()
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(redisBridgeRetryInterval):
+		}
+	}
+}
+
+func (b *redisBridge) readInputMessages(conn net.Conn, reader *bufio.Reader, pattern string) {
+	if err := writeRESPCommand(conn, "PSUBSCRIBE", pattern); err != nil {
+		return
+	}
+	channelPrefix := b.prefix + ".in."
+	for {
+		reply, err := readRESPReply(reader)
+		if err != nil {
+			return
+		}
+		parts, ok := reply.([]any)
+		// PSUBSCRIBE pushes ["pmessage", pattern, channel, payload]; the
+		// initial subscription confirmation has a different shape and is
+		// skipped here.
+		if !ok || len(parts) != 4 {
+			continue
+		}
+		kind, _ := parts[0].(string)
+		channel, _ := parts[2].(string)
+		payload, _ := parts[3].(string)
+		if kind != "pmessage" || !strings.HasPrefix(channel, channelPrefix) {
+			continue
+		}
+		sessionID := strings.TrimPrefix(channel, channelPrefix)
+		var input bridgeInputEvent
+		if err := json.Unmarshal([]byte(payload), &input); err != nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(input.DataBase64)
+		if err != nil {
+			continue
+		}
+		session, ok := b.manager.GetSession(sessionID)
+		if !ok {
+			// Not hosted here; another replica owns the session.
+			continue
+		}
+		if err := session.WriteDataWithSource(data, "redis-bridge"); err != nil {
+			b.logger.Warn("Redis bridge input write failed", "sessionID", sessionID, "error", err)
+		}
+	}
+}
+
+// writeRESPCommand writes one command as a RESP array of bulk strings.
+func writeRESPCommand(conn net.Conn, args ...string) error {
+	var sb strings.Builder
+	sb.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		sb.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n")
+		sb.WriteString(arg)
+		sb.WriteString("\r\n")
+	}
+	_, err := conn.Write([]byte(sb.String()))
+	return err
+}
+
+// readRESPReply reads one reply. Simple strings and bulk strings decode to
+// string, integers to int64, arrays to []any; error replies surface as Go
+// errors.
+func readRESPReply(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if line == "" {
+		return nil, fmt.Errorf("empty RESP reply")
+	}
+	body := line[1:]
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, fmt.Errorf("redis error: %s", body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:length]), nil
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readRESPReply(reader)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported RESP type %q", line[0])
+	}
+}
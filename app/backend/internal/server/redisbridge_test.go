@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// fakeRedis speaks just enough RESP to accept AUTH, PUBLISH and PSUBSCRIBE
+// from the bridge and to push pmessage frames to subscribers.
+type fakeRedis struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	published   map[string][]string
+	subscribers []net.Conn
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{listener: listener, published: map[string][]string{}}
+	go f.serve()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		reply, err := readRESPReply(reader)
+		if err != nil {
+			return
+		}
+		parts, ok := reply.([]any)
+		if !ok || len(parts) == 0 {
+			continue
+		}
+		command, _ := parts[0].(string)
+		switch strings.ToUpper(command) {
+		case "AUTH":
+			conn.Write([]byte("+OK\r\n"))
+		case "PUBLISH":
+			channel, _ := parts[1].(string)
+			payload, _ := parts[2].(string)
+			f.mu.Lock()
+			f.published[channel] = append(f.published[channel], payload)
+			f.mu.Unlock()
+			conn.Write([]byte(":1\r\n"))
+		case "PSUBSCRIBE":
+			pattern, _ := parts[1].(string)
+			writeRESPCommand(conn, "psubscribe", pattern, "1")
+			f.mu.Lock()
+			f.subscribers = append(f.subscribers, conn)
+			f.mu.Unlock()
+		}
+	}
+}
+
+func (f *fakeRedis) publishedOn(channel string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.published[channel]...)
+}
+
+func (f *fakeRedis) pushInput(pattern, channel, payload string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.subscribers {
+		writeRESPCommand(conn, "pmessage", pattern, channel, payload)
+	}
+	return len(f.subscribers) > 0
+}
+
+func (f *fakeRedis) waitForSubscriber(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		n := len(f.subscribers)
+		f.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("bridge never subscribed")
+}
+
+func TestRedisBridgePublishesLifecycleAndConsumesInput(t *testing.T) {
+	redis := newFakeRedis(t)
+	srv := New(Config{
+		RedisBridge: RedisBridgeConfig{Addr: redis.listener.Addr().String(), Password: "secret"},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	defer srv.Close()
+
+	redis.waitForSubscriber(t)
+
+	session, err := srv.Manager().CreateSession("bridged", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Manager().ActivateSessionContext(ctx, session.ID, 80, 24); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	var lifecycle []string
+	for time.Now().Before(deadline) {
+		lifecycle = redis.publishedOn("floeterm.lifecycle")
+		if len(lifecycle) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(lifecycle) == 0 {
+		t.Fatal("no lifecycle event published")
+	}
+	var created bridgeLifecycleEvent
+	if err := json.Unmarshal([]byte(lifecycle[0]), &created); err != nil {
+		t.Fatalf("invalid lifecycle payload: %v", err)
+	}
+	if created.Type != "created" || created.SessionID != session.ID {
+		t.Fatalf("lifecycle payload = %+v", created)
+	}
+
+	// Input published by a peer replica must land in the local PTY; cat
+	// echoes it back as output, which the bridge mirrors to the out channel.
+	input, _ := json.Marshal(bridgeInputEvent{DataBase64: base64.StdEncoding.EncodeToString([]byte("ping\n"))})
+	redis.pushInput("floeterm.in.*", "floeterm.in."+session.ID, string(input))
+
+	outChannel := "floeterm.out." + session.ID
+	for time.Now().Before(deadline) {
+		for _, payload := range redis.publishedOn(outChannel) {
+			var event bridgeOutputEvent
+			if json.Unmarshal([]byte(payload), &event) != nil {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(event.DataBase64)
+			if err == nil && strings.Contains(string(data), "ping") {
+				return
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("input pushed over the bridge never appeared in mirrored output")
+}
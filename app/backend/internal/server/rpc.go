@@ -0,0 +1,622 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/coder/websocket"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// The /ws/rpc endpoint carries a request/response envelope over a single
+// websocket: clients send {id, method, params} and receive {id, result} or
+// {id, error}, while the server pushes id-less {method, params} notifications
+// for terminal output, geometry changes and session close. This lets
+// integrators drive attach/input/resize/history over one connection instead
+// of mixing REST calls with the binary output stream on /ws.
+
+const (
+	rpcErrInvalidRequest = -32600
+	rpcErrMethodNotFound = -32601
+	rpcErrInvalidParams  = -32602
+	rpcErrServer         = -32000
+)
+
+// rpcSubprotocolMsgpack selects the compact MessagePack encoding of the same
+// envelope, for native clients that do not want to pay JSON parse costs at
+// high output rates. The message shapes are documented in
+// protocol/rpc_msgpack_v1.json; without the subprotocol the connection speaks
+// JSON over text frames as before.
+const rpcSubprotocolMsgpack = "floeterm.rpc.msgpack.v1"
+
+type rpcRequest struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+
+	// paramsValue holds the decoded params when the connection negotiated a
+	// non-JSON codec; Params stays nil in that case.
+	paramsValue any
+}
+
+// rpcCodec abstracts how the envelope is serialized so both encodings share
+// the dispatch and handler logic.
+type rpcCodec interface {
+	messageType() websocket.MessageType
+	marshal(v any) ([]byte, error)
+	unmarshalRequest(data []byte) (rpcRequest, error)
+	unmarshalParams(req rpcRequest, dst any) error
+}
+
+type jsonRPCCodec struct{}
+
+func (jsonRPCCodec) messageType() websocket.MessageType { return websocket.MessageText }
+
+func (jsonRPCCodec) marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonRPCCodec) unmarshalRequest(data []byte) (rpcRequest, error) {
+	var req rpcRequest
+	err := json.Unmarshal(data, &req)
+	return req, err
+}
+
+func (jsonRPCCodec) unmarshalParams(req rpcRequest, dst any) error {
+	return json.Unmarshal(req.Params, dst)
+}
+
+type msgpackRPCCodec struct{}
+
+func (msgpackRPCCodec) messageType() websocket.MessageType { return websocket.MessageBinary }
+
+func (msgpackRPCCodec) marshal(v any) ([]byte, error) { return marshalMsgpack(v) }
+
+func (msgpackRPCCodec) unmarshalRequest(data []byte) (rpcRequest, error) {
+	var envelope struct {
+		ID     int64  `json:"id"`
+		Method string `json:"method"`
+		Params any    `json:"params"`
+	}
+	if err := unmarshalMsgpack(data, &envelope); err != nil {
+		return rpcRequest{}, err
+	}
+	return rpcRequest{ID: envelope.ID, Method: envelope.Method, paramsValue: envelope.Params}, nil
+}
+
+func (msgpackRPCCodec) unmarshalParams(req rpcRequest, dst any) error {
+	if req.paramsValue == nil {
+		return errors.New("missing params")
+	}
+	return assignMsgpackInto(req.paramsValue, dst)
+}
+
+type rpcResponse struct {
+	ID     int64     `json:"id"`
+	Result any       `json:"result,omitempty"`
+	Error  *rpcError `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcNotification struct {
+	Method string `json:"method"`
+	Params any    `json:"params"`
+}
+
+type rpcAttachParams struct {
+	SessionID string `json:"sessionId"`
+	Cols      uint32 `json:"cols"`
+	Rows      uint32 `json:"rows"`
+	// SuppressOwnEcho skips output notifications attributed to this
+	// attachment's own input; see livev1.Attach.SuppressOwnEcho.
+	SuppressOwnEcho bool `json:"suppressOwnEcho,omitempty"`
+}
+
+type rpcAttachResult struct {
+	ConnectionID string `json:"connectionId"`
+	Cols         uint32 `json:"cols"`
+	Rows         uint32 `json:"rows"`
+}
+
+type rpcInputParams struct {
+	// SessionID targets a multiplexed subscription; empty means the
+	// connection's single attachment.
+	SessionID string `json:"sessionId,omitempty"`
+	Data      []byte `json:"data"`
+}
+
+type rpcResizeParams struct {
+	SessionID string `json:"sessionId,omitempty"`
+	Cols      uint32 `json:"cols"`
+	Rows      uint32 `json:"rows"`
+}
+
+type rpcResizeResult struct {
+	Generation uint64 `json:"generation"`
+	Cols       uint32 `json:"cols"`
+	Rows       uint32 `json:"rows"`
+}
+
+type rpcHistoryParams struct {
+	SessionID string `json:"sessionId"`
+	StartSeq  int64  `json:"startSeq"`
+	EndSeq    int64  `json:"endSeq"`
+	MaxBytes  int64  `json:"maxBytes"`
+}
+
+type rpcOutputNotification struct {
+	// SessionID identifies the source session for multiplexed
+	// subscriptions; empty for the connection's single attachment.
+	SessionID   string `json:"sessionId,omitempty"`
+	Data        []byte `json:"data"`
+	Sequence    uint64 `json:"sequence"`
+	TimestampMs int64  `json:"timestampMs"`
+	Cols        uint32 `json:"cols"`
+	Rows        uint32 `json:"rows"`
+}
+
+type rpcGeometryNotification struct {
+	SessionID  string `json:"sessionId,omitempty"`
+	Generation uint64 `json:"generation"`
+	Cols       uint32 `json:"cols"`
+	Rows       uint32 `json:"rows"`
+}
+
+type rpcClosedNotification struct {
+	SessionID string `json:"sessionId,omitempty"`
+}
+
+type rpcPresenceNotification struct {
+	SessionID string `json:"sessionId,omitempty"`
+	ConnID    string `json:"connId"`
+	Joined    bool   `json:"joined"`
+	// Count is the number of live attachments after the change.
+	Count int `json:"count"`
+}
+
+// rpcConn tracks the per-connection state: the websocket, a write lock shared
+// by responses and notifications, and at most one live attachment.
+type rpcConn struct {
+	server *Server
+	conn   *websocket.Conn
+	ctx    context.Context
+	codec  rpcCodec
+
+	writeMu sync.Mutex
+
+	attachMu   sync.Mutex
+	attachment *livev1.Attach
+	detach     func()
+	// subs holds multiplexed per-session attachments keyed by session ID, so
+	// a dashboard showing many terminals needs one socket instead of one per
+	// session.
+	subs map[string]*rpcSubscription
+}
+
+type rpcSubscription struct {
+	attachment livev1.Attach
+	detach     func()
+}
+
+// maxRPCSubscriptions bounds how many sessions one connection may multiplex.
+const maxRPCSubscriptions = 64
+
+func (s *Server) handleRPCWS(w http.ResponseWriter, r *http.Request) {
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		OriginPatterns:       s.allowedOrigins,
+		Subprotocols:         []string{rpcSubprotocolMsgpack},
+	})
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(s.wsReadLimit(defaultRPCReadLimitBytes))
+	s.startWSKeepalive(r.Context(), conn)
+
+	codec := rpcCodec(jsonRPCCodec{})
+	if conn.Subprotocol() == rpcSubprotocolMsgpack {
+		codec = msgpackRPCCodec{}
+	}
+	c := &rpcConn{server: s, conn: conn, ctx: r.Context(), codec: codec}
+	defer c.close()
+	for {
+		_, data, err := conn.Read(c.ctx)
+		if err != nil {
+			return
+		}
+		req, err := c.codec.unmarshalRequest(data)
+		if err != nil {
+			_ = c.write(rpcResponse{Error: &rpcError{Code: rpcErrInvalidRequest, Message: "invalid request"}})
+			continue
+		}
+		c.dispatch(req)
+	}
+}
+
+func (c *rpcConn) dispatch(req rpcRequest) {
+	var result any
+	var rpcErr *rpcError
+	switch req.Method {
+	case "attach":
+		result, rpcErr = c.handleAttach(req)
+	case "detach":
+		c.detachCurrent()
+		result = struct{}{}
+	case "input":
+		result, rpcErr = c.handleInput(req)
+	case "resize":
+		result, rpcErr = c.handleResize(req)
+	case "history":
+		result, rpcErr = c.handleHistory(req)
+	case "subscribe":
+		result, rpcErr = c.handleSubscribe(req)
+	case "unsubscribe":
+		result, rpcErr = c.handleUnsubscribe(req)
+	default:
+		rpcErr = &rpcError{Code: rpcErrMethodNotFound, Message: "unknown method: " + req.Method}
+	}
+	_ = c.write(rpcResponse{ID: req.ID, Result: result, Error: rpcErr})
+}
+
+func (c *rpcConn) handleAttach(req rpcRequest) (any, *rpcError) {
+	var p rpcAttachParams
+	if err := c.codec.unmarshalParams(req, &p); err != nil || p.SessionID == "" {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "sessionId is required"}
+	}
+	if p.Cols == 0 {
+		p.Cols = 80
+	}
+	if p.Rows == 0 {
+		p.Rows = 24
+	}
+
+	c.attachMu.Lock()
+	if c.attachment != nil {
+		c.attachMu.Unlock()
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "already attached"}
+	}
+	c.attachMu.Unlock()
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, &rpcError{Code: rpcErrServer, Message: "failed to mint connection id"}
+	}
+	attachment := livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             p.Cols,
+		Rows:             p.Rows,
+		SessionID:        p.SessionID,
+		ConnectionID:     "rpc-" + hex.EncodeToString(buf),
+		SuppressOwnEcho:  p.SuppressOwnEcho,
+	}
+	attached, detach, err := c.server.liveBackend.Attach(c.ctx, attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			if attachment.SuppressOwnEcho && record.EchoSourceConnID == attachment.ConnectionID {
+				return true
+			}
+			return c.write(rpcNotification{Method: "output", Params: rpcOutputNotification{
+				Data:        append([]byte(nil), record.Data...),
+				Sequence:    record.Sequence,
+				TimestampMs: int64(record.TimestampMs),
+				Cols:        record.Cols,
+				Rows:        record.Rows,
+			}}) == nil
+		},
+		OnGeometry: func(geometry livev1.EffectiveGeometry) bool {
+			return c.write(rpcNotification{Method: "geometry", Params: rpcGeometryNotification{
+				Generation: geometry.Generation,
+				Cols:       geometry.Cols,
+				Rows:       geometry.Rows,
+			}}) == nil
+		},
+		OnPresence: func(presence livev1.PresenceChanged) bool {
+			return c.write(rpcNotification{Method: "presence", Params: rpcPresenceNotification{
+				ConnID: presence.ConnectionID,
+				Joined: presence.Joined,
+				Count:  int(presence.ConnectionCount),
+			}}) == nil
+		},
+		OnSessionClosed: func() {
+			_ = c.write(rpcNotification{Method: "closed", Params: rpcClosedNotification{}})
+		},
+		OnSuperseded: func() {
+			_ = c.write(rpcNotification{Method: "closed", Params: rpcClosedNotification{}})
+		},
+	})
+	if err != nil {
+		return nil, backendRPCError(err)
+	}
+
+	c.attachMu.Lock()
+	c.attachment = &attachment
+	c.detach = detach
+	c.attachMu.Unlock()
+	return rpcAttachResult{
+		ConnectionID: attachment.ConnectionID,
+		Cols:         attached.Cols,
+		Rows:         attached.Rows,
+	}, nil
+}
+
+// handleSubscribe attaches to an additional session over the same socket.
+// Output, geometry and closed notifications for subscriptions carry the
+// session ID so the client can demultiplex them.
+func (c *rpcConn) handleSubscribe(req rpcRequest) (any, *rpcError) {
+	var p rpcAttachParams
+	if err := c.codec.unmarshalParams(req, &p); err != nil || p.SessionID == "" {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "sessionId is required"}
+	}
+	if p.Cols == 0 {
+		p.Cols = 80
+	}
+	if p.Rows == 0 {
+		p.Rows = 24
+	}
+
+	c.attachMu.Lock()
+	if _, ok := c.subs[p.SessionID]; ok {
+		c.attachMu.Unlock()
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "already subscribed"}
+	}
+	if len(c.subs) >= maxRPCSubscriptions {
+		c.attachMu.Unlock()
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "too many subscriptions"}
+	}
+	c.attachMu.Unlock()
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, &rpcError{Code: rpcErrServer, Message: "failed to mint connection id"}
+	}
+	sessionID := p.SessionID
+	attachment := livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             p.Cols,
+		Rows:             p.Rows,
+		SessionID:        sessionID,
+		ConnectionID:     "rpc-" + hex.EncodeToString(buf),
+		SuppressOwnEcho:  p.SuppressOwnEcho,
+	}
+	attached, detach, err := c.server.liveBackend.Attach(c.ctx, attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			if attachment.SuppressOwnEcho && record.EchoSourceConnID == attachment.ConnectionID {
+				return true
+			}
+			return c.write(rpcNotification{Method: "output", Params: rpcOutputNotification{
+				SessionID:   sessionID,
+				Data:        append([]byte(nil), record.Data...),
+				Sequence:    record.Sequence,
+				TimestampMs: int64(record.TimestampMs),
+				Cols:        record.Cols,
+				Rows:        record.Rows,
+			}}) == nil
+		},
+		OnGeometry: func(geometry livev1.EffectiveGeometry) bool {
+			return c.write(rpcNotification{Method: "geometry", Params: rpcGeometryNotification{
+				SessionID:  sessionID,
+				Generation: geometry.Generation,
+				Cols:       geometry.Cols,
+				Rows:       geometry.Rows,
+			}}) == nil
+		},
+		OnPresence: func(presence livev1.PresenceChanged) bool {
+			return c.write(rpcNotification{Method: "presence", Params: rpcPresenceNotification{
+				SessionID: sessionID,
+				ConnID:    presence.ConnectionID,
+				Joined:    presence.Joined,
+				Count:     int(presence.ConnectionCount),
+			}}) == nil
+		},
+		OnSessionClosed: func() {
+			_ = c.write(rpcNotification{Method: "closed", Params: rpcClosedNotification{SessionID: sessionID}})
+		},
+		OnSuperseded: func() {
+			_ = c.write(rpcNotification{Method: "closed", Params: rpcClosedNotification{SessionID: sessionID}})
+		},
+	})
+	if err != nil {
+		return nil, backendRPCError(err)
+	}
+
+	c.attachMu.Lock()
+	if _, ok := c.subs[sessionID]; ok {
+		c.attachMu.Unlock()
+		detach()
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "already subscribed"}
+	}
+	if c.subs == nil {
+		c.subs = make(map[string]*rpcSubscription)
+	}
+	c.subs[sessionID] = &rpcSubscription{attachment: attachment, detach: detach}
+	c.attachMu.Unlock()
+	return rpcAttachResult{
+		ConnectionID: attachment.ConnectionID,
+		Cols:         attached.Cols,
+		Rows:         attached.Rows,
+	}, nil
+}
+
+func (c *rpcConn) handleUnsubscribe(req rpcRequest) (any, *rpcError) {
+	var p rpcAttachParams
+	if err := c.codec.unmarshalParams(req, &p); err != nil || p.SessionID == "" {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "sessionId is required"}
+	}
+	c.attachMu.Lock()
+	sub, ok := c.subs[p.SessionID]
+	delete(c.subs, p.SessionID)
+	c.attachMu.Unlock()
+	if !ok {
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "not subscribed"}
+	}
+	sub.detach()
+	return struct{}{}, nil
+}
+
+func (c *rpcConn) handleInput(req rpcRequest) (any, *rpcError) {
+	var p rpcInputParams
+	if err := c.codec.unmarshalParams(req, &p); err != nil || len(p.Data) == 0 {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "data is required"}
+	}
+	attachment, ok := c.targetAttachment(p.SessionID)
+	if !ok {
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "not attached"}
+	}
+	if err := c.server.liveBackend.WriteInput(c.ctx, attachment, livev1.Input{Data: p.Data}); err != nil {
+		return nil, backendRPCError(err)
+	}
+	return struct{}{}, nil
+}
+
+func (c *rpcConn) handleResize(req rpcRequest) (any, *rpcError) {
+	var p rpcResizeParams
+	if err := c.codec.unmarshalParams(req, &p); err != nil || p.Cols == 0 || p.Rows == 0 {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "cols and rows are required"}
+	}
+	attachment, ok := c.targetAttachment(p.SessionID)
+	if !ok {
+		return nil, &rpcError{Code: rpcErrInvalidRequest, Message: "not attached"}
+	}
+	geometry, err := c.server.liveBackend.Resize(c.ctx, attachment, livev1.Resize{Cols: p.Cols, Rows: p.Rows})
+	if err != nil {
+		return nil, backendRPCError(err)
+	}
+	return rpcResizeResult{Generation: geometry.Generation, Cols: geometry.Cols, Rows: geometry.Rows}, nil
+}
+
+func (c *rpcConn) handleHistory(req rpcRequest) (any, *rpcError) {
+	p := rpcHistoryParams{EndSeq: -1}
+	if err := c.codec.unmarshalParams(req, &p); err != nil || p.SessionID == "" {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "sessionId is required"}
+	}
+	if p.MaxBytes <= 0 {
+		p.MaxBytes = defaultHistoryPageBytes
+	}
+	if p.MaxBytes > maxHistoryPageBytes {
+		return nil, &rpcError{Code: rpcErrInvalidParams, Message: "invalid maxBytes"}
+	}
+
+	session, ok := c.server.manager.GetSession(p.SessionID)
+	if !ok || !identityFromContext(c.ctx).canAccess(session.Owner) {
+		return nil, &rpcError{Code: rpcErrServer, Message: "session not found"}
+	}
+	page, err := session.GetHistoryPage(terminal.HistoryPageOptions{
+		StartSeq:    p.StartSeq,
+		EndSeq:      p.EndSeq,
+		LimitChunks: maxHistoryPageChunks,
+		MaxBytes:    int(p.MaxBytes),
+	})
+	if err != nil {
+		return nil, &rpcError{Code: rpcErrServer, Message: err.Error()}
+	}
+
+	chunks := make([]historyChunk, 0, len(page.Chunks))
+	for _, chunk := range page.Chunks {
+		chunks = append(chunks, historyChunk{
+			Sequence:    chunk.Sequence,
+			DataBase64:  base64.StdEncoding.EncodeToString(chunk.Data),
+			TimestampMs: chunk.Timestamp,
+		})
+	}
+	return historyPageResponse{
+		Chunks:                 chunks,
+		FirstRetainedSequence:  page.FirstRetainedSequence,
+		NextStartSequence:      page.NextStartSeq,
+		NextSeq:                page.NextStartSeq,
+		HasMore:                page.HasMore,
+		CoveredThroughSequence: page.CoveredThroughSequence,
+		SnapshotEndSequence:    page.SnapshotEndSequence,
+		HistoryGeneration:      page.HistoryGeneration,
+		HistoryReset:           page.HistoryReset,
+		HistoryTruncated:       page.HistoryTruncated,
+		TotalBytes:             page.TotalBytes,
+	}, nil
+}
+
+func (c *rpcConn) currentAttachment() (livev1.Attach, bool) {
+	c.attachMu.Lock()
+	defer c.attachMu.Unlock()
+	if c.attachment == nil {
+		return livev1.Attach{}, false
+	}
+	return *c.attachment, true
+}
+
+// targetAttachment resolves which attachment an input or resize addresses: a
+// multiplexed subscription when sessionID is set, the single attachment
+// otherwise.
+func (c *rpcConn) targetAttachment(sessionID string) (livev1.Attach, bool) {
+	if sessionID == "" {
+		return c.currentAttachment()
+	}
+	c.attachMu.Lock()
+	defer c.attachMu.Unlock()
+	sub, ok := c.subs[sessionID]
+	if !ok {
+		return livev1.Attach{}, false
+	}
+	return sub.attachment, true
+}
+
+func (c *rpcConn) detachCurrent() {
+	c.attachMu.Lock()
+	detach := c.detach
+	c.attachment = nil
+	c.detach = nil
+	c.attachMu.Unlock()
+	if detach != nil {
+		detach()
+	}
+}
+
+func (c *rpcConn) close() {
+	c.detachCurrent()
+	c.attachMu.Lock()
+	subs := c.subs
+	c.subs = nil
+	c.attachMu.Unlock()
+	for _, sub := range subs {
+		sub.detach()
+	}
+	_ = c.conn.CloseNow()
+}
+
+func (c *rpcConn) write(v any) error {
+	data, err := c.codec.marshal(v)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.Write(c.ctx, c.codec.messageType(), data)
+}
+
+func backendRPCError(err error) *rpcError {
+	switch {
+	case errors.Is(err, livev1.ErrSessionNotFound):
+		return &rpcError{Code: rpcErrServer, Message: "session not found"}
+	case errors.Is(err, livev1.ErrPermissionDenied):
+		return &rpcError{Code: rpcErrServer, Message: "permission denied"}
+	default:
+		return &rpcError{Code: rpcErrServer, Message: err.Error()}
+	}
+}
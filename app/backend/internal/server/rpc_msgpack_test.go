@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	cases := []any{
+		rpcResponse{ID: 7, Result: rpcAttachResult{ConnectionID: "rpc-abc", Cols: 100, Rows: 30}},
+		rpcResponse{ID: -3, Error: &rpcError{Code: rpcErrInvalidParams, Message: "cols and rows are required"}},
+		rpcNotification{Method: "output", Params: rpcOutputNotification{
+			Data:        []byte{0x00, 0x1b, 0xff},
+			Sequence:    1 << 40,
+			TimestampMs: time.Now().UnixMilli(),
+			Cols:        80,
+			Rows:        24,
+		}},
+	}
+	for _, value := range cases {
+		data, err := marshalMsgpack(value)
+		if err != nil {
+			t.Fatalf("marshal %T: %v", value, err)
+		}
+		decoded := reflect.New(reflect.TypeOf(value))
+		if err := unmarshalMsgpack(data, decoded.Interface()); err != nil {
+			t.Fatalf("unmarshal %T: %v", value, err)
+		}
+	}
+
+	// Binary output survives without base64 or UTF-8 mangling.
+	notification := struct {
+		Params rpcOutputNotification `json:"params"`
+	}{}
+	data, err := marshalMsgpack(cases[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unmarshalMsgpack(data, &notification); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(notification.Params.Data, []byte{0x00, 0x1b, 0xff}) || notification.Params.Sequence != 1<<40 {
+		t.Fatalf("decoded notification = %+v", notification.Params)
+	}
+
+	// Truncated payloads are rejected rather than misread.
+	if err := unmarshalMsgpack(data[:len(data)-2], &notification); err == nil {
+		t.Fatal("truncated payload was accepted")
+	}
+}
+
+func TestMsgpackRejectsOversizedCollectionHeaders(t *testing.T) {
+	// Array and map headers claiming far more elements than the payload
+	// carries must fail up front: preallocating for a wire-controlled count
+	// would let a few bytes demand gigabytes before decoding anything.
+	payloads := [][]byte{
+		{0xdd, 0x7f, 0xff, 0xff, 0xff},             // array32 claiming 2^31-1 elements
+		{0xdf, 0x7f, 0xff, 0xff, 0xff},             // map32 claiming 2^31-1 entries
+		{0xdc, 0xff, 0xff},                         // array16 with empty payload
+		{0xde, 0x00, 0x02, 0xa1, 0x61, 0xc0},       // map16 claiming 2 entries, carrying 1
+		{0x92, 0xc0},                               // fixarray claiming 2 elements, carrying 1
+		{0xdd, 0x00, 0x00, 0x00, 0x02, 0xc0, 0xc0}, // valid array32 control
+	}
+	for i, payload := range payloads[:len(payloads)-1] {
+		var out any
+		if err := unmarshalMsgpack(payload, &out); err == nil {
+			t.Fatalf("payload %d (% x) was accepted", i, payload)
+		}
+	}
+	var out []any
+	if err := unmarshalMsgpack(payloads[len(payloads)-1], &out); err != nil || len(out) != 2 {
+		t.Fatalf("control payload rejected: %v (decoded %v)", err, out)
+	}
+}
+
+func msgpackRPCCall(t *testing.T, ctx context.Context, conn *websocket.Conn, id int64, method string, params any) rpcResponse {
+	t.Helper()
+	req, err := marshalMsgpack(map[string]any{"id": id, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, req); err != nil {
+		t.Fatal(err)
+	}
+	// Skip any notifications interleaved before the matching response.
+	for {
+		messageType, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("rpc read failed: %v", err)
+		}
+		if messageType != websocket.MessageBinary {
+			t.Fatalf("message type = %v, want binary", messageType)
+		}
+		var resp struct {
+			ID     int64          `json:"id"`
+			Result map[string]any `json:"result"`
+			Error  *rpcError      `json:"error"`
+		}
+		if err := unmarshalMsgpack(data, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.ID == id {
+			return rpcResponse{ID: resp.ID, Result: resp.Result, Error: resp.Error}
+		}
+	}
+}
+
+func TestRPCWebSocketMsgpackSubprotocol(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/rpc", &websocket.DialOptions{
+		Subprotocols: []string{rpcSubprotocolMsgpack},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+	if conn.Subprotocol() != rpcSubprotocolMsgpack {
+		t.Fatalf("negotiated subprotocol = %q", conn.Subprotocol())
+	}
+
+	resp := msgpackRPCCall(t, ctx, conn, 1, "attach", rpcAttachParams{SessionID: created.ID, Cols: 100, Rows: 30})
+	if resp.Error != nil {
+		t.Fatalf("attach failed: %+v", resp.Error)
+	}
+	result, ok := resp.Result.(map[string]any)
+	if !ok || result["connectionId"] == "" {
+		t.Fatalf("attach result = %+v", resp.Result)
+	}
+
+	resp = msgpackRPCCall(t, ctx, conn, 2, "input", rpcInputParams{Data: []byte("echo msgpack\n")})
+	if resp.Error != nil {
+		t.Fatalf("input failed: %+v", resp.Error)
+	}
+
+	// The shell echoes back via binary output notifications.
+	deadline := time.After(10 * time.Second)
+	var echoed []byte
+	for !bytes.Contains(echoed, []byte("msgpack")) {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for echoed output")
+		default:
+		}
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var note struct {
+			Method string                `json:"method"`
+			Params rpcOutputNotification `json:"params"`
+		}
+		if err := unmarshalMsgpack(data, &note); err != nil {
+			t.Fatal(err)
+		}
+		if note.Method == "output" {
+			echoed = append(echoed, note.Params.Data...)
+		}
+	}
+}
+
+func TestRPCWebSocketDefaultsToJSON(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+	if conn.Subprotocol() != "" {
+		t.Fatalf("negotiated subprotocol = %q", conn.Subprotocol())
+	}
+
+	resp := rpcCall(t, ctx, conn, 1, "attach", rpcAttachParams{SessionID: created.ID, Cols: 80, Rows: 24})
+	if resp.Error != nil {
+		t.Fatalf("attach failed: %+v", resp.Error)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestRPCWebSocketMultiplexesSessions(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	first := createTestSession(t, httpSrv.URL)
+	second := createTestSession(t, httpSrv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+
+	resp := rpcCall(t, ctx, conn, 1, "subscribe", rpcAttachParams{SessionID: first.ID, Cols: 80, Rows: 24})
+	if resp.Error != nil {
+		t.Fatalf("subscribe first failed: %+v", resp.Error)
+	}
+	resp = rpcCall(t, ctx, conn, 2, "subscribe", rpcAttachParams{SessionID: second.ID, Cols: 80, Rows: 24})
+	if resp.Error != nil {
+		t.Fatalf("subscribe second failed: %+v", resp.Error)
+	}
+	resp = rpcCall(t, ctx, conn, 3, "subscribe", rpcAttachParams{SessionID: first.ID})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "already subscribed") {
+		t.Fatalf("duplicate subscribe = %+v", resp.Error)
+	}
+
+	// Input targeted by session ID echoes back tagged with the same ID. The
+	// echoes can interleave with the input responses, so send both requests
+	// first and demultiplex everything in one read loop; calling rpcCall here
+	// would silently swallow notifications that race ahead of a response.
+	for i, input := range []rpcInputParams{
+		{SessionID: first.ID, Data: []byte("one\n")},
+		{SessionID: second.ID, Data: []byte("two\n")},
+	} {
+		raw, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := json.Marshal(rpcRequest{ID: int64(4 + i), Method: "input", Params: raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.Write(ctx, websocket.MessageText, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[string]bool{}
+	deadline := time.After(10 * time.Second)
+	for !seen[first.ID] || !seen[second.ID] {
+		select {
+		case <-deadline:
+			t.Fatalf("timed out demultiplexing output, seen=%v", seen)
+		default:
+		}
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var in rpcResponse
+		if err := json.Unmarshal(data, &in); err == nil && in.ID != 0 {
+			if in.Error != nil {
+				t.Fatalf("input %d failed: %+v", in.ID, in.Error)
+			}
+			continue
+		}
+		var note struct {
+			Method string                `json:"method"`
+			Params rpcOutputNotification `json:"params"`
+		}
+		if err := json.Unmarshal(data, &note); err != nil || note.Method != "output" {
+			continue
+		}
+		switch {
+		case note.Params.SessionID == first.ID && strings.Contains(string(note.Params.Data), "one"):
+			seen[first.ID] = true
+		case note.Params.SessionID == second.ID && strings.Contains(string(note.Params.Data), "two"):
+			seen[second.ID] = true
+		}
+	}
+
+	resp = rpcCall(t, ctx, conn, 6, "unsubscribe", rpcAttachParams{SessionID: first.ID})
+	if resp.Error != nil {
+		t.Fatalf("unsubscribe failed: %+v", resp.Error)
+	}
+	resp = rpcCall(t, ctx, conn, 7, "input", rpcInputParams{SessionID: first.ID, Data: []byte("gone\n")})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "not attached") {
+		t.Fatalf("input after unsubscribe = %+v", resp.Error)
+	}
+	resp = rpcCall(t, ctx, conn, 8, "unsubscribe", rpcAttachParams{SessionID: first.ID})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "not subscribed") {
+		t.Fatalf("double unsubscribe = %+v", resp.Error)
+	}
+}
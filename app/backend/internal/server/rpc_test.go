@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func rpcCall(t *testing.T, ctx context.Context, conn *websocket.Conn, id int64, method string, params any) rpcResponse {
+	t.Helper()
+	raw, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := json.Marshal(rpcRequest{ID: id, Method: method, Params: raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Write(ctx, websocket.MessageText, req); err != nil {
+		t.Fatal(err)
+	}
+	// Skip any notifications interleaved before the matching response.
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("rpc read failed: %v", err)
+		}
+		var resp rpcResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.ID == id {
+			return resp
+		}
+	}
+}
+
+func TestRPCWebSocketAttachInputHistory(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(1 << 20)
+
+	resp := rpcCall(t, ctx, conn, 1, "attach", rpcAttachParams{SessionID: created.ID, Cols: 100, Rows: 30})
+	if resp.Error != nil {
+		t.Fatalf("attach failed: %+v", resp.Error)
+	}
+	var attached rpcAttachResult
+	remarshal(t, resp.Result, &attached)
+	if attached.ConnectionID == "" {
+		t.Fatalf("missing connection id: %+v", attached)
+	}
+
+	resp = rpcCall(t, ctx, conn, 2, "input", rpcInputParams{Data: []byte("hello rpc\n")})
+	if resp.Error != nil {
+		t.Fatalf("input failed: %+v", resp.Error)
+	}
+
+	// The shell echoes back via output notifications.
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for echoed output")
+		default:
+		}
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("notification read failed: %v", err)
+		}
+		var note struct {
+			Method string                `json:"method"`
+			Params rpcOutputNotification `json:"params"`
+		}
+		if err := json.Unmarshal(data, &note); err != nil {
+			continue
+		}
+		if note.Method == "output" && strings.Contains(string(note.Params.Data), "hello rpc") {
+			break
+		}
+	}
+
+	resp = rpcCall(t, ctx, conn, 3, "history", rpcHistoryParams{SessionID: created.ID, EndSeq: -1})
+	if resp.Error != nil {
+		t.Fatalf("history failed: %+v", resp.Error)
+	}
+	var page historyPageResponse
+	remarshal(t, resp.Result, &page)
+	if len(page.Chunks) == 0 {
+		t.Fatal("expected history chunks after input echo")
+	}
+
+	resp = rpcCall(t, ctx, conn, 4, "bogus", struct{}{})
+	if resp.Error == nil || resp.Error.Code != rpcErrMethodNotFound {
+		t.Fatalf("expected method-not-found error, got %+v", resp.Error)
+	}
+}
+
+// remarshal decodes an any-typed RPC result into a concrete struct.
+func remarshal(t *testing.T, from any, to any) {
+	t.Helper()
+	data, err := json.Marshal(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,12 +1,19 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"go.opentelemetry.io/otel/trace"
 
 	terminal "github.com/floegence/floeterm/terminal-go"
 	"github.com/floegence/floeterm/terminal-go/livev1"
@@ -22,6 +29,142 @@ type Config struct {
 
 	// EnablePerformanceDiagnostics exposes process-local metrics for controlled test runs.
 	EnablePerformanceDiagnostics bool
+
+	// AuthToken, when non-empty, requires a matching bearer token (or `token`
+	// query parameter for WebSocket clients) on all /api and /ws requests.
+	AuthToken string
+
+	// AuthTokenFile reads the token from a file at startup as an alternative
+	// to AuthToken. A load failure makes protected routes fail closed.
+	AuthTokenFile string
+
+	// BasicAuthUsername and BasicAuthPasswordHash (a bcrypt hash) enable HTTP
+	// basic auth as an alternative to bearer tokens for browser-only setups.
+	// Unlike tokens, basic auth also covers the SPA routes.
+	BasicAuthUsername     string
+	BasicAuthPasswordHash string
+
+	// AuthUsers defines per-user bearer tokens for multi-user setups. Sessions
+	// are tagged with the creator's name and other users cannot see or touch
+	// them unless marked Admin.
+	AuthUsers []AuthUser
+
+	// AllowedOrigins lists additional origin host patterns (e.g.
+	// "terminal.example.com", "*.example.com") permitted to open websocket
+	// connections. Browsers send the page origin on the handshake; by default
+	// only same-origin pages may connect, which keeps arbitrary websites from
+	// driving a locally running server.
+	AllowedOrigins []string
+
+	// ClientCertAuth accepts TLS client certificates already verified by the
+	// listener (see the -tls-client-ca flag) as authentication, mapping the
+	// certificate common name to the user identity. An AuthUsers entry with
+	// the same name supplies the admin role.
+	ClientCertAuth bool
+
+	// PAMAuth authenticates basic-auth credentials against the local system
+	// accounts (via PAM's unix_chkpwd helper) and runs every session as the
+	// authenticated user, giving a shellinabox-style local-account login
+	// flow. The server must run as root.
+	PAMAuth bool
+
+	// RateLimit bounds API request, websocket connect and terminal input
+	// rates. All limits are off by default.
+	RateLimit RateLimitConfig
+
+	// MaxJSONBodyBytes caps JSON request bodies on the REST API; larger
+	// requests get a 413. Zero keeps the 1 MiB default.
+	MaxJSONBodyBytes int64
+
+	// MaxInputBytes caps a single websocket message on /ws and /ws/rpc,
+	// which bounds the largest input write a client can send at once. Zero
+	// keeps the per-endpoint defaults.
+	MaxInputBytes int64
+
+	// MaxWSPerIP caps simultaneous websocket connections per remote IP;
+	// excess handshakes get a 429. Zero disables the cap.
+	MaxWSPerIP int
+
+	// MaxSessionsPerIP caps simultaneous sessions created per remote IP;
+	// excess creates get a 429. Zero disables the cap.
+	MaxSessionsPerIP int
+
+	// SecurityHeaders controls the CSP and related headers added to every
+	// response. The zero value enables strict defaults.
+	SecurityHeaders SecurityHeadersConfig
+
+	// CommandPolicy, when set, authorizes /api/exec command lines before they
+	// run. Session shells are authorized separately via
+	// ManagerConfig.CommandPolicy, typically with the same policy value.
+	CommandPolicy terminal.CommandPolicy
+
+	// BuildInfo describes the running binary and is served on
+	// /api/server/info. Zero fields are reported as-is.
+	BuildInfo BuildInfo
+
+	// Tracing enables OpenTelemetry spans around API handlers, websocket
+	// lifecycles, live attach and history reads. Off by default.
+	Tracing TracingConfig
+
+	// Webhooks lists HTTP endpoints that receive session lifecycle, command
+	// and bell/activity events as signed JSON POSTs.
+	Webhooks []WebhookConfig
+
+	// AuditLog, when set, receives audit events (auth attempts, session
+	// lifecycle, input batches, admin ops) as JSON lines. The
+	// /api/audit/events SSE stream works regardless.
+	AuditLog io.Writer
+
+	// RedisBridge mirrors session output and lifecycle events to Redis
+	// pub/sub and consumes remotely published input, so several replicas
+	// behind a load balancer can serve one session. Disabled when Addr is
+	// empty.
+	RedisBridge RedisBridgeConfig
+
+	// SSH exposes the same persistent sessions over an embedded SSH server
+	// with public-key authentication. Disabled when Addr is empty.
+	SSH SSHConfig
+
+	// ReadOnly rejects every mutating endpoint — session create, delete,
+	// rename, input, resize, exec — while leaving history and live output
+	// viewable. Useful for broadcasting a build or deploy terminal to an
+	// audience.
+	ReadOnly bool
+
+	// Profiles seeds named session templates at startup; the /api/profiles
+	// CRUD endpoints can add, change and remove profiles at runtime.
+	Profiles []Profile
+
+	// BasePath mounts every route (API, websockets, SPA assets) under a URL
+	// prefix such as "/floeterm", for deployments behind a reverse proxy at
+	// a subpath. Empty serves at the root.
+	BasePath string
+
+	// TrustedProxies lists proxy addresses (IPs or CIDRs) whose
+	// X-Forwarded-For/X-Real-IP headers are believed when deriving the
+	// client IP for per-IP limits. Empty means forwarding headers are
+	// ignored and the socket address is used.
+	TrustedProxies []string
+
+	// WSCompression enables permessage-deflate on /ws and /ws/rpc. Off by
+	// default, which suits LAN deployments.
+	WSCompression WSCompressionConfig
+
+	// WSPingInterval is how often the server pings live websockets to detect
+	// half-open connections. Zero keeps the 30s default; negative disables
+	// keepalive pings entirely.
+	WSPingInterval time.Duration
+
+	// WSPingTimeout is how long the server waits for a pong before closing
+	// the connection. Zero keeps the 10s default.
+	WSPingTimeout time.Duration
+}
+
+// AuthUser is a named bearer-token identity with an optional admin role.
+type AuthUser struct {
+	Name  string
+	Token string
+	Admin bool
 }
 
 // Server is a runnable HTTP/WebSocket server that bridges terminal-go sessions to terminal-web clients.
@@ -29,9 +172,59 @@ type Server struct {
 	manager *terminal.Manager
 
 	staticDir              string
+	basePath               string
 	logger                 terminal.Logger
 	live                   *livev1.Service
+	liveBackend            livev1.Backend
+	events                 *eventFanout
+	webhooks               *webhookDispatcher
+	redisBridge            *redisBridge
+	sshServer              *sshServer
+	signalRooms            *signalRooms
+	audit                  *auditLog
+	metrics                *serverMetrics
+	rateLimiter            *rateLimiter
+	ipLimits               *ipLimiter
+	proxies                *trustedProxies
+	maxJSONBodyBytes       int64
+	maxInputBytes          int64
 	performanceDiagnostics bool
+
+	wsCompressionMode      websocket.CompressionMode
+	wsCompressionThreshold int
+	wsPingInterval         time.Duration
+	wsPingTimeout          time.Duration
+
+	tracer         trace.Tracer
+	tracingEnabled bool
+	tracerShutdown func(context.Context) error
+
+	readOnly        bool
+	startedAt       time.Time
+	buildInfo       BuildInfo
+	commandPolicy   terminal.CommandPolicy
+	securityHeaders SecurityHeadersConfig
+	authToken       string
+	authErr         error
+	basicAuthUser   string
+	basicAuthHash   string
+	authUsers       []AuthUser
+	clientCertAuth  bool
+	pamAuth         *pamAuthenticator
+	allowedOrigins  []string
+
+	shareMu     sync.Mutex
+	shareGrants map[string]shareGrant
+
+	ticketMu sync.Mutex
+	tickets  map[string]attachTicket
+
+	profiles *profileStore
+
+	// idemMu guards idemSessions, which maps owner-scoped idempotency keys to
+	// the session they created; entries are dropped when the session closes.
+	idemMu       sync.Mutex
+	idemSessions map[string]string
 }
 
 func New(cfg Config) *Server {
@@ -40,14 +233,136 @@ func New(cfg Config) *Server {
 		logger = terminal.NopLogger{}
 	}
 
+	metrics := newServerMetrics()
+	if cfg.ManagerConfig.Metrics == nil {
+		cfg.ManagerConfig.Metrics = metrics
+	}
 	manager := terminal.NewManager(cfg.ManagerConfig)
+	limiter := newRateLimiter(cfg.RateLimit)
+	var managerBackend livev1.Backend = livev1.NewManagerBackend(manager, livev1.ManagerBackendOptions{
+		Authorize: func(ctx context.Context, session *terminal.Session, request livev1.Attach) error {
+			if handled, err := authorizeShareAttach(ctx, session, request); handled {
+				return err
+			}
+			if ticket, ok := ticketScopeFromContext(ctx); ok {
+				return ticket.authorizeAttach(session.ID, request)
+			}
+			if identityFromContext(ctx).canAccess(session.Owner) {
+				return nil
+			}
+			return errors.New("session is owned by another user")
+		},
+	})
+	if limiter != nil && limiter.inputBytes != nil {
+		managerBackend = rateLimitedBackend{Backend: managerBackend, bucket: limiter.inputBytes, metrics: metrics}
+	}
+	tracer, tracerShutdown := newTracer(cfg.Tracing)
+	if cfg.Tracing.Enabled {
+		managerBackend = tracingBackend{Backend: managerBackend, tracer: tracer}
+	}
+	audit := newAuditLog(cfg.AuditLog)
+	managerBackend = auditBackend{Backend: managerBackend, audit: audit}
+	backend := viewOnlyBackend{Backend: managerBackend, readOnly: cfg.ReadOnly}
+	if cfg.MaxJSONBodyBytes <= 0 {
+		cfg.MaxJSONBodyBytes = maxJSONBodyBytesDefault
+	}
 	s := &Server{
 		manager:                manager,
 		staticDir:              cfg.StaticDir,
+		basePath:               normalizeBasePath(cfg.BasePath),
 		logger:                 logger,
-		live:                   livev1.NewService(livev1.NewManagerBackend(manager, livev1.ManagerBackendOptions{})),
+		live:                   livev1.NewService(backend),
+		liveBackend:            backend,
+		events:                 newEventFanout(metrics),
+		signalRooms:            newSignalRooms(),
+		audit:                  audit,
+		metrics:                metrics,
+		rateLimiter:            limiter,
+		ipLimits:               newIPLimiter(cfg.MaxWSPerIP, cfg.MaxSessionsPerIP),
+		maxJSONBodyBytes:       cfg.MaxJSONBodyBytes,
+		maxInputBytes:          cfg.MaxInputBytes,
 		performanceDiagnostics: cfg.EnablePerformanceDiagnostics,
+		tracer:                 tracer,
+		tracingEnabled:         cfg.Tracing.Enabled,
+		tracerShutdown:         tracerShutdown,
+		readOnly:               cfg.ReadOnly,
+		startedAt:              time.Now(),
+		buildInfo:              cfg.BuildInfo,
+		commandPolicy:          cfg.CommandPolicy,
+		securityHeaders:        cfg.SecurityHeaders,
+		profiles:               newProfileStore(cfg.Profiles),
+		idemSessions:           make(map[string]string),
+	}
+	s.events.onSessionClosed = func(sessionID string) {
+		s.ipLimits.releaseSession(sessionID)
+		s.forgetIdempotentSession(sessionID)
+	}
+	if len(cfg.Webhooks) > 0 {
+		s.webhooks = newWebhookDispatcher(cfg.Webhooks, logger, metrics)
+		s.events.webhooks = s.webhooks
+	}
+	if cfg.RedisBridge.Addr != "" {
+		s.redisBridge = newRedisBridge(cfg.RedisBridge, manager, logger)
+	}
+	if cfg.SSH.Addr != "" {
+		sshSrv, err := newSSHServer(cfg.SSH, backend, manager, audit, logger)
+		if err != nil {
+			logger.Error("Failed to start SSH server", "error", err)
+		} else {
+			s.sshServer = sshSrv
+		}
+	}
+	manager.SetEventHandler(s.events)
+	s.authToken, s.authErr = resolveAuthToken(cfg)
+	if s.authErr != nil {
+		logger.Error("Failed to load auth token, refusing protected requests", "error", s.authErr)
+	}
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		// A typo here would silently merge all proxied clients into one
+		// bucket, so fail closed like other configuration mistakes.
+		s.authErr = err
+		logger.Error("Invalid trusted proxy configuration, refusing protected requests", "error", err)
 	}
+	s.proxies = proxies
+	s.wsPingInterval = cfg.WSPingInterval
+	if s.wsPingInterval == 0 {
+		s.wsPingInterval = defaultWSPingInterval
+	}
+	s.wsPingTimeout = cfg.WSPingTimeout
+	if s.wsPingTimeout <= 0 {
+		s.wsPingTimeout = defaultWSPingTimeout
+	}
+	s.wsCompressionMode, s.wsCompressionThreshold, err = parseWSCompression(cfg.WSCompression)
+	if err != nil {
+		// Uncompressed is the safe degradation, so unlike auth mistakes this
+		// only costs bandwidth — but still log it loudly.
+		logger.Error("Invalid websocket compression configuration, compression disabled", "error", err)
+	}
+	if cfg.BasicAuthUsername != "" && cfg.BasicAuthPasswordHash != "" {
+		s.basicAuthUser = cfg.BasicAuthUsername
+		s.basicAuthHash = cfg.BasicAuthPasswordHash
+	} else if cfg.BasicAuthUsername != "" || cfg.BasicAuthPasswordHash != "" {
+		s.authErr = errors.New("basic auth requires both username and password hash")
+		logger.Error("Incomplete basic auth configuration, refusing protected requests")
+	}
+	for _, user := range cfg.AuthUsers {
+		if user.Name == "" || user.Token == "" {
+			s.authErr = errors.New("auth users require both name and token")
+			logger.Error("Incomplete auth user configuration, refusing protected requests")
+			break
+		}
+	}
+	s.authUsers = cfg.AuthUsers
+	s.clientCertAuth = cfg.ClientCertAuth
+	if cfg.PAMAuth {
+		s.pamAuth = newPAMAuthenticator()
+		if _, err := findPAMHelper(); err != nil {
+			s.authErr = err
+			logger.Error("PAM auth unavailable, refusing protected requests", "error", err)
+		}
+	}
+	s.allowedOrigins = cfg.AllowedOrigins
 	return s
 }
 
@@ -55,21 +370,89 @@ func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/sessions", s.handleSessions)
 	mux.HandleFunc("/api/sessions/", s.handleSessionByID)
+	mux.HandleFunc("/api/server/info", s.handleServerInfo)
+	mux.HandleFunc("/api/server/stats", s.handleServerStats)
+	mux.HandleFunc("/api/tmux/sessions", s.handleTmuxSessions)
+	mux.HandleFunc("/api/server/doctor", s.handleServerDoctor)
+	mux.HandleFunc("/api/profiles", s.handleProfiles)
+	mux.HandleFunc("/api/profiles/", s.handleProfileByName)
+	mux.HandleFunc("/api/exec", s.handleExec)
+	mux.HandleFunc("/api/audit/events", s.handleAuditEvents)
+	mux.HandleFunc("/metrics", s.handleMetrics)
 	mux.HandleFunc("/ws", s.handleWS)
+	mux.HandleFunc("/ws/rpc", s.handleRPCWS)
+	mux.HandleFunc("/ws/events", s.handleEventsWS)
+	mux.HandleFunc("/ws/webrtc", s.handleWebRTCSignal)
+	mux.HandleFunc("/gotty/", s.handleGotty)
 	if s.performanceDiagnostics {
 		mux.HandleFunc("/api/performance/runtime", s.handlePerformanceRuntime)
 		mux.HandleFunc("/api/performance/goroutines", s.handlePerformanceGoroutines)
 	}
 
 	if strings.TrimSpace(s.staticDir) != "" {
-		mux.Handle("/", spaFileServer(s.staticDir))
+		mux.Handle("/", spaFileServer(s.staticDir, s.basePath))
 	}
 
-	return mux
+	// Rate limiting sits outside auth so abusive clients are shed before any
+	// credential work happens.
+	handler := s.withTracing(s.withSecurityHeaders(s.withRateLimit(s.withAuth(s.withReadOnly(mux)))))
+	if s.basePath != "" {
+		handler = mountUnder(s.basePath, handler)
+	}
+	return handler
+}
+
+// Manager exposes the underlying terminal manager so alternative transports
+// (e.g. the gRPC API) can share it with the HTTP server.
+func (s *Server) Manager() *terminal.Manager {
+	return s.manager
+}
+
+// LiveBackend exposes the composed live backend — authorization, rate
+// limiting, tracing, audit and read-only enforcement included — so
+// alternative transports apply the same policy chain instead of talking to
+// the manager directly.
+func (s *Server) LiveBackend() livev1.Backend {
+	return s.liveBackend
+}
+
+// AuthToken reports the effective bearer token resolved from the
+// configuration, or "" when no token-style credential exists (auth disabled,
+// non-token schemes only, or a token file that failed to load).
+func (s *Server) AuthToken() string {
+	if s.authErr != nil {
+		return ""
+	}
+	return s.authToken
+}
+
+// SSHAddr reports the embedded SSH server's listen address, or "" when it is
+// disabled (or failed to start). Useful when the configured address was ":0".
+func (s *Server) SSHAddr() string {
+	if s.sshServer == nil {
+		return ""
+	}
+	return s.sshServer.addr()
 }
 
 func (s *Server) Close() {
 	s.manager.Cleanup()
+	if s.webhooks != nil {
+		s.webhooks.stop()
+	}
+	if s.redisBridge != nil {
+		s.redisBridge.stop()
+	}
+	if s.sshServer != nil {
+		s.sshServer.stop()
+	}
+	if s.tracerShutdown != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.tracerShutdown(ctx); err != nil {
+			s.logger.Warn("Failed to flush trace spans", "error", err)
+		}
+	}
 }
 
 // --- API helpers ---
@@ -93,11 +476,23 @@ func readJSON(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) e
 
 	var tooLarge *http.MaxBytesError
 	if errors.As(err, &tooLarge) {
-		return &httpError{status: http.StatusRequestEntityTooLarge, message: "payload too large"}
+		return &httpError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: fmt.Sprintf("request body exceeds %d bytes", maxBytes),
+		}
 	}
 	return err
 }
 
+// wsReadLimit returns the configured per-message websocket limit, falling
+// back to the endpoint's default when none is set.
+func (s *Server) wsReadLimit(def int64) int64 {
+	if s.maxInputBytes > 0 {
+		return s.maxInputBytes
+	}
+	return def
+}
+
 func parseIntQuery(q map[string][]string, key string, def int64) (int64, error) {
 	val := ""
 	if raw := q[key]; len(raw) > 0 {
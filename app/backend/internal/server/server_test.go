@@ -8,6 +8,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -76,8 +79,24 @@ func TestAPISessionInfoIncludesOutputActivity(t *testing.T) {
 	}
 }
 
+// The server may coalesce several frames into a single websocket message, so
+// leftovers are queued per connection and returned on subsequent calls.
+var (
+	pendingLiveFramesMu sync.Mutex
+	pendingLiveFrames   = map[*websocket.Conn][]livev1.Frame{}
+)
+
 func readLiveFrame(t *testing.T, ctx context.Context, conn *websocket.Conn) livev1.Frame {
 	t.Helper()
+	pendingLiveFramesMu.Lock()
+	if queued := pendingLiveFrames[conn]; len(queued) > 0 {
+		frame := queued[0]
+		pendingLiveFrames[conn] = queued[1:]
+		pendingLiveFramesMu.Unlock()
+		return frame
+	}
+	pendingLiveFramesMu.Unlock()
+
 	messageType, data, err := conn.Read(ctx)
 	if err != nil {
 		t.Fatalf("read websocket: %v", err)
@@ -87,9 +106,14 @@ func readLiveFrame(t *testing.T, ctx context.Context, conn *websocket.Conn) live
 	}
 	decoder := livev1.NewDecoder()
 	frames, err := decoder.Push(data)
-	if err != nil || len(frames) != 1 {
+	if err != nil || len(frames) == 0 {
 		t.Fatalf("decode frames=%d err=%v", len(frames), err)
 	}
+	if len(frames) > 1 {
+		pendingLiveFramesMu.Lock()
+		pendingLiveFrames[conn] = append(pendingLiveFrames[conn], frames[1:]...)
+		pendingLiveFramesMu.Unlock()
+	}
 	return frames[0]
 }
 
@@ -464,3 +488,452 @@ func TestServerJSONBodyLimitReturns413(t *testing.T) {
 		t.Fatalf("status=%d, want 413", resp.StatusCode)
 	}
 }
+
+func TestServerDoctorEndpoint(t *testing.T) {
+	baseDir := t.TempDir()
+	srv := New(Config{
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellInitWriter:   terminal.DefaultShellInitWriter{BaseDir: baseDir},
+			ShellArgsProvider: terminal.DefaultShellArgsProvider{ShellInitBaseDir: baseDir},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer func() {
+		httpSrv.Close()
+		srv.Close()
+	}()
+
+	resp, err := http.Get(httpSrv.URL + "/api/server/doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("doctor status=%d", resp.StatusCode)
+	}
+
+	var doctor doctorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&doctor); err != nil {
+		t.Fatal(err)
+	}
+	if doctor.DefaultShell != "/bin/sh" {
+		t.Fatalf("defaultShell=%q", doctor.DefaultShell)
+	}
+	if len(doctor.Probes) != 1 || !doctor.Probes[0].OK {
+		t.Fatalf("expected a passing probe, got %+v", doctor.Probes)
+	}
+}
+
+func TestSessionStatsReportsHealthFigures(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn := attachLiveTestConnection(t, ctx, httpSrv.URL, created.ID, "stats-conn")
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+
+	input, err := livev1.EncodeInput(livev1.Input{Sequence: 1, Data: []byte("STATS_PROBE\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, input); err != nil {
+		t.Fatal(err)
+	}
+	_ = readOutputContaining(t, ctx, conn, []byte("STATS_PROBE"))
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions/" + created.ID + "/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stats status=%d", resp.StatusCode)
+	}
+	var stats sessionStatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.IO.InputBytes == 0 || stats.IO.OutputBytes == 0 {
+		t.Fatalf("io stats = %+v, want non-zero counters", stats.IO)
+	}
+	if stats.ConnectionCount != 1 {
+		t.Fatalf("connectionCount=%d, want 1", stats.ConnectionCount)
+	}
+	if !stats.IsActive || stats.UptimeMs < 0 {
+		t.Fatalf("isActive=%v uptimeMs=%d", stats.IsActive, stats.UptimeMs)
+	}
+	if stats.Process == nil || stats.Process.PID <= 0 {
+		t.Fatalf("process stats = %+v, want a live pid", stats.Process)
+	}
+	if stats.History.UsedChunks == 0 || stats.History.TotalBytes == 0 {
+		t.Fatalf("history stats = %+v, want recorded output", stats.History)
+	}
+	if stats.LastExit != nil {
+		t.Fatalf("lastExit=%+v, want nil while running", stats.LastExit)
+	}
+}
+
+func TestPatchSessionUpdatesMetadata(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	patch := func(t *testing.T, sessionID, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPatch, httpSrv.URL+"/api/sessions/"+sessionID, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := patch(t, created.ID, `{"name":"build box","tags":["ci","staging"],"notes":"long-running build","nameLocked":true}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch status=%d, want 200", resp.StatusCode)
+	}
+	var updated apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "build box" {
+		t.Fatalf("name=%q, want %q", updated.Name, "build box")
+	}
+	if len(updated.Tags) != 2 || updated.Tags[0] != "ci" || updated.Tags[1] != "staging" {
+		t.Fatalf("tags=%v", updated.Tags)
+	}
+	if updated.Notes != "long-running build" {
+		t.Fatalf("notes=%q", updated.Notes)
+	}
+	if !updated.NameLocked {
+		t.Fatal("nameLocked not applied")
+	}
+
+	// A partial update must leave absent fields untouched.
+	resp = patch(t, created.ID, `{"notes":""}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("partial patch status=%d, want 200", resp.StatusCode)
+	}
+	var partial apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&partial); err != nil {
+		t.Fatal(err)
+	}
+	if partial.Name != "build box" || len(partial.Tags) != 2 || !partial.NameLocked {
+		t.Fatalf("partial patch clobbered other fields: %+v", partial)
+	}
+	if partial.Notes != "" {
+		t.Fatalf("notes=%q, want cleared", partial.Notes)
+	}
+
+	resp = patch(t, created.ID, `{"name":"   "}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("blank name status=%d, want 400", resp.StatusCode)
+	}
+	resp = patch(t, created.ID, `{"tags":["ok",""]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("empty tag status=%d, want 400", resp.StatusCode)
+	}
+	resp = patch(t, "missing-session", `{"name":"x"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing session status=%d, want 404", resp.StatusCode)
+	}
+}
+
+func TestSignalEndpointDeliversSignalToProcessGroup(t *testing.T) {
+	srv, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	signal := func(t *testing.T, sessionID, body string) int {
+		t.Helper()
+		resp, err := http.Post(httpSrv.URL+"/api/sessions/"+sessionID+"/signal", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A dormant session has no process to signal.
+	if status := signal(t, created.ID, `{"signal":"SIGTERM"}`); status != http.StatusConflict {
+		t.Fatalf("dormant signal status=%d, want 409", status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Manager().ActivateSessionContext(ctx, created.ID, 80, 24); err != nil {
+		t.Fatalf("activate failed: %v", err)
+	}
+	session, ok := srv.Manager().GetSession(created.ID)
+	if !ok {
+		t.Fatal("session not found after create")
+	}
+
+	if status := signal(t, created.ID, `{"signal":"SIGSTOP"}`); status != http.StatusBadRequest {
+		t.Fatalf("disallowed signal status=%d, want 400", status)
+	}
+	if status := signal(t, created.ID, `{"signal":"SIGTERM"}`); status != http.StatusNoContent {
+		t.Fatalf("signal status=%d, want 204", status)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for session.LastExit() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the signalled process to exit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHistorySupportsSinceSeqLimitPaging(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn := attachLiveTestConnection(t, ctx, httpSrv.URL, created.ID, "pager")
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+	for i, marker := range []string{"PAGE_ONE", "PAGE_TWO", "PAGE_THREE"} {
+		input, err := livev1.EncodeInput(livev1.Input{Sequence: uint64(i + 1), Data: []byte(marker + "\n")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.Write(ctx, websocket.MessageBinary, input); err != nil {
+			t.Fatal(err)
+		}
+		// Wait for each echo so the output lands in separate history chunks.
+		readOutputContaining(t, ctx, conn, []byte(marker))
+	}
+
+	var all []byte
+	pages := 0
+	cursor := int64(0)
+	for {
+		resp, err := http.Get(httpSrv.URL + "/api/sessions/" + created.ID + "/history?limit=1&sinceSeq=" + strconv.FormatInt(cursor, 10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("history status=%d", resp.StatusCode)
+		}
+		if pages == 0 && len(resp.TransferEncoding) > 0 && resp.TransferEncoding[0] != "chunked" {
+			t.Fatalf("transfer encoding=%v, want chunked", resp.TransferEncoding)
+		}
+		var page historyPageResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Chunks) > 1 {
+			t.Fatalf("limit=1 returned %d chunks", len(page.Chunks))
+		}
+		for _, chunk := range page.Chunks {
+			data, err := base64.StdEncoding.DecodeString(chunk.DataBase64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, data...)
+		}
+		pages++
+		if page.NextSeq != page.NextStartSequence {
+			t.Fatalf("nextSeq=%d, nextStartSequence=%d, want equal", page.NextSeq, page.NextStartSequence)
+		}
+		cursor = page.NextSeq
+		if !page.HasMore || len(page.Chunks) == 0 {
+			break
+		}
+		if pages > 100 {
+			t.Fatal("cursor did not terminate")
+		}
+	}
+	if pages < 3 {
+		t.Fatalf("pages=%d, want at least 3", pages)
+	}
+	for _, marker := range []string{"PAGE_ONE", "PAGE_TWO", "PAGE_THREE"} {
+		if !bytes.Contains(all, []byte(marker)) {
+			t.Fatalf("paged history missing %q", marker)
+		}
+	}
+}
+
+func TestResizePolicyEndpointUpdatesSession(t *testing.T) {
+	srv, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+	if created.ResizePolicy != "min" {
+		t.Fatalf("default resize policy=%q, want min", created.ResizePolicy)
+	}
+
+	setPolicy := func(t *testing.T, sessionID, body string) (int, apiSessionInfo) {
+		t.Helper()
+		resp, err := http.Post(httpSrv.URL+"/api/sessions/"+sessionID+"/resize-policy", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var info apiSessionInfo
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode, info
+	}
+
+	status, info := setPolicy(t, created.ID, `{"policy":"fixed:120x40"}`)
+	if status != http.StatusOK || info.ResizePolicy != "fixed:120x40" {
+		t.Fatalf("fixed policy status=%d resizePolicy=%q", status, info.ResizePolicy)
+	}
+	session, ok := srv.Manager().GetSession(created.ID)
+	if !ok {
+		t.Fatal("session not found after create")
+	}
+	policy := session.GetResizePolicy()
+	if policy.Mode != terminal.ResizePolicyFixed || policy.Cols != 120 || policy.Rows != 40 {
+		t.Fatalf("session policy=%+v", policy)
+	}
+
+	status, info = setPolicy(t, created.ID, `{"policy":"lastWriter"}`)
+	if status != http.StatusOK || info.ResizePolicy != "lastWriter" {
+		t.Fatalf("lastWriter policy status=%d resizePolicy=%q", status, info.ResizePolicy)
+	}
+
+	for _, body := range []string{`{"policy":"biggest"}`, `{"policy":"fixed:10x10"}`, `{"policy":""}`} {
+		if status, _ := setPolicy(t, created.ID, body); status != http.StatusBadRequest {
+			t.Fatalf("invalid policy %s status=%d, want 400", body, status)
+		}
+	}
+	if status, _ := setPolicy(t, "missing-session", `{"policy":"max"}`); status != http.StatusNotFound {
+		t.Fatalf("missing session status=%d, want 404", status)
+	}
+}
+
+func TestConfigurableJSONBodyLimit(t *testing.T) {
+	srv := New(Config{
+		MaxJSONBodyBytes: 64,
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+
+	oversized := append([]byte(`{"name":"`), bytes.Repeat([]byte("a"), 64)...)
+	oversized = append(oversized, []byte(`"}`)...)
+	resp, err := http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewReader(oversized))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized status=%d, want 413", resp.StatusCode)
+	}
+	if !bytes.Contains(body, []byte("64 bytes")) {
+		t.Fatalf("413 body %q does not state the limit", body)
+	}
+
+	resp, err = http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(`{"name":"small"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("small body status=%d, want 200", resp.StatusCode)
+	}
+}
+
+func TestCreateSessionIdempotencyKey(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	create := func(t *testing.T, body string) apiSessionInfo {
+		t.Helper()
+		resp, err := http.Post(httpSrv.URL+"/api/sessions", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("create status=%d, want 200", resp.StatusCode)
+		}
+		var info apiSessionInfo
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+	listCount := func(t *testing.T) int {
+		t.Helper()
+		resp, err := http.Get(httpSrv.URL + "/api/sessions")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var sessions []apiSessionInfo
+		if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+			t.Fatal(err)
+		}
+		return len(sessions)
+	}
+
+	first := create(t, `{"name":"idem","idempotencyKey":"retry-1"}`)
+	retried := create(t, `{"name":"idem","idempotencyKey":"retry-1"}`)
+	if retried.ID != first.ID {
+		t.Fatalf("retry created a new session: %q != %q", retried.ID, first.ID)
+	}
+	if got := listCount(t); got != 1 {
+		t.Fatalf("session count=%d after retry, want 1", got)
+	}
+
+	other := create(t, `{"name":"idem","idempotencyKey":"retry-2"}`)
+	if other.ID == first.ID {
+		t.Fatal("a different key returned the same session")
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+first.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status=%d, want 204", resp.StatusCode)
+	}
+	// The key dies with the session, so a later create starts fresh.
+	recreated := create(t, `{"name":"idem","idempotencyKey":"retry-1"}`)
+	if recreated.ID == first.ID {
+		t.Fatal("key survived session deletion")
+	}
+
+	oversizedKey := strings.Repeat("k", maxIdempotencyKeyBytes+1)
+	resp, err = http.Post(httpSrv.URL+"/api/sessions", "application/json",
+		bytes.NewBufferString(`{"idempotencyKey":"`+oversizedKey+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("oversized key status=%d, want 400", resp.StatusCode)
+	}
+}
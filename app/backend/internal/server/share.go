@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+const (
+	shareTokenTTLDefault = time.Hour
+	shareTokenTTLMax     = 24 * time.Hour
+)
+
+// shareGrant is a minted view-only credential for a single session.
+type shareGrant struct {
+	sessionID string
+	expiresAt time.Time
+}
+
+type createShareRequest struct {
+	TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+type createShareResponse struct {
+	Token       string `json:"token"`
+	SessionID   string `json:"sessionId"`
+	ExpiresAtMs int64  `json:"expiresAtMs"`
+}
+
+// handleSessionShare mints an expiring view-only token for a session. Holders
+// can attach over the websocket and read output/history, but input and resize
+// are rejected.
+func (s *Server) handleSessionShare(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := s.manager.GetSession(sessionID); !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	var req createShareRequest
+	if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil && !strings.Contains(err.Error(), "EOF") {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	ttl := shareTokenTTLDefault
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > shareTokenTTLMax {
+		http.Error(w, "ttl too large", http.StatusBadRequest)
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		http.Error(w, "failed to mint token", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(ttl)
+
+	s.shareMu.Lock()
+	if s.shareGrants == nil {
+		s.shareGrants = make(map[string]shareGrant)
+	}
+	s.shareGrants[token] = shareGrant{sessionID: sessionID, expiresAt: expiresAt}
+	s.shareMu.Unlock()
+
+	writeJSON(w, http.StatusOK, createShareResponse{
+		Token:       token,
+		SessionID:   sessionID,
+		ExpiresAtMs: expiresAt.UnixMilli(),
+	})
+}
+
+// lookupShareGrant resolves a share token, pruning it once expired.
+func (s *Server) lookupShareGrant(token string) (shareGrant, bool) {
+	if token == "" {
+		return shareGrant{}, false
+	}
+	s.shareMu.Lock()
+	defer s.shareMu.Unlock()
+
+	grant, ok := s.shareGrants[token]
+	if !ok {
+		return shareGrant{}, false
+	}
+	if time.Now().After(grant.expiresAt) {
+		delete(s.shareGrants, token)
+		return shareGrant{}, false
+	}
+	return grant, true
+}
+
+// shareRequestAllowed limits what a share-token holder may call: the live
+// websocket plus read-only history/stats for the shared session.
+func shareRequestAllowed(r *http.Request, sessionID string) bool {
+	if r.URL.Path == "/ws" {
+		return true
+	}
+	if r.Method != http.MethodGet {
+		return false
+	}
+	prefix := "/api/sessions/" + sessionID + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		return false
+	}
+	rest := strings.TrimPrefix(r.URL.Path, prefix)
+	return rest == "history" || strings.HasPrefix(rest, "history/") || rest == "stats" || rest == "events" || rest == "screen"
+}
+
+type shareScopeContextKey struct{}
+
+func withShareScope(ctx context.Context, grant shareGrant) context.Context {
+	return context.WithValue(ctx, shareScopeContextKey{}, grant)
+}
+
+func shareScopeFromContext(ctx context.Context) (shareGrant, bool) {
+	grant, ok := ctx.Value(shareScopeContextKey{}).(shareGrant)
+	return grant, ok
+}
+
+// viewOnlyBackend rejects input and resize for share-token and read-only
+// ticket attachments while delegating everything else to the real backend.
+// With readOnly set the whole server is view-only and every attachment is
+// treated that way.
+type viewOnlyBackend struct {
+	livev1.Backend
+	readOnly bool
+}
+
+func (b viewOnlyBackend) WriteInput(ctx context.Context, attachment livev1.Attach, input livev1.Input) error {
+	if b.readOnly {
+		return errReadOnlyServer
+	}
+	if _, ok := shareScopeFromContext(ctx); ok {
+		return errReadOnlyShare
+	}
+	if ticket, ok := ticketScopeFromContext(ctx); ok && ticket.readOnly {
+		return errReadOnlyTicket
+	}
+	return b.Backend.WriteInput(ctx, attachment, input)
+}
+
+// ReplayOutput forwards the reconnect catch-up capability; replay is a read
+// and therefore fine for view-only attachments too.
+func (b viewOnlyBackend) ReplayOutput(ctx context.Context, attachment livev1.Attach, afterSequence uint64) ([]livev1.OutputRecord, error) {
+	if replayer, ok := b.Backend.(livev1.OutputReplayer); ok {
+		return replayer.ReplayOutput(ctx, attachment, afterSequence)
+	}
+	return nil, nil
+}
+
+func (b viewOnlyBackend) Resize(ctx context.Context, attachment livev1.Attach, resize livev1.Resize) (livev1.EffectiveGeometry, error) {
+	if b.readOnly {
+		return livev1.EffectiveGeometry{}, errReadOnlyServer
+	}
+	if _, ok := shareScopeFromContext(ctx); ok {
+		return livev1.EffectiveGeometry{}, errReadOnlyShare
+	}
+	if ticket, ok := ticketScopeFromContext(ctx); ok && ticket.readOnly {
+		return livev1.EffectiveGeometry{}, errReadOnlyTicket
+	}
+	return b.Backend.Resize(ctx, attachment, resize)
+}
+
+var errReadOnlyShare = errors.New("share token is view-only")
+
+// authorizeShareAttach allows a share-token holder to attach only to the
+// shared session, regardless of ownership.
+func authorizeShareAttach(ctx context.Context, session *terminal.Session, request livev1.Attach) (handled bool, err error) {
+	grant, ok := shareScopeFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+	if grant.sessionID != request.SessionID || grant.sessionID != session.ID {
+		return true, errors.New("share token does not cover this session")
+	}
+	return true, nil
+}
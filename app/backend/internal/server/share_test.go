@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShareTokenGrantsViewOnlyAccess(t *testing.T) {
+	srv, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	resp, err := http.Post(httpSrv.URL+"/api/sessions/"+created.ID+"/share", "application/json",
+		bytes.NewBufferString(`{"ttlSeconds":60}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var share createShareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&share); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if share.Token == "" || share.SessionID != created.ID {
+		t.Fatalf("unexpected share response: %+v", share)
+	}
+
+	// History reads are allowed with the share token.
+	resp, err = http.Get(httpSrv.URL + "/api/sessions/" + created.ID + "/history?token=" + share.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("history with share token status=%d, want 200", resp.StatusCode)
+	}
+
+	// Anything mutating is rejected.
+	req, _ := http.NewRequest(http.MethodDelete, httpSrv.URL+"/api/sessions/"+created.ID+"?token="+share.Token, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("delete with share token status=%d, want 403", resp.StatusCode)
+	}
+
+	// Expired grants stop resolving.
+	srv.shareMu.Lock()
+	srv.shareGrants[share.Token] = shareGrant{sessionID: created.ID, expiresAt: time.Now().Add(-time.Second)}
+	srv.shareMu.Unlock()
+	if _, ok := srv.lookupShareGrant(share.Token); ok {
+		t.Fatalf("expected expired grant to be rejected")
+	}
+}
@@ -0,0 +1,323 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// The /api/sessions/{id}/events endpoint streams the session's data, name and
+// error events as server-sent events for environments where websockets are
+// blocked or inconvenient (strict proxies, curl scripts). It is output-only;
+// input still goes through the websocket or RPC endpoints.
+
+const (
+	sseKeepaliveInterval = 15 * time.Second
+	sseSubscriberBuffer  = 256
+)
+
+type sseEvent struct {
+	name string
+	data any
+}
+
+type sseDataPayload struct {
+	DataBase64  string `json:"data"`
+	Sequence    int64  `json:"sequence"`
+	TimestampMs int64  `json:"timestampMs"`
+}
+
+type sseNamePayload struct {
+	OldName    string `json:"oldName"`
+	NewName    string `json:"newName"`
+	WorkingDir string `json:"workingDir"`
+}
+
+type sseErrorPayload struct {
+	Error string `json:"error"`
+}
+
+type sseClosedPayload struct {
+	ExitCode          *int   `json:"exitCode,omitempty"`
+	ExitedAtMs        int64  `json:"exitedAtMs,omitempty"`
+	TerminationReason string `json:"terminationReason,omitempty"`
+}
+
+type sseSubscriber struct {
+	events chan sseEvent
+}
+
+// eventFanout multiplexes the manager's single TerminalEventHandler slot to
+// any number of per-session SSE subscribers. Slow subscribers drop events
+// rather than stall the PTY reader; SSE is a convenience view, the ring
+// buffer remains the source of truth for history.
+type eventFanout struct {
+	metrics *serverMetrics
+
+	// webhooks, when set, receives lifecycle, command and bell events for
+	// delivery to configured HTTP endpoints.
+	webhooks *webhookDispatcher
+
+	// onSessionClosed, when set, is invoked for every closed session before
+	// subscribers are notified (used to release per-IP session slots).
+	onSessionClosed func(sessionID string)
+
+	mu        sync.Mutex
+	subs      map[string]map[*sseSubscriber]struct{}
+	lifecycle map[*lifecycleSubscriber]struct{}
+	// sessions holds a handle per live session so closed lifecycle events can
+	// attach exit details; the manager has already removed its record by the
+	// time OnTerminalSessionClosed runs.
+	sessions map[string]*terminal.Session
+}
+
+func newEventFanout(metrics *serverMetrics) *eventFanout {
+	return &eventFanout{
+		metrics:   metrics,
+		subs:      make(map[string]map[*sseSubscriber]struct{}),
+		lifecycle: make(map[*lifecycleSubscriber]struct{}),
+		sessions:  make(map[string]*terminal.Session),
+	}
+}
+
+func (f *eventFanout) subscribe(sessionID string) *sseSubscriber {
+	sub := &sseSubscriber{events: make(chan sseEvent, sseSubscriberBuffer)}
+	f.mu.Lock()
+	if f.subs[sessionID] == nil {
+		f.subs[sessionID] = make(map[*sseSubscriber]struct{})
+	}
+	f.subs[sessionID][sub] = struct{}{}
+	f.mu.Unlock()
+	return sub
+}
+
+func (f *eventFanout) unsubscribe(sessionID string, sub *sseSubscriber) {
+	f.mu.Lock()
+	if subs := f.subs[sessionID]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(f.subs, sessionID)
+		}
+	}
+	f.mu.Unlock()
+}
+
+func (f *eventFanout) publish(sessionID string, event sseEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs[sessionID] {
+		select {
+		case sub.events <- event:
+		default:
+			f.metrics.IncCounter(metricBroadcastDrops, 1)
+		}
+	}
+}
+
+// broadcastStats counts subscribers and buffered events across both fan-out
+// paths for the /api/server/stats endpoint.
+func (f *eventFanout) broadcastStats() broadcastStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stats broadcastStats
+	for _, subs := range f.subs {
+		for sub := range subs {
+			stats.SSESubscribers++
+			stats.SSEQueuedEvents += len(sub.events)
+		}
+	}
+	for sub := range f.lifecycle {
+		stats.LifecycleSubscribers++
+		stats.LifecycleQueuedEvents += len(sub.events)
+	}
+	return stats
+}
+
+func (f *eventFanout) hasSubscribers(sessionID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs[sessionID]) > 0
+}
+
+// sessionOwner looks up a live session's owner for lifecycle events published
+// without a session handle; unknown sessions report an empty owner.
+func (f *eventFanout) sessionOwner(sessionID string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if session := f.sessions[sessionID]; session != nil {
+		return session.Owner
+	}
+	return ""
+}
+
+func (f *eventFanout) OnTerminalData(sessionID string, event terminal.TerminalOutputEvent) {
+	if f.webhooks != nil {
+		f.webhooks.scanBell(sessionID, event.Data)
+	}
+	// This runs on the PTY broadcast path for every chunk; skip the encoding
+	// work entirely unless someone is actually streaming events.
+	if !f.hasSubscribers(sessionID) {
+		return
+	}
+	f.publish(sessionID, sseEvent{name: "data", data: sseDataPayload{
+		DataBase64:  base64.StdEncoding.EncodeToString(event.Data),
+		Sequence:    event.Sequence,
+		TimestampMs: event.TimestampMs,
+	}})
+}
+
+func (f *eventFanout) OnTerminalNameChanged(sessionID, oldName, newName, workingDir string) {
+	f.publish(sessionID, sseEvent{name: "name", data: sseNamePayload{
+		OldName:    oldName,
+		NewName:    newName,
+		WorkingDir: workingDir,
+	}})
+	f.publishLifecycle(sessionLifecycleEvent{
+		Type:       "renamed",
+		SessionID:  sessionID,
+		OldName:    oldName,
+		NewName:    newName,
+		WorkingDir: workingDir,
+		owner:      f.sessionOwner(sessionID),
+	})
+}
+
+func (f *eventFanout) OnTerminalSessionCreated(session *terminal.Session) {
+	f.mu.Lock()
+	f.sessions[session.ID] = session
+	f.mu.Unlock()
+	f.publishLifecycle(sessionLifecycleEvent{
+		Type:        "created",
+		SessionID:   session.ID,
+		Name:        session.GetName(),
+		WorkingDir:  session.GetWorkingDir(),
+		CreatedAtMs: session.GetCreatedAt().UnixMilli(),
+		owner:       session.Owner,
+	})
+	if f.webhooks != nil {
+		f.webhooks.sessionCreated(session)
+	}
+}
+
+func (f *eventFanout) OnTerminalSessionClosed(sessionID string) {
+	if f.onSessionClosed != nil {
+		f.onSessionClosed(sessionID)
+	}
+	f.publish(sessionID, sseEvent{name: "closed", data: struct{}{}})
+
+	f.mu.Lock()
+	session := f.sessions[sessionID]
+	delete(f.sessions, sessionID)
+	f.mu.Unlock()
+	closed := sessionLifecycleEvent{Type: "closed", SessionID: sessionID}
+	if session != nil {
+		closed.owner = session.Owner
+		if exit := session.LastExit(); exit != nil {
+			exitCode := exit.ExitCode
+			closed.ExitCode = &exitCode
+			closed.ExitedAtMs = exit.ExitedAt
+			closed.TerminationReason = string(exit.Reason)
+		}
+	}
+	f.publishLifecycle(closed)
+	if f.webhooks != nil {
+		f.webhooks.sessionClosed(sessionID, session)
+	}
+}
+
+func (f *eventFanout) OnTerminalError(sessionID string, err error) {
+	f.publish(sessionID, sseEvent{name: "error", data: sseErrorPayload{Error: err.Error()}})
+}
+
+// OnTerminalSessionMetadataChanged receives shell-reported foreground command
+// transitions; webhooks use them to detect finished commands.
+func (f *eventFanout) OnTerminalSessionMetadataChanged(sessionID string, info terminal.TerminalSessionInfo) {
+	if f.webhooks != nil {
+		f.webhooks.commandChanged(sessionID, info.ForegroundCommand)
+	}
+}
+
+// OnTerminalOutputActivityChanged forwards output phase changes to webhooks.
+func (f *eventFanout) OnTerminalOutputActivityChanged(sessionID string, info terminal.TerminalOutputActivityInfo) {
+	if f.webhooks != nil {
+		f.webhooks.activityChanged(sessionID, info)
+	}
+}
+
+func (s *Server) handleSessionEvents(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := s.events.subscribe(sessionID)
+	defer s.events.unsubscribe(sessionID, sub)
+
+	// A curl viewer should see output without a websocket attach, so make
+	// sure the PTY is running.
+	if !session.IsActive() {
+		if err := s.manager.ActivateSessionContext(r.Context(), sessionID, 80, 24); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-sub.events:
+			if event.name == "closed" {
+				// The session record is usually removed right after the shell
+				// exits, so attach the exit details here while the handle is
+				// still valid; viewers can then show why the session ended.
+				closed := sseClosedPayload{}
+				if exit := session.LastExit(); exit != nil {
+					exitCode := exit.ExitCode
+					closed.ExitCode = &exitCode
+					closed.ExitedAtMs = exit.ExitedAt
+					closed.TerminationReason = string(exit.Reason)
+				}
+				event.data = closed
+			}
+			payload, err := json.Marshal(event.data)
+			if err != nil {
+				continue
+			}
+			if _, err := w.Write([]byte("event: " + event.name + "\ndata: " + string(payload) + "\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+			if event.name == "closed" {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionEventsStreamsOutputAsSSE(t *testing.T) {
+	srv, httpSrv := newTestServer(t)
+	created := createTestSession(t, httpSrv.URL)
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions/" + created.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("events status=%d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type=%q", got)
+	}
+
+	// Opening the stream activates the session; write through the manager to
+	// trigger echoed output.
+	session, ok := srv.manager.GetSession(created.ID)
+	if !ok {
+		t.Fatal("session missing")
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !session.IsActive() {
+		if time.Now().After(deadline) {
+			t.Fatal("session never became active")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := session.WriteData("hello sse\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var eventName string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			eventName = name
+			continue
+		}
+		payload, ok := strings.CutPrefix(line, "data: ")
+		if !ok || eventName != "data" {
+			continue
+		}
+		var data sseDataPayload
+		if err := json.Unmarshal([]byte(payload), &data); err != nil {
+			t.Fatalf("invalid data payload %q: %v", payload, err)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data.DataBase64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(decoded), "hello sse") {
+			return
+		}
+	}
+	t.Fatalf("stream ended without echoed output: %v", scanner.Err())
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// Remote SSH sessions turn floeterm into a browser-based jump host: instead
+// of a local shell, the session's child process is this binary re-exec'd as
+// an SSH client that requests a PTY on the remote machine. Because the bridge
+// runs as an ordinary PTY child (the same trick the sandbox helper uses),
+// history, reconnects, resizes and exit reporting all behave exactly like a
+// local session — the ring buffer never knows the shell is elsewhere.
+
+// RemoteSSHSpec describes the remote endpoint of an SSH-backed session.
+type RemoteSSHSpec struct {
+	// Host is the remote machine to connect to. Required.
+	Host string `json:"host"`
+	// Port defaults to 22.
+	Port int `json:"port,omitempty"`
+	// User is the remote account name. Required.
+	User string `json:"user"`
+	// IdentityFile is a PEM private key file used for public-key auth. Empty
+	// falls back to the SSH agent at SSH_AUTH_SOCK.
+	IdentityFile string `json:"identityFile,omitempty"`
+	// KnownHostsFile verifies the remote host key; empty defaults to
+	// ~/.ssh/known_hosts.
+	KnownHostsFile string `json:"knownHostsFile,omitempty"`
+	// InsecureIgnoreHostKey skips host key verification. Lab use only.
+	InsecureIgnoreHostKey bool `json:"insecureIgnoreHostKey,omitempty"`
+}
+
+func (spec RemoteSSHSpec) validate() error {
+	if spec.Host == "" {
+		return errors.New("ssh host must not be empty")
+	}
+	if spec.User == "" {
+		return errors.New("ssh user must not be empty")
+	}
+	if spec.Port < 0 || spec.Port > 65535 {
+		return fmt.Errorf("invalid ssh port %d", spec.Port)
+	}
+	return nil
+}
+
+// sshRemoteHelperArg is the hidden argv[1] marker for the SSH client re-exec.
+const sshRemoteHelperArg = "__floeterm-ssh-remote"
+
+// applyRemoteSSH points the session's shell at the SSH client helper so the
+// spawned PTY child bridges to the remote machine.
+func applyRemoteSSH(opts *terminal.CreateSessionOptions, spec RemoteSSHSpec) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate own binary for ssh re-exec: %w", err)
+	}
+	payload, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("cannot encode ssh spec: %w", err)
+	}
+	opts.Shell = self
+	opts.ShellArgs = []string{sshRemoteHelperArg, string(payload)}
+	return nil
+}
+
+// MaybeRunSSHRemoteHelper handles the hidden SSH client invocation:
+// argv = [self, marker, spec-json]. When the marker is present it bridges
+// stdio to a remote PTY and exits with the remote status, never returning;
+// otherwise it does nothing. Call it at the top of main, before flag parsing.
+func MaybeRunSSHRemoteHelper() {
+	if len(os.Args) < 3 || os.Args[1] != sshRemoteHelperArg {
+		return
+	}
+	var spec RemoteSSHSpec
+	if err := json.Unmarshal([]byte(os.Args[2]), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "ssh helper: invalid spec: %v\r\n", err)
+		os.Exit(125)
+	}
+	code, err := runSSHRemote(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ssh helper: %v\r\n", err)
+		os.Exit(255)
+	}
+	os.Exit(code)
+}
+
+// runSSHRemote connects to the remote machine, requests a PTY sized like our
+// own controlling terminal (the session PTY), and bridges stdio until the
+// remote shell exits. SIGWINCH — delivered by the kernel when the session PTY
+// resizes — is forwarded as a window-change request.
+func runSSHRemote(spec RemoteSSHSpec) (int, error) {
+	auth, err := sshRemoteAuth(spec)
+	if err != nil {
+		return 0, err
+	}
+	hostKeys, err := sshRemoteHostKeyCallback(spec)
+	if err != nil {
+		return 0, err
+	}
+	port := spec.Port
+	if port == 0 {
+		port = 22
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(spec.Host, strconv.Itoa(port)), &ssh.ClientConfig{
+		User:            spec.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to %s: %w", spec.Host, err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open session: %w", err)
+	}
+	defer session.Close()
+
+	cols, rows := 80, 24
+	if c, r, err := term.GetSize(int(os.Stdin.Fd())); err == nil && c > 0 && r > 0 {
+		cols, rows = c, r
+	}
+	termName := os.Getenv("TERM")
+	if termName == "" {
+		termName = "xterm-256color"
+	}
+	if err := session.RequestPty(termName, rows, cols, ssh.TerminalModes{}); err != nil {
+		return 0, fmt.Errorf("failed to request remote PTY: %w", err)
+	}
+
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+	if err := session.Shell(); err != nil {
+		return 0, fmt.Errorf("failed to start remote shell: %w", err)
+	}
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	go func() {
+		for range winch {
+			if c, r, err := term.GetSize(int(os.Stdin.Fd())); err == nil && c > 0 && r > 0 {
+				_ = session.WindowChange(r, c)
+			}
+		}
+	}()
+
+	err = session.Wait()
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("remote shell ended abnormally: %w", err)
+	}
+	return 0, nil
+}
+
+// sshRemoteAuth builds the auth chain: an explicit identity file when
+// configured, otherwise the SSH agent.
+func sshRemoteAuth(spec RemoteSSHSpec) ([]ssh.AuthMethod, error) {
+	if spec.IdentityFile != "" {
+		keyPEM, err := os.ReadFile(spec.IdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity file: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse identity file: %w", err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
+	}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, errors.New("no identity file configured and no SSH agent at SSH_AUTH_SOCK")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach SSH agent: %w", err)
+	}
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, nil
+}
+
+func sshRemoteHostKeyCallback(spec RemoteSSHSpec) (ssh.HostKeyCallback, error) {
+	if spec.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	path := spec.KnownHostsFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve home for known_hosts: %w", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %w", err)
+	}
+	return callback, nil
+}
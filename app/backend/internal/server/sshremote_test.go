@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestRemoteSSHSpecValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		spec    RemoteSSHSpec
+		wantErr bool
+	}{
+		{"valid", RemoteSSHSpec{Host: "jump.example.com", User: "ops"}, false},
+		{"missing host", RemoteSSHSpec{User: "ops"}, true},
+		{"missing user", RemoteSSHSpec{Host: "jump.example.com"}, true},
+		{"bad port", RemoteSSHSpec{Host: "jump.example.com", User: "ops", Port: 70000}, true},
+	}
+	for _, tc := range cases {
+		err := tc.spec.validate()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: validate() = %v, wantErr=%v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestApplyRemoteSSHBuildsHelperInvocation(t *testing.T) {
+	spec := RemoteSSHSpec{Host: "jump.example.com", User: "ops", Port: 2200, IdentityFile: "/tmp/key"}
+	var opts terminal.CreateSessionOptions
+	if err := applyRemoteSSH(&opts, spec); err != nil {
+		t.Fatal(err)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Shell != self {
+		t.Fatalf("shell=%q, want own binary %q", opts.Shell, self)
+	}
+	if len(opts.ShellArgs) != 2 || opts.ShellArgs[0] != sshRemoteHelperArg {
+		t.Fatalf("shell args=%v, want [%s <spec-json>]", opts.ShellArgs, sshRemoteHelperArg)
+	}
+	var decoded RemoteSSHSpec
+	if err := json.Unmarshal([]byte(opts.ShellArgs[1]), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != spec {
+		t.Fatalf("round-tripped spec = %+v, want %+v", decoded, spec)
+	}
+}
+
+func TestCreateSessionRejectsInvalidSSHSpec(t *testing.T) {
+	srv := New(Config{ManagerConfig: terminal.ManagerConfig{Logger: terminal.NopLogger{}}})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	resp, err := http.Post(httpSrv.URL+"/api/sessions", "application/json",
+		strings.NewReader(`{"ssh":{"host":"jump.example.com"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status=%d, want 400", resp.StatusCode)
+	}
+}
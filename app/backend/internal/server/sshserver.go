@@ -0,0 +1,375 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// The embedded SSH server exposes the same persistent sessions that the web
+// UI attaches to, so `ssh -p 2222 host` and a browser tab can share one
+// shell. Authentication is public-key only: the client key must appear in the
+// configured authorized_keys content. The SSH username picks the session by
+// name, creating it when it does not exist; an explicit command
+// (`ssh host <name>`) overrides the username. Channels attach through the
+// same livev1 backend chain as the websocket endpoints, so rate limiting,
+// auditing and read-only mode apply unchanged.
+
+// SSHConfig configures the embedded SSH server.
+type SSHConfig struct {
+	// Addr is the TCP listen address, e.g. ":2222". Empty disables the SSH
+	// server entirely.
+	Addr string
+	// HostKeyPEM is the PEM-encoded server host key. Required when Addr is
+	// set; cmd/floeterm generates and persists one on first use.
+	HostKeyPEM []byte
+	// AuthorizedKeys is the set of client public keys allowed to connect, in
+	// OpenSSH authorized_keys format. At least one key is required: an SSH
+	// listener without authentication would hand out shells to anyone.
+	AuthorizedKeys []byte
+}
+
+// sshDefaultCols and sshDefaultRows size sessions for clients that skip the
+// pty-req (e.g. `ssh -T`).
+const (
+	sshDefaultCols = 80
+	sshDefaultRows = 24
+)
+
+type sshServer struct {
+	backend  livev1.Backend
+	manager  *terminal.Manager
+	audit    *auditLog
+	logger   terminal.Logger
+	config   *ssh.ServerConfig
+	listener net.Listener
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newSSHServer(cfg SSHConfig, backend livev1.Backend, manager *terminal.Manager, audit *auditLog, logger terminal.Logger) (*sshServer, error) {
+	signer, err := ssh.ParsePrivateKey(cfg.HostKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH host key: %w", err)
+	}
+	authorized, err := parseAuthorizedKeys(cfg.AuthorizedKeys)
+	if err != nil {
+		return nil, err
+	}
+	if len(authorized) == 0 {
+		return nil, errors.New("SSH server requires at least one authorized key")
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !authorized[string(key.Marshal())] {
+				return nil, fmt.Errorf("unknown public key for user %q", conn.User())
+			}
+			return &ssh.Permissions{
+				Extensions: map[string]string{"pubkey-fp": ssh.FingerprintSHA256(key)},
+			}, nil
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &sshServer{
+		backend:  backend,
+		manager:  manager,
+		audit:    audit,
+		logger:   logger,
+		config:   serverConfig,
+		listener: listener,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	srv.wg.Add(1)
+	go srv.acceptLoop()
+	logger.Info("SSH server listening", "addr", listener.Addr().String())
+	return srv, nil
+}
+
+// parseAuthorizedKeys reads authorized_keys content into a set keyed by the
+// wire encoding of each public key.
+func parseAuthorizedKeys(content []byte) (map[string]bool, error) {
+	authorized := make(map[string]bool)
+	rest := content
+	for len(rest) > 0 {
+		key, _, _, remaining, err := ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse authorized keys: %w", err)
+		}
+		authorized[string(key.Marshal())] = true
+		rest = remaining
+	}
+	return authorized, nil
+}
+
+func (s *sshServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *sshServer) stop() {
+	s.cancel()
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *sshServer) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// Closed listener during shutdown; anything else is fatal for the
+			// accept loop either way.
+			return
+		}
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+func (s *sshServer) handleConn(raw net.Conn) {
+	defer s.wg.Done()
+	remoteIP := sshRemoteIP(raw.RemoteAddr())
+	sshConn, channels, requests, err := ssh.NewServerConn(raw, s.config)
+	if err != nil {
+		s.audit.record(auditEvent{Type: auditAuthFailure, RemoteIP: remoteIP, Path: "ssh"})
+		raw.Close()
+		return
+	}
+	defer sshConn.Close()
+	s.audit.record(auditEvent{
+		Type:     auditAuthSuccess,
+		User:     sshConn.User(),
+		RemoteIP: remoteIP,
+		Path:     "ssh",
+	})
+	go ssh.DiscardRequests(requests)
+
+	// Close the transport when the server shuts down so per-channel loops
+	// unblock.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			sshConn.Close()
+		case <-done:
+		}
+	}()
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		s.wg.Add(1)
+		go s.handleSessionChannel(sshConn, channel, channelRequests)
+	}
+}
+
+// handleSessionChannel serves one SSH session channel: it records the
+// requested PTY size, attaches to the named floeterm session on shell/exec,
+// and forwards window-change requests as resizes.
+func (s *sshServer) handleSessionChannel(conn *ssh.ServerConn, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer s.wg.Done()
+	defer channel.Close()
+
+	cols, rows := uint32(sshDefaultCols), uint32(sshDefaultRows)
+	var attachment *livev1.Attach
+	var detach func()
+	defer func() {
+		if detach != nil {
+			detach()
+		}
+	}()
+
+	for request := range requests {
+		switch request.Type {
+		case "pty-req":
+			if c, r, ok := parsePTYRequest(request.Payload); ok {
+				cols, rows = c, r
+			}
+			request.Reply(true, nil)
+
+		case "shell", "exec":
+			if attachment != nil {
+				request.Reply(false, nil)
+				continue
+			}
+			name := conn.User()
+			if request.Type == "exec" {
+				if command, ok := parseExecCommand(request.Payload); ok && command != "" {
+					name = command
+				}
+			}
+			attached, cleanup, err := s.attachByName(name, cols, rows, channel)
+			if err != nil {
+				request.Reply(false, nil)
+				fmt.Fprintf(channel.Stderr(), "floeterm: %v\r\n", err)
+				return
+			}
+			attachment, detach = attached, cleanup
+			request.Reply(true, nil)
+
+		case "window-change":
+			if c, r, ok := parseWindowChange(request.Payload); ok {
+				cols, rows = c, r
+				if attachment != nil {
+					_, _ = s.backend.Resize(s.ctx, *attachment, livev1.Resize{Cols: cols, Rows: rows})
+				}
+			}
+
+		default:
+			if request.WantReply {
+				request.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// attachByName resolves or creates the named session and bridges it onto the
+// SSH channel. The returned cleanup detaches and stops the input pump.
+func (s *sshServer) attachByName(name string, cols, rows uint32, channel ssh.Channel) (*livev1.Attach, func(), error) {
+	session, err := s.resolveSession(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, nil, fmt.Errorf("failed to mint connection id: %w", err)
+	}
+	attachment := &livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             cols,
+		Rows:             rows,
+		SessionID:        session.ID,
+		ConnectionID:     "ssh-" + hex.EncodeToString(buf),
+	}
+	_, detach, err := s.backend.Attach(s.ctx, *attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			_, err := channel.Write(record.Data)
+			return err == nil
+		},
+		// SSH clients size their own terminal; effective-geometry changes
+		// have no wire representation on a session channel.
+		OnGeometry: func(livev1.EffectiveGeometry) bool { return true },
+		OnSessionClosed: func() {
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+			channel.Close()
+		},
+		OnSuperseded: func() {
+			channel.Close()
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to attach: %w", err)
+	}
+
+	// Pump SSH channel input into the session until the client disconnects.
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		buf := make([]byte, 4096)
+		for {
+			n, err := channel.Read(buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				if err := s.backend.WriteInput(s.ctx, *attachment, livev1.Input{Data: data}); err != nil {
+					channel.Close()
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return attachment, detach, nil
+}
+
+// resolveSession finds the session with the given name, creating it when no
+// live session matches.
+func (s *sshServer) resolveSession(name string) (*terminal.Session, error) {
+	name = strings.TrimSpace(name)
+	for _, session := range s.manager.ListSessions() {
+		if session.GetName() == name {
+			return session, nil
+		}
+	}
+	return s.manager.CreateSession(name, "")
+}
+
+// parsePTYRequest extracts cols and rows from a pty-req payload.
+func parsePTYRequest(payload []byte) (cols, rows uint32, ok bool) {
+	var request struct {
+		Term          string
+		Cols          uint32
+		Rows          uint32
+		WidthPx       uint32
+		HeightPx      uint32
+		TerminalModes string
+	}
+	if err := ssh.Unmarshal(payload, &request); err != nil || request.Cols == 0 || request.Rows == 0 {
+		return 0, 0, false
+	}
+	return request.Cols, request.Rows, true
+}
+
+// parseWindowChange extracts cols and rows from a window-change payload.
+func parseWindowChange(payload []byte) (cols, rows uint32, ok bool) {
+	if len(payload) < 8 {
+		return 0, 0, false
+	}
+	cols = binary.BigEndian.Uint32(payload[0:4])
+	rows = binary.BigEndian.Uint32(payload[4:8])
+	if cols == 0 || rows == 0 {
+		return 0, 0, false
+	}
+	return cols, rows, true
+}
+
+// parseExecCommand extracts the command string from an exec payload.
+func parseExecCommand(payload []byte) (string, bool) {
+	var request struct {
+		Command string
+	}
+	if err := ssh.Unmarshal(payload, &request); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(request.Command), true
+}
+
+// sshRemoteIP strips the port from a remote address for audit records.
+func sshRemoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
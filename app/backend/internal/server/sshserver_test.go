@@ -0,0 +1,141 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// testSSHKeyPair generates an ed25519 key and returns its PEM private key and
+// authorized_keys line.
+func testSSHKeyPair(t *testing.T) (ssh.Signer, []byte, []byte) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, pem.EncodeToMemory(block), ssh.MarshalAuthorizedKey(signer.PublicKey())
+}
+
+func newSSHTestServer(t *testing.T) (*Server, ssh.Signer) {
+	t.Helper()
+	clientSigner, _, authorizedKeys := testSSHKeyPair(t)
+	_, hostKeyPEM, _ := testSSHKeyPair(t)
+	srv := New(Config{
+		SSH: SSHConfig{
+			Addr:           "127.0.0.1:0",
+			HostKeyPEM:     hostKeyPEM,
+			AuthorizedKeys: authorizedKeys,
+		},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	t.Cleanup(srv.Close)
+	if srv.SSHAddr() == "" {
+		t.Fatal("SSH server did not start")
+	}
+	return srv, clientSigner
+}
+
+func TestSSHServerAttachesSessionByUsername(t *testing.T) {
+	srv, signer := newSSHTestServer(t)
+
+	client, err := ssh.Dial("tcp", srv.SSHAddr(), &ssh.ClientConfig{
+		User:            "ops",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.RequestPty("xterm", 24, 80, ssh.TerminalModes{}); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Shell(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stdin.Write([]byte("ping\n")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	var output strings.Builder
+	buf := make([]byte, 4096)
+	for !strings.Contains(output.String(), "ping") {
+		select {
+		case <-deadline:
+			t.Fatalf("no echo from session, got %q", output.String())
+		default:
+		}
+		n, err := stdout.Read(buf)
+		if n > 0 {
+			output.Write(buf[:n])
+		}
+		if err != nil {
+			break
+		}
+	}
+	if !strings.Contains(output.String(), "ping") {
+		t.Fatalf("no echo from session, got %q", output.String())
+	}
+
+	// The SSH username selected the session name.
+	found := false
+	for _, session := range srv.Manager().ListSessions() {
+		if session.GetName() == "ops" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("no session named after the SSH user")
+	}
+}
+
+func TestSSHServerRejectsUnknownKeys(t *testing.T) {
+	srv, _ := newSSHTestServer(t)
+	strangerSigner, _, _ := testSSHKeyPair(t)
+
+	_, err := ssh.Dial("tcp", srv.SSHAddr(), &ssh.ClientConfig{
+		User:            "intruder",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(strangerSigner)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err == nil {
+		t.Fatal("handshake with an unauthorized key succeeded")
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -8,7 +9,7 @@ import (
 )
 
 // spaFileServer serves a Vite/SPA build directory and falls back to index.html for client-side routes.
-func spaFileServer(dir string) http.Handler {
+func spaFileServer(dir, basePath string) http.Handler {
 	fs := http.FileServer(http.Dir(dir))
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -22,6 +23,10 @@ func spaFileServer(dir string) http.Handler {
 			http.NotFound(w, r)
 			return
 		}
+		if clean == "index.html" {
+			serveSPAIndex(w, r, dir, basePath)
+			return
+		}
 
 		abs := filepath.Join(dir, clean)
 		if info, err := os.Stat(abs); err == nil && !info.IsDir() {
@@ -31,12 +36,34 @@ func spaFileServer(dir string) http.Handler {
 
 		// If this looks like a client-side route, serve the SPA entrypoint.
 		if !strings.Contains(clean, ".") {
-			r2 := r.Clone(r.Context())
-			r2.URL.Path = "/index.html"
-			fs.ServeHTTP(w, r2)
+			serveSPAIndex(w, r, dir, basePath)
 			return
 		}
 
 		http.NotFound(w, r)
 	})
 }
+
+// serveSPAIndex serves the SPA entrypoint. When the server is mounted under a
+// subpath, a <base> tag pointing at the mount is injected so the build's
+// relative asset URLs (and the frontend's fetch/ws paths) resolve under it
+// without rebuilding the bundle.
+func serveSPAIndex(w http.ResponseWriter, r *http.Request, dir, basePath string) {
+	abs := filepath.Join(dir, "index.html")
+	if basePath == "" {
+		http.ServeFile(w, r, abs)
+		return
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if head := []byte("<head>"); bytes.Contains(data, head) && !bytes.Contains(data, []byte("<base ")) {
+		tag := []byte("<head>\n    <base href=\"" + basePath + "/\">")
+		data = bytes.Replace(data, head, tag, 1)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(data)
+}
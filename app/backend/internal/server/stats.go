@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// The /api/server/stats endpoint aggregates runtime health numbers —
+// goroutines, heap usage, session and connection counts, broadcast queue
+// depths and rate-limiter fill — into one JSON document for the web UI's
+// server status panel. It overlaps with /metrics on purpose: same numbers,
+// but shaped for a dashboard fetch instead of a Prometheus scrape.
+
+// serverStats is the /api/server/stats response.
+type serverStats struct {
+	UptimeMs       int64  `json:"uptimeMs"`
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heapAllocBytes"`
+	HeapSysBytes   uint64 `json:"heapSysBytes"`
+	GCCycles       uint32 `json:"gcCycles"`
+
+	Sessions        int   `json:"sessions"`
+	ActiveSessions  int   `json:"activeSessions"`
+	Connections     int   `json:"connections"`
+	LiveAttachments int   `json:"liveAttachments"`
+	HistoryBytes    int64 `json:"historyBytes"`
+
+	Broadcast broadcastStats `json:"broadcast"`
+
+	// RateLimits is omitted entirely when no rate limiting is configured.
+	RateLimits []rateLimitStats `json:"rateLimits,omitempty"`
+}
+
+// broadcastStats reports the event fan-out queues: how many subscribers are
+// attached and how many events sit buffered waiting for them. A queued count
+// near the buffer size means a slow reader is about to drop events.
+type broadcastStats struct {
+	SSESubscribers        int `json:"sseSubscribers"`
+	SSEQueuedEvents       int `json:"sseQueuedEvents"`
+	LifecycleSubscribers  int `json:"lifecycleSubscribers"`
+	LifecycleQueuedEvents int `json:"lifecycleQueuedEvents"`
+}
+
+// rateLimitStats reports one token bucket's configuration and current fill.
+type rateLimitStats struct {
+	Name            string  `json:"name"`
+	RatePerSecond   float64 `json:"ratePerSecond"`
+	Burst           float64 `json:"burst"`
+	AvailableTokens float64 `json:"availableTokens"`
+}
+
+// handleServerStats reports aggregated runtime statistics.
+func (s *Server) handleServerStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	diag := s.manager.GetDiagnostics()
+
+	writeJSON(w, http.StatusOK, serverStats{
+		UptimeMs:        time.Since(s.startedAt).Milliseconds(),
+		Goroutines:      runtime.NumGoroutine(),
+		HeapAllocBytes:  mem.HeapAlloc,
+		HeapSysBytes:    mem.HeapSys,
+		GCCycles:        mem.NumGC,
+		Sessions:        diag.SessionCount,
+		ActiveSessions:  diag.ActiveSessionCount,
+		Connections:     diag.ConnectionCount,
+		LiveAttachments: diag.LiveAttachmentCount,
+		HistoryBytes:    diag.HistoryBytes,
+		Broadcast:       s.events.broadcastStats(),
+		RateLimits:      s.rateLimiter.stats(),
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestServerStatsReportsRuntimeAndSessions(t *testing.T) {
+	srv := New(Config{
+		RateLimit: RateLimitConfig{RequestsPerMinute: 600},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	if _, err := srv.Manager().CreateSession("stats", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(httpSrv.URL + "/api/server/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d, want 200", resp.StatusCode)
+	}
+	var stats serverStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Goroutines <= 0 {
+		t.Fatalf("goroutines=%d, want > 0", stats.Goroutines)
+	}
+	if stats.HeapAllocBytes == 0 {
+		t.Fatal("heapAllocBytes is zero")
+	}
+	if stats.Sessions != 1 {
+		t.Fatalf("sessions=%d, want 1", stats.Sessions)
+	}
+	if len(stats.RateLimits) != 1 || stats.RateLimits[0].Name != "requests" {
+		t.Fatalf("rateLimits=%+v, want one 'requests' bucket", stats.RateLimits)
+	}
+	if bucket := stats.RateLimits[0]; bucket.Burst != 600 || bucket.AvailableTokens <= 0 {
+		t.Fatalf("requests bucket=%+v", bucket)
+	}
+}
+
+func TestServerStatsOmitsRateLimitsWhenUnconfigured(t *testing.T) {
+	srv := New(Config{ManagerConfig: terminal.ManagerConfig{Logger: terminal.NopLogger{}}})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+	defer srv.Close()
+
+	resp, err := http.Get(httpSrv.URL + "/api/server/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["rateLimits"]; ok {
+		t.Fatal("rateLimits present without rate limiting configured")
+	}
+	if _, ok := raw["broadcast"]; !ok {
+		t.Fatal("broadcast section missing")
+	}
+}
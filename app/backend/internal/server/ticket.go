@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// Attach tickets are short-lived, single-use websocket credentials. Browsers
+// cannot set an Authorization header on a websocket handshake, so clients
+// mint a ticket over the (header-authenticated) REST API and pass it in the
+// /ws query string instead of exposing the long-lived bearer token there.
+
+const (
+	attachTicketTTLDefault = 30 * time.Second
+	attachTicketTTLMax     = 5 * time.Minute
+)
+
+// attachTicket is a minted websocket credential bound to one session and,
+// optionally, one connection ID and read-only permissions.
+type attachTicket struct {
+	sessionID string
+	connID    string
+	readOnly  bool
+	expiresAt time.Time
+}
+
+type createTicketRequest struct {
+	// ConnID, when set, pins the ticket to one connection ID so a leaked
+	// ticket cannot be replayed under a different attachment.
+	ConnID     string `json:"connId"`
+	TTLSeconds int64  `json:"ttlSeconds"`
+	ReadOnly   bool   `json:"readOnly"`
+}
+
+type createTicketResponse struct {
+	Ticket      string `json:"ticket"`
+	SessionID   string `json:"sessionId"`
+	ConnID      string `json:"connId,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+	ExpiresAtMs int64  `json:"expiresAtMs"`
+}
+
+// handleSessionTicket mints a single-use attach ticket for a session. The
+// mint call itself goes through regular auth; the ticket then authenticates
+// exactly one websocket handshake.
+func (s *Server) handleSessionTicket(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := s.manager.GetSession(sessionID); !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	var req createTicketRequest
+	if err := readJSON(w, r, &req, s.maxJSONBodyBytes); err != nil && !strings.Contains(err.Error(), "EOF") {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	ttl := attachTicketTTLDefault
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > attachTicketTTLMax {
+		http.Error(w, "ttl too large", http.StatusBadRequest)
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		http.Error(w, "failed to mint ticket", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(ttl)
+
+	s.ticketMu.Lock()
+	if s.tickets == nil {
+		s.tickets = make(map[string]attachTicket)
+	}
+	s.tickets[token] = attachTicket{
+		sessionID: sessionID,
+		connID:    req.ConnID,
+		readOnly:  req.ReadOnly,
+		expiresAt: expiresAt,
+	}
+	s.ticketMu.Unlock()
+
+	writeJSON(w, http.StatusOK, createTicketResponse{
+		Ticket:      token,
+		SessionID:   sessionID,
+		ConnID:      req.ConnID,
+		ReadOnly:    req.ReadOnly,
+		ExpiresAtMs: expiresAt.UnixMilli(),
+	})
+}
+
+// consumeAttachTicket resolves and burns a ticket: the first lookup removes
+// it, so a replayed handshake fails even inside the TTL.
+func (s *Server) consumeAttachTicket(token string) (attachTicket, bool) {
+	if token == "" {
+		return attachTicket{}, false
+	}
+	s.ticketMu.Lock()
+	defer s.ticketMu.Unlock()
+
+	ticket, ok := s.tickets[token]
+	if !ok {
+		return attachTicket{}, false
+	}
+	delete(s.tickets, token)
+	if time.Now().After(ticket.expiresAt) {
+		return attachTicket{}, false
+	}
+	return ticket, true
+}
+
+// authorizeAttach checks an attach request against the ticket's bindings.
+func (t attachTicket) authorizeAttach(sessionID string, request livev1.Attach) error {
+	if t.sessionID != request.SessionID || t.sessionID != sessionID {
+		return errors.New("ticket does not cover this session")
+	}
+	if t.connID != "" && t.connID != request.ConnectionID {
+		return errors.New("ticket is bound to a different connection id")
+	}
+	return nil
+}
+
+type ticketScopeContextKey struct{}
+
+func withTicketScope(ctx context.Context, ticket attachTicket) context.Context {
+	return context.WithValue(ctx, ticketScopeContextKey{}, ticket)
+}
+
+func ticketScopeFromContext(ctx context.Context) (attachTicket, bool) {
+	ticket, ok := ctx.Value(ticketScopeContextKey{}).(attachTicket)
+	return ticket, ok
+}
+
+var errReadOnlyTicket = errors.New("attach ticket is read-only")
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	terminal "github.com/floegence/floeterm/terminal-go"
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+func TestAttachTicketAuthenticatesSingleWebsocketHandshake(t *testing.T) {
+	srv := New(Config{
+		AuthToken: "ticket-secret",
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		httpSrv.Close()
+		srv.Close()
+	})
+	authed := func(t *testing.T, method, path, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, httpSrv.URL+path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer ticket-secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := authed(t, http.MethodPost, "/api/sessions", `{}`)
+	var created apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp = authed(t, http.MethodPost, "/api/sessions/"+created.ID+"/ticket", `{"connId":"conn-1"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("mint status=%d, want 200", resp.StatusCode)
+	}
+	var ticket createTicketResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ticket); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ticket.Ticket == "" || ticket.SessionID != created.ID || ticket.ExpiresAtMs <= time.Now().UnixMilli() {
+		t.Fatalf("ticket=%+v", ticket)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	wsBase := "ws" + httpSrv.URL[len("http"):]
+
+	// Without credentials the handshake is refused outright.
+	if conn, dialResp, err := websocket.Dial(ctx, wsBase+"/ws", nil); err == nil {
+		conn.CloseNow()
+		t.Fatal("unauthenticated ws dial succeeded")
+	} else if dialResp == nil || dialResp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated ws dial error=%v, want 401", err)
+	}
+
+	conn, _, err := websocket.Dial(ctx, wsBase+"/ws?ticket="+ticket.Ticket, nil)
+	if err != nil {
+		t.Fatalf("ticket ws dial failed: %v", err)
+	}
+	conn.CloseNow()
+
+	// The ticket is single-use: replaying it fails inside the TTL.
+	if conn, dialResp, err := websocket.Dial(ctx, wsBase+"/ws?ticket="+ticket.Ticket, nil); err == nil {
+		conn.CloseNow()
+		t.Fatal("replayed ticket was accepted")
+	} else if dialResp == nil || dialResp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("replayed ticket error=%v, want 401", err)
+	}
+
+	// Expired tickets are rejected even on first use.
+	resp = authed(t, http.MethodPost, "/api/sessions/"+created.ID+"/ticket", `{}`)
+	var expired createTicketResponse
+	if err := json.NewDecoder(resp.Body).Decode(&expired); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	srv.ticketMu.Lock()
+	stale := srv.tickets[expired.Ticket]
+	stale.expiresAt = time.Now().Add(-time.Second)
+	srv.tickets[expired.Ticket] = stale
+	srv.ticketMu.Unlock()
+	if conn, dialResp, err := websocket.Dial(ctx, wsBase+"/ws?ticket="+expired.Ticket, nil); err == nil {
+		conn.CloseNow()
+		t.Fatal("expired ticket was accepted")
+	} else if dialResp == nil || dialResp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expired ticket error=%v, want 401", err)
+	}
+}
+
+func TestAttachTicketBindings(t *testing.T) {
+	ticket := attachTicket{sessionID: "sess-1", connID: "conn-1"}
+
+	if err := ticket.authorizeAttach("sess-1", livev1.Attach{SessionID: "sess-1", ConnectionID: "conn-1"}); err != nil {
+		t.Fatalf("matching attach rejected: %v", err)
+	}
+	if err := ticket.authorizeAttach("sess-2", livev1.Attach{SessionID: "sess-2", ConnectionID: "conn-1"}); err == nil {
+		t.Fatal("ticket authorized a different session")
+	}
+	if err := ticket.authorizeAttach("sess-1", livev1.Attach{SessionID: "sess-1", ConnectionID: "conn-2"}); err == nil {
+		t.Fatal("ticket authorized a different connection id")
+	}
+
+	unbound := attachTicket{sessionID: "sess-1"}
+	if err := unbound.authorizeAttach("sess-1", livev1.Attach{SessionID: "sess-1", ConnectionID: "any"}); err != nil {
+		t.Fatalf("unbound connId rejected: %v", err)
+	}
+}
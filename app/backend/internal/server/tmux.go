@@ -0,0 +1,255 @@
+package server
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// The tmux integration lets users with long-lived tmux workflows view their
+// panes in the web UI without migrating. Listing goes through ordinary tmux
+// queries; attaching maps one tmux pane onto one floeterm session via a PTY
+// child (the same re-exec trick the SSH and Docker backends use) that speaks
+// tmux control mode (`tmux -C`): pane output arrives as %output events and
+// input goes back as send-keys, so the pane stays usable from tmux's own
+// clients at the same time.
+
+// TmuxPaneSpec selects the tmux pane backing a session.
+type TmuxPaneSpec struct {
+	// Target is a tmux target: a pane id ("%5") or a
+	// session:window.pane spec ("main:1.0"). Required.
+	Target string `json:"target"`
+}
+
+func (spec TmuxPaneSpec) validate() error {
+	if spec.Target == "" {
+		return errors.New("tmux target must not be empty")
+	}
+	return nil
+}
+
+// tmuxPaneHelperArg is the hidden argv[1] marker for the tmux bridge re-exec.
+const tmuxPaneHelperArg = "__floeterm-tmux-pane"
+
+// applyTmuxPane points the session's shell at the tmux control-mode bridge.
+func applyTmuxPane(opts *terminal.CreateSessionOptions, spec TmuxPaneSpec) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate own binary for tmux re-exec: %w", err)
+	}
+	payload, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("cannot encode tmux spec: %w", err)
+	}
+	opts.Shell = self
+	opts.ShellArgs = []string{tmuxPaneHelperArg, string(payload)}
+	return nil
+}
+
+// MaybeRunTmuxPaneHelper handles the hidden tmux bridge invocation:
+// argv = [self, marker, spec-json]. When the marker is present it bridges
+// stdio to the tmux pane and never returns; otherwise it does nothing. Call
+// it at the top of main.
+func MaybeRunTmuxPaneHelper() {
+	if len(os.Args) < 3 || os.Args[1] != tmuxPaneHelperArg {
+		return
+	}
+	var spec TmuxPaneSpec
+	if err := json.Unmarshal([]byte(os.Args[2]), &spec); err != nil {
+		fmt.Fprintf(os.Stderr, "tmux helper: invalid spec: %v\r\n", err)
+		os.Exit(125)
+	}
+	if err := runTmuxPane(spec); err != nil {
+		fmt.Fprintf(os.Stderr, "tmux helper: %v\r\n", err)
+		os.Exit(255)
+	}
+	os.Exit(0)
+}
+
+// runTmuxPane attaches a control-mode client to the pane's tmux session and
+// bridges: %output events for the pane go to stdout, stdin goes back as
+// hex-encoded send-keys so arbitrary bytes survive, and SIGWINCH becomes a
+// client resize.
+func runTmuxPane(spec TmuxPaneSpec) error {
+	// Resolve the target to a stable pane id and its owning session up
+	// front; targets like "main:1.0" are ambiguous once windows move.
+	resolved, err := exec.Command("tmux", "display-message", "-p", "-t", spec.Target,
+		"-F", "#{pane_id} #{session_name}").Output()
+	if err != nil {
+		return fmt.Errorf("failed to resolve tmux target %q: %w", spec.Target, tmuxExecError(err))
+	}
+	fields := strings.Fields(strings.TrimSpace(string(resolved)))
+	if len(fields) < 2 {
+		return fmt.Errorf("unexpected tmux reply %q", strings.TrimSpace(string(resolved)))
+	}
+	paneID, sessionName := fields[0], fields[1]
+
+	cmd := exec.Command("tmux", "-C", "attach-session", "-t", sessionName)
+	controlIn, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	controlOut, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start tmux control client: %w", err)
+	}
+	defer cmd.Wait()
+	defer controlIn.Close()
+
+	resize := func() {
+		if cols, rows, err := term.GetSize(int(os.Stdin.Fd())); err == nil && cols > 0 && rows > 0 {
+			fmt.Fprintf(controlIn, "refresh-client -C %dx%d\n", cols, rows)
+		}
+	}
+	resize()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	go func() {
+		for range winch {
+			resize()
+		}
+	}()
+
+	// Input pump: hex-encoded send-keys delivers raw bytes to the pane
+	// without shell-quoting hazards.
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				words := make([]string, 0, n)
+				for _, b := range buf[:n] {
+					words = append(words, "0x"+hex.EncodeToString([]byte{b}))
+				}
+				fmt.Fprintf(controlIn, "send-keys -t %s -H %s\n", paneID, strings.Join(words, " "))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(controlOut)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "%output "):
+			rest := strings.TrimPrefix(line, "%output ")
+			id, data, ok := strings.Cut(rest, " ")
+			if ok && id == paneID {
+				os.Stdout.Write(tmuxUnescape(data))
+			}
+		case strings.HasPrefix(line, "%exit"):
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// tmuxUnescape decodes the octal \ooo escapes control mode uses for
+// non-printable output bytes.
+func tmuxUnescape(data string) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' && i+3 < len(data) &&
+			isOctal(data[i+1]) && isOctal(data[i+2]) && isOctal(data[i+3]) {
+			out = append(out, (data[i+1]-'0')<<6|(data[i+2]-'0')<<3|(data[i+3]-'0'))
+			i += 3
+			continue
+		}
+		out = append(out, data[i])
+	}
+	return out
+}
+
+func isOctal(c byte) bool { return c >= '0' && c <= '7' }
+
+// tmuxExecError surfaces tmux's stderr instead of the opaque exit status.
+func tmuxExecError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// tmuxPaneInfo is one pane in the /api/tmux/sessions listing.
+type tmuxPaneInfo struct {
+	Session string `json:"session"`
+	Window  int    `json:"window"`
+	Pane    int    `json:"pane"`
+	PaneID  string `json:"paneId"`
+	Title   string `json:"title"`
+	Command string `json:"command"`
+}
+
+// parseTmuxPaneList parses `tmux list-panes -a` output in the tab-separated
+// format requested by handleTmuxSessions.
+func parseTmuxPaneList(output string) []tmuxPaneInfo {
+	var panes []tmuxPaneInfo
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 6 {
+			continue
+		}
+		var window, pane int
+		fmt.Sscanf(fields[1], "%d", &window)
+		fmt.Sscanf(fields[2], "%d", &pane)
+		panes = append(panes, tmuxPaneInfo{
+			Session: fields[0],
+			Window:  window,
+			Pane:    pane,
+			PaneID:  fields[3],
+			Title:   fields[4],
+			Command: fields[5],
+		})
+	}
+	return panes
+}
+
+// handleTmuxSessions lists panes across all tmux sessions so the UI can offer
+// them for attachment. No tmux server running is an empty list, not an error.
+func (s *Server) handleTmuxSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	output, err := exec.Command("tmux", "list-panes", "-a", "-F",
+		"#{session_name}\t#{window_index}\t#{pane_index}\t#{pane_id}\t#{pane_title}\t#{pane_current_command}").Output()
+	if err != nil {
+		message := tmuxExecError(err).Error()
+		if strings.Contains(message, "no server running") || errors.Is(err, exec.ErrNotFound) {
+			writeJSON(w, http.StatusOK, []tmuxPaneInfo{})
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to list tmux panes: %s", message), http.StatusBadGateway)
+		return
+	}
+	panes := parseTmuxPaneList(string(output))
+	if panes == nil {
+		panes = []tmuxPaneInfo{}
+	}
+	writeJSON(w, http.StatusOK, panes)
+}
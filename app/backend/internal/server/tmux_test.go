@@ -0,0 +1,62 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestTmuxUnescape(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []byte
+	}{
+		{"plain text", []byte("plain text")},
+		{`prompt\033[1m$`, []byte("prompt\x1b[1m$")},
+		{`\015\012`, []byte("\r\n")},
+		{`trailing\`, []byte(`trailing\`)},
+		{`not\9octal`, []byte(`not\9octal`)},
+	}
+	for _, tc := range cases {
+		if got := tmuxUnescape(tc.in); !bytes.Equal(got, tc.want) {
+			t.Errorf("tmuxUnescape(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseTmuxPaneList(t *testing.T) {
+	output := "main\t0\t0\t%0\tvim\tvim\n" +
+		"main\t1\t0\t%3\tzsh\tzsh\n" +
+		"work\t0\t1\t%7\tbuild\tmake\n"
+	panes := parseTmuxPaneList(output)
+	if len(panes) != 3 {
+		t.Fatalf("got %d panes, want 3", len(panes))
+	}
+	if panes[2].Session != "work" || panes[2].Window != 0 || panes[2].Pane != 1 ||
+		panes[2].PaneID != "%7" || panes[2].Command != "make" {
+		t.Fatalf("pane[2] = %+v", panes[2])
+	}
+}
+
+func TestApplyTmuxPaneBuildsHelperInvocation(t *testing.T) {
+	var opts terminal.CreateSessionOptions
+	if err := applyTmuxPane(&opts, TmuxPaneSpec{Target: "main:1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.ShellArgs) != 2 || opts.ShellArgs[0] != tmuxPaneHelperArg {
+		t.Fatalf("shell args = %v, want [%s <spec-json>]", opts.ShellArgs, tmuxPaneHelperArg)
+	}
+	var decoded TmuxPaneSpec
+	if err := json.Unmarshal([]byte(opts.ShellArgs[1]), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Target != "main:1.0" {
+		t.Fatalf("round-tripped spec = %+v", decoded)
+	}
+
+	if err := applyTmuxPane(&opts, TmuxPaneSpec{}); err == nil {
+		t.Fatal("empty target accepted")
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// TracingConfig enables OpenTelemetry spans around API handlers, websocket
+// lifecycles, live attach (which covers PTY activation) and history replay,
+// so the latency of attach/replay paths can be analyzed in production.
+type TracingConfig struct {
+	// Enabled turns span recording on; off, tracing costs nothing.
+	Enabled bool
+	// ServiceName labels exported spans. Empty means "floeterm".
+	ServiceName string
+	// Exporter receives finished spans. Nil falls back to a JSON-lines
+	// exporter on stderr (see NewWriterSpanExporter); embedders plug in an
+	// OTLP or vendor exporter here.
+	Exporter sdktrace.SpanExporter
+}
+
+// newTracer builds the tracer and its shutdown hook. Disabled tracing returns
+// a no-op tracer so span call sites need no conditionals, plus a nil shutdown.
+func newTracer(cfg TracingConfig) (trace.Tracer, func(context.Context) error) {
+	if !cfg.Enabled {
+		return noop.NewTracerProvider().Tracer(""), nil
+	}
+	exporter := cfg.Exporter
+	if exporter == nil {
+		exporter = NewWriterSpanExporter(os.Stderr)
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "floeterm"
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+		)),
+	)
+	return provider.Tracer("github.com/floegence/floeterm/app/backend/internal/server"), provider.Shutdown
+}
+
+// withTracing wraps every API and websocket request in a server span. For
+// websockets the handler blocks until the connection closes, so the span
+// covers the whole connection lifecycle.
+func (s *Server) withTracing(next http.Handler) http.Handler {
+	if !s.tracingEnabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isProtectedPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		kind := "HTTP"
+		if r.URL.Path == "/ws" || strings.HasPrefix(r.URL.Path, "/ws/") || strings.HasPrefix(r.URL.Path, "/gotty/") {
+			kind = "WS"
+		}
+		ctx, span := s.tracer.Start(r.Context(), kind+" "+r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// statusRecorder captures the response status for the request span. Unwrap
+// keeps http.ResponseController (and so websocket hijacking) working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// tracingBackend wraps the live backend with spans around attach and replay.
+// The attach span covers PTY activation for dormant sessions; a second span
+// runs from successful attach until detach, tracking attachment lifetimes.
+type tracingBackend struct {
+	livev1.Backend
+	tracer trace.Tracer
+}
+
+func (b tracingBackend) Attach(ctx context.Context, request livev1.Attach, subscriber livev1.Subscriber) (livev1.Attached, func(), error) {
+	attrs := trace.WithAttributes(
+		attribute.String("session.id", request.SessionID),
+		attribute.String("connection.id", request.ConnectionID),
+	)
+	ctx, span := b.tracer.Start(ctx, "live.attach", attrs)
+	attached, detach, err := b.Backend.Attach(ctx, request, subscriber)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return attached, detach, err
+	}
+	span.End()
+
+	_, lifeSpan := b.tracer.Start(ctx, "live.attachment", attrs)
+	return attached, func() {
+		if detach != nil {
+			detach()
+		}
+		lifeSpan.End()
+	}, nil
+}
+
+// ReplayOutput forwards the reconnect catch-up capability with a span around
+// the replay read.
+func (b tracingBackend) ReplayOutput(ctx context.Context, attachment livev1.Attach, afterSequence uint64) ([]livev1.OutputRecord, error) {
+	replayer, ok := b.Backend.(livev1.OutputReplayer)
+	if !ok {
+		return nil, nil
+	}
+	ctx, span := b.tracer.Start(ctx, "live.replay", trace.WithAttributes(
+		attribute.String("session.id", attachment.SessionID),
+		attribute.Int64("replay.after_sequence", int64(afterSequence)),
+	))
+	defer span.End()
+	records, err := replayer.ReplayOutput(ctx, attachment, afterSequence)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return records, err
+	}
+	span.SetAttributes(attribute.Int("replay.records", len(records)))
+	return records, nil
+}
+
+// WriterSpanExporter writes one JSON object per finished span, for tracing
+// setups without an OTLP endpoint (pipe to a file and inspect with jq).
+type WriterSpanExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterSpanExporter returns an exporter emitting JSON lines to w.
+func NewWriterSpanExporter(w io.Writer) *WriterSpanExporter {
+	return &WriterSpanExporter{enc: json.NewEncoder(w)}
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *WriterSpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, span := range spans {
+		entry := map[string]any{
+			"name":       span.Name(),
+			"traceId":    span.SpanContext().TraceID().String(),
+			"spanId":     span.SpanContext().SpanID().String(),
+			"start":      span.StartTime(),
+			"end":        span.EndTime(),
+			"durationMs": float64(span.EndTime().Sub(span.StartTime()).Microseconds()) / 1000,
+		}
+		if parent := span.Parent(); parent.IsValid() {
+			entry["parentSpanId"] = parent.SpanID().String()
+		}
+		if status := span.Status(); status.Code == codes.Error {
+			entry["error"] = status.Description
+		}
+		attrs := make(map[string]any, len(span.Attributes()))
+		for _, kv := range span.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		if len(attrs) > 0 {
+			entry["attributes"] = attrs
+		}
+		if err := e.enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (e *WriterSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
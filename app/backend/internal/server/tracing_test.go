@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// recordingSpanExporter collects finished span names for assertions.
+type recordingSpanExporter struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (e *recordingSpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, span := range spans {
+		e.names = append(e.names, span.Name())
+	}
+	return nil
+}
+
+func (e *recordingSpanExporter) Shutdown(context.Context) error { return nil }
+
+func (e *recordingSpanExporter) has(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, got := range e.names {
+		if got == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTracingEmitsSpansForAPIAndAttach(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	srv := New(Config{
+		Tracing: TracingConfig{Enabled: true, Exporter: exporter},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+	httpSrv := httptest.NewServer(srv.Handler())
+	defer httpSrv.Close()
+
+	session, err := srv.Manager().CreateSession("traced", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get(httpSrv.URL + "/api/sessions/" + session.ID + "/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn := attachLiveTestConnection(t, ctx, httpSrv.URL, session.ID, "tracer")
+	conn.Close(websocket.StatusNormalClosure, "done")
+
+	// Close flushes the batch processor through the provider shutdown.
+	srv.Close()
+
+	for _, want := range []string{"HTTP GET /api/sessions", "history.read", "live.attach"} {
+		if !exporter.has(want) {
+			t.Fatalf("span %q not exported, got %v", want, exporter.names)
+		}
+	}
+}
+
+func TestTracingDisabledAddsNoMiddleware(t *testing.T) {
+	srv, httpSrv := newReadOnlyTestServer(t)
+	if srv.tracingEnabled {
+		t.Fatal("tracing must be off by default")
+	}
+	resp, err := http.Get(httpSrv.URL + "/api/sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// In-terminal file transfers: when someone runs `rz` or `sz` inside a
+// session, the session core detects the handshake and withholds the binary
+// payload from history (see terminal-go's transfer detection). The endpoints
+// here bridge the actual bytes: POST .../transfer/upload speaks the ZMODEM
+// sender side into the PTY to satisfy a waiting `rz`, and GET
+// .../transfer/download speaks the receiver side to collect what `sz`
+// offers, so the browser moves files over plain HTTP instead of implementing
+// ZMODEM itself. GET .../transfer reports the detected state so the frontend
+// knows when to show the dialog.
+
+// transferTimeout bounds one bridged transfer end to end.
+const transferTimeout = 10 * time.Minute
+
+// transferStreamBuffer is how many output chunks may queue between the PTY
+// read path and the protocol engine before the transfer is declared stalled.
+const transferStreamBuffer = 1024
+
+// sessionTransferStream adapts a session to the io.ReadWriter the ZMODEM
+// engine wants: reads are the session's output chunks (delivered through the
+// manager's typed event subscription), writes go to the PTY as input.
+type sessionTransferStream struct {
+	ctx      context.Context
+	session  *terminal.Session
+	connID   string
+	incoming chan []byte
+	leftover []byte
+	sub      *terminal.EventSubscription
+
+	overflowOnce sync.Once
+	overflow     chan struct{}
+}
+
+func newSessionTransferStream(ctx context.Context, manager *terminal.Manager, session *terminal.Session) *sessionTransferStream {
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	stream := &sessionTransferStream{
+		ctx:      ctx,
+		session:  session,
+		connID:   "transfer-" + hex.EncodeToString(buf),
+		incoming: make(chan []byte, transferStreamBuffer),
+		overflow: make(chan struct{}),
+	}
+	stream.sub = manager.Events().OnData(func(sessionID string, event terminal.TerminalOutputEvent) {
+		if sessionID != session.ID {
+			return
+		}
+		select {
+		case stream.incoming <- event.Data:
+		default:
+			// The callback runs on the PTY read path and must not block;
+			// a bridge that cannot keep up fails the transfer instead.
+			stream.overflowOnce.Do(func() { close(stream.overflow) })
+		}
+	})
+	return stream
+}
+
+func (s *sessionTransferStream) Read(p []byte) (int, error) {
+	if len(s.leftover) == 0 {
+		select {
+		case chunk := <-s.incoming:
+			s.leftover = chunk
+		case <-s.overflow:
+			return 0, errors.New("transfer bridge fell behind session output")
+		case <-s.ctx.Done():
+			return 0, s.ctx.Err()
+		}
+	}
+	n := copy(p, s.leftover)
+	s.leftover = s.leftover[n:]
+	return n, nil
+}
+
+func (s *sessionTransferStream) Write(p []byte) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := s.session.WriteDataWithSource(p, s.connID); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *sessionTransferStream) Close() {
+	s.sub.Cancel()
+}
+
+func (s *Server) handleSessionTransfer(w http.ResponseWriter, r *http.Request, sessionID string, parts []string) {
+	session, ok := s.manager.GetSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	action := ""
+	if len(parts) > 2 {
+		action = parts[2]
+	}
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, session.FileTransfer())
+
+	case "upload":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.readOnly {
+			http.Error(w, "server is read-only", http.StatusForbidden)
+			return
+		}
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if name == "" || strings.ContainsAny(name, "/\x00") {
+			http.Error(w, "name query parameter is required and must be a bare file name", http.StatusBadRequest)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to read payload", http.StatusBadRequest)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), transferTimeout)
+		defer cancel()
+		stream := newSessionTransferStream(ctx, s.manager, session)
+		defer stream.Close()
+		defer session.EndFileTransfer()
+		if err := driveZmodemSend(stream, name, data); err != nil {
+			http.Error(w, fmt.Sprintf("transfer failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"name": name, "bytes": len(data)})
+
+	case "download":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), transferTimeout)
+		defer cancel()
+		stream := newSessionTransferStream(ctx, s.manager, session)
+		defer stream.Close()
+		defer session.EndFileTransfer()
+		name, data, err := driveZmodemReceive(stream)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("transfer failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		if name == "" {
+			name = "download"
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		_, _ = w.Write(data)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
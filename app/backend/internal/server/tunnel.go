@@ -0,0 +1,289 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// An outbound tunnel shares a local floeterm with the public internet from
+// behind NAT: the server dials out to a relay, the relay hands back an HTTPS
+// URL, and inbound requests flow back over the established connection. Two
+// provider styles are supported: "cloudflared" runs the cloudflared binary as
+// a child process, and any other value is treated as a localhost.run-style
+// SSH relay host that is driven with a native reverse-forward client, so no
+// extra binary is needed. The tunnel only moves packets — authentication must
+// already be enabled on the HTTP listener, and callers are expected to refuse
+// to start one otherwise, because the public URL is reachable by anyone who
+// guesses it.
+
+// tunnelURLPattern extracts the public HTTPS URL from provider banners: the
+// boxed trycloudflare.com line cloudflared prints, or the plain URL a
+// localhost.run-style relay writes to the session channel.
+var tunnelURLPattern = regexp.MustCompile(`https://[A-Za-z0-9][A-Za-z0-9.-]*\.[A-Za-z]{2,}(/[^\s"']*)?`)
+
+// tunnelDialTimeout bounds the outbound connection to the relay.
+const tunnelDialTimeout = 10 * time.Second
+
+// Tunnel is a running outbound tunnel. The public URL arrives asynchronously
+// once the provider reports it; Close tears the tunnel down.
+type Tunnel struct {
+	provider string
+	cancel   context.CancelFunc
+
+	deliverOnce sync.Once
+	urlCh       chan string
+	errCh       chan error
+}
+
+// StartTunnel establishes an outbound tunnel from the given provider to the
+// local listen address. The provider is either "cloudflared" or the host (or
+// user@host:port) of a localhost.run-style SSH relay.
+func StartTunnel(ctx context.Context, provider, localAddr string, logger terminal.Logger) (*Tunnel, error) {
+	if provider == "" {
+		return nil, errors.New("tunnel provider is required")
+	}
+	if logger == nil {
+		logger = terminal.NopLogger{}
+	}
+	tunnelCtx, cancel := context.WithCancel(ctx)
+	t := &Tunnel{
+		provider: provider,
+		cancel:   cancel,
+		urlCh:    make(chan string, 1),
+		errCh:    make(chan error, 1),
+	}
+	if provider == "cloudflared" {
+		path, err := exec.LookPath("cloudflared")
+		if err != nil {
+			cancel()
+			return nil, errors.New("cloudflared is not on PATH; install it or use an SSH relay host")
+		}
+		go t.runCloudflared(tunnelCtx, path, localAddr, logger)
+		return t, nil
+	}
+	go t.runSSHRelay(tunnelCtx, provider, localAddr, logger)
+	return t, nil
+}
+
+// URL blocks until the provider reports the public HTTPS URL, the tunnel
+// fails, or the context expires.
+func (t *Tunnel) URL(ctx context.Context) (string, error) {
+	select {
+	case url := <-t.urlCh:
+		return url, nil
+	case err := <-t.errCh:
+		return "", err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close tears the tunnel down; the public URL stops working immediately.
+func (t *Tunnel) Close() {
+	if t != nil && t.cancel != nil {
+		t.cancel()
+	}
+}
+
+func (t *Tunnel) deliverURL(url string) {
+	t.deliverOnce.Do(func() { t.urlCh <- url })
+}
+
+func (t *Tunnel) fail(err error) {
+	t.deliverOnce.Do(func() { t.errCh <- err })
+}
+
+// runCloudflared supervises a cloudflared quick tunnel and scrapes the
+// assigned trycloudflare.com URL from its log output.
+func (t *Tunnel) runCloudflared(ctx context.Context, path, localAddr string, logger terminal.Logger) {
+	cmd := exec.CommandContext(ctx, path, "tunnel", "--no-autoupdate", "--url", "http://"+localAddr)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.fail(fmt.Errorf("failed to start cloudflared: %w", err))
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		t.fail(fmt.Errorf("failed to start cloudflared: %w", err))
+		return
+	}
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		if url := scanTunnelURL(scanner.Text()); url != "" {
+			t.deliverURL(url)
+		}
+	}
+	err = cmd.Wait()
+	if ctx.Err() == nil {
+		if err == nil {
+			err = errors.New("cloudflared exited")
+		}
+		logger.Error("Tunnel process exited", "provider", "cloudflared", "error", err)
+	}
+	t.fail(fmt.Errorf("cloudflared exited: %w", err))
+}
+
+// runSSHRelay drives a localhost.run-style relay: an SSH connection with a
+// reverse forward of remote port 80, plus a session channel whose banner
+// carries the assigned public URL.
+func (t *Tunnel) runSSHRelay(ctx context.Context, endpoint, localAddr string, logger terminal.Logger) {
+	user, hostPort := splitTunnelEndpoint(endpoint)
+	client, err := ssh.Dial("tcp", hostPort, &ssh.ClientConfig{
+		User: user,
+		Auth: tunnelSSHAuth(),
+		// Relay host keys are throwaway by design (localhost.run rotates
+		// them); the fingerprint is logged so a persistent MITM is at least
+		// visible across runs.
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			logger.Info("Tunnel relay host key", "host", hostname, "fingerprint", ssh.FingerprintSHA256(key))
+			return nil
+		},
+		Timeout: tunnelDialTimeout,
+	})
+	if err != nil {
+		t.fail(fmt.Errorf("failed to reach tunnel relay %s: %w", hostPort, err))
+		return
+	}
+	defer client.Close()
+	go func() {
+		<-ctx.Done()
+		client.Close()
+	}()
+
+	listener, err := client.Listen("tcp", "127.0.0.1:80")
+	if err != nil {
+		t.fail(fmt.Errorf("tunnel relay refused the reverse forward: %w", err))
+		return
+	}
+	defer listener.Close()
+
+	// The relay prints the assigned URL on the session channel, the same
+	// place the ssh CLI would show it.
+	go t.scanRelayBanner(client, logger)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Tunnel relay connection lost", "relay", hostPort, "error", err)
+				t.fail(fmt.Errorf("tunnel relay connection lost: %w", err))
+			} else {
+				t.fail(ctx.Err())
+			}
+			return
+		}
+		go proxyTunnelConn(conn, localAddr)
+	}
+}
+
+func (t *Tunnel) scanRelayBanner(client *ssh.Client, logger terminal.Logger) {
+	session, err := client.NewSession()
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		return
+	}
+	if err := session.Shell(); err != nil {
+		return
+	}
+	scanURL := func(reader io.Reader) {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if url := scanTunnelURL(scanner.Text()); url != "" {
+				t.deliverURL(url)
+			}
+		}
+	}
+	go scanURL(stderr)
+	scanURL(stdout)
+}
+
+// proxyTunnelConn splices one relayed connection onto the local HTTP
+// listener.
+func proxyTunnelConn(remote net.Conn, localAddr string) {
+	defer remote.Close()
+	local, err := net.DialTimeout("tcp", localAddr, tunnelDialTimeout)
+	if err != nil {
+		return
+	}
+	defer local.Close()
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(local, remote)
+		close(done)
+	}()
+	_, _ = io.Copy(remote, local)
+	<-done
+}
+
+// tunnelSSHAuth offers the local SSH agent when one is available; relays like
+// localhost.run accept any key (or none) for anonymous tunnels, so an empty
+// method list is fine too.
+func tunnelSSHAuth() []ssh.AuthMethod {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil
+	}
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}
+}
+
+// splitTunnelEndpoint parses "host", "host:port" or "user@host:port",
+// defaulting to the anonymous localhost.run user and port 22.
+func splitTunnelEndpoint(endpoint string) (user, hostPort string) {
+	user = "nokey"
+	hostPort = endpoint
+	if at := strings.LastIndex(hostPort, "@"); at >= 0 {
+		if at > 0 {
+			user = hostPort[:at]
+		}
+		hostPort = hostPort[at+1:]
+	}
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		hostPort = net.JoinHostPort(hostPort, "22")
+	}
+	return user, hostPort
+}
+
+// scanTunnelURL extracts the first public HTTPS URL from a provider output
+// line, or returns empty.
+func scanTunnelURL(line string) string {
+	return tunnelURLPattern.FindString(line)
+}
+
+// TunnelTargetAddr rewrites a listen address into one the tunnel can dial:
+// empty and wildcard hosts become loopback.
+func TunnelTargetAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
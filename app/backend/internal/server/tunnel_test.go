@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+func TestScanTunnelURL(t *testing.T) {
+	cases := []struct {
+		line string
+		want string
+	}{
+		{"|  https://quick-brown-fox.trycloudflare.com  |", "https://quick-brown-fox.trycloudflare.com"},
+		{"abc123 tunneled with tls termination, https://abc123.lhr.life", "https://abc123.lhr.life"},
+		{"INF Starting tunnel tunnelID=deadbeef", ""},
+		{"serving at http://127.0.0.1:8080", ""},
+	}
+	for _, c := range cases {
+		if got := scanTunnelURL(c.line); got != c.want {
+			t.Fatalf("scanTunnelURL(%q) = %q, want %q", c.line, got, c.want)
+		}
+	}
+}
+
+func TestSplitTunnelEndpoint(t *testing.T) {
+	cases := []struct {
+		endpoint string
+		user     string
+		hostPort string
+	}{
+		{"localhost.run", "nokey", "localhost.run:22"},
+		{"relay.example.com:2222", "nokey", "relay.example.com:2222"},
+		{"dev@relay.example.com", "dev", "relay.example.com:22"},
+	}
+	for _, c := range cases {
+		user, hostPort := splitTunnelEndpoint(c.endpoint)
+		if user != c.user || hostPort != c.hostPort {
+			t.Fatalf("splitTunnelEndpoint(%q) = %q, %q, want %q, %q", c.endpoint, user, hostPort, c.user, c.hostPort)
+		}
+	}
+}
+
+func TestTunnelTargetAddr(t *testing.T) {
+	if got := TunnelTargetAddr(":8080"); got != "127.0.0.1:8080" {
+		t.Fatalf("TunnelTargetAddr(\":8080\") = %q", got)
+	}
+	if got := TunnelTargetAddr("192.168.1.5:8080"); got != "192.168.1.5:8080" {
+		t.Fatalf("TunnelTargetAddr kept host = %q", got)
+	}
+}
+
+// fakeRelay speaks the relay side of a localhost.run-style tunnel: it accepts
+// one SSH connection, grants the reverse forward, announces a public URL on
+// the session channel, then opens one forwarded connection and checks that
+// data round-trips through the local target.
+func fakeRelay(t *testing.T, listener net.Listener, hostSigner ssh.Signer) <-chan error {
+	t.Helper()
+	result := make(chan error, 1)
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostSigner)
+	go func() {
+		result <- func() error {
+			nConn, err := listener.Accept()
+			if err != nil {
+				return err
+			}
+			conn, chans, reqs, err := ssh.NewServerConn(nConn, config)
+			if err != nil {
+				return fmt.Errorf("handshake: %w", err)
+			}
+			defer conn.Close()
+
+			forwarded := make(chan struct{})
+			go func() {
+				granted := false
+				for req := range reqs {
+					if req.Type == "tcpip-forward" && !granted {
+						granted = true
+						_ = req.Reply(true, nil)
+						close(forwarded)
+						continue
+					}
+					if req.WantReply {
+						_ = req.Reply(false, nil)
+					}
+				}
+			}()
+
+			var session ssh.Channel
+			for newChannel := range chans {
+				if newChannel.ChannelType() != "session" {
+					_ = newChannel.Reject(ssh.UnknownChannelType, "session only")
+					continue
+				}
+				channel, channelReqs, err := newChannel.Accept()
+				if err != nil {
+					return err
+				}
+				go func() {
+					for req := range channelReqs {
+						if req.WantReply {
+							_ = req.Reply(true, nil)
+						}
+					}
+				}()
+				session = channel
+				break
+			}
+			go func() {
+				for newChannel := range chans {
+					_ = newChannel.Reject(ssh.UnknownChannelType, "done")
+				}
+			}()
+
+			<-forwarded
+			if _, err := fmt.Fprintf(session, "abc123 tunneled with tls termination, https://abc123.lhr.life\r\n"); err != nil {
+				return err
+			}
+
+			payload := ssh.Marshal(struct {
+				Addr       string
+				Port       uint32
+				OriginAddr string
+				OriginPort uint32
+			}{"127.0.0.1", 80, "203.0.113.9", 44321})
+			forward, forwardReqs, err := conn.OpenChannel("forwarded-tcpip", payload)
+			if err != nil {
+				return fmt.Errorf("open forwarded channel: %w", err)
+			}
+			go ssh.DiscardRequests(forwardReqs)
+			defer forward.Close()
+			if _, err := forward.Write([]byte("ping\n")); err != nil {
+				return err
+			}
+			response, err := bufio.NewReader(forward).ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("read proxied response: %w", err)
+			}
+			if response != "pong:ping\n" {
+				return fmt.Errorf("proxied response = %q", response)
+			}
+			return nil
+		}()
+	}()
+	return result
+}
+
+func TestSSHRelayTunnelForwardsAndReportsURL(t *testing.T) {
+	hostSigner, _, _ := testSSHKeyPair(t)
+	relayListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer relayListener.Close()
+
+	// The local target stands in for the floeterm HTTP listener.
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		conn, err := target.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("pong:" + line))
+	}()
+
+	relayResult := fakeRelay(t, relayListener, hostSigner)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	tunnel, err := StartTunnel(ctx, "nokey@"+relayListener.Addr().String(), target.Addr().String(), terminal.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tunnel.Close()
+
+	url, err := tunnel.URL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://abc123.lhr.life" {
+		t.Fatalf("url = %q", url)
+	}
+
+	select {
+	case err := <-relayResult:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-ctx.Done():
+		t.Fatal("relay round-trip timed out")
+	}
+}
+
+func TestStartTunnelRequiresProvider(t *testing.T) {
+	if _, err := StartTunnel(context.Background(), "", "127.0.0.1:8080", nil); err == nil {
+		t.Fatal("empty provider was accepted")
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+// Webhook event names. A webhook with an empty filter receives all of them.
+const (
+	webhookSessionCreated  = "session.created"
+	webhookSessionClosed   = "session.closed"
+	webhookSessionExited   = "session.exited"
+	webhookCommandFinished = "command.finished"
+	webhookActivity        = "activity"
+	webhookBell            = "bell"
+)
+
+const (
+	webhookQueueSize      = 256
+	webhookDeliverTimeout = 10 * time.Second
+	// webhookBellQuiet suppresses repeat bell deliveries per session so a
+	// BEL flood (e.g. catting a binary) does not hammer the endpoint.
+	webhookBellQuiet = 5 * time.Second
+)
+
+// WebhookConfig describes one webhook endpoint. Events filters delivery to
+// the named event types ("session.created", "session.closed",
+// "session.exited", "command.finished", "activity", "bell"); an empty list
+// receives everything. When Secret is set each request carries an
+// X-Floeterm-Signature header with the hex HMAC-SHA256 of the body.
+type WebhookConfig struct {
+	URL    string
+	Secret string
+	Events []string
+}
+
+func (c WebhookConfig) wants(event string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, name := range c.Events {
+		if name == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookEvent is the JSON body posted to webhook endpoints. Fields beyond
+// the first three are populated per event type.
+type webhookEvent struct {
+	Event       string `json:"event"`
+	SessionID   string `json:"sessionId"`
+	TimestampMs int64  `json:"timestampMs"`
+
+	Name              string `json:"name,omitempty"`
+	WorkingDir        string `json:"workingDir,omitempty"`
+	ExitCode          *int   `json:"exitCode,omitempty"`
+	TerminationReason string `json:"terminationReason,omitempty"`
+	Command           string `json:"command,omitempty"`
+	Phase             string `json:"phase,omitempty"`
+}
+
+type webhookDelivery struct {
+	url    string
+	secret string
+	event  string
+	body   []byte
+}
+
+// webhookDispatcher posts terminal events to configured endpoints. Delivery
+// is asynchronous on a single worker so a slow or dead endpoint never stalls
+// the PTY reader; when the queue is full events are dropped and counted, the
+// same stance the SSE fanout takes towards slow subscribers.
+type webhookDispatcher struct {
+	hooks   []WebhookConfig
+	client  *http.Client
+	logger  terminal.Logger
+	metrics *serverMetrics
+
+	queue chan webhookDelivery
+	wg    sync.WaitGroup
+
+	mu           sync.Mutex
+	commandPhase map[string]terminal.ForegroundCommandPhase
+	lastBell     map[string]time.Time
+}
+
+func newWebhookDispatcher(hooks []WebhookConfig, logger terminal.Logger, metrics *serverMetrics) *webhookDispatcher {
+	d := &webhookDispatcher{
+		hooks:        hooks,
+		client:       &http.Client{Timeout: webhookDeliverTimeout},
+		logger:       logger,
+		metrics:      metrics,
+		queue:        make(chan webhookDelivery, webhookQueueSize),
+		commandPhase: make(map[string]terminal.ForegroundCommandPhase),
+		lastBell:     make(map[string]time.Time),
+	}
+	d.wg.Add(1)
+	go d.run()
+	return d
+}
+
+func (d *webhookDispatcher) stop() {
+	close(d.queue)
+	d.wg.Wait()
+}
+
+func (d *webhookDispatcher) run() {
+	defer d.wg.Done()
+	for delivery := range d.queue {
+		d.deliver(delivery)
+	}
+}
+
+func (d *webhookDispatcher) deliver(delivery webhookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookDeliverTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		d.metrics.IncCounter(metricWebhookFailures, 1)
+		d.logger.Warn("Invalid webhook request", "url", delivery.url, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Floeterm-Event", delivery.event)
+	if delivery.secret != "" {
+		mac := hmac.New(sha256.New, []byte(delivery.secret))
+		mac.Write(delivery.body)
+		req.Header.Set("X-Floeterm-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.metrics.IncCounter(metricWebhookFailures, 1)
+		d.logger.Warn("Webhook delivery failed", "url", delivery.url, "event", delivery.event, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		d.metrics.IncCounter(metricWebhookFailures, 1)
+		d.logger.Warn("Webhook endpoint rejected event", "url", delivery.url, "event", delivery.event, "status", resp.StatusCode)
+		return
+	}
+	d.metrics.IncCounter(metricWebhookDeliveries, 1)
+}
+
+// wantsAny reports whether any configured hook subscribes to the event, so
+// hot paths can skip payload construction entirely.
+func (d *webhookDispatcher) wantsAny(event string) bool {
+	for _, hook := range d.hooks {
+		if hook.wants(event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *webhookDispatcher) emit(event webhookEvent) {
+	if event.TimestampMs == 0 {
+		event.TimestampMs = time.Now().UnixMilli()
+	}
+	var body []byte
+	for _, hook := range d.hooks {
+		if !hook.wants(event.Event) {
+			continue
+		}
+		if body == nil {
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			body = encoded
+		}
+		select {
+		case d.queue <- webhookDelivery{url: hook.URL, secret: hook.Secret, event: event.Event, body: body}:
+		default:
+			d.metrics.IncCounter(metricWebhookFailures, 1)
+			d.logger.Warn("Webhook queue full, dropping event", "url", hook.URL, "event", event.Event)
+		}
+	}
+}
+
+func (d *webhookDispatcher) sessionCreated(session *terminal.Session) {
+	d.emit(webhookEvent{
+		Event:       webhookSessionCreated,
+		SessionID:   session.ID,
+		TimestampMs: session.GetCreatedAt().UnixMilli(),
+		Name:        session.GetName(),
+		WorkingDir:  session.GetWorkingDir(),
+	})
+}
+
+func (d *webhookDispatcher) sessionClosed(sessionID string, session *terminal.Session) {
+	d.mu.Lock()
+	delete(d.commandPhase, sessionID)
+	delete(d.lastBell, sessionID)
+	d.mu.Unlock()
+
+	closed := webhookEvent{Event: webhookSessionClosed, SessionID: sessionID}
+	var exit *terminal.TerminalExitInfo
+	if session != nil {
+		closed.Name = session.GetName()
+		exit = session.LastExit()
+	}
+	if exit != nil {
+		exitCode := exit.ExitCode
+		closed.ExitCode = &exitCode
+		closed.TerminationReason = string(exit.Reason)
+	}
+	d.emit(closed)
+	if exit != nil {
+		exited := closed
+		exited.Event = webhookSessionExited
+		d.emit(exited)
+	}
+}
+
+// commandChanged tracks the shell-reported foreground command phase and
+// fires command.finished on the running -> idle transition.
+func (d *webhookDispatcher) commandChanged(sessionID string, info terminal.TerminalForegroundCommandInfo) {
+	d.mu.Lock()
+	previous := d.commandPhase[sessionID]
+	d.commandPhase[sessionID] = info.Phase
+	d.mu.Unlock()
+
+	if previous == terminal.ForegroundCommandRunning && info.Phase == terminal.ForegroundCommandIdle {
+		d.emit(webhookEvent{
+			Event:     webhookCommandFinished,
+			SessionID: sessionID,
+			Command:   info.DisplayName,
+		})
+	}
+}
+
+func (d *webhookDispatcher) activityChanged(sessionID string, info terminal.TerminalOutputActivityInfo) {
+	d.emit(webhookEvent{
+		Event:       webhookActivity,
+		SessionID:   sessionID,
+		TimestampMs: info.UpdatedAt,
+		Phase:       string(info.Phase),
+	})
+}
+
+// scanBell runs on the PTY broadcast path; it only looks for BEL when a hook
+// subscribes to bell events, and rate-limits deliveries per session.
+func (d *webhookDispatcher) scanBell(sessionID string, data []byte) {
+	if !d.wantsAny(webhookBell) || bytes.IndexByte(data, 0x07) < 0 {
+		return
+	}
+	now := time.Now()
+	d.mu.Lock()
+	if now.Sub(d.lastBell[sessionID]) < webhookBellQuiet {
+		d.mu.Unlock()
+		return
+	}
+	d.lastBell[sessionID] = now
+	d.mu.Unlock()
+	d.emit(webhookEvent{Event: webhookBell, SessionID: sessionID})
+}
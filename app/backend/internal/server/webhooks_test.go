@@ -0,0 +1,100 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	terminal "github.com/floegence/floeterm/terminal-go"
+)
+
+type receivedWebhook struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+func TestWebhooksDeliverSignedLifecycleEvents(t *testing.T) {
+	var mu sync.Mutex
+	var received []receivedWebhook
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedWebhook{
+			event:     r.Header.Get("X-Floeterm-Event"),
+			signature: r.Header.Get("X-Floeterm-Signature"),
+			body:      body,
+		})
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	secret := "hook-secret"
+	srv := New(Config{
+		Webhooks: []WebhookConfig{{URL: endpoint.URL, Secret: secret}},
+		ManagerConfig: terminal.ManagerConfig{
+			Logger:            terminal.NopLogger{},
+			ShellResolver:     fixedShellResolver{shell: "/bin/sh"},
+			ShellArgsProvider: fixedShellArgsProvider{args: []string{"-c", "cat"}},
+		},
+	})
+
+	session, err := srv.Manager().CreateSession("hooked", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Manager().DeleteSession(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Close stops the dispatcher after draining queued deliveries.
+	srv.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	events := map[string]receivedWebhook{}
+	for _, hook := range received {
+		events[hook.event] = hook
+	}
+	created, ok := events[webhookSessionCreated]
+	if !ok {
+		t.Fatalf("no session.created delivery, got %v", events)
+	}
+	if _, ok := events[webhookSessionClosed]; !ok {
+		t.Fatalf("no session.closed delivery, got %v", events)
+	}
+
+	var payload webhookEvent
+	if err := json.Unmarshal(created.body, &payload); err != nil {
+		t.Fatalf("invalid created payload: %v", err)
+	}
+	if payload.SessionID != session.ID || payload.Name != "hooked" {
+		t.Fatalf("created payload = %+v", payload)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(created.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if created.signature != want {
+		t.Fatalf("signature = %q, want %q", created.signature, want)
+	}
+}
+
+func TestWebhookEventFilter(t *testing.T) {
+	hook := WebhookConfig{Events: []string{webhookBell}}
+	if !hook.wants(webhookBell) {
+		t.Fatal("filtered hook must receive listed events")
+	}
+	if hook.wants(webhookSessionCreated) {
+		t.Fatal("filtered hook must not receive unlisted events")
+	}
+	if !(WebhookConfig{}).wants(webhookSessionCreated) {
+		t.Fatal("empty filter must receive everything")
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/coder/websocket"
+)
+
+// The /ws/webrtc endpoint is a signaling relay for peer-to-peer terminal
+// transport: two browsers viewing the same session (e.g. a sharer and a
+// viewer behind different NATs) exchange SDP offers, answers and ICE
+// candidates through it, then move terminal data onto a WebRTC data channel
+// directly between themselves. The server never terminates the data channel —
+// it only forwards opaque signaling payloads within a room — so the existing
+// websocket transport remains the fallback whenever the peer connection
+// cannot be established. The browser halves of the transport live in
+// app/web/src/webrtcTransport.ts: the session owner bridges its
+// authenticated /ws byte stream onto the channel, viewers consume the
+// channel as a byte stream.
+
+// signalMessage is one message on the signaling relay. Client-sent messages
+// carry To (a peer id from a peer-joined notice, or empty to broadcast to the
+// rest of the room); relayed messages carry From. Payload is opaque to the
+// server: SDP, ICE candidates, or whatever else the peers agree on.
+type signalMessage struct {
+	Type    string          `json:"type"`
+	From    string          `json:"from,omitempty"`
+	To      string          `json:"to,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Relay message types the server itself emits.
+const (
+	signalWelcome    = "welcome"
+	signalPeerJoined = "peer-joined"
+	signalPeerLeft   = "peer-left"
+)
+
+const signalPeerBuffer = 64
+
+type signalPeer struct {
+	id       string
+	messages chan signalMessage
+}
+
+// signalRooms groups signaling peers by room name (by convention the session
+// id, but the server does not care).
+type signalRooms struct {
+	mu    sync.Mutex
+	rooms map[string]map[*signalPeer]struct{}
+}
+
+func newSignalRooms() *signalRooms {
+	return &signalRooms{rooms: make(map[string]map[*signalPeer]struct{})}
+}
+
+func (sr *signalRooms) join(room string, peer *signalPeer) {
+	sr.mu.Lock()
+	if sr.rooms[room] == nil {
+		sr.rooms[room] = make(map[*signalPeer]struct{})
+	}
+	sr.rooms[room][peer] = struct{}{}
+	sr.mu.Unlock()
+	sr.relay(room, peer, signalMessage{Type: signalPeerJoined, From: peer.id})
+}
+
+func (sr *signalRooms) leave(room string, peer *signalPeer) {
+	sr.mu.Lock()
+	if peers := sr.rooms[room]; peers != nil {
+		delete(peers, peer)
+		if len(peers) == 0 {
+			delete(sr.rooms, room)
+		}
+	}
+	sr.mu.Unlock()
+	sr.relay(room, peer, signalMessage{Type: signalPeerLeft, From: peer.id})
+}
+
+// relay forwards a message from the sender to the addressed peer, or to every
+// other peer in the room when To is empty. Slow receivers drop signaling
+// messages; peers re-negotiate rather than stall the relay.
+func (sr *signalRooms) relay(room string, sender *signalPeer, message signalMessage) {
+	message.From = sender.id
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	for peer := range sr.rooms[room] {
+		if peer == sender {
+			continue
+		}
+		if message.To != "" && peer.id != message.To {
+			continue
+		}
+		select {
+		case peer.messages <- message:
+		default:
+		}
+	}
+}
+
+// handleWebRTCSignal relays signaling messages within the room named by the
+// ?room query parameter.
+func (s *Server) handleWebRTCSignal(w http.ResponseWriter, r *http.Request) {
+	room := r.URL.Query().Get("room")
+	if room == "" {
+		http.Error(w, "room query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	// When the room names a live session, joining requires access to that
+	// session: knowing an id must not be enough to exchange SDP and ICE with
+	// its owner's browsers. Responding 404 avoids leaking session IDs across
+	// tenants, matching the REST handlers.
+	if session, ok := s.manager.GetSession(room); ok {
+		if !identityFromContext(r.Context()).canAccess(session.Owner) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		OriginPatterns:       s.allowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	s.startWSKeepalive(ctx, conn)
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		conn.Close(websocket.StatusInternalError, "failed to mint peer id")
+		return
+	}
+	peer := &signalPeer{
+		id:       "peer-" + hex.EncodeToString(buf),
+		messages: make(chan signalMessage, signalPeerBuffer),
+	}
+	welcome, _ := json.Marshal(signalMessage{Type: signalWelcome, To: peer.id})
+	if conn.Write(ctx, websocket.MessageText, welcome) != nil {
+		return
+	}
+	s.signalRooms.join(room, peer)
+	defer s.signalRooms.leave(room, peer)
+
+	// Writer: relayed messages out to this peer.
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message := <-peer.messages:
+				payload, err := json.Marshal(message)
+				if err != nil {
+					continue
+				}
+				if conn.Write(ctx, websocket.MessageText, payload) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// Reader: client messages into the room.
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			break
+		}
+		var message signalMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			continue
+		}
+		s.signalRooms.relay(room, peer, message)
+	}
+	cancel()
+	<-writerDone
+}
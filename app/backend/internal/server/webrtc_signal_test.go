@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func readSignalMessage(t *testing.T, ctx context.Context, conn *websocket.Conn) signalMessage {
+	t.Helper()
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message signalMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatalf("invalid signal message %q: %v", data, err)
+	}
+	return message
+}
+
+func TestWebRTCSignalRelaysBetweenRoomPeers(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/ws/webrtc?room=session-1"
+
+	sharer, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sharer.Close(websocket.StatusNormalClosure, "")
+	welcome := readSignalMessage(t, ctx, sharer)
+	if welcome.Type != signalWelcome || welcome.To == "" {
+		t.Fatalf("welcome = %+v", welcome)
+	}
+
+	viewer, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer viewer.Close(websocket.StatusNormalClosure, "")
+	viewerWelcome := readSignalMessage(t, ctx, viewer)
+	viewerID := viewerWelcome.To
+
+	// The sharer learns about the viewer joining...
+	joined := readSignalMessage(t, ctx, sharer)
+	if joined.Type != signalPeerJoined || joined.From != viewerID {
+		t.Fatalf("peer-joined = %+v", joined)
+	}
+
+	// ...and can address an offer directly to it.
+	offer, _ := json.Marshal(signalMessage{Type: "offer", To: viewerID, Payload: json.RawMessage(`{"sdp":"v=0"}`)})
+	if err := sharer.Write(ctx, websocket.MessageText, offer); err != nil {
+		t.Fatal(err)
+	}
+	relayed := readSignalMessage(t, ctx, viewer)
+	if relayed.Type != "offer" || relayed.From != welcome.To || string(relayed.Payload) != `{"sdp":"v=0"}` {
+		t.Fatalf("relayed offer = %+v", relayed)
+	}
+
+	// Peers in other rooms hear nothing; the viewer leaving notifies the room.
+	viewer.Close(websocket.StatusNormalClosure, "")
+	left := readSignalMessage(t, ctx, sharer)
+	if left.Type != signalPeerLeft || left.From != viewerID {
+		t.Fatalf("peer-left = %+v", left)
+	}
+}
+
+func TestWebRTCSignalEnforcesSessionOwnership(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{
+		AuthUsers: []AuthUser{
+			{Name: "alice", Token: "alice-token"},
+			{Name: "bob", Token: "bob-token"},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequest(http.MethodPost, httpSrv.URL+"/api/sessions", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer alice-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	dial := func(room, token string) (*websocket.Conn, *http.Response, error) {
+		t.Helper()
+		return websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/webrtc?room="+room+"&token="+token, nil)
+	}
+
+	// bob knows the session id but does not own the session, so he cannot
+	// join its signaling room; 404 matches the REST handlers' stance on
+	// foreign session IDs.
+	if _, resp, err := dial(created.ID, "bob-token"); err == nil {
+		t.Fatal("foreign user joined the session's signaling room")
+	} else if resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("response = %+v, want 404", resp)
+	}
+
+	// The owner joins fine.
+	conn, _, err := dial(created.ID, "alice-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if welcome := readSignalMessage(t, ctx, conn); welcome.Type != signalWelcome {
+		t.Fatalf("welcome = %+v", welcome)
+	}
+
+	// Rooms that do not name a session carry no session data and stay open
+	// to any authenticated caller.
+	adHoc, _, err := dial("ad-hoc-room", "bob-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adHoc.Close(websocket.StatusNormalClosure, "")
+	if welcome := readSignalMessage(t, ctx, adHoc); welcome.Type != signalWelcome {
+		t.Fatalf("ad-hoc welcome = %+v", welcome)
+	}
+}
+
+func TestWebRTCSignalRequiresRoom(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, resp, err := websocket.Dial(ctx, "ws"+httpSrv.URL[len("http"):]+"/ws/webrtc", nil)
+	if err == nil {
+		t.Fatal("dial without room succeeded")
+	}
+	if resp == nil || resp.StatusCode != 400 {
+		t.Fatalf("response = %+v, want 400", resp)
+	}
+}
@@ -1,19 +1,109 @@
 package server
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/coder/websocket"
 )
 
+const (
+	defaultWSPingInterval = 30 * time.Second
+	defaultWSPingTimeout  = 10 * time.Second
+)
+
+// wsSubprotocolLiveV1 names the current livev1 binary framing on /ws.
+const wsSubprotocolLiveV1 = "floeterm.v1"
+
+// wsSubprotocols lists the live terminal protocol revisions this server
+// speaks, newest first. When the framing changes, the new revision is
+// appended here as "floeterm.v2" and negotiated per connection, so updated
+// clients get the new frames while v1 clients keep working. Clients that
+// send no Sec-WebSocket-Protocol header get v1 framing for compatibility.
+var wsSubprotocols = []string{wsSubprotocolLiveV1}
+
+// wsKeepalive pings the peer at interval and invokes onDead when a pong does
+// not arrive within timeout, then returns. Half-open connections (laptop lid
+// closed, mobile network drop) never answer, so this bounds how long a ghost
+// connection can linger.
+func wsKeepalive(ctx context.Context, ping func(context.Context) error, interval, timeout time.Duration, onDead func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := ping(pingCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				onDead()
+			}
+			return
+		}
+	}
+}
+
+// startWSKeepalive runs wsKeepalive for a websocket connection. Closing the
+// connection unblocks its read loop, which detaches the live attachment and
+// runs RemoveConnection instead of leaving the ghost holding the
+// minimum-size resize.
+func (s *Server) startWSKeepalive(ctx context.Context, conn *websocket.Conn) {
+	if s.wsPingInterval <= 0 {
+		return
+	}
+	go wsKeepalive(ctx, conn.Ping, s.wsPingInterval, s.wsPingTimeout, func() {
+		s.logger.Debug("websocket keepalive timed out, closing connection")
+		_ = conn.CloseNow()
+	})
+}
+
 func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "":
+	case "raw":
+		s.handleRawWS(w, r)
+		return
+	case "ttyd":
+		s.handleTTYDWS(w, r)
+		return
+	default:
+		http.Error(w, "unknown mode: "+mode, http.StatusBadRequest)
+		return
+	}
+
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
 	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-		CompressionMode: websocket.CompressionDisabled,
+		Subprotocols:         wsSubprotocols,
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		// Accept rejects cross-origin handshakes by default; OriginPatterns
+		// widens that to operator-approved hosts only.
+		OriginPatterns: s.allowedOrigins,
 	})
 	if err != nil {
 		return
 	}
-	conn.SetReadLimit(8 * 1024 * 1024)
+	if r.Header.Get("Sec-WebSocket-Protocol") != "" && conn.Subprotocol() == "" {
+		// The client only offered revisions this server does not speak. Fail
+		// the handshake loudly instead of feeding it frames it cannot parse.
+		conn.Close(websocket.StatusPolicyViolation, "unsupported protocol version")
+		return
+	}
+	conn.SetReadLimit(s.wsReadLimit(defaultWSReadLimitBytes))
+	s.startWSKeepalive(r.Context(), conn)
 	stream := websocket.NetConn(r.Context(), conn, websocket.MessageBinary)
 	if err := s.live.Serve(r.Context(), stream); err != nil {
 		s.logger.Debug("terminal live websocket closed", "error", err)
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/coder/websocket"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// The /gotty/{sessionID}/ prefix mirrors gotty's URL scheme so scripts and
+// client libraries written for gotty (gotty-client and friends) work against
+// a floeterm backend unchanged: {base}/ws speaks gotty's webtty protocol and
+// {base}/auth_token.js serves the token snippet those clients fetch first.
+// Unlike gotty, the base path selects which session to attach to.
+//
+// webtty framing: client frames are prefixed with '1' (raw input), '2'
+// (ping) or '3' (resize JSON); server frames with '1' (base64 output), '2'
+// (pong) or '3' (window title). The client's first message is a JSON blob
+// {"Arguments": ..., "AuthToken": ...} checked against the same credentials
+// the Authorization header accepts.
+
+const (
+	gottyCmdClientInput  = '1'
+	gottyCmdClientPing   = '2'
+	gottyCmdClientResize = '3'
+
+	gottyCmdOutput      = '1'
+	gottyCmdPong        = '2'
+	gottyCmdWindowTitle = '3'
+)
+
+// gottySubprotocol is the subprotocol gotty clients offer on the handshake.
+const gottySubprotocol = "webtty"
+
+type gottyInitMessage struct {
+	Arguments string `json:"Arguments"`
+	AuthToken string `json:"AuthToken"`
+}
+
+type gottyResizeMessage struct {
+	Columns uint32 `json:"columns"`
+	Rows    uint32 `json:"rows"`
+}
+
+func (s *Server) handleGotty(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/gotty/")
+	sessionID, endpoint, ok := strings.Cut(rest, "/")
+	if !ok || sessionID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	switch endpoint {
+	case "ws":
+		s.handleGottyWS(w, r, sessionID)
+	case "auth_token.js":
+		// gotty clients fetch this snippet and echo the token back in their
+		// init message. It only reflects the credential the caller already
+		// presented on this request, so nothing is leaked.
+		w.Header().Set("Content-Type", "application/javascript")
+		_, _ = w.Write([]byte("var gotty_auth_token = '" + requestToken(r) + "';\n"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleGottyWS(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.authErr != nil {
+		http.Error(w, "authentication misconfigured", http.StatusServiceUnavailable)
+		return
+	}
+
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:         []string{gottySubprotocol},
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		OriginPatterns:       s.allowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(s.wsReadLimit(defaultWSReadLimitBytes))
+	ctx := r.Context()
+	s.startWSKeepalive(ctx, conn)
+
+	// The init message carries the credential; nothing else is processed
+	// before it passes.
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return
+	}
+	var init gottyInitMessage
+	if json.Unmarshal(data, &init) != nil {
+		conn.Close(websocket.StatusPolicyViolation, "init message required")
+		return
+	}
+	id, ok := s.authenticateInBandToken(init.AuthToken)
+	if !ok {
+		conn.Close(websocket.StatusPolicyViolation, "invalid token")
+		return
+	}
+	ctx = withIdentity(ctx, id)
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		conn.Close(websocket.StatusInternalError, "failed to mint connection id")
+		return
+	}
+	attachment := livev1.Attach{
+		AttachGeneration: 1,
+		// gotty has no size in its init message; the client sends a resize
+		// immediately after connecting.
+		Cols:         80,
+		Rows:         24,
+		SessionID:    sessionID,
+		ConnectionID: "gotty-" + hex.EncodeToString(buf),
+	}
+	_, detach, err := s.liveBackend.Attach(ctx, attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			encoded := base64.StdEncoding.EncodeToString(record.Data)
+			return conn.Write(ctx, websocket.MessageText, append([]byte{gottyCmdOutput}, encoded...)) == nil
+		},
+		// webtty has no effective-geometry frame; clients keep their own size.
+		OnGeometry: func(livev1.EffectiveGeometry) bool { return true },
+		OnSessionClosed: func() {
+			_ = conn.Close(websocket.StatusNormalClosure, "session closed")
+		},
+		OnSuperseded: func() {
+			_ = conn.Close(websocket.StatusPolicyViolation, "attachment superseded")
+		},
+	})
+	if err != nil {
+		conn.Close(websocket.StatusPolicyViolation, rawWSAttachError(err))
+		return
+	}
+	defer detach()
+
+	if session, ok := s.manager.GetSession(sessionID); ok {
+		_ = conn.Write(ctx, websocket.MessageText, append([]byte{gottyCmdWindowTitle}, session.GetName()...))
+	}
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		if len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case gottyCmdClientInput:
+			if len(data) == 1 {
+				continue
+			}
+			if err := s.liveBackend.WriteInput(ctx, attachment, livev1.Input{Data: data[1:]}); err != nil {
+				conn.Close(websocket.StatusInternalError, "terminal input failed")
+				return
+			}
+		case gottyCmdClientPing:
+			if conn.Write(ctx, websocket.MessageText, []byte{gottyCmdPong}) != nil {
+				return
+			}
+		case gottyCmdClientResize:
+			var resize gottyResizeMessage
+			if json.Unmarshal(data[1:], &resize) != nil || resize.Columns == 0 || resize.Rows == 0 {
+				conn.Close(websocket.StatusPolicyViolation, "invalid resize message")
+				return
+			}
+			if _, err := s.liveBackend.Resize(ctx, attachment, livev1.Resize{Cols: resize.Columns, Rows: resize.Rows}); err != nil {
+				conn.Close(websocket.StatusInternalError, "terminal resize failed")
+				return
+			}
+		default:
+			conn.Close(websocket.StatusPolicyViolation, "unknown command")
+			return
+		}
+	}
+}
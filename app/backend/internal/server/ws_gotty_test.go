@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGottyWebSocketSpeaksWebttyProtocol(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	session := createTestSession(t, httpSrv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/gotty/" + session.ID + "/ws"
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		Subprotocols: []string{gottySubprotocol},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if got := conn.Subprotocol(); got != gottySubprotocol {
+		t.Fatalf("negotiated subprotocol = %q, want %q", got, gottySubprotocol)
+	}
+
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`{"Arguments":"","AuthToken":""}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, title, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(title) == 0 || title[0] != gottyCmdWindowTitle || string(title[1:]) != session.Name {
+		t.Fatalf("title frame = %q", title)
+	}
+
+	// Pings get pongs.
+	if err := conn.Write(ctx, websocket.MessageText, []byte{gottyCmdClientPing}); err != nil {
+		t.Fatal(err)
+	}
+	if _, pong, err := conn.Read(ctx); err != nil || len(pong) != 1 || pong[0] != gottyCmdPong {
+		t.Fatalf("pong frame = %q err=%v", pong, err)
+	}
+
+	// Output frames are base64-encoded; the test shell is cat.
+	if err := conn.Write(ctx, websocket.MessageText, []byte("1gotty hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	var output []byte
+	for !bytes.Contains(output, []byte("gotty hello")) {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("read: %v (output so far %q)", err, output)
+		}
+		if len(data) == 0 || data[0] != gottyCmdOutput {
+			t.Fatalf("unexpected frame %q", data)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(data[1:]))
+		if err != nil {
+			t.Fatalf("output frame is not base64: %v", err)
+		}
+		output = append(output, decoded...)
+	}
+
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`3{"columns":90,"rows":25}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGottyWebSocketFailsClosedWithoutTokenCredential(t *testing.T) {
+	// Like the ttyd path, /gotty/{id}/ws bypasses the auth middleware and
+	// checks its credential in-band. With only basic auth configured there is
+	// no token to check, so every init message must be rejected.
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := newAuthTestServer(t, Config{
+		BasicAuthUsername:     "admin",
+		BasicAuthPasswordHash: string(hash),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/gotty/any-session/ws"
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		Subprotocols: []string{gottySubprotocol},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	if err := conn.Write(ctx, websocket.MessageText, []byte(`{"Arguments":"","AuthToken":"anything"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("read error = %v, want policy violation close", err)
+	}
+}
+
+func TestGottyAuthTokenEndpointEchoesCallerToken(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{AuthToken: "secret"})
+
+	resp, err := http.Get(httpSrv.URL + "/gotty/any/auth_token.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated status=%d, want 401", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, httpSrv.URL+"/gotty/any/auth_token.js", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "var gotty_auth_token = 'secret';\n"; string(body) != want {
+		t.Fatalf("auth_token.js body = %q, want %q", body, want)
+	}
+}
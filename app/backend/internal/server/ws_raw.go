@@ -0,0 +1,119 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/coder/websocket"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// Raw mode (/ws?mode=raw&sessionId=...) speaks the trivial protocol that
+// xterm.js's attach addon expects: every message from the server is terminal
+// output bytes, every message from the client is terminal input bytes. There
+// is no attach handshake, no sequencing and no resize channel — the PTY size
+// comes from the cols/rows query parameters. Integrations that need history
+// replay, flow control or resizes should use the framed protocol or /ws/rpc.
+
+func (s *Server) handleRawWS(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		http.Error(w, "sessionId query parameter is required", http.StatusBadRequest)
+		return
+	}
+	cols := rawWSDimension(r.URL.Query().Get("cols"), 80)
+	rows := rawWSDimension(r.URL.Query().Get("rows"), 24)
+
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		OriginPatterns:       s.allowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(s.wsReadLimit(defaultWSReadLimitBytes))
+	ctx := r.Context()
+	s.startWSKeepalive(ctx, conn)
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		conn.Close(websocket.StatusInternalError, "failed to mint connection id")
+		return
+	}
+	attachment := livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             cols,
+		Rows:             rows,
+		SessionID:        sessionID,
+		ConnectionID:     "raw-" + hex.EncodeToString(buf),
+	}
+	_, detach, err := s.liveBackend.Attach(ctx, attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			return conn.Write(ctx, websocket.MessageBinary, record.Data) == nil
+		},
+		// Raw clients size their own terminal; effective-geometry changes have
+		// no wire representation here.
+		OnGeometry: func(livev1.EffectiveGeometry) bool { return true },
+		OnSessionClosed: func() {
+			_ = conn.Close(websocket.StatusNormalClosure, "session closed")
+		},
+		OnSuperseded: func() {
+			_ = conn.Close(websocket.StatusPolicyViolation, "attachment superseded")
+		},
+	})
+	if err != nil {
+		conn.Close(websocket.StatusPolicyViolation, rawWSAttachError(err))
+		return
+	}
+	defer detach()
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if err := s.liveBackend.WriteInput(ctx, attachment, livev1.Input{Data: data}); err != nil {
+			conn.Close(websocket.StatusInternalError, "terminal input failed")
+			return
+		}
+	}
+}
+
+// rawWSDimension parses a cols/rows query parameter, falling back for absent
+// or nonsensical values rather than failing the handshake.
+func rawWSDimension(value string, fallback uint32) uint32 {
+	parsed, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || parsed == 0 {
+		return fallback
+	}
+	return uint32(parsed)
+}
+
+func rawWSAttachError(err error) string {
+	switch {
+	case errors.Is(err, livev1.ErrSessionNotFound):
+		return "session not found"
+	case errors.Is(err, livev1.ErrPermissionDenied):
+		return "permission denied"
+	default:
+		return "terminal attach failed"
+	}
+}
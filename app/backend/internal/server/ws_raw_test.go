@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestRawWebSocketBridgesInputAndOutput(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	session := createTestSession(t, httpSrv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/ws?mode=raw&sessionId=" + session.ID
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte("raw hello\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The test shell is cat, so the input comes straight back as output.
+	var output []byte
+	for !bytes.Contains(output, []byte("raw hello")) {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("read: %v (output so far %q)", err, output)
+		}
+		output = append(output, data...)
+	}
+}
+
+func TestRawWebSocketRejectsMissingOrUnknownSession(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	base := "ws" + httpSrv.URL[len("http"):]
+	if conn, _, err := websocket.Dial(ctx, base+"/ws?mode=raw", nil); err == nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		t.Fatal("handshake without sessionId was accepted")
+	}
+	if conn, _, err := websocket.Dial(ctx, base+"/ws?mode=bogus", nil); err == nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		t.Fatal("handshake with unknown mode was accepted")
+	}
+
+	conn, _, err := websocket.Dial(ctx, base+"/ws?mode=raw&sessionId=missing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("read error = %v, want policy violation close", err)
+	}
+}
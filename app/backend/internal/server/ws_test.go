@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestWebSocketRejectsCrossOriginByDefault(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/ws"
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Origin": []string{"http://evil.example"}},
+	})
+	if err == nil {
+		conn.Close(websocket.StatusNormalClosure, "")
+		t.Fatalf("expected cross-origin handshake to be rejected")
+	}
+
+	// Same-origin handshakes still work.
+	conn, _, err = websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Origin": []string{httpSrv.URL}},
+	})
+	if err != nil {
+		t.Fatalf("same-origin handshake failed: %v", err)
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func TestWebSocketNegotiatesLiveSubprotocol(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/ws"
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		Subprotocols: []string{"floeterm.v1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.Subprotocol(); got != wsSubprotocolLiveV1 {
+		t.Fatalf("negotiated subprotocol = %q, want %q", got, wsSubprotocolLiveV1)
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+
+	// A client that only speaks a future revision is refused explicitly
+	// rather than being fed frames it cannot parse.
+	conn, _, err = websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		Subprotocols: []string{"floeterm.v99"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("read error = %v, want policy violation close", err)
+	}
+}
+
+func TestWebSocketAllowsConfiguredOrigins(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{AllowedOrigins: []string{"app.example.com"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + httpSrv.URL[len("http"):] + "/ws"
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		HTTPHeader: http.Header{"Origin": []string{"https://app.example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("allowed origin handshake failed: %v", err)
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/coder/websocket"
+
+	"github.com/floegence/floeterm/terminal-go/livev1"
+)
+
+// ttyd mode (/ws?mode=ttyd&sessionId=...) implements ttyd's websocket
+// protocol so its web client and the tooling built around it can point at a
+// floeterm backend unchanged. The client's first message is a JSON blob
+// carrying the auth token and initial size; after that every client frame is
+// prefixed with a command byte ('0' input, '1' resize JSON) and every server
+// frame with '0' for output or '1' for the window title. ttyd clients cannot
+// set an Authorization header, which is why the token arrives in-band and the
+// auth middleware defers /ws?mode=ttyd enforcement to this handler.
+
+const (
+	// Client commands.
+	ttydCmdInput    = '0'
+	ttydCmdResize   = '1'
+	ttydCmdPause    = '2'
+	ttydCmdResume   = '3'
+	ttydCmdJSONData = '{'
+	// Server commands.
+	ttydCmdOutput      = '0'
+	ttydCmdWindowTitle = '1'
+)
+
+// ttydSubprotocol is the subprotocol ttyd clients offer on the handshake.
+const ttydSubprotocol = "tty"
+
+// ttydInitMessage is the client's first message: the auth token plus the
+// initial grid size. ttyd sends it as bare JSON without a command prefix.
+type ttydInitMessage struct {
+	AuthToken string `json:"AuthToken"`
+	Columns   uint32 `json:"columns"`
+	Rows      uint32 `json:"rows"`
+}
+
+type ttydResizeMessage struct {
+	Columns uint32 `json:"columns"`
+	Rows    uint32 `json:"rows"`
+}
+
+// authenticateInBandToken resolves a token sent inside the protocol stream
+// (ttyd and gotty clients cannot set headers) against the token-style
+// credentials the Authorization header accepts. Only with no auth configured
+// at all does every token pass, matching the rest of the server. When the
+// server is protected solely by credentials that cannot be checked in-band
+// (basic auth, PAM, client certificates) this fails closed: these endpoints
+// bypass the auth middleware, and letting everyone through would turn them
+// into an unauthenticated side door with full input access.
+func (s *Server) authenticateInBandToken(token string) (identity, bool) {
+	if s.authToken == "" && len(s.authUsers) == 0 &&
+		s.basicAuthUser == "" && !s.clientCertAuth && s.pamAuth == nil {
+		return identity{}, true
+	}
+	for _, user := range s.authUsers {
+		if tokenMatches(token, user.Token) {
+			return identity{name: user.Name, admin: user.Admin}, true
+		}
+	}
+	if s.authToken != "" && tokenMatches(token, s.authToken) {
+		return identity{admin: true}, true
+	}
+	return identity{}, false
+}
+
+func (s *Server) handleTTYDWS(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("sessionId")
+	if sessionID == "" {
+		http.Error(w, "sessionId query parameter is required", http.StatusBadRequest)
+		return
+	}
+	if s.authErr != nil {
+		http.Error(w, "authentication misconfigured", http.StatusServiceUnavailable)
+		return
+	}
+
+	release, ok := s.ipLimits.acquireWS(s.clientIP(r))
+	if !ok {
+		s.metrics.IncCounter(metricRateLimitRejected, 1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many connections from this address", http.StatusTooManyRequests)
+		return
+	}
+	defer release()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:         []string{ttydSubprotocol},
+		CompressionMode:      s.wsCompressionMode,
+		CompressionThreshold: s.wsCompressionThreshold,
+		OriginPatterns:       s.allowedOrigins,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(s.wsReadLimit(defaultWSReadLimitBytes))
+	ctx := r.Context()
+	s.startWSKeepalive(ctx, conn)
+
+	// The init message must arrive before anything else; it carries the
+	// credential this connection authenticates with.
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return
+	}
+	var init ttydInitMessage
+	if len(data) == 0 || data[0] != ttydCmdJSONData || json.Unmarshal(data, &init) != nil {
+		conn.Close(websocket.StatusPolicyViolation, "init message required")
+		return
+	}
+	id, ok := s.authenticateInBandToken(init.AuthToken)
+	if !ok {
+		conn.Close(websocket.StatusPolicyViolation, "invalid token")
+		return
+	}
+	ctx = withIdentity(ctx, id)
+	if init.Columns == 0 {
+		init.Columns = 80
+	}
+	if init.Rows == 0 {
+		init.Rows = 24
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		conn.Close(websocket.StatusInternalError, "failed to mint connection id")
+		return
+	}
+	attachment := livev1.Attach{
+		AttachGeneration: 1,
+		Cols:             init.Columns,
+		Rows:             init.Rows,
+		SessionID:        sessionID,
+		ConnectionID:     "ttyd-" + hex.EncodeToString(buf),
+	}
+	_, detach, err := s.liveBackend.Attach(ctx, attachment, livev1.Subscriber{
+		OnOutput: func(record livev1.OutputRecord) bool {
+			frame := make([]byte, 1+len(record.Data))
+			frame[0] = ttydCmdOutput
+			copy(frame[1:], record.Data)
+			return conn.Write(ctx, websocket.MessageBinary, frame) == nil
+		},
+		// ttyd has no effective-geometry frame; clients keep their own size.
+		OnGeometry: func(livev1.EffectiveGeometry) bool { return true },
+		OnSessionClosed: func() {
+			_ = conn.Close(websocket.StatusNormalClosure, "session closed")
+		},
+		OnSuperseded: func() {
+			_ = conn.Close(websocket.StatusPolicyViolation, "attachment superseded")
+		},
+	})
+	if err != nil {
+		conn.Close(websocket.StatusPolicyViolation, rawWSAttachError(err))
+		return
+	}
+	defer detach()
+
+	if session, ok := s.manager.GetSession(sessionID); ok {
+		_ = conn.Write(ctx, websocket.MessageBinary, append([]byte{ttydCmdWindowTitle}, session.GetName()...))
+	}
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		if len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case ttydCmdInput:
+			if len(data) == 1 {
+				continue
+			}
+			if err := s.liveBackend.WriteInput(ctx, attachment, livev1.Input{Data: data[1:]}); err != nil {
+				conn.Close(websocket.StatusInternalError, "terminal input failed")
+				return
+			}
+		case ttydCmdResize:
+			var resize ttydResizeMessage
+			if json.Unmarshal(data[1:], &resize) != nil || resize.Columns == 0 || resize.Rows == 0 {
+				conn.Close(websocket.StatusPolicyViolation, "invalid resize message")
+				return
+			}
+			if _, err := s.liveBackend.Resize(ctx, attachment, livev1.Resize{Cols: resize.Columns, Rows: resize.Rows}); err != nil {
+				conn.Close(websocket.StatusInternalError, "terminal resize failed")
+				return
+			}
+		case ttydCmdPause, ttydCmdResume:
+			// Flow control is handled by the output queue; accept and ignore.
+		case ttydCmdJSONData:
+			// Repeated init messages carry nothing new.
+		default:
+			conn.Close(websocket.StatusPolicyViolation, "unknown command")
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func dialTTYD(t *testing.T, ctx context.Context, baseURL, sessionID string) *websocket.Conn {
+	t.Helper()
+	wsURL := "ws" + baseURL[len("http"):] + "/ws?mode=ttyd&sessionId=" + sessionID
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		Subprotocols: []string{ttydSubprotocol},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	return conn
+}
+
+func TestTTYDWebSocketSpeaksTTYDProtocol(t *testing.T) {
+	_, httpSrv := newTestServer(t)
+	session := createTestSession(t, httpSrv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn := dialTTYD(t, ctx, httpSrv.URL, session.ID)
+	if got := conn.Subprotocol(); got != ttydSubprotocol {
+		t.Fatalf("negotiated subprotocol = %q, want %q", got, ttydSubprotocol)
+	}
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte(`{"AuthToken":"","columns":100,"rows":30}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The window title arrives first, then '0'-prefixed output frames. The
+	// test shell is cat, so input comes straight back.
+	_, title, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(title) == 0 || title[0] != ttydCmdWindowTitle || string(title[1:]) != session.Name {
+		t.Fatalf("title frame = %q", title)
+	}
+
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte("0ttyd hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	var output []byte
+	for !bytes.Contains(output, []byte("ttyd hello")) {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("read: %v (output so far %q)", err, output)
+		}
+		if len(data) == 0 || data[0] != ttydCmdOutput {
+			t.Fatalf("unexpected frame %q", data)
+		}
+		output = append(output, data[1:]...)
+	}
+
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte(`1{"columns":90,"rows":25}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTTYDWebSocketAuthenticatesViaInitMessage(t *testing.T) {
+	httpSrv := newAuthTestServer(t, Config{AuthToken: "secret"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Create the session with regular header auth; the ttyd connection itself
+	// carries the token in its init message.
+	req, _ := http.NewRequest(http.MethodPost, httpSrv.URL+"/api/sessions", bytes.NewBufferString(`{}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+	var session apiSessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := dialTTYD(t, ctx, httpSrv.URL, session.ID)
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte(`{"AuthToken":"wrong","columns":80,"rows":24}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("read error = %v, want policy violation close", err)
+	}
+
+	conn = dialTTYD(t, ctx, httpSrv.URL, session.ID)
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte(`{"AuthToken":"secret","columns":80,"rows":24}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, title, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(title) == 0 || title[0] != ttydCmdWindowTitle {
+		t.Fatalf("title frame = %q", title)
+	}
+}
+
+func TestTTYDWebSocketFailsClosedWithoutTokenCredential(t *testing.T) {
+	// A server protected only by basic auth has no token the in-band check
+	// could verify; the ttyd path must reject everyone rather than let the
+	// auth-middleware exemption become a bypass.
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpSrv := newAuthTestServer(t, Config{
+		BasicAuthUsername:     "admin",
+		BasicAuthPasswordHash: string(hash),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn := dialTTYD(t, ctx, httpSrv.URL, "any-session")
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte(`{"AuthToken":"anything","columns":80,"rows":24}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := conn.Read(ctx); websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("read error = %v, want policy violation close", err)
+	}
+}
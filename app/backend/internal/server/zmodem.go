@@ -0,0 +1,552 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+)
+
+// A minimal ZMODEM engine, enough to stand in for the local end of an lrzsz
+// transfer: driveZmodemSend feeds a file to a remote `rz`, and
+// driveZmodemReceive collects the file a remote `sz` offers. Both speak
+// CRC-32 binary frames and advertise them in ZRINIT, which every lrzsz since
+// the 90s supports. Like the RESP client and the Docker API bridge, this is
+// hand-rolled on purpose: the subset is small, stable, and not worth a
+// dependency. Error recovery is deliberately simple — on a bad frame the
+// transfer fails and the user retries — because the transport underneath is
+// a reliable PTY stream, not a phone line.
+
+const (
+	zdle      = 0x18
+	zpad      = '*'
+	zbinFmt   = 'A'
+	zhexFmt   = 'B'
+	zbin32Fmt = 'C'
+
+	zrqinit = 0
+	zrinit  = 1
+	zack    = 3
+	zfile   = 4
+	zskip   = 5
+	znak    = 6
+	zabort  = 7
+	zfin    = 8
+	zrpos   = 9
+	zdata   = 10
+	zeof    = 11
+
+	zcrce = 'h' // end of frame, header follows
+	zcrcg = 'i' // frame continues non-stop
+	zcrcq = 'j' // frame continues, ZACK expected
+	zcrcw = 'k' // end of frame, response expected
+
+	zrub0 = 'l' // escaped 0x7f
+	zrub1 = 'm' // escaped 0xff
+
+	// ZRINIT capability flags we advertise.
+	zmodemCanFDX  = 0x01
+	zmodemCanOvIO = 0x02
+	zmodemCanFC32 = 0x20
+
+	zmodemDataChunk    = 1024
+	zmodemMaxFileBytes = 512 << 20
+)
+
+var errZmodemCancelled = errors.New("zmodem transfer cancelled by remote")
+
+// zmodemHeader is one decoded frame header: a type plus four bytes that hold
+// either flags or a little-endian file position.
+type zmodemHeader struct {
+	frameType byte
+	p         [4]byte
+	binary16  bool
+}
+
+func (h zmodemHeader) pos() int {
+	return int(h.p[0]) | int(h.p[1])<<8 | int(h.p[2])<<16 | int(h.p[3])<<24
+}
+
+func zmodemPosHeader(frameType byte, pos int) zmodemHeader {
+	return zmodemHeader{frameType: frameType, p: [4]byte{byte(pos), byte(pos >> 8), byte(pos >> 16), byte(pos >> 24)}}
+}
+
+// crc16CCITT is the CRC used by ZMODEM hex headers (poly 0x1021, zero init).
+func crc16CCITT(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// zmodemNeedsEscape reports bytes that must be ZDLE-escaped on the wire:
+// ZDLE itself plus the flow-control and telenet-sensitive characters a PTY or
+// modem chain might eat. CR is escaped unconditionally instead of only after
+// '@', which stays within what every receiver unescapes.
+func zmodemNeedsEscape(b byte) bool {
+	switch b {
+	case zdle, 0x10, 0x90, 0x11, 0x91, 0x13, 0x93, 0x0d, 0x8d:
+		return true
+	}
+	return false
+}
+
+type zmodemStream struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func newZmodemStream(rw io.ReadWriter) *zmodemStream {
+	return &zmodemStream{r: bufio.NewReader(rw), w: rw}
+}
+
+func (z *zmodemStream) writeEscaped(dst *bytes.Buffer, data []byte) {
+	for _, b := range data {
+		switch {
+		case b == 0x7f:
+			dst.WriteByte(zdle)
+			dst.WriteByte(zrub0)
+		case b == 0xff:
+			dst.WriteByte(zdle)
+			dst.WriteByte(zrub1)
+		case zmodemNeedsEscape(b):
+			dst.WriteByte(zdle)
+			dst.WriteByte(b ^ 0x40)
+		default:
+			dst.WriteByte(b)
+		}
+	}
+}
+
+// readUnescaped returns the next data byte, undoing ZDLE escapes, or the
+// frame-end marker when one is found (data < 0x100 carries the byte,
+// otherwise 0x100+marker). Five CAN bytes in a row abort the transfer.
+func (z *zmodemStream) readUnescaped() (int, error) {
+	b, err := z.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != zdle {
+		return int(b), nil
+	}
+	cancels := 1
+	var next byte
+	for {
+		next, err = z.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if next != zdle {
+			break
+		}
+		cancels++
+		if cancels >= 5 {
+			return 0, errZmodemCancelled
+		}
+	}
+	switch next {
+	case zcrce, zcrcg, zcrcq, zcrcw:
+		return 0x100 + int(next), nil
+	case zrub0:
+		return 0x7f, nil
+	case zrub1:
+		return 0xff, nil
+	default:
+		return int(next ^ 0x40), nil
+	}
+}
+
+func (z *zmodemStream) writeHexHeader(h zmodemHeader) error {
+	var buf bytes.Buffer
+	buf.WriteString("**\x18B")
+	raw := append([]byte{h.frameType}, h.p[:]...)
+	crc := crc16CCITT(raw)
+	raw = append(raw, byte(crc>>8), byte(crc))
+	for _, b := range raw {
+		fmt.Fprintf(&buf, "%02x", b)
+	}
+	buf.WriteString("\r\n")
+	if h.frameType != zfin && h.frameType != zack {
+		buf.WriteByte(0x11) // XON, as lrzsz appends
+	}
+	_, err := z.w.Write(buf.Bytes())
+	return err
+}
+
+func (z *zmodemStream) writeBin32Header(h zmodemHeader) error {
+	var buf bytes.Buffer
+	buf.WriteByte(zpad)
+	buf.WriteByte(zdle)
+	buf.WriteByte(zbin32Fmt)
+	raw := append([]byte{h.frameType}, h.p[:]...)
+	z.writeEscaped(&buf, raw)
+	crc := crc32.ChecksumIEEE(raw)
+	z.writeEscaped(&buf, []byte{byte(crc), byte(crc >> 8), byte(crc >> 16), byte(crc >> 24)})
+	_, err := z.w.Write(buf.Bytes())
+	return err
+}
+
+// writeSubpacket sends one data subpacket terminated by the given marker,
+// with a CRC-32 covering payload plus marker.
+func (z *zmodemStream) writeSubpacket(payload []byte, marker byte) error {
+	var buf bytes.Buffer
+	z.writeEscaped(&buf, payload)
+	buf.WriteByte(zdle)
+	buf.WriteByte(marker)
+	crc := crc32.Update(crc32.ChecksumIEEE(payload), crc32.IEEETable, []byte{marker})
+	z.writeEscaped(&buf, []byte{byte(crc), byte(crc >> 8), byte(crc >> 16), byte(crc >> 24)})
+	_, err := z.w.Write(buf.Bytes())
+	return err
+}
+
+// readHeader hunts for the next frame header, skipping any noise (prompt
+// text, "rz waiting..." banners) before the ZPAD run.
+func (z *zmodemStream) readHeader() (zmodemHeader, error) {
+	for {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return zmodemHeader{}, err
+		}
+		if b != zpad {
+			continue
+		}
+		b, err = z.r.ReadByte()
+		if err != nil {
+			return zmodemHeader{}, err
+		}
+		if b == zpad {
+			b, err = z.r.ReadByte()
+			if err != nil {
+				return zmodemHeader{}, err
+			}
+		}
+		if b != zdle {
+			continue
+		}
+		format, err := z.r.ReadByte()
+		if err != nil {
+			return zmodemHeader{}, err
+		}
+		switch format {
+		case zhexFmt:
+			return z.readHexHeader()
+		case zbin32Fmt:
+			return z.readBinaryHeader(false)
+		case zbinFmt:
+			return z.readBinaryHeader(true)
+		default:
+			continue
+		}
+	}
+}
+
+func (z *zmodemStream) readHexHeader() (zmodemHeader, error) {
+	raw := make([]byte, 7)
+	for i := range raw {
+		hi, err := z.readHexNibble()
+		if err != nil {
+			return zmodemHeader{}, err
+		}
+		lo, err := z.readHexNibble()
+		if err != nil {
+			return zmodemHeader{}, err
+		}
+		raw[i] = hi<<4 | lo
+	}
+	if crc16CCITT(raw[:5]) != uint16(raw[5])<<8|uint16(raw[6]) {
+		return zmodemHeader{}, errors.New("zmodem hex header crc mismatch")
+	}
+	header := zmodemHeader{frameType: raw[0]}
+	copy(header.p[:], raw[1:5])
+	return header, nil
+}
+
+func (z *zmodemStream) readHexNibble() (byte, error) {
+	b, err := z.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0', nil
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10, nil
+	case b >= 'A' && b <= 'F':
+		return b - 'A' + 10, nil
+	default:
+		return 0, fmt.Errorf("zmodem hex header has non-hex byte %#x", b)
+	}
+}
+
+func (z *zmodemStream) readBinaryHeader(crc16 bool) (zmodemHeader, error) {
+	crcSize := 4
+	if crc16 {
+		crcSize = 2
+	}
+	raw := make([]byte, 5+crcSize)
+	for i := range raw {
+		v, err := z.readUnescaped()
+		if err != nil {
+			return zmodemHeader{}, err
+		}
+		if v > 0xff {
+			return zmodemHeader{}, errors.New("zmodem header interrupted by frame end")
+		}
+		raw[i] = byte(v)
+	}
+	if crc16 {
+		if crc16CCITT(raw[:5]) != uint16(raw[5])<<8|uint16(raw[6]) {
+			return zmodemHeader{}, errors.New("zmodem binary header crc mismatch")
+		}
+	} else {
+		want := uint32(raw[5]) | uint32(raw[6])<<8 | uint32(raw[7])<<16 | uint32(raw[8])<<24
+		if crc32.ChecksumIEEE(raw[:5]) != want {
+			return zmodemHeader{}, errors.New("zmodem binary header crc mismatch")
+		}
+	}
+	header := zmodemHeader{frameType: raw[0], binary16: crc16}
+	copy(header.p[:], raw[1:5])
+	return header, nil
+}
+
+// readSubpacket reads one data subpacket following a binary header and
+// returns the payload and its terminating marker.
+func (z *zmodemStream) readSubpacket(crc16 bool) ([]byte, byte, error) {
+	var payload []byte
+	for {
+		v, err := z.readUnescaped()
+		if err != nil {
+			return nil, 0, err
+		}
+		if v <= 0xff {
+			payload = append(payload, byte(v))
+			if len(payload) > zmodemMaxFileBytes {
+				return nil, 0, errors.New("zmodem subpacket exceeds size limit")
+			}
+			continue
+		}
+		marker := byte(v - 0x100)
+		crcSize := 4
+		if crc16 {
+			crcSize = 2
+		}
+		crcRaw := make([]byte, crcSize)
+		for i := range crcRaw {
+			c, err := z.readUnescaped()
+			if err != nil {
+				return nil, 0, err
+			}
+			if c > 0xff {
+				return nil, 0, errors.New("zmodem subpacket crc interrupted by frame end")
+			}
+			crcRaw[i] = byte(c)
+		}
+		if crc16 {
+			crc := crc16CCITT(append(append([]byte(nil), payload...), marker))
+			if crc != uint16(crcRaw[0])<<8|uint16(crcRaw[1]) {
+				return nil, 0, errors.New("zmodem subpacket crc mismatch")
+			}
+		} else {
+			crc := crc32.Update(crc32.ChecksumIEEE(payload), crc32.IEEETable, []byte{marker})
+			want := uint32(crcRaw[0]) | uint32(crcRaw[1])<<8 | uint32(crcRaw[2])<<16 | uint32(crcRaw[3])<<24
+			if crc != want {
+				return nil, 0, errors.New("zmodem subpacket crc mismatch")
+			}
+		}
+		return payload, marker, nil
+	}
+}
+
+// driveZmodemSend feeds one file to a remote receiver (`rz`): it waits for
+// the receiver's ZRINIT, offers the file, streams the data from the
+// requested position and closes the session.
+func driveZmodemSend(rw io.ReadWriter, name string, data []byte) error {
+	z := newZmodemStream(rw)
+	for {
+		header, err := z.readHeader()
+		if err != nil {
+			return fmt.Errorf("waiting for receiver: %w", err)
+		}
+		if header.frameType == zrinit {
+			break
+		}
+		if header.frameType == zabort || header.frameType == zfin {
+			return errZmodemCancelled
+		}
+	}
+
+	info := append([]byte(name), 0)
+	info = append(info, []byte(strconv.Itoa(len(data)))...)
+	info = append(info, 0)
+	pos := -1
+	for pos < 0 {
+		if err := z.writeBin32Header(zmodemHeader{frameType: zfile}); err != nil {
+			return err
+		}
+		if err := z.writeSubpacket(info, zcrcw); err != nil {
+			return err
+		}
+		header, err := z.readHeader()
+		if err != nil {
+			return fmt.Errorf("waiting for file acceptance: %w", err)
+		}
+		switch header.frameType {
+		case zrpos:
+			pos = header.pos()
+		case zrinit, znak:
+			// Receiver is not ready for the offer yet; repeat it.
+		case zskip:
+			return errors.New("receiver skipped the file (already exists?)")
+		case zabort, zfin:
+			return errZmodemCancelled
+		default:
+			return fmt.Errorf("unexpected response %d to file offer", header.frameType)
+		}
+	}
+	if pos > len(data) {
+		return fmt.Errorf("receiver requested position %d beyond file size %d", pos, len(data))
+	}
+
+	if err := z.writeBin32Header(zmodemPosHeader(zdata, pos)); err != nil {
+		return err
+	}
+	for {
+		end := min(pos+zmodemDataChunk, len(data))
+		marker := byte(zcrcg)
+		if end == len(data) {
+			// The last subpacket (possibly empty) ends the frame.
+			marker = zcrce
+		}
+		if err := z.writeSubpacket(data[pos:end], marker); err != nil {
+			return err
+		}
+		pos = end
+		if marker == zcrce {
+			break
+		}
+	}
+	if err := z.writeBin32Header(zmodemPosHeader(zeof, len(data))); err != nil {
+		return err
+	}
+
+	for {
+		header, err := z.readHeader()
+		if err != nil {
+			return fmt.Errorf("waiting for transfer end: %w", err)
+		}
+		switch header.frameType {
+		case zrinit:
+			if err := z.writeHexHeader(zmodemHeader{frameType: zfin}); err != nil {
+				return err
+			}
+		case zfin:
+			_, err := rw.Write([]byte("OO"))
+			return err
+		case zrpos:
+			return fmt.Errorf("receiver requested retransmission from %d; transfer failed", header.pos())
+		case zabort:
+			return errZmodemCancelled
+		}
+	}
+}
+
+// driveZmodemReceive collects the file a remote sender (`sz`) offers and
+// returns its name and contents.
+func driveZmodemReceive(rw io.ReadWriter) (string, []byte, error) {
+	z := newZmodemStream(rw)
+	zrinitHeader := zmodemHeader{frameType: zrinit, p: [4]byte{0, 0, 0, zmodemCanFDX | zmodemCanOvIO | zmodemCanFC32}}
+	if err := z.writeHexHeader(zrinitHeader); err != nil {
+		return "", nil, err
+	}
+	name := ""
+	var data []byte
+	for {
+		header, err := z.readHeader()
+		if err != nil {
+			return "", nil, fmt.Errorf("waiting for sender: %w", err)
+		}
+		switch header.frameType {
+		case zrqinit:
+			if err := z.writeHexHeader(zrinitHeader); err != nil {
+				return "", nil, err
+			}
+		case zfile:
+			payload, _, err := z.readSubpacket(header.binary16)
+			if err != nil {
+				return "", nil, fmt.Errorf("reading file offer: %w", err)
+			}
+			name = parseZmodemFileName(payload)
+			data = nil
+			if err := z.writeHexHeader(zmodemPosHeader(zrpos, 0)); err != nil {
+				return "", nil, err
+			}
+		case zdata:
+			if header.pos() != len(data) {
+				if err := z.writeHexHeader(zmodemPosHeader(zrpos, len(data))); err != nil {
+					return "", nil, err
+				}
+				continue
+			}
+			for {
+				payload, marker, err := z.readSubpacket(header.binary16)
+				if err != nil {
+					return "", nil, fmt.Errorf("reading data: %w", err)
+				}
+				data = append(data, payload...)
+				if len(data) > zmodemMaxFileBytes {
+					return "", nil, errors.New("zmodem file exceeds size limit")
+				}
+				if marker == zcrcg {
+					continue
+				}
+				if marker == zcrcq || marker == zcrcw {
+					if err := z.writeHexHeader(zmodemPosHeader(zack, len(data))); err != nil {
+						return "", nil, err
+					}
+				}
+				if marker == zcrce || marker == zcrcw {
+					break
+				}
+			}
+		case zeof:
+			if header.pos() == len(data) {
+				if err := z.writeHexHeader(zrinitHeader); err != nil {
+					return "", nil, err
+				}
+			} else if err := z.writeHexHeader(zmodemPosHeader(zrpos, len(data))); err != nil {
+				return "", nil, err
+			}
+		case zfin:
+			if err := z.writeHexHeader(zmodemHeader{frameType: zfin}); err != nil {
+				return "", nil, err
+			}
+			return name, data, nil
+		case zabort:
+			return "", nil, errZmodemCancelled
+		}
+	}
+}
+
+// parseZmodemFileName extracts the filename from a ZFILE data subpacket
+// (NUL-terminated name followed by size/mtime text).
+func parseZmodemFileName(payload []byte) string {
+	if nul := bytes.IndexByte(payload, 0); nul >= 0 {
+		payload = payload[:nul]
+	}
+	// Defend against path-laden names: only the base name matters.
+	if slash := bytes.LastIndexByte(payload, '/'); slash >= 0 {
+		payload = payload[slash+1:]
+	}
+	return string(payload)
+}
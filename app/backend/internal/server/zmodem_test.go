@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCRC16CCITT(t *testing.T) {
+	// Reference value for the standard check string with zero init.
+	if got := crc16CCITT([]byte("123456789")); got != 0x31c3 {
+		t.Fatalf("crc16CCITT = %#04x, want 0x31c3", got)
+	}
+}
+
+func TestZmodemEscapeRoundTrip(t *testing.T) {
+	payload := []byte{0x00, zdle, 0x10, 0x11, 0x13, 0x8d, 0x0d, 0x7f, 0xff, 'a', 0x90, 0x91, 0x93}
+	var wire bytes.Buffer
+	z := newZmodemStream(&wire)
+	z.writeEscaped(&wire, payload)
+
+	reader := newZmodemStream(&wire)
+	var got []byte
+	for range payload {
+		v, err := reader.readUnescaped()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v > 0xff {
+			t.Fatalf("unexpected frame-end marker %#x", v)
+		}
+		got = append(got, byte(v))
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("escape round trip = %v, want %v", got, payload)
+	}
+}
+
+func TestZmodemHexHeaderRoundTrip(t *testing.T) {
+	var wire bytes.Buffer
+	writer := newZmodemStream(&wire)
+	want := zmodemPosHeader(zrpos, 0x01020304)
+	if err := writer.writeHexHeader(want); err != nil {
+		t.Fatal(err)
+	}
+	reader := newZmodemStream(&wire)
+	got, err := reader.readHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.frameType != zrpos || got.pos() != 0x01020304 {
+		t.Fatalf("header = %+v (pos %d)", got, got.pos())
+	}
+}
+
+func TestZmodemBin32HeaderRoundTrip(t *testing.T) {
+	var wire bytes.Buffer
+	writer := newZmodemStream(&wire)
+	// Position bytes that all need escaping exercise the ZDLE path.
+	want := zmodemPosHeader(zdata, 0x0d110d18)
+	if err := writer.writeBin32Header(want); err != nil {
+		t.Fatal(err)
+	}
+	reader := newZmodemStream(&wire)
+	got, err := reader.readHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.frameType != zdata || got.pos() != 0x0d110d18 {
+		t.Fatalf("header = %+v (pos %d)", got, got.pos())
+	}
+}
+
+func TestZmodemCancelRun(t *testing.T) {
+	z := newZmodemStream(bytes.NewBufferString("\x18\x18\x18\x18\x18"))
+	if _, err := z.readUnescaped(); err != errZmodemCancelled {
+		t.Fatalf("err = %v, want errZmodemCancelled", err)
+	}
+}
+
+func TestParseZmodemFileName(t *testing.T) {
+	cases := map[string]string{
+		"notes.txt\x00123 1700000000 100644": "notes.txt",
+		"/etc/passwd\x00999":                 "passwd",
+		"../../escape.sh\x001":               "escape.sh",
+		"bare":                               "bare",
+	}
+	for input, want := range cases {
+		if got := parseZmodemFileName([]byte(input)); got != want {
+			t.Fatalf("parseZmodemFileName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestZmodemSendReceiveLoopback(t *testing.T) {
+	payload := make([]byte, 3000)
+	for i := range payload {
+		payload[i] = byte(i * 7)
+	}
+
+	sendConn, recvConn := net.Pipe()
+	defer sendConn.Close()
+	defer recvConn.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	_ = sendConn.SetDeadline(deadline)
+	_ = recvConn.SetDeadline(deadline)
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- driveZmodemSend(sendConn, "report.bin", payload)
+	}()
+
+	name, data, err := driveZmodemReceive(recvConn)
+	if err != nil {
+		t.Fatalf("receive failed: %v", err)
+	}
+	// Drain the sender's trailing "OO" so its final write does not block on
+	// the unbuffered pipe.
+	go func() { _, _ = io.Copy(io.Discard, recvConn) }()
+	if err := <-sendErr; err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	if name != "report.bin" {
+		t.Fatalf("name = %q", name)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Fatalf("payload mismatch: got %d bytes, want %d", len(data), len(payload))
+	}
+}
+
+func TestZmodemSendEmptyFile(t *testing.T) {
+	sendConn, recvConn := net.Pipe()
+	defer sendConn.Close()
+	defer recvConn.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	_ = sendConn.SetDeadline(deadline)
+	_ = recvConn.SetDeadline(deadline)
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- driveZmodemSend(sendConn, "empty", nil)
+	}()
+
+	name, data, err := driveZmodemReceive(recvConn)
+	if err != nil {
+		t.Fatalf("receive failed: %v", err)
+	}
+	go func() { _, _ = io.Copy(io.Discard, recvConn) }()
+	if err := <-sendErr; err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if name != "empty" || len(data) != 0 {
+		t.Fatalf("got %q with %d bytes", name, len(data))
+	}
+}
@@ -0,0 +1,107 @@
+import { describe, expect, it } from 'vitest';
+
+import type { TerminalByteStream } from '@floegence/floeterm-terminal-web/live';
+import { RTCDataChannelByteStream, bridgeByteStreams } from './webrtcTransport';
+
+class FakeDataChannel extends EventTarget {
+  binaryType: BinaryType = 'blob';
+  bufferedAmount = 0;
+  readyState: RTCDataChannelState = 'open';
+  readonly sends: Uint8Array[] = [];
+
+  send(data: Uint8Array): void {
+    this.sends.push(data.slice());
+  }
+
+  close(): void {
+    this.readyState = 'closed';
+    this.dispatchEvent(new Event('close'));
+  }
+
+  message(data: ArrayBuffer | string): void {
+    this.dispatchEvent(new MessageEvent('message', { data }));
+  }
+}
+
+// An in-memory byte stream for bridge tests: reads drain a scripted queue and
+// writes are recorded. Draining the queue either ends the stream (so the
+// bridge sees EOF) or blocks until close, depending on endWhenDrained.
+class ScriptedByteStream implements TerminalByteStream {
+  readonly writes: Uint8Array[] = [];
+  closed = false;
+  private readonly waiters: ((value: Uint8Array | null) => void)[] = [];
+
+  constructor(
+    private readonly chunks: Uint8Array[],
+    private readonly endWhenDrained: boolean,
+  ) {}
+
+  async read(): Promise<Uint8Array | null> {
+    const next = this.chunks.shift();
+    if (next) return next;
+    if (this.closed || this.endWhenDrained) return null;
+    return await new Promise<Uint8Array | null>(resolve => {
+      this.waiters.push(resolve);
+    });
+  }
+
+  async write(data: Uint8Array): Promise<void> {
+    if (this.closed) throw new Error('stream closed');
+    this.writes.push(data.slice());
+  }
+
+  async close(): Promise<void> {
+    this.closed = true;
+    for (const waiter of this.waiters.splice(0)) waiter(null);
+  }
+}
+
+describe('RTC data channel byte stream', () => {
+  it('switches to arraybuffer mode and preserves binary message boundaries', async () => {
+    const channel = new FakeDataChannel();
+    const stream = new RTCDataChannelByteStream(channel as unknown as RTCDataChannel);
+    expect(channel.binaryType).toBe('arraybuffer');
+
+    channel.message(new Uint8Array([1, 2, 3]).buffer);
+    await expect(stream.read()).resolves.toEqual(new Uint8Array([1, 2, 3]));
+
+    await stream.write(new Uint8Array([4, 5]));
+    expect(channel.sends).toEqual([new Uint8Array([4, 5])]);
+  });
+
+  it('rejects non-binary messages and closes the channel', async () => {
+    const channel = new FakeDataChannel();
+    const stream = new RTCDataChannelByteStream(channel as unknown as RTCDataChannel);
+    const reading = stream.read();
+
+    channel.message('{"type":"data"}');
+
+    await expect(reading).rejects.toThrow(/non-binary/i);
+    expect(channel.readyState).toBe('closed');
+  });
+
+  it('ends pending reads when the channel closes', async () => {
+    const channel = new FakeDataChannel();
+    const stream = new RTCDataChannelByteStream(channel as unknown as RTCDataChannel);
+    const reading = stream.read();
+
+    channel.close();
+
+    await expect(reading).resolves.toBeNull();
+    await expect(stream.read()).resolves.toBeNull();
+  });
+});
+
+describe('byte stream bridge', () => {
+  it('pumps both directions and closes both ends when one runs out', async () => {
+    const local = new ScriptedByteStream([new Uint8Array([1]), new Uint8Array([2])], true);
+    const remote = new ScriptedByteStream([new Uint8Array([9])], false);
+
+    await bridgeByteStreams(local, remote);
+
+    expect(remote.writes).toEqual([new Uint8Array([1]), new Uint8Array([2])]);
+    expect(local.writes).toEqual([new Uint8Array([9])]);
+    expect(local.closed).toBe(true);
+    expect(remote.closed).toBe(true);
+  });
+});
@@ -0,0 +1,329 @@
+import type { TerminalByteStream } from '@floegence/floeterm-terminal-web/live';
+
+// Peer-to-peer terminal transport over a WebRTC data channel. The backend's
+// /ws/webrtc endpoint only relays signaling (SDP offers/answers and ICE
+// candidates) within a room named after the session; the terminal bytes
+// themselves flow directly between browsers. The browser that owns the
+// session calls shareTerminalOverWebRTC to bridge its authenticated /ws byte
+// stream onto the channel; a viewer calls connectTerminalDataChannel and gets
+// back the same TerminalByteStream shape the websocket transport provides,
+// so everything downstream is transport-agnostic. The websocket remains the
+// fallback whenever the peer connection cannot be established.
+
+const WRITE_BUFFER_LOW_WATERMARK_BYTES = 1024 * 1024;
+const TERMINAL_CHANNEL_LABEL = 'terminal';
+
+type ReadWaiter = Readonly<{
+  resolve: (value: Uint8Array | null) => void;
+  reject: (error: Error) => void;
+}>;
+
+type SocketFactory = (url: string) => WebSocket;
+
+type PeerConnectionFactory = (config?: RTCConfiguration) => RTCPeerConnection;
+
+const asError = (value: unknown, fallback: string): Error => (
+  value instanceof Error ? value : new Error(fallback)
+);
+
+// Mirrors the signalMessage shape of the backend relay: the server stamps
+// From, clients address To (or broadcast with it empty), Payload is opaque.
+type SignalMessage = {
+  type: string;
+  from?: string;
+  to?: string;
+  payload?: unknown;
+};
+
+export class RTCDataChannelByteStream implements TerminalByteStream {
+  private readonly reads: Uint8Array[] = [];
+  private readonly waiters: ReadWaiter[] = [];
+  private closed = false;
+  private failure: Error | null = null;
+
+  constructor(private readonly channel: RTCDataChannel) {
+    channel.binaryType = 'arraybuffer';
+    channel.addEventListener('message', this.handleMessage);
+    channel.addEventListener('close', this.handleClose);
+    channel.addEventListener('error', this.handleError);
+  }
+
+  async read(): Promise<Uint8Array | null> {
+    if (this.reads.length > 0) return this.reads.shift() ?? null;
+    if (this.failure) throw this.failure;
+    if (this.closed) return null;
+    return await new Promise<Uint8Array | null>((resolve, reject) => {
+      this.waiters.push({ resolve, reject });
+    });
+  }
+
+  async write(data: Uint8Array): Promise<void> {
+    if (this.failure) throw this.failure;
+    if (this.closed || this.channel.readyState !== 'open') {
+      throw new Error('terminal data channel is not open');
+    }
+    this.channel.send(data);
+    while (this.channel.bufferedAmount > WRITE_BUFFER_LOW_WATERMARK_BYTES) {
+      await new Promise<void>((resolve, reject) => {
+        window.setTimeout(() => {
+          if (this.failure) reject(this.failure);
+          else if (this.closed || this.channel.readyState !== 'open') reject(new Error('terminal data channel closed while writing'));
+          else resolve();
+        }, 1);
+      });
+    }
+  }
+
+  async close(): Promise<void> {
+    if (this.closed) return;
+    this.closed = true;
+    this.settleReaders(null);
+    this.detach();
+    if (this.channel.readyState === 'connecting' || this.channel.readyState === 'open') {
+      this.channel.close();
+    }
+  }
+
+  private readonly handleMessage = (event: MessageEvent): void => {
+    if (!(event.data instanceof ArrayBuffer)) {
+      this.fail(new Error('terminal data channel received a non-binary message'));
+      return;
+    }
+    const data = new Uint8Array(event.data);
+    const waiter = this.waiters.shift();
+    if (waiter) waiter.resolve(data);
+    else this.reads.push(data);
+  };
+
+  private readonly handleClose = (): void => {
+    if (this.closed) return;
+    this.closed = true;
+    this.settleReaders(null);
+    this.detach();
+  };
+
+  private readonly handleError = (event: Event): void => {
+    this.fail(asError(event, 'terminal data channel failed'));
+  };
+
+  private fail(error: Error): void {
+    if (this.closed) return;
+    this.failure = error;
+    this.closed = true;
+    this.rejectReaders(error);
+    this.detach();
+    if (this.channel.readyState === 'connecting' || this.channel.readyState === 'open') {
+      this.channel.close();
+    }
+  }
+
+  private settleReaders(value: Uint8Array | null): void {
+    for (const waiter of this.waiters.splice(0)) waiter.resolve(value);
+  }
+
+  private rejectReaders(error: Error): void {
+    for (const waiter of this.waiters.splice(0)) waiter.reject(error);
+  }
+
+  private detach(): void {
+    this.channel.removeEventListener('message', this.handleMessage);
+    this.channel.removeEventListener('close', this.handleClose);
+    this.channel.removeEventListener('error', this.handleError);
+  }
+}
+
+// bridgeByteStreams pumps bytes both ways until either stream ends, then
+// closes both. The sharer uses it to splice its /ws attachment onto the data
+// channel; it works for any stream pair.
+export const bridgeByteStreams = async (a: TerminalByteStream, b: TerminalByteStream): Promise<void> => {
+  const pump = async (src: TerminalByteStream, dst: TerminalByteStream): Promise<void> => {
+    for (;;) {
+      const chunk = await src.read();
+      if (chunk === null) return;
+      await dst.write(chunk);
+    }
+  };
+  const forward = pump(a, b);
+  const backward = pump(b, a);
+  try {
+    await Promise.race([forward, backward]);
+  } finally {
+    await a.close();
+    await b.close();
+    // The surviving pump settles once its stream closes; swallow its outcome.
+    await Promise.allSettled([forward, backward]);
+  }
+};
+
+const signalingUrl = (room: string): string => {
+  const url = new URL('/ws/webrtc', window.location.href);
+  url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+  url.searchParams.set('room', room);
+  return url.toString();
+};
+
+type SignalingChannel = Readonly<{
+  peerId: string;
+  send: (message: SignalMessage) => void;
+  onMessage: (handler: (message: SignalMessage) => void) => void;
+  close: () => void;
+}>;
+
+// openSignaling dials the relay and resolves once the server's welcome
+// message assigns this peer its id.
+const openSignaling = async (room: string, createSocket: SocketFactory): Promise<SignalingChannel> => {
+  const socket = createSocket(signalingUrl(room));
+  let handler: ((message: SignalMessage) => void) | null = null;
+  const peerId = await new Promise<string>((resolve, reject) => {
+    socket.addEventListener('message', event => {
+      if (typeof event.data !== 'string') return;
+      let message: SignalMessage;
+      try {
+        message = JSON.parse(event.data) as SignalMessage;
+      } catch {
+        return;
+      }
+      if (message.type === 'welcome' && message.to) resolve(message.to);
+      else handler?.(message);
+    });
+    socket.addEventListener('error', event => reject(asError(event, 'signaling socket failed')));
+    socket.addEventListener('close', () => reject(new Error('signaling socket closed before welcome')));
+  });
+  return {
+    peerId,
+    send: message => socket.send(JSON.stringify(message)),
+    onMessage: next => { handler = next; },
+    close: () => socket.close(1000, 'signaling done'),
+  };
+};
+
+export type WebRTCTransportOptions = Readonly<{
+  rtcConfiguration?: RTCConfiguration;
+  createSocket?: SocketFactory;
+  createPeerConnection?: PeerConnectionFactory;
+}>;
+
+const defaultOptions = {
+  createSocket: (url: string) => new WebSocket(url),
+  createPeerConnection: (config?: RTCConfiguration) => new RTCPeerConnection(config),
+} as const;
+
+// connectTerminalDataChannel is the viewer side: it offers a data channel to
+// whichever peer shares the room (the session owner's browser) and resolves
+// with a byte stream once the channel opens. Callers race this against a
+// timeout and fall back to the websocket transport on failure.
+export const connectTerminalDataChannel = async (
+  room: string,
+  options: WebRTCTransportOptions = {},
+): Promise<TerminalByteStream> => {
+  const createSocket = options.createSocket ?? defaultOptions.createSocket;
+  const createPeerConnection = options.createPeerConnection ?? defaultOptions.createPeerConnection;
+  const signaling = await openSignaling(room, createSocket);
+  const peer = createPeerConnection(options.rtcConfiguration);
+  try {
+    const channel = peer.createDataChannel(TERMINAL_CHANNEL_LABEL);
+    // The sharer is whichever peer answers first; once known, candidates from
+    // other viewers' broadcasts are ignored and ours are addressed directly.
+    let sharerId: string | undefined;
+    peer.addEventListener('icecandidate', event => {
+      if (event.candidate) signaling.send({ type: 'ice', to: sharerId, payload: event.candidate.toJSON() });
+    });
+    signaling.onMessage(message => {
+      void (async () => {
+        if (message.type === 'answer' && sharerId === undefined) {
+          sharerId = message.from;
+          await peer.setRemoteDescription(message.payload as RTCSessionDescriptionInit);
+        } else if (message.type === 'ice' && message.from === sharerId) {
+          await peer.addIceCandidate(message.payload as RTCIceCandidateInit).catch(() => {});
+        }
+      })();
+    });
+    const offer = await peer.createOffer();
+    await peer.setLocalDescription(offer);
+    // Broadcast: the sharer is whoever else is in the room.
+    signaling.send({ type: 'offer', payload: offer });
+
+    await new Promise<void>((resolve, reject) => {
+      channel.addEventListener('open', () => resolve());
+      channel.addEventListener('error', event => reject(asError(event, 'terminal data channel failed to open')));
+      peer.addEventListener('connectionstatechange', () => {
+        if (peer.connectionState === 'failed' || peer.connectionState === 'closed') {
+          reject(new Error('peer connection failed before the data channel opened'));
+        }
+      });
+    });
+    signaling.close();
+    return new RTCDataChannelByteStream(channel);
+  } catch (error) {
+    signaling.close();
+    peer.close();
+    throw error;
+  }
+};
+
+export type TerminalShare = Readonly<{
+  stop: () => void;
+}>;
+
+// shareTerminalOverWebRTC is the owner side: it answers every viewer offer in
+// the session's room and bridges a fresh local byte stream (normally a /ws
+// attachment made with the sharer's own credentials) onto each opened
+// channel. The returned handle tears down the signaling socket and every
+// active peer connection.
+export const shareTerminalOverWebRTC = async (
+  room: string,
+  openLocalStream: () => Promise<TerminalByteStream>,
+  options: WebRTCTransportOptions = {},
+): Promise<TerminalShare> => {
+  const createSocket = options.createSocket ?? defaultOptions.createSocket;
+  const createPeerConnection = options.createPeerConnection ?? defaultOptions.createPeerConnection;
+  const signaling = await openSignaling(room, createSocket);
+  const peers = new Map<string, RTCPeerConnection>();
+
+  signaling.onMessage(message => {
+    void (async () => {
+      const from = message.from;
+      if (!from) return;
+      if (message.type === 'offer') {
+        peers.get(from)?.close();
+        const peer = createPeerConnection(options.rtcConfiguration);
+        peers.set(from, peer);
+        peer.addEventListener('icecandidate', event => {
+          if (event.candidate) signaling.send({ type: 'ice', to: from, payload: event.candidate.toJSON() });
+        });
+        peer.addEventListener('datachannel', event => {
+          if (event.channel.label !== TERMINAL_CHANNEL_LABEL) return;
+          const remote = new RTCDataChannelByteStream(event.channel);
+          void (async () => {
+            try {
+              const local = await openLocalStream();
+              await bridgeByteStreams(local, remote);
+            } catch {
+              await remote.close();
+            } finally {
+              peers.get(from)?.close();
+              peers.delete(from);
+            }
+          })();
+        });
+        await peer.setRemoteDescription(message.payload as RTCSessionDescriptionInit);
+        const answer = await peer.createAnswer();
+        await peer.setLocalDescription(answer);
+        signaling.send({ type: 'answer', to: from, payload: answer });
+      } else if (message.type === 'ice') {
+        await peers.get(from)?.addIceCandidate(message.payload as RTCIceCandidateInit).catch(() => {});
+      } else if (message.type === 'peer-left') {
+        peers.get(from)?.close();
+        peers.delete(from);
+      }
+    })();
+  });
+
+  return {
+    stop: () => {
+      signaling.close();
+      for (const peer of peers.values()) peer.close();
+      peers.clear();
+    },
+  };
+};
@@ -0,0 +1,83 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// applySessionChroot confines the command to the isolation's chroot tree. The
+// kernel chroots before dropping credentials and before chdir, so this works
+// together with run-as-user; paths that referenced the tree from outside
+// (working directory, HOME) are rewritten to their in-jail equivalents.
+func applySessionChroot(cmd *exec.Cmd, iso SessionIsolation, runAsUser string) error {
+	root, err := resolveChrootDir(iso, runAsUser)
+	if err != nil {
+		return err
+	}
+	if root == "" {
+		return nil
+	}
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("chroot dir: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("chroot dir %s is not a directory", root)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Chroot = root
+	cmd.Dir = rebaseIntoChroot(cmd.Dir, root)
+	for i, entry := range cmd.Env {
+		if home, ok := strings.CutPrefix(entry, "HOME="); ok {
+			cmd.Env[i] = "HOME=" + rebaseIntoChroot(home, root)
+		}
+	}
+	return nil
+}
+
+// resolveChrootDir returns the effective jail root, or "" when chroot
+// confinement is not requested.
+func resolveChrootDir(iso SessionIsolation, runAsUser string) (string, error) {
+	if iso.ChrootDir != "" {
+		return iso.ChrootDir, nil
+	}
+	if !iso.ChrootHome {
+		return "", nil
+	}
+	if runAsUser != "" {
+		account, err := user.Lookup(runAsUser)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up chroot home: %w", err)
+		}
+		return account.HomeDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve chroot home: %w", err)
+	}
+	if home == "" {
+		return "", errors.New("no home directory to chroot into")
+	}
+	return home, nil
+}
+
+// rebaseIntoChroot translates a host path into the jail: paths inside the
+// tree keep their relative location, everything else lands at the jail root.
+func rebaseIntoChroot(path, root string) string {
+	if path == root {
+		return "/"
+	}
+	if rel, ok := strings.CutPrefix(path, root+string(filepath.Separator)); ok {
+		return "/" + rel
+	}
+	return "/"
+}
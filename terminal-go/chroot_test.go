@@ -0,0 +1,55 @@
+package terminal
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestApplySessionChrootRebasesPaths(t *testing.T) {
+	root := t.TempDir()
+	cmd := exec.Command("/bin/sh")
+	cmd.Dir = root + "/work"
+	cmd.Env = []string{"HOME=" + root, "TERM=xterm"}
+	if err := applySessionChroot(cmd, SessionIsolation{ChrootDir: root}, ""); err != nil {
+		t.Fatalf("applySessionChroot failed: %v", err)
+	}
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.Chroot != root {
+		t.Fatalf("chroot not set, SysProcAttr = %+v", cmd.SysProcAttr)
+	}
+	if cmd.Dir != "/work" {
+		t.Fatalf("working dir = %q, want /work", cmd.Dir)
+	}
+	if cmd.Env[0] != "HOME=/" {
+		t.Fatalf("HOME = %q, want HOME=/", cmd.Env[0])
+	}
+}
+
+func TestApplySessionChrootOutsidePathsLandAtRoot(t *testing.T) {
+	root := t.TempDir()
+	cmd := exec.Command("/bin/sh")
+	cmd.Dir = "/srv/elsewhere"
+	if err := applySessionChroot(cmd, SessionIsolation{ChrootDir: root}, ""); err != nil {
+		t.Fatalf("applySessionChroot failed: %v", err)
+	}
+	if cmd.Dir != "/" {
+		t.Fatalf("working dir = %q, want /", cmd.Dir)
+	}
+}
+
+func TestApplySessionChrootDisabled(t *testing.T) {
+	cmd := exec.Command("/bin/sh")
+	cmd.Dir = "/srv"
+	if err := applySessionChroot(cmd, SessionIsolation{}, ""); err != nil {
+		t.Fatalf("applySessionChroot failed: %v", err)
+	}
+	if cmd.SysProcAttr != nil || cmd.Dir != "/srv" {
+		t.Fatal("disabled chroot must leave the command untouched")
+	}
+}
+
+func TestApplySessionChrootMissingDir(t *testing.T) {
+	cmd := exec.Command("/bin/sh")
+	if err := applySessionChroot(cmd, SessionIsolation{ChrootDir: "/no/such/jail"}, ""); err == nil {
+		t.Fatal("missing chroot dir must fail")
+	}
+}
@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CommandPolicy authorizes a resolved command line before a PTY process is
+// spawned. The path and args are the final values the session would execute,
+// after shell resolution and argument providers have run; returning an error
+// fails the activation instead of starting the process.
+type CommandPolicy interface {
+	AuthorizeCommand(launch ShellLaunchContext, path string, args []string) error
+}
+
+// CommandRule matches one command line. Path is a shell-style glob compared
+// against both the full executable path and its base name (so "bash" matches
+// /bin/bash). Args, when non-nil, must equal the resolved argv exactly; nil
+// accepts any arguments.
+type CommandRule struct {
+	Path string
+	Args []string
+}
+
+func (r CommandRule) matches(path string, args []string) bool {
+	if ok, err := filepath.Match(r.Path, path); err != nil || !ok {
+		if ok, err := filepath.Match(r.Path, filepath.Base(path)); err != nil || !ok {
+			return false
+		}
+	}
+	if r.Args == nil {
+		return true
+	}
+	if len(r.Args) != len(args) {
+		return false
+	}
+	for i, arg := range r.Args {
+		if arg != args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// RuleCommandPolicy allows or denies command lines by rule, so locked-down
+// deployments can restrict sessions to a fixed set of entry commands. Deny
+// rules are checked first; a non-empty Allow list then requires a match,
+// while an empty one accepts everything not denied.
+type RuleCommandPolicy struct {
+	Allow []CommandRule
+	Deny  []CommandRule
+}
+
+func (p RuleCommandPolicy) AuthorizeCommand(launch ShellLaunchContext, path string, args []string) error {
+	display := strings.TrimSpace(path + " " + strings.Join(args, " "))
+	for _, rule := range p.Deny {
+		if rule.matches(path, args) {
+			return fmt.Errorf("command %q is denied by policy", display)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, rule := range p.Allow {
+		if rule.matches(path, args) {
+			return nil
+		}
+	}
+	return fmt.Errorf("command %q is not in the policy allowlist", display)
+}
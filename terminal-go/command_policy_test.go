@@ -0,0 +1,56 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleCommandPolicy(t *testing.T) {
+	policy := RuleCommandPolicy{
+		Allow: []CommandRule{
+			{Path: "bash"},
+			{Path: "/usr/bin/htop", Args: []string{}},
+		},
+		Deny: []CommandRule{{Path: "bash", Args: []string{"-c", "rm -rf /"}}},
+	}
+
+	if err := policy.AuthorizeCommand(ShellLaunchContext{}, "/bin/bash", []string{"-l"}); err != nil {
+		t.Fatalf("allowed shell rejected: %v", err)
+	}
+	if err := policy.AuthorizeCommand(ShellLaunchContext{}, "/usr/bin/htop", nil); err != nil {
+		t.Fatalf("allowed bare command rejected: %v", err)
+	}
+	if err := policy.AuthorizeCommand(ShellLaunchContext{}, "/usr/bin/htop", []string{"-d", "10"}); err == nil {
+		t.Fatal("argv-pinned rule accepted extra arguments")
+	}
+	if err := policy.AuthorizeCommand(ShellLaunchContext{}, "/bin/zsh", nil); err == nil {
+		t.Fatal("command outside the allowlist accepted")
+	} else if !strings.Contains(err.Error(), "allowlist") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := policy.AuthorizeCommand(ShellLaunchContext{}, "/bin/bash", []string{"-c", "rm -rf /"}); err == nil {
+		t.Fatal("denied command accepted")
+	} else if !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCommandPolicyBlocksActivation(t *testing.T) {
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     FixedShellResolver{Shell: "/bin/sh"},
+		ShellArgsProvider: FixedShellArgsProvider{Args: []string{"-c", "cat"}},
+		CommandPolicy:     RuleCommandPolicy{Allow: []CommandRule{{Path: "forbidden-shell"}}},
+	})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSession("locked", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.ActivateSession(session.ID, 80, 24); err == nil {
+		t.Fatal("expected activation to be rejected by policy")
+	} else if !strings.Contains(err.Error(), "policy") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
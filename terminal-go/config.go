@@ -5,6 +5,7 @@ import "time"
 // ManagerConfig defines defaults used for all sessions created by a manager.
 type ManagerConfig struct {
 	Logger                      Logger
+	Metrics                     Metrics
 	EnvProvider                 ShellEnvProvider
 	ShellResolver               ShellResolver
 	ShellArgsProvider           ShellArgsProvider
@@ -14,6 +15,37 @@ type ManagerConfig struct {
 	HistoryBufferMaxChunks      int
 	HistoryBufferMaxBytes       int64
 	OutputActivityQuietDuration time.Duration
+	// LoginShell controls whether shells are started as login shells (bash
+	// `-l`, zsh `-l`, fish `-l`) when the ShellArgsProvider has no opinion
+	// (returns nil args). Nil preserves the historical default of true.
+	// Providers that return explicit args manage login behaviour themselves.
+	LoginShell *bool
+	// EnablePromptMarkers makes the default shell init writer and args
+	// provider install OSC 133 A/B/C/D prompt markers so command tracking
+	// works without user dotfile changes. It only affects the defaults;
+	// custom writers/providers manage their own scripts.
+	EnablePromptMarkers bool
+	// MaxSessions caps how many sessions may exist at once; creation beyond
+	// the cap fails. Zero leaves creation unlimited.
+	MaxSessions int
+	// SessionIdleTimeout deletes sessions that have had no attached
+	// connections and no activity for this long. Zero disables the reaper.
+	SessionIdleTimeout time.Duration
+	// CommandPolicy, when set, authorizes every resolved shell command line
+	// before its PTY process spawns; a rejection fails the activation.
+	CommandPolicy CommandPolicy
+	// Isolation launches session processes in fresh Linux namespaces for
+	// lightweight separation between terminals; ignored on other platforms.
+	Isolation SessionIsolation
+	// Sandbox, when set, confines every session with Landlock path rules
+	// and an optional seccomp denylist (Linux only). Sessions may override
+	// it via CreateSessionOptions.Sandbox. The embedding binary must call
+	// MaybeRunSandboxHelper at the top of main.
+	Sandbox *SandboxProfile
+	// EchoAttributionWindow bounds how long after an input write output is
+	// still attributed to the writing connection for echo suppression. Zero
+	// keeps the 50ms default.
+	EchoAttributionWindow time.Duration
 	// Deprecated: terminal output is no longer dropped during initial resize.
 	InitialResizeSuppressDuration time.Duration
 	// Deprecated: terminal output is no longer dropped during resize.
@@ -33,17 +65,23 @@ type TerminalEnv struct {
 	TermFeatures       string
 }
 
-// DefaultTerminalEnv returns a baseline environment configuration.
+// DefaultTerminalEnv returns a baseline environment configuration. The locale
+// is detected from the host (with a C.UTF-8 fallback) rather than hardcoded,
+// so systems without en_US.UTF-8 keep working character handling.
 func DefaultTerminalEnv() TerminalEnv {
+	const term = "xterm-256color"
+	locale := detectUTF8Locale()
 	return TerminalEnv{
-		Term:               "xterm-256color",
+		Term:               term,
 		ColorTerm:          "truecolor",
-		Lang:               "en_US.UTF-8",
-		LcAll:              "en_US.UTF-8",
+		Lang:               locale,
+		LcAll:              locale,
 		TermProgram:        "floeterm",
 		TermProgramVersion: "0.4.4",
-		Terminfo:           "/usr/share/terminfo",
-		TermFeatures:       "256color:altscreen:mouse",
+		// Detected per host; empty when no database exists, in which case
+		// TERMINFO is left unset and curses falls back to its own paths.
+		Terminfo:     detectTerminfoDir(term),
+		TermFeatures: "256color:altscreen:mouse",
 	}
 }
 
@@ -52,6 +90,9 @@ func (cfg ManagerConfig) applyDefaults() ManagerConfig {
 	if cfg.Logger == nil {
 		cfg.Logger = NopLogger{}
 	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = NopMetrics{}
+	}
 	if cfg.EnvProvider == nil {
 		cfg.EnvProvider = DefaultEnvProvider{}
 	}
@@ -59,14 +100,18 @@ func (cfg ManagerConfig) applyDefaults() ManagerConfig {
 		cfg.ShellResolver = DefaultShellResolver{}
 	}
 	if cfg.ShellArgsProvider == nil {
-		cfg.ShellArgsProvider = DefaultShellArgsProvider{}
+		cfg.ShellArgsProvider = DefaultShellArgsProvider{EnablePromptMarkers: cfg.EnablePromptMarkers}
 	}
 	if cfg.ShellInitWriter == nil {
-		cfg.ShellInitWriter = DefaultShellInitWriter{}
+		cfg.ShellInitWriter = DefaultShellInitWriter{EnablePromptMarkers: cfg.EnablePromptMarkers}
 	}
 	if cfg.HistoryFilter == nil {
 		cfg.HistoryFilter = DefaultHistoryFilter{}
 	}
+	if cfg.LoginShell == nil {
+		loginShell := true
+		cfg.LoginShell = &loginShell
+	}
 	if cfg.HistoryBufferSize <= 0 {
 		cfg.HistoryBufferSize = 2048
 	}
@@ -76,6 +121,9 @@ func (cfg ManagerConfig) applyDefaults() ManagerConfig {
 	if cfg.OutputActivityQuietDuration <= 0 {
 		cfg.OutputActivityQuietDuration = 3500 * time.Millisecond
 	}
+	if cfg.EchoAttributionWindow <= 0 {
+		cfg.EchoAttributionWindow = defaultEchoAttributionWindow
+	}
 	if cfg.InitialResizeSuppressDuration <= 0 {
 		cfg.InitialResizeSuppressDuration = 500 * time.Millisecond
 	}
@@ -85,12 +133,19 @@ func (cfg ManagerConfig) applyDefaults() ManagerConfig {
 	if cfg.TerminalEnv == (TerminalEnv{}) {
 		cfg.TerminalEnv = DefaultTerminalEnv()
 	}
+	if cfg.TerminalEnv.Lang == "" {
+		cfg.TerminalEnv.Lang = detectUTF8Locale()
+	}
+	if cfg.TerminalEnv.LcAll == "" {
+		cfg.TerminalEnv.LcAll = cfg.TerminalEnv.Lang
+	}
 
 	return cfg
 }
 
 type sessionConfig struct {
 	logger                      Logger
+	metrics                     Metrics
 	envProvider                 ShellEnvProvider
 	shellResolver               ShellResolver
 	shellArgsProvider           ShellArgsProvider
@@ -100,6 +155,11 @@ type sessionConfig struct {
 	historyBufferMaxChunks      int
 	historyBufferMaxBytes       int64
 	outputActivityQuietDuration time.Duration
+	echoAttributionWindow       time.Duration
+	commandPolicy               CommandPolicy
+	isolation                   SessionIsolation
+	sandbox                     *SandboxProfile
+	loginShell                  bool
 	terminalEnv                 TerminalEnv
 }
 
@@ -107,6 +167,7 @@ func newSessionConfig(cfg ManagerConfig) sessionConfig {
 	cfg = cfg.applyDefaults()
 	return sessionConfig{
 		logger:                      cfg.Logger,
+		metrics:                     cfg.Metrics,
 		envProvider:                 cfg.EnvProvider,
 		shellResolver:               cfg.ShellResolver,
 		shellArgsProvider:           cfg.ShellArgsProvider,
@@ -116,6 +177,11 @@ func newSessionConfig(cfg ManagerConfig) sessionConfig {
 		historyBufferMaxChunks:      cfg.HistoryBufferMaxChunks,
 		historyBufferMaxBytes:       cfg.HistoryBufferMaxBytes,
 		outputActivityQuietDuration: cfg.OutputActivityQuietDuration,
+		echoAttributionWindow:       cfg.EchoAttributionWindow,
+		commandPolicy:               cfg.CommandPolicy,
+		isolation:                   cfg.Isolation,
+		sandbox:                     cfg.Sandbox,
+		loginShell:                  *cfg.LoginShell,
 		terminalEnv:                 cfg.TerminalEnv,
 	}
 }
@@ -16,11 +16,11 @@ func (s *Session) AddConnection(connectionID string, cols, rows int) {
 // committed source sequence that belongs to its initial history snapshot.
 func (s *Session) AddConnectionWithHistoryBoundary(connectionID string, cols, rows int) int64 {
 	if connectionID == "" {
-		s.config.logger.Error("Cannot add connection with empty ID", "sessionID", s.ID)
+		s.log().Error("Cannot add connection with empty ID")
 		return 0
 	}
 
-	s.config.logger.Debug("Adding connection", "sessionID", s.ID, "connectionID", connectionID, "cols", cols, "rows", rows)
+	s.log().Debug("Adding connection", "connectionID", connectionID, "cols", cols, "rows", rows)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -33,7 +33,7 @@ func (s *Session) AddConnectionWithHistoryBoundary(connectionID string, cols, ro
 	}
 
 	if existing != nil {
-		s.config.logger.Debug("Replacing existing connection", "sessionID", s.ID, "connectionID", connectionID, "oldJoinedAt", existing.JoinedAt)
+		s.log().Debug("Replacing existing connection", "connectionID", connectionID, "oldJoinedAt", existing.JoinedAt)
 	}
 	if s.isActive {
 		s.schedulePTYSizeReconcileLocked("connection-added")
@@ -47,7 +47,7 @@ func (s *Session) RemoveConnection(connectionID string) {
 		return
 	}
 
-	s.config.logger.Debug("Removing connection", "sessionID", s.ID, "connectionID", connectionID)
+	s.log().Debug("Removing connection", "connectionID", connectionID)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -56,7 +56,7 @@ func (s *Session) RemoveConnection(connectionID string) {
 		return
 	}
 	delete(s.connections, connectionID)
-	s.config.logger.Debug("Removed connection", "sessionID", s.ID, "connectionID", connectionID, "joinedAt", conn.JoinedAt)
+	s.log().Debug("Removed connection", "connectionID", connectionID, "joinedAt", conn.JoinedAt)
 
 	// IMPORTANT: A detached session keeps the last applied PTY size. Resetting
 	// to 80x24 would reflow the shell and create output without a user resize.
@@ -71,14 +71,14 @@ func (s *Session) UpdateConnectionSize(connectionID string, cols, rows int) {
 		return
 	}
 
-	s.config.logger.Debug("Updating connection size", "sessionID", s.ID, "connectionID", connectionID, "cols", cols, "rows", rows)
+	s.log().Debug("Updating connection size", "connectionID", connectionID, "cols", cols, "rows", rows)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	conn, exists := s.connections[connectionID]
 	if !exists {
 		// A resize may race ahead of attach or arrive after a fast reconnect.
-		s.config.logger.Debug("Connection not found for size update", "sessionID", s.ID, "connectionID", connectionID)
+		s.log().Debug("Connection not found for size update", "connectionID", connectionID)
 		return
 	}
 	conn.Cols = cols
@@ -89,7 +89,8 @@ func (s *Session) UpdateConnectionSize(connectionID string, cols, rows int) {
 }
 
 // ApplyConnectionSize records one view's dimensions and returns only after the
-// shared PTY reflects the minimum rows and columns required by all live views.
+// shared PTY reflects the size required by the session's resize policy across
+// all live views.
 func (s *Session) ApplyConnectionSize(connectionID string, cols, rows int) (TerminalGeometry, error) {
 	if connectionID == "" {
 		return TerminalGeometry{}, fmt.Errorf("connection ID is required")
@@ -173,8 +174,8 @@ func (s *Session) getMinimumTerminalSize() (int, int) {
 func (s *Session) effectiveGeometryLocked() TerminalGeometry {
 	cols, rows := s.lastAppliedCols, s.lastAppliedRows
 	if cols <= 0 || rows <= 0 {
-		if minimumCols, minimumRows, ok := s.getMinimumTerminalSizeLocked(); ok {
-			cols, rows = minimumCols, minimumRows
+		if desiredCols, desiredRows, ok := s.desiredTerminalSizeLocked(); ok {
+			cols, rows = desiredCols, desiredRows
 		} else {
 			cols, rows = 80, 24
 		}
@@ -191,7 +192,7 @@ func (s *Session) effectiveGeometryLocked() TerminalGeometry {
 }
 
 func (s *Session) reconcilePTYSizeLocked(reason string, force bool) error {
-	cols, rows, ok := s.getMinimumTerminalSizeLocked()
+	cols, rows, ok := s.desiredTerminalSizeLocked()
 	if !ok {
 		return nil
 	}
@@ -219,7 +220,7 @@ func (s *Session) runPTYSizeReconciler() {
 		}
 		reason := s.resizeReason
 		s.resizeQueued = false
-		cols, rows, ok := s.getMinimumTerminalSizeLocked()
+		cols, rows, ok := s.desiredTerminalSizeLocked()
 		ptyFile := s.PTY
 		setSize := s.setPTYSize
 		if setSize == nil {
@@ -253,7 +254,7 @@ func (s *Session) runPTYSizeReconciler() {
 		}
 
 		if err != nil && stillCurrent {
-			s.config.logger.Warn("Failed to reconcile PTY size", "sessionID", s.ID, "reason", reason, "error", err)
+			s.log().Warn("Failed to reconcile PTY size", "reason", reason, "error", err)
 		}
 	}
 }
@@ -276,7 +277,7 @@ func (s *Session) applyPTYSizeLocked(cols, rows int, reason string, force bool)
 		if s.geometryGeneration == 0 {
 			s.geometryGeneration = 1
 		}
-		s.config.logger.Debug("PTY resize skipped", "sessionID", s.ID, "cols", cols, "rows", rows, "reason", reason)
+		s.log().Debug("PTY resize skipped", "cols", cols, "rows", rows, "reason", reason)
 		return nil
 	}
 
@@ -295,7 +296,8 @@ func (s *Session) applyPTYSizeLocked(cols, rows int, reason string, force bool)
 			s.geometryGeneration = 1
 		}
 	}
-	s.config.logger.Debug("PTY resized", "sessionID", s.ID, "cols", cols, "rows", rows, "reason", reason)
+	s.config.metrics.IncCounter(MetricPTYResizes, 1)
+	s.log().Debug("PTY resized", "cols", cols, "rows", rows, "reason", reason)
 	return nil
 }
 
@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOutputEchoAttribution(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}})
+	t.Cleanup(manager.Cleanup)
+	session, err := manager.CreateSession("echo-attribution", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A pipe stands in for the PTY master so input writes succeed without
+	// launching a shell; output is injected directly below.
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = reader.Close()
+		_ = writer.Close()
+	})
+	session.mu.Lock()
+	session.PTY = writer
+	session.mu.Unlock()
+
+	events := make(chan TerminalOutputEvent, 4)
+	attachment, err := session.AttachLiveConnection("conn-1", 1, 80, 24, LiveSubscriber{
+		OnOutput: func(event TerminalOutputEvent) bool {
+			events <- event
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(attachment.Detach)
+
+	// Output right after an input write is attributed to the writer.
+	if err := session.WriteDataWithSource([]byte("x"), "conn-1"); err != nil {
+		t.Fatal(err)
+	}
+	session.processRawPTYData([]byte("x"))
+	event := <-events
+	if event.EchoSourceConnID != "conn-1" {
+		t.Fatalf("echo source = %q, want conn-1", event.EchoSourceConnID)
+	}
+
+	// Output beyond the attribution window is nobody's echo.
+	session.mu.Lock()
+	session.lastInputTime = time.Now().Add(-2 * defaultEchoAttributionWindow)
+	session.mu.Unlock()
+	session.processRawPTYData([]byte("y"))
+	event = <-events
+	if event.EchoSourceConnID != "" {
+		t.Fatalf("echo source = %q, want unattributed", event.EchoSourceConnID)
+	}
+}
@@ -3,6 +3,7 @@ package terminal
 import (
 	"context"
 	"os"
+	"strings"
 )
 
 // ShellEnvProvider builds environment variables for a new PTY session.
@@ -31,6 +32,18 @@ func (p DefaultEnvProvider) BuildEnvContext(ctx context.Context, shellPath strin
 	return p.BuildEnv(shellPath, workingDir)
 }
 
+// combinePathPrepends joins colon-separated PATH prefixes in priority order,
+// dropping empty parts. The first argument ends up first in PATH.
+func combinePathPrepends(prepends ...string) string {
+	parts := make([]string, 0, len(prepends))
+	for _, prepend := range prepends {
+		if prepend != "" {
+			parts = append(parts, prepend)
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
 // StaticEnvProvider allows callers to provide explicit env and PATH prepends.
 type StaticEnvProvider struct {
 	Env         []string
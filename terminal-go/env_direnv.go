@@ -0,0 +1,128 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DirenvEnvProvider evaluates the session working directory with direnv
+// (`direnv export json`) and merges the exported variables on top of the
+// process environment, so terminals opened into a project start with the
+// project's `.envrc` applied just like a native terminal would.
+//
+// When direnv is not installed, the directory has no allowed `.envrc`, or
+// evaluation fails, the provider falls back to the plain process environment
+// instead of blocking session creation.
+type DirenvEnvProvider struct {
+	// DirenvPath overrides the direnv executable. Defaults to "direnv" from PATH.
+	DirenvPath string
+	// Timeout bounds `.envrc` evaluation. Defaults to 5 seconds.
+	Timeout time.Duration
+	// Logger receives evaluation failures. Defaults to NopLogger.
+	Logger Logger
+}
+
+func (p DirenvEnvProvider) BuildEnv(shellPath string, workingDir string) ([]string, string, error) {
+	return p.BuildEnvContext(context.Background(), shellPath, workingDir)
+}
+
+func (p DirenvEnvProvider) BuildEnvContext(ctx context.Context, shellPath string, workingDir string) ([]string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	logger := p.Logger
+	if logger == nil {
+		logger = NopLogger{}
+	}
+
+	direnvPath := p.DirenvPath
+	if direnvPath == "" {
+		direnvPath = "direnv"
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	exported, err := runDirenvExport(ctx, direnvPath, workingDir, timeout)
+	if err != nil {
+		logger.Warn("direnv evaluation failed, using plain environment", "dir", workingDir, "error", err)
+		return os.Environ(), "", nil
+	}
+	if len(exported) == 0 {
+		return os.Environ(), "", nil
+	}
+
+	return mergeEnv(os.Environ(), exported), "", nil
+}
+
+// runDirenvExport runs `direnv export json` in dir and decodes the result.
+// direnv prints an empty output when the directory has no allowed `.envrc`.
+// Removed variables are reported with a null value.
+func runDirenvExport(ctx context.Context, direnvPath string, dir string, timeout time.Duration) (map[string]*string, error) {
+	if _, err := exec.LookPath(direnvPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, direnvPath, "export", "json")
+	cmd.Dir = dir
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	var exported map[string]*string
+	if err := json.Unmarshal(out, &exported); err != nil {
+		return nil, err
+	}
+	return exported, nil
+}
+
+// mergeEnv applies exported overrides on top of base, removing variables whose
+// exported value is nil and preserving the order of untouched entries.
+func mergeEnv(base []string, exported map[string]*string) []string {
+	merged := make([]string, 0, len(base)+len(exported))
+	seen := make(map[string]bool, len(exported))
+
+	for _, entry := range base {
+		name, _, ok := strings.Cut(entry, "=")
+		if !ok {
+			merged = append(merged, entry)
+			continue
+		}
+		if value, overridden := exported[name]; overridden {
+			seen[name] = true
+			if value == nil {
+				continue
+			}
+			merged = append(merged, name+"="+*value)
+			continue
+		}
+		merged = append(merged, entry)
+	}
+
+	for name, value := range exported {
+		if seen[name] || value == nil {
+			continue
+		}
+		merged = append(merged, name+"="+*value)
+	}
+
+	return merged
+}
@@ -0,0 +1,38 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeEnvAppliesOverridesRemovalsAndAdditions(t *testing.T) {
+	base := []string{"HOME=/home/u", "PATH=/usr/bin", "STALE=1"}
+	removed := (*string)(nil)
+	path := "/project/bin:/usr/bin"
+	extra := "yes"
+
+	merged := mergeEnv(base, map[string]*string{
+		"PATH":  &path,
+		"STALE": removed,
+		"EXTRA": &extra,
+	})
+
+	want := []string{"HOME=/home/u", "PATH=/project/bin:/usr/bin", "EXTRA=yes"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("merged env = %v, want %v", merged, want)
+	}
+}
+
+func TestDirenvEnvProviderFallsBackWhenDirenvMissing(t *testing.T) {
+	p := DirenvEnvProvider{DirenvPath: "/nonexistent/direnv"}
+	env, pathPrepend, err := p.BuildEnv("/bin/sh", t.TempDir())
+	if err != nil {
+		t.Fatalf("BuildEnv returned error: %v", err)
+	}
+	if len(env) == 0 {
+		t.Fatalf("expected fallback to process environment")
+	}
+	if pathPrepend != "" {
+		t.Fatalf("expected empty pathPrepend, got %q", pathPrepend)
+	}
+}
@@ -0,0 +1,149 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dotenvMaxFileBytesDefault = 64 * 1024
+
+// DotenvEnvProvider loads `.env` style files from the session working
+// directory and merges their variables on top of the process environment, so
+// API-created sessions for a project automatically carry its configuration.
+//
+// Unlike DirenvEnvProvider it never executes code: files are parsed as plain
+// KEY=VALUE lines. A missing or unreadable file falls back to the plain
+// process environment instead of blocking session creation.
+type DotenvEnvProvider struct {
+	// Filenames lists the files loaded from the working directory, in order;
+	// later files override earlier ones. Defaults to [".env"].
+	Filenames []string
+	// Allowlist restricts which variable names are imported. Empty means all.
+	Allowlist []string
+	// MaxFileBytes bounds the size of each file. Defaults to 64 KiB.
+	MaxFileBytes int64
+	// Logger receives parse failures. Defaults to NopLogger.
+	Logger Logger
+}
+
+func (p DotenvEnvProvider) BuildEnv(shellPath string, workingDir string) ([]string, string, error) {
+	return p.BuildEnvContext(context.Background(), shellPath, workingDir)
+}
+
+func (p DotenvEnvProvider) BuildEnvContext(ctx context.Context, shellPath string, workingDir string) ([]string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	logger := p.Logger
+	if logger == nil {
+		logger = NopLogger{}
+	}
+
+	filenames := p.Filenames
+	if len(filenames) == 0 {
+		filenames = []string{".env"}
+	}
+	maxBytes := p.MaxFileBytes
+	if maxBytes <= 0 {
+		maxBytes = dotenvMaxFileBytesDefault
+	}
+
+	allowed := make(map[string]bool, len(p.Allowlist))
+	for _, name := range p.Allowlist {
+		allowed[name] = true
+	}
+
+	loaded := map[string]*string{}
+	for _, filename := range filenames {
+		path := filepath.Join(workingDir, filename)
+		vars, err := parseDotenvFile(path, maxBytes)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Warn("Failed to load dotenv file", "path", path, "error", err)
+			}
+			continue
+		}
+		for name, value := range vars {
+			if len(allowed) > 0 && !allowed[name] {
+				continue
+			}
+			v := value
+			loaded[name] = &v
+		}
+	}
+
+	if len(loaded) == 0 {
+		return os.Environ(), "", nil
+	}
+	return mergeEnv(os.Environ(), loaded), "", nil
+}
+
+// parseDotenvFile reads KEY=VALUE pairs from path. It skips blank lines and
+// `#` comments, tolerates an `export ` prefix and strips matching single or
+// double quotes around values. No expansion or escaping is performed.
+func parseDotenvFile(path string, maxBytes int64) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxBytes {
+		return nil, fmt.Errorf("dotenv file exceeds %d bytes", maxBytes)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(io.LimitReader(file, maxBytes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || !isValidEnvName(name) {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 {
+			if (value[0] == '"' && value[len(value)-1] == '"') ||
+				(value[0] == '\'' && value[len(value)-1] == '\'') {
+				value = value[1 : len(value)-1]
+			}
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+func isValidEnvName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
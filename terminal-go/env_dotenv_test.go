@@ -0,0 +1,72 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDotenvEnvProviderLoadsAndFilters(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Join([]string{
+		"# comment",
+		"export API_URL=\"https://example.test\"",
+		"SECRET_TOKEN='abc'",
+		"IGNORED=1",
+		"not a valid line",
+	}, "\n")
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	p := DotenvEnvProvider{Allowlist: []string{"API_URL", "SECRET_TOKEN"}}
+	env, _, err := p.BuildEnv("/bin/sh", dir)
+	if err != nil {
+		t.Fatalf("BuildEnv: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		got[name] = value
+	}
+	if got["API_URL"] != "https://example.test" {
+		t.Fatalf("API_URL = %q", got["API_URL"])
+	}
+	if got["SECRET_TOKEN"] != "abc" {
+		t.Fatalf("SECRET_TOKEN = %q", got["SECRET_TOKEN"])
+	}
+	if _, ok := got["IGNORED"]; ok {
+		t.Fatalf("IGNORED should have been filtered by the allowlist")
+	}
+}
+
+func TestDotenvEnvProviderRejectsOversizedFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("BIG=1\nTOO_BIG=2\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	p := DotenvEnvProvider{MaxFileBytes: 4}
+	env, _, err := p.BuildEnv("/bin/sh", dir)
+	if err != nil {
+		t.Fatalf("BuildEnv: %v", err)
+	}
+	for _, entry := range env {
+		if strings.HasPrefix(entry, "BIG=") || strings.HasPrefix(entry, "TOO_BIG=") {
+			t.Fatalf("oversized file should not be loaded, found %q", entry)
+		}
+	}
+}
+
+func TestDotenvEnvProviderMissingFileFallsBack(t *testing.T) {
+	p := DotenvEnvProvider{}
+	env, pathPrepend, err := p.BuildEnv("/bin/sh", t.TempDir())
+	if err != nil {
+		t.Fatalf("BuildEnv: %v", err)
+	}
+	if len(env) == 0 || pathPrepend != "" {
+		t.Fatalf("expected plain process environment, got %d entries, prepend %q", len(env), pathPrepend)
+	}
+}
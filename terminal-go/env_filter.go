@@ -0,0 +1,87 @@
+package terminal
+
+import (
+	"context"
+	"strings"
+)
+
+// FilteredEnvProvider wraps another provider and strips environment variables
+// before PTY start, so daemon secrets (cloud credentials, tokens, agent
+// sockets) do not leak into every user shell.
+//
+// Patterns match variable names exactly or with a single `*` wildcard at
+// either end (e.g. "AWS_*", "*_TOKEN", "SSH_AUTH_SOCK"). When Allow is
+// non-empty, only matching variables are kept; Deny is applied afterwards
+// either way.
+type FilteredEnvProvider struct {
+	// Base builds the environment being filtered. Defaults to DefaultEnvProvider.
+	Base  ShellEnvProvider
+	Allow []string
+	Deny  []string
+}
+
+func (p FilteredEnvProvider) BuildEnv(shellPath string, workingDir string) ([]string, string, error) {
+	return p.BuildEnvContext(context.Background(), shellPath, workingDir)
+}
+
+func (p FilteredEnvProvider) BuildEnvContext(ctx context.Context, shellPath string, workingDir string) ([]string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	base := p.Base
+	if base == nil {
+		base = DefaultEnvProvider{}
+	}
+	var env []string
+	var pathPrepend string
+	var err error
+	if contextBase, ok := base.(ContextShellEnvProvider); ok {
+		env, pathPrepend, err = contextBase.BuildEnvContext(ctx, shellPath, workingDir)
+	} else {
+		env, pathPrepend, err = base.BuildEnv(shellPath, workingDir)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return p.filterEnv(env), pathPrepend, nil
+}
+
+func (p FilteredEnvProvider) filterEnv(env []string) []string {
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if len(p.Allow) > 0 && !envPatternsMatch(p.Allow, name) {
+			continue
+		}
+		if envPatternsMatch(p.Deny, name) {
+			continue
+		}
+		filtered = append(filtered, kv)
+	}
+	return filtered
+}
+
+func envPatternsMatch(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if envPatternMatches(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func envPatternMatches(pattern, name string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*") && strings.HasSuffix(pattern, "*") && len(pattern) > 1:
+		return strings.Contains(name, pattern[1:len(pattern)-1])
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(name, pattern[:len(pattern)-1])
+	case strings.HasPrefix(pattern, "*"):
+		return strings.HasSuffix(name, pattern[1:])
+	default:
+		return pattern == name
+	}
+}
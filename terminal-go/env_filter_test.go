@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"testing"
+)
+
+func TestFilteredEnvProviderDenyPatterns(t *testing.T) {
+	provider := FilteredEnvProvider{
+		Base: StaticEnvProvider{Env: []string{
+			"HOME=/home/u",
+			"AWS_SECRET_ACCESS_KEY=hunter2",
+			"GITHUB_TOKEN=gh",
+			"SSH_AUTH_SOCK=/tmp/agent",
+			"TERM=xterm-256color",
+		}},
+		Deny: []string{"AWS_*", "*_TOKEN", "SSH_AUTH_SOCK"},
+	}
+
+	env, _, err := provider.BuildEnv("/bin/sh", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"HOME=/home/u", "TERM=xterm-256color"}
+	if len(env) != len(want) {
+		t.Fatalf("env = %v, want %v", env, want)
+	}
+	for i, kv := range want {
+		if env[i] != kv {
+			t.Fatalf("env[%d] = %q, want %q", i, env[i], kv)
+		}
+	}
+}
+
+func TestFilteredEnvProviderAllowlist(t *testing.T) {
+	provider := FilteredEnvProvider{
+		Base: StaticEnvProvider{Env: []string{
+			"HOME=/home/u",
+			"PATH=/usr/bin",
+			"TERM_PROGRAM=floeterm",
+			"AWS_REGION=eu-west-1",
+		}},
+		Allow: []string{"HOME", "PATH", "TERM*"},
+	}
+
+	env, _, err := provider.BuildEnv("/bin/sh", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"HOME=/home/u", "PATH=/usr/bin", "TERM_PROGRAM=floeterm"}
+	if len(env) != len(want) {
+		t.Fatalf("env = %v, want %v", env, want)
+	}
+
+	// Deny still applies on top of an allowlist.
+	provider.Deny = []string{"TERM_PROGRAM"}
+	env, _, err = provider.BuildEnv("/bin/sh", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env) != 2 {
+		t.Fatalf("env = %v, want HOME and PATH only", env)
+	}
+}
+
+func TestEnvPatternMatching(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"AWS_*", "AWS_REGION", true},
+		{"AWS_*", "MY_AWS_REGION", false},
+		{"*_TOKEN", "GITHUB_TOKEN", true},
+		{"*_TOKEN", "TOKEN", false},
+		{"*SECRET*", "MY_SECRET_VALUE", true},
+		{"SSH_AUTH_SOCK", "SSH_AUTH_SOCK", true},
+		{"SSH_AUTH_SOCK", "SSH_AUTH_SOCK2", false},
+		{"*", "ANYTHING", true},
+	}
+	for _, tc := range cases {
+		if got := envPatternMatches(tc.pattern, tc.name); got != tc.want {
+			t.Fatalf("envPatternMatches(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package terminal
+
+import "sync"
+
+// ManagerEvents lets embedders observe individual event types through
+// subscription functions instead of implementing the full
+// TerminalEventHandler interface with empty stubs. Subscriptions coexist
+// with a handler installed via SetEventHandler; both see every event.
+//
+// Callbacks run synchronously on the dispatching goroutine (including the
+// PTY read path for OnData), so they must return quickly and must not call
+// back into the Session or Manager while holding their own locks.
+type ManagerEvents struct {
+	mu     sync.RWMutex
+	nextID int
+
+	created     map[int]func(session *Session)
+	closed      map[int]func(sessionID string)
+	data        map[int]func(sessionID string, event TerminalOutputEvent)
+	nameChanged map[int]func(sessionID, oldName, newName, workingDir string)
+	errors      map[int]func(sessionID string, err error)
+	metadata    map[int]func(sessionID string, info TerminalSessionInfo)
+	activity    map[int]func(sessionID string, info TerminalOutputActivityInfo)
+	transfer    map[int]func(sessionID string, info FileTransferInfo)
+}
+
+func newManagerEvents() *ManagerEvents {
+	return &ManagerEvents{
+		created:     make(map[int]func(*Session)),
+		closed:      make(map[int]func(string)),
+		data:        make(map[int]func(string, TerminalOutputEvent)),
+		nameChanged: make(map[int]func(string, string, string, string)),
+		errors:      make(map[int]func(string, error)),
+		metadata:    make(map[int]func(string, TerminalSessionInfo)),
+		activity:    make(map[int]func(string, TerminalOutputActivityInfo)),
+		transfer:    make(map[int]func(string, FileTransferInfo)),
+	}
+}
+
+// EventSubscription identifies one registered callback; Cancel removes it.
+type EventSubscription struct {
+	cancel func()
+	once   sync.Once
+}
+
+// Cancel removes the subscription. It is safe to call more than once.
+func (s *EventSubscription) Cancel() {
+	s.once.Do(s.cancel)
+}
+
+func (e *ManagerEvents) subscribe(register func(id int), remove func(id int)) *EventSubscription {
+	e.mu.Lock()
+	e.nextID++
+	id := e.nextID
+	register(id)
+	e.mu.Unlock()
+	return &EventSubscription{cancel: func() {
+		e.mu.Lock()
+		remove(id)
+		e.mu.Unlock()
+	}}
+}
+
+// OnSessionCreated registers fn for every newly created session.
+func (e *ManagerEvents) OnSessionCreated(fn func(session *Session)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.created[id] = fn },
+		func(id int) { delete(e.created, id) },
+	)
+}
+
+// OnSessionClosed registers fn for every deleted or exited session. Exit
+// details, when present, are available via the session list before removal
+// or through the handler interface's session handle.
+func (e *ManagerEvents) OnSessionClosed(fn func(sessionID string)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.closed[id] = fn },
+		func(id int) { delete(e.closed, id) },
+	)
+}
+
+// OnData registers fn for every committed output chunk. It runs on the PTY
+// read path, so fn must be cheap.
+func (e *ManagerEvents) OnData(fn func(sessionID string, event TerminalOutputEvent)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.data[id] = fn },
+		func(id int) { delete(e.data, id) },
+	)
+}
+
+// OnNameChanged registers fn for session renames, including automatic ones
+// derived from the working directory.
+func (e *ManagerEvents) OnNameChanged(fn func(sessionID, oldName, newName, workingDir string)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.nameChanged[id] = fn },
+		func(id int) { delete(e.nameChanged, id) },
+	)
+}
+
+// OnError registers fn for session-level errors.
+func (e *ManagerEvents) OnError(fn func(sessionID string, err error)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.errors[id] = fn },
+		func(id int) { delete(e.errors, id) },
+	)
+}
+
+// OnMetadataChanged registers fn for session metadata transitions such as
+// working directory and foreground command changes.
+func (e *ManagerEvents) OnMetadataChanged(fn func(sessionID string, info TerminalSessionInfo)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.metadata[id] = fn },
+		func(id int) { delete(e.metadata, id) },
+	)
+}
+
+// OnOutputActivityChanged registers fn for output phase transitions.
+func (e *ManagerEvents) OnOutputActivityChanged(fn func(sessionID string, info TerminalOutputActivityInfo)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.activity[id] = fn },
+		func(id int) { delete(e.activity, id) },
+	)
+}
+
+// OnFileTransferChanged registers fn for file-transfer state transitions
+// (handshake detected, transfer finished or cancelled).
+func (e *ManagerEvents) OnFileTransferChanged(fn func(sessionID string, info FileTransferInfo)) *EventSubscription {
+	return e.subscribe(
+		func(id int) { e.transfer[id] = fn },
+		func(id int) { delete(e.transfer, id) },
+	)
+}
+
+func (e *ManagerEvents) emitSessionCreated(session *Session) {
+	e.mu.RLock()
+	fns := make([]func(*Session), 0, len(e.created))
+	for _, fn := range e.created {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(session)
+	}
+}
+
+func (e *ManagerEvents) emitSessionClosed(sessionID string) {
+	e.mu.RLock()
+	fns := make([]func(string), 0, len(e.closed))
+	for _, fn := range e.closed {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID)
+	}
+}
+
+func (e *ManagerEvents) emitData(sessionID string, event TerminalOutputEvent) {
+	e.mu.RLock()
+	fns := make([]func(string, TerminalOutputEvent), 0, len(e.data))
+	for _, fn := range e.data {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID, event)
+	}
+}
+
+func (e *ManagerEvents) emitNameChanged(sessionID, oldName, newName, workingDir string) {
+	e.mu.RLock()
+	fns := make([]func(string, string, string, string), 0, len(e.nameChanged))
+	for _, fn := range e.nameChanged {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID, oldName, newName, workingDir)
+	}
+}
+
+func (e *ManagerEvents) emitError(sessionID string, err error) {
+	e.mu.RLock()
+	fns := make([]func(string, error), 0, len(e.errors))
+	for _, fn := range e.errors {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID, err)
+	}
+}
+
+func (e *ManagerEvents) emitMetadataChanged(sessionID string, info TerminalSessionInfo) {
+	e.mu.RLock()
+	fns := make([]func(string, TerminalSessionInfo), 0, len(e.metadata))
+	for _, fn := range e.metadata {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID, info)
+	}
+}
+
+func (e *ManagerEvents) emitOutputActivityChanged(sessionID string, info TerminalOutputActivityInfo) {
+	e.mu.RLock()
+	fns := make([]func(string, TerminalOutputActivityInfo), 0, len(e.activity))
+	for _, fn := range e.activity {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID, info)
+	}
+}
+
+func (e *ManagerEvents) emitFileTransferChanged(sessionID string, info FileTransferInfo) {
+	e.mu.RLock()
+	fns := make([]func(string, FileTransferInfo), 0, len(e.transfer))
+	for _, fn := range e.transfer {
+		fns = append(fns, fn)
+	}
+	e.mu.RUnlock()
+	for _, fn := range fns {
+		fn(sessionID, info)
+	}
+}
+
+// eventTee is the handler the manager actually installs once Events() has
+// been called: it forwards everything to the handler from SetEventHandler
+// (which may be nil) and to the typed subscriptions.
+type eventTee struct {
+	handler TerminalEventHandler
+	events  *ManagerEvents
+}
+
+func (t eventTee) OnTerminalData(sessionID string, event TerminalOutputEvent) {
+	if t.handler != nil {
+		t.handler.OnTerminalData(sessionID, event)
+	}
+	t.events.emitData(sessionID, event)
+}
+
+func (t eventTee) OnTerminalNameChanged(sessionID, oldName, newName, workingDir string) {
+	if t.handler != nil {
+		t.handler.OnTerminalNameChanged(sessionID, oldName, newName, workingDir)
+	}
+	t.events.emitNameChanged(sessionID, oldName, newName, workingDir)
+}
+
+func (t eventTee) OnTerminalSessionCreated(session *Session) {
+	if t.handler != nil {
+		t.handler.OnTerminalSessionCreated(session)
+	}
+	t.events.emitSessionCreated(session)
+}
+
+func (t eventTee) OnTerminalSessionClosed(sessionID string) {
+	if t.handler != nil {
+		t.handler.OnTerminalSessionClosed(sessionID)
+	}
+	t.events.emitSessionClosed(sessionID)
+}
+
+func (t eventTee) OnTerminalError(sessionID string, err error) {
+	if t.handler != nil {
+		t.handler.OnTerminalError(sessionID, err)
+	}
+	t.events.emitError(sessionID, err)
+}
+
+func (t eventTee) OnTerminalSessionMetadataChanged(sessionID string, info TerminalSessionInfo) {
+	if metadataHandler, ok := t.handler.(TerminalSessionMetadataEventHandler); ok {
+		metadataHandler.OnTerminalSessionMetadataChanged(sessionID, info)
+	}
+	t.events.emitMetadataChanged(sessionID, info)
+}
+
+func (t eventTee) OnTerminalOutputActivityChanged(sessionID string, info TerminalOutputActivityInfo) {
+	if outputHandler, ok := t.handler.(TerminalOutputActivityEventHandler); ok {
+		outputHandler.OnTerminalOutputActivityChanged(sessionID, info)
+	}
+	t.events.emitOutputActivityChanged(sessionID, info)
+}
+
+func (t eventTee) OnTerminalFileTransferChanged(sessionID string, info FileTransferInfo) {
+	if transferHandler, ok := t.handler.(TerminalFileTransferEventHandler); ok {
+		transferHandler.OnTerminalFileTransferChanged(sessionID, info)
+	}
+	t.events.emitFileTransferChanged(sessionID, info)
+}
@@ -0,0 +1,130 @@
+package terminal
+
+import (
+	"sync"
+	"testing"
+)
+
+type countingHandler struct {
+	mu      sync.Mutex
+	created int
+	closed  int
+}
+
+func (h *countingHandler) OnTerminalData(string, TerminalOutputEvent)           {}
+func (h *countingHandler) OnTerminalNameChanged(string, string, string, string) {}
+func (h *countingHandler) OnTerminalSessionCreated(*Session) {
+	h.mu.Lock()
+	h.created++
+	h.mu.Unlock()
+}
+func (h *countingHandler) OnTerminalSessionClosed(string) {
+	h.mu.Lock()
+	h.closed++
+	h.mu.Unlock()
+}
+func (h *countingHandler) OnTerminalError(string, error) {}
+
+func TestEventsSubscriptionsSeeLifecycle(t *testing.T) {
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: testShellArgsProvider{},
+	})
+
+	var mu sync.Mutex
+	var createdIDs, closedIDs []string
+	manager.Events().OnSessionCreated(func(session *Session) {
+		mu.Lock()
+		createdIDs = append(createdIDs, session.ID)
+		mu.Unlock()
+	})
+	manager.Events().OnSessionClosed(func(sessionID string) {
+		mu.Lock()
+		closedIDs = append(closedIDs, sessionID)
+		mu.Unlock()
+	})
+
+	session, err := manager.CreateSession("observed", "")
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if err := manager.DeleteSession(session.ID); err != nil {
+		t.Fatalf("delete session failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(createdIDs) != 1 || createdIDs[0] != session.ID {
+		t.Fatalf("created subscriptions saw %v, want [%s]", createdIDs, session.ID)
+	}
+	if len(closedIDs) != 1 || closedIDs[0] != session.ID {
+		t.Fatalf("closed subscriptions saw %v, want [%s]", closedIDs, session.ID)
+	}
+}
+
+func TestEventsCancelStopsDelivery(t *testing.T) {
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: testShellArgsProvider{},
+	})
+
+	var mu sync.Mutex
+	created := 0
+	sub := manager.Events().OnSessionCreated(func(*Session) {
+		mu.Lock()
+		created++
+		mu.Unlock()
+	})
+	sub.Cancel()
+	sub.Cancel() // idempotent
+
+	if _, err := manager.CreateSession("unobserved", ""); err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if created != 0 {
+		t.Fatalf("cancelled subscription fired %d times", created)
+	}
+}
+
+func TestEventsCoexistWithSetEventHandler(t *testing.T) {
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: testShellArgsProvider{},
+	})
+
+	handler := &countingHandler{}
+	manager.SetEventHandler(handler)
+
+	var mu sync.Mutex
+	created := 0
+	manager.Events().OnSessionCreated(func(*Session) {
+		mu.Lock()
+		created++
+		mu.Unlock()
+	})
+
+	session, err := manager.CreateSession("both", "")
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if err := manager.DeleteSession(session.ID); err != nil {
+		t.Fatalf("delete session failed: %v", err)
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.created != 1 || handler.closed != 1 {
+		t.Fatalf("handler saw created=%d closed=%d, want 1/1", handler.created, handler.closed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if created != 1 {
+		t.Fatalf("subscription saw created=%d, want 1", created)
+	}
+}
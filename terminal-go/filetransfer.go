@@ -0,0 +1,228 @@
+package terminal
+
+import (
+	"bytes"
+	"time"
+)
+
+// File transfers started inside a session (`sz`/`rz` from lrzsz, `tsz`/`trz`
+// from trzsz) put raw protocol frames on the PTY stream. Captured into
+// history they would replay as garbage after every reconnect, so the session
+// watches its output for the transfer handshakes and withholds the payload
+// from the ring buffer while a transfer is active. Live subscribers still
+// receive every byte — whatever speaks the protocol on the other end of the
+// wire needs them. Embedders learn about transitions through the
+// TerminalFileTransferEventHandler extension or the typed subscription and
+// can bridge the payload elsewhere, the way floeterm's HTTP layer does with
+// its upload/download endpoints.
+
+// Transfer protocols recognized in the output stream.
+const (
+	TransferProtocolZMODEM = "zmodem"
+	TransferProtocolTrzsz  = "trzsz"
+)
+
+// Transfer directions, named from the attached client's point of view: an
+// "upload" means the remote end is waiting to receive a file, a "download"
+// means the remote end is offering one.
+const (
+	TransferDirectionUpload   = "upload"
+	TransferDirectionDownload = "download"
+)
+
+// FileTransferInfo describes a session's file-transfer state.
+type FileTransferInfo struct {
+	Active    bool   `json:"active"`
+	Protocol  string `json:"protocol,omitempty"`
+	Direction string `json:"direction,omitempty"`
+	// StartedSequence is the first output sequence withheld from history.
+	StartedSequence int64 `json:"startedSequence,omitempty"`
+	// SkippedBytes counts output bytes kept out of history so far.
+	SkippedBytes int64 `json:"skippedBytes,omitempty"`
+}
+
+// TerminalFileTransferEventHandler optionally receives file-transfer state
+// transitions without widening the required TerminalEventHandler contract.
+type TerminalFileTransferEventHandler interface {
+	OnTerminalFileTransferChanged(sessionID string, info FileTransferInfo)
+}
+
+func notifyTerminalFileTransfer(handler TerminalEventHandler, sessionID string, info FileTransferInfo) {
+	if transferHandler, ok := handler.(TerminalFileTransferEventHandler); ok {
+		transferHandler.OnTerminalFileTransferChanged(sessionID, info)
+	}
+}
+
+// Handshake markers. ZMODEM frames every session with hex headers of the form
+// "**<ZDLE>B<type as two hex digits>": the sender opens with ZRQINIT (00),
+// the receiver with ZRINIT (01), and either side closes with ZFIN (08) or a
+// run of CAN bytes. trzsz announces itself with a magic text line carrying
+// the transfer mode.
+var (
+	zmodemZRQINIT = []byte("**\x18B00")
+	zmodemZRINIT  = []byte("**\x18B01")
+	zmodemZFIN    = []byte("**\x18B08")
+	zmodemCancel  = []byte("\x18\x18\x18\x18\x18")
+	trzszMagic    = []byte("::TRZSZ:TRANSFER:")
+)
+
+// transferMarkerCarry is how many trailing bytes are re-scanned with the next
+// chunk so a marker split across two PTY reads is still seen. It only needs
+// to cover the longest marker plus the trzsz mode byte.
+const transferMarkerCarry = 24
+
+// Detection is heuristic, and a false positive — cat-ing a file that happens
+// to contain a handshake marker — would otherwise withhold history for the
+// rest of the session: trzsz has no in-stream end marker the detector trusts,
+// and nothing obliges anyone to call EndFileTransfer. A real transfer streams
+// data continuously, so a quiet gap in the output means the transfer is over
+// (or never was one); the byte cap backstops a stream that never pauses.
+const (
+	transferInactivityTimeout = 10 * time.Second
+	transferMaxSkippedBytes   = int64(1) << 30
+)
+
+type transferSignal int
+
+const (
+	transferSignalNone transferSignal = iota
+	transferSignalZmodemDownload
+	transferSignalZmodemUpload
+	transferSignalTrzszDownload
+	transferSignalTrzszUpload
+	transferSignalEnd
+)
+
+// transferDetector scans output chunks for transfer handshakes. The zero
+// value is ready to use; it is not safe for concurrent use and is only
+// touched under the session mutex.
+type transferDetector struct {
+	carry []byte
+}
+
+// scan reports the first relevant marker in the chunk: a start marker while
+// no transfer is active, or an end marker while one is.
+func (d *transferDetector) scan(chunk []byte, active bool) transferSignal {
+	data := chunk
+	if len(d.carry) > 0 {
+		data = append(append(make([]byte, 0, len(d.carry)+len(chunk)), d.carry...), chunk...)
+	}
+	signal := transferSignalNone
+	if active {
+		if bytes.Contains(data, zmodemZFIN) || bytes.Contains(data, zmodemCancel) {
+			signal = transferSignalEnd
+		}
+	} else {
+		switch {
+		case bytes.Contains(data, zmodemZRQINIT):
+			signal = transferSignalZmodemDownload
+		case bytes.Contains(data, zmodemZRINIT):
+			signal = transferSignalZmodemUpload
+		default:
+			if at := bytes.Index(data, trzszMagic); at >= 0 {
+				rest := data[at+len(trzszMagic):]
+				if len(rest) > 0 {
+					switch rest[0] {
+					case 'R':
+						signal = transferSignalTrzszUpload
+					case 'S', 'D':
+						signal = transferSignalTrzszDownload
+					}
+				}
+			}
+		}
+	}
+	if len(data) > transferMarkerCarry {
+		data = data[len(data)-transferMarkerCarry:]
+	}
+	d.carry = append(d.carry[:0], data...)
+	return signal
+}
+
+func (s transferSignal) protocol() string {
+	switch s {
+	case transferSignalZmodemDownload, transferSignalZmodemUpload:
+		return TransferProtocolZMODEM
+	case transferSignalTrzszDownload, transferSignalTrzszUpload:
+		return TransferProtocolTrzsz
+	default:
+		return ""
+	}
+}
+
+func (s transferSignal) direction() string {
+	switch s {
+	case transferSignalZmodemUpload, transferSignalTrzszUpload:
+		return TransferDirectionUpload
+	case transferSignalZmodemDownload, transferSignalTrzszDownload:
+		return TransferDirectionDownload
+	default:
+		return ""
+	}
+}
+
+// applyTransferDetection advances the transfer state for one output chunk and
+// reports whether the chunk must stay out of history plus whether the state
+// changed. Chunks carrying the handshake or termination markers are withheld
+// too: they are protocol bytes, not terminal content. Called under s.mu.
+func (s *Session) applyTransferDetection(data []byte, sequence int64) (skipHistory, changed bool) {
+	now := time.Now()
+	recovered := false
+	if s.fileTransfer.Active &&
+		(now.Sub(s.transferLastData) > transferInactivityTimeout ||
+			s.fileTransfer.SkippedBytes > transferMaxSkippedBytes) {
+		// Automatic recovery (see the timeout constants above): end the
+		// withheld state and let this chunk be captured normally. The carry
+		// still holds the tail of the last withheld chunk — drop it so a stale
+		// handshake marker cannot restart the transfer immediately.
+		s.fileTransfer.Active = false
+		s.transferDetector.carry = nil
+		recovered = true
+	}
+	signal := s.transferDetector.scan(data, s.fileTransfer.Active)
+	if s.fileTransfer.Active {
+		s.fileTransfer.SkippedBytes += int64(len(data))
+		s.transferLastData = now
+		if signal == transferSignalEnd {
+			s.fileTransfer.Active = false
+			return true, true
+		}
+		return true, false
+	}
+	if protocol := signal.protocol(); protocol != "" {
+		s.fileTransfer = FileTransferInfo{
+			Active:          true,
+			Protocol:        protocol,
+			Direction:       signal.direction(),
+			StartedSequence: sequence,
+			SkippedBytes:    int64(len(data)),
+		}
+		s.transferLastData = now
+		return true, true
+	}
+	return false, recovered
+}
+
+// FileTransfer returns the session's current file-transfer state.
+func (s *Session) FileTransfer() FileTransferInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fileTransfer
+}
+
+// EndFileTransfer marks an active transfer as finished, resuming history
+// capture. Bridges call it when the protocol they drive completes without an
+// in-stream end marker (trzsz, or a ZMODEM session torn down out of band);
+// it is a no-op when no transfer is active.
+func (s *Session) EndFileTransfer() {
+	s.mu.Lock()
+	if !s.fileTransfer.Active {
+		s.mu.Unlock()
+		return
+	}
+	s.fileTransfer.Active = false
+	info := s.fileTransfer
+	handler := s.eventHandler
+	s.mu.Unlock()
+	notifyTerminalFileTransfer(handler, s.ID, info)
+}
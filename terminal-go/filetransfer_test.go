@@ -0,0 +1,205 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTransferDetectorSignals(t *testing.T) {
+	var detector transferDetector
+	if got := detector.scan([]byte("plain prompt output $ "), false); got != transferSignalNone {
+		t.Fatalf("plain output signalled %v", got)
+	}
+	if got := detector.scan([]byte("rz waiting to receive.**\x18B0100000023be50\r\n"), false); got != transferSignalZmodemUpload {
+		t.Fatalf("ZRINIT signalled %v", got)
+	}
+	if got := detector.scan([]byte("\x00\x01binary payload"), true); got != transferSignalNone {
+		t.Fatalf("payload signalled %v", got)
+	}
+	if got := detector.scan([]byte("**\x18B0800000000022d\r\n"), true); got != transferSignalEnd {
+		t.Fatalf("ZFIN signalled %v", got)
+	}
+
+	// Markers split across two PTY reads are still seen via the carry.
+	detector = transferDetector{}
+	if got := detector.scan([]byte("sending**\x18B"), false); got != transferSignalNone {
+		t.Fatalf("partial marker signalled %v", got)
+	}
+	if got := detector.scan([]byte("00000000000000\r\n"), false); got != transferSignalZmodemDownload {
+		t.Fatalf("ZRQINIT continuation signalled %v", got)
+	}
+	if got := detector.scan([]byte("\x18\x18\x18\x18\x18\b\b\b"), true); got != transferSignalEnd {
+		t.Fatalf("cancel run signalled %v", got)
+	}
+
+	detector = transferDetector{}
+	if got := detector.scan([]byte("\x1b[s\x07::TRZSZ:TRANSFER:R:1.0.0:123\r\n"), false); got != transferSignalTrzszUpload {
+		t.Fatalf("trzsz receive signalled %v", got)
+	}
+	detector = transferDetector{}
+	if got := detector.scan([]byte("::TRZSZ:TRANSFER:S:1.0.0:123\r\n"), false); got != transferSignalTrzszDownload {
+		t.Fatalf("trzsz send signalled %v", got)
+	}
+}
+
+func TestSessionWithholdsTransferPayloadFromHistory(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}})
+	t.Cleanup(manager.Cleanup)
+	session, err := manager.CreateSession("transfer", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var transitions []FileTransferInfo
+	sub := manager.Events().OnFileTransferChanged(func(sessionID string, info FileTransferInfo) {
+		if sessionID == session.ID {
+			transitions = append(transitions, info)
+		}
+	})
+	t.Cleanup(sub.Cancel)
+
+	live := 0
+	attachment, err := session.AttachLiveConnection("conn-1", 1, 80, 24, LiveSubscriber{
+		OnOutput: func(TerminalOutputEvent) bool {
+			live++
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(attachment.Detach)
+
+	session.processRawPTYData([]byte("hello\r\n"))
+	session.processRawPTYData([]byte("rz waiting to receive.**\x18B0100000023be50\r\n"))
+	if info := session.FileTransfer(); !info.Active || info.Protocol != TransferProtocolZMODEM || info.Direction != TransferDirectionUpload {
+		t.Fatalf("transfer state after handshake = %+v", info)
+	}
+	session.processRawPTYData([]byte("\x00\x01\x02 raw zmodem payload \xff\xfe"))
+	session.processRawPTYData([]byte("**\x18B0800000000022d\r\n"))
+	if info := session.FileTransfer(); info.Active {
+		t.Fatalf("transfer still active after ZFIN: %+v", info)
+	}
+	session.processRawPTYData([]byte("done\r\n"))
+
+	chunks, err := session.GetHistoryChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var history []byte
+	for _, chunk := range chunks {
+		history = append(history, chunk.Data...)
+	}
+	if !bytes.Contains(history, []byte("hello")) || !bytes.Contains(history, []byte("done")) {
+		t.Fatalf("history lost regular output: %q", history)
+	}
+	if bytes.Contains(history, []byte("raw zmodem payload")) || bytes.IndexByte(history, 0x18) >= 0 {
+		t.Fatalf("history captured transfer bytes: %q", history)
+	}
+
+	// Live subscribers saw every chunk: the bridge on the other end of the
+	// wire speaks the protocol from them.
+	if live != 5 {
+		t.Fatalf("live chunks = %d, want 5", live)
+	}
+
+	if len(transitions) != 2 || !transitions[0].Active || transitions[1].Active {
+		t.Fatalf("transitions = %+v", transitions)
+	}
+	if transitions[1].SkippedBytes == 0 {
+		t.Fatalf("end transition reports no skipped bytes: %+v", transitions[1])
+	}
+}
+
+func TestTransferDetectionRecoversAfterInactivity(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}})
+	t.Cleanup(manager.Cleanup)
+	session, err := manager.CreateSession("false-positive", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cat-ing a file containing the magic line looks like a transfer start.
+	session.processRawPTYData([]byte("::TRZSZ:TRANSFER:S:1.0.0:123\r\n"))
+	if info := session.FileTransfer(); !info.Active {
+		t.Fatalf("transfer state = %+v", info)
+	}
+
+	// Nobody speaks the protocol, so the stream goes quiet; the next output
+	// after the timeout ends the withheld state and is captured normally.
+	session.mu.Lock()
+	session.transferLastData = time.Now().Add(-2 * transferInactivityTimeout)
+	session.mu.Unlock()
+	session.processRawPTYData([]byte("prompt$ \r\n"))
+	if info := session.FileTransfer(); info.Active {
+		t.Fatalf("transfer still active after inactivity: %+v", info)
+	}
+
+	chunks, err := session.GetHistoryChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var history []byte
+	for _, chunk := range chunks {
+		history = append(history, chunk.Data...)
+	}
+	if !bytes.Contains(history, []byte("prompt$")) {
+		t.Fatalf("capture did not resume: %q", history)
+	}
+}
+
+func TestTransferDetectionRecoversAfterByteCap(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}})
+	t.Cleanup(manager.Cleanup)
+	session, err := manager.CreateSession("runaway", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session.processRawPTYData([]byte("rz waiting to receive.**\x18B0100000023be50\r\n"))
+	if info := session.FileTransfer(); !info.Active {
+		t.Fatalf("transfer state = %+v", info)
+	}
+
+	session.mu.Lock()
+	session.fileTransfer.SkippedBytes = transferMaxSkippedBytes + 1
+	session.transferLastData = time.Now()
+	session.mu.Unlock()
+	session.processRawPTYData([]byte("still streaming\r\n"))
+	if info := session.FileTransfer(); info.Active {
+		t.Fatalf("transfer still active past the byte cap: %+v", info)
+	}
+}
+
+func TestEndFileTransferResumesCapture(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}})
+	t.Cleanup(manager.Cleanup)
+	session, err := manager.CreateSession("trzsz", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session.processRawPTYData([]byte("::TRZSZ:TRANSFER:S:1.0.0:123\r\n"))
+	if info := session.FileTransfer(); !info.Active || info.Protocol != TransferProtocolTrzsz {
+		t.Fatalf("transfer state = %+v", info)
+	}
+	// trzsz has no in-stream end marker the detector trusts; the bridge ends
+	// the transfer explicitly.
+	session.EndFileTransfer()
+	if info := session.FileTransfer(); info.Active {
+		t.Fatalf("transfer still active: %+v", info)
+	}
+	session.processRawPTYData([]byte("after\r\n"))
+	chunks, err := session.GetHistoryChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var history []byte
+	for _, chunk := range chunks {
+		history = append(history, chunk.Data...)
+	}
+	if !bytes.Contains(history, []byte("after")) {
+		t.Fatalf("capture did not resume: %q", history)
+	}
+}
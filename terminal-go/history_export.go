@@ -0,0 +1,541 @@
+package terminal
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// exportCellStyle is the SGR state applied to one exported cell.
+type exportCellStyle struct {
+	fg        string
+	bg        string
+	bold      bool
+	faint     bool
+	italic    bool
+	underline bool
+	strike    bool
+	inverse   bool
+}
+
+func (s exportCellStyle) isDefault() bool {
+	return s == exportCellStyle{}
+}
+
+type exportCell struct {
+	r     rune
+	style exportCellStyle
+}
+
+// exportLineBuffer replays PTY output into styled logical lines so exporters
+// can resolve carriage-return overwrites and backspaces instead of emitting
+// raw control bytes.
+type exportLineBuffer struct {
+	lines [][]exportCell
+	row   int
+	col   int
+	style exportCellStyle
+
+	// pending holds an incomplete escape sequence or UTF-8 rune that spans a
+	// chunk boundary.
+	pending []byte
+}
+
+func newExportLineBuffer() *exportLineBuffer {
+	return &exportLineBuffer{lines: [][]exportCell{nil}}
+}
+
+func (b *exportLineBuffer) currentLine() []exportCell {
+	for b.row >= len(b.lines) {
+		b.lines = append(b.lines, nil)
+	}
+	return b.lines[b.row]
+}
+
+func (b *exportLineBuffer) putRune(r rune) {
+	line := b.currentLine()
+	for b.col >= len(line) {
+		line = append(line, exportCell{r: ' '})
+	}
+	line[b.col] = exportCell{r: r, style: b.style}
+	b.lines[b.row] = line
+	b.col++
+}
+
+// Write consumes a chunk of PTY output.
+func (b *exportLineBuffer) Write(data []byte) {
+	buffer := data
+	if len(b.pending) > 0 {
+		buffer = append(append(make([]byte, 0, len(b.pending)+len(data)), b.pending...), data...)
+		b.pending = nil
+	}
+
+	i := 0
+	for i < len(buffer) {
+		c := buffer[i]
+		switch {
+		case c == 0x1b:
+			consumed, complete := b.consumeEscape(buffer[i:])
+			if !complete {
+				b.pending = append([]byte(nil), buffer[i:]...)
+				return
+			}
+			i += consumed
+
+		case c == '\n':
+			b.row++
+			b.col = 0
+			i++
+
+		case c == '\r':
+			b.col = 0
+			i++
+
+		case c == '\b':
+			if b.col > 0 {
+				b.col--
+			}
+			i++
+
+		case c == '\t':
+			next := (b.col/8 + 1) * 8
+			for b.col < next {
+				b.putRune(' ')
+			}
+			i++
+
+		case c < 0x20 || c == 0x7f:
+			// Other control bytes (BEL, SO/SI, ...) have no transcript rendering.
+			i++
+
+		default:
+			r, size := utf8.DecodeRune(buffer[i:])
+			if r == utf8.RuneError && size == 1 && !utf8.FullRune(buffer[i:]) {
+				b.pending = append([]byte(nil), buffer[i:]...)
+				return
+			}
+			b.putRune(r)
+			i += size
+		}
+	}
+}
+
+// consumeEscape parses one escape sequence starting at data[0] == ESC.
+// It returns the consumed length and whether the sequence was complete.
+func (b *exportLineBuffer) consumeEscape(data []byte) (int, bool) {
+	if len(data) < 2 {
+		return 0, false
+	}
+
+	switch data[1] {
+	case '[':
+		// CSI: parameter bytes then one final byte in 0x40-0x7e.
+		j := 2
+		for j < len(data) {
+			c := data[j]
+			if c >= 0x40 && c <= 0x7e {
+				b.applyCSI(data[2:j], c)
+				return j + 1, true
+			}
+			j++
+		}
+		return 0, false
+
+	case ']':
+		// OSC: terminated by BEL or ST.
+		j := 2
+		for j < len(data) {
+			if data[j] == 0x07 {
+				return j + 1, true
+			}
+			if data[j] == 0x1b && j+1 < len(data) && data[j+1] == '\\' {
+				return j + 2, true
+			}
+			j++
+		}
+		return 0, false
+
+	case 'P', '^', '_':
+		// DCS/PM/APC: terminated by ST.
+		j := 2
+		for j < len(data) {
+			if data[j] == 0x1b && j+1 < len(data) && data[j+1] == '\\' {
+				return j + 2, true
+			}
+			j++
+		}
+		return 0, false
+
+	case '(', ')', '#':
+		// Two-byte intermediates select charsets and alignment.
+		if len(data) < 3 {
+			return 0, false
+		}
+		return 3, true
+
+	default:
+		return 2, true
+	}
+}
+
+// applyCSI interprets the sequences that affect a transcript: SGR styling and
+// erase-in-line. Cursor movement is intentionally ignored.
+func (b *exportLineBuffer) applyCSI(params []byte, final byte) {
+	switch final {
+	case 'm':
+		b.applySGR(string(params))
+	case 'K':
+		b.eraseInLine(string(params))
+	}
+}
+
+func (b *exportLineBuffer) eraseInLine(params string) {
+	line := b.currentLine()
+	switch params {
+	case "", "0":
+		if b.col < len(line) {
+			b.lines[b.row] = line[:b.col]
+		}
+	case "1":
+		for i := 0; i < b.col && i < len(line); i++ {
+			line[i] = exportCell{r: ' '}
+		}
+	case "2":
+		b.lines[b.row] = nil
+	}
+}
+
+func (b *exportLineBuffer) applySGR(params string) {
+	if params == "" {
+		b.style = exportCellStyle{}
+		return
+	}
+
+	fields := strings.Split(params, ";")
+	for i := 0; i < len(fields); i++ {
+		code := atoiDefault(fields[i], 0)
+		switch {
+		case code == 0:
+			b.style = exportCellStyle{}
+		case code == 1:
+			b.style.bold = true
+		case code == 2:
+			b.style.faint = true
+		case code == 3:
+			b.style.italic = true
+		case code == 4:
+			b.style.underline = true
+		case code == 7:
+			b.style.inverse = true
+		case code == 9:
+			b.style.strike = true
+		case code == 22:
+			b.style.bold = false
+			b.style.faint = false
+		case code == 23:
+			b.style.italic = false
+		case code == 24:
+			b.style.underline = false
+		case code == 27:
+			b.style.inverse = false
+		case code == 29:
+			b.style.strike = false
+		case code >= 30 && code <= 37:
+			b.style.fg = ansiPaletteColor(code - 30)
+		case code == 39:
+			b.style.fg = ""
+		case code >= 40 && code <= 47:
+			b.style.bg = ansiPaletteColor(code - 40)
+		case code == 49:
+			b.style.bg = ""
+		case code >= 90 && code <= 97:
+			b.style.fg = ansiPaletteColor(code - 90 + 8)
+		case code >= 100 && code <= 107:
+			b.style.bg = ansiPaletteColor(code - 100 + 8)
+		case code == 38 || code == 48:
+			color, consumed := parseExtendedColor(fields[i+1:])
+			if consumed == 0 {
+				return
+			}
+			if code == 38 {
+				b.style.fg = color
+			} else {
+				b.style.bg = color
+			}
+			i += consumed
+		}
+	}
+}
+
+func parseExtendedColor(fields []string) (string, int) {
+	if len(fields) == 0 {
+		return "", 0
+	}
+	switch fields[0] {
+	case "5":
+		if len(fields) < 2 {
+			return "", 0
+		}
+		return ansiPaletteColor(atoiDefault(fields[1], 0)), 2
+	case "2":
+		if len(fields) < 4 {
+			return "", 0
+		}
+		r := atoiDefault(fields[1], 0) & 0xff
+		g := atoiDefault(fields[2], 0) & 0xff
+		bl := atoiDefault(fields[3], 0) & 0xff
+		return fmt.Sprintf("#%02x%02x%02x", r, g, bl), 4
+	default:
+		return "", 0
+	}
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return def
+		}
+		n = n*10 + int(s[i]-'0')
+		if n > 1<<24 {
+			return def
+		}
+	}
+	return n
+}
+
+var ansiBasePalette = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510",
+	"#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543",
+	"#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+func ansiPaletteColor(index int) string {
+	if index < 0 || index > 255 {
+		return ""
+	}
+	if index < 16 {
+		return ansiBasePalette[index]
+	}
+	if index < 232 {
+		index -= 16
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		r := levels[index/36]
+		g := levels[(index/6)%6]
+		b := levels[index%6]
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	gray := 8 + (index-232)*10
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+const (
+	exportDefaultForeground = "#e5e5e5"
+	exportDefaultBackground = "#1e1e1e"
+)
+
+// ExportHistoryHTML renders history chunks as a standalone HTML document with
+// inline colors. Chunks should already have passed the session history filter.
+func ExportHistoryHTML(chunks []TerminalDataChunk, title string) string {
+	buffer := newExportLineBuffer()
+	for _, chunk := range chunks {
+		buffer.Write(chunk.Data)
+	}
+
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	out.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	out.WriteString("<style>\nbody { background: " + exportDefaultBackground + "; color: " + exportDefaultForeground + "; }\n")
+	out.WriteString("pre { font-family: Menlo, Consolas, \"DejaVu Sans Mono\", monospace; font-size: 13px; line-height: 1.3; }\n</style>\n")
+	out.WriteString("</head>\n<body>\n<pre>")
+	for _, line := range buffer.lines {
+		out.WriteString(renderHTMLLine(line))
+		out.WriteByte('\n')
+	}
+	out.WriteString("</pre>\n</body>\n</html>\n")
+	return out.String()
+}
+
+// ExportHistoryText renders history chunks as readable plain text. All
+// SGR/CSI/OSC sequences are stripped and carriage-return overwrites and
+// backspaces are resolved, so progress bars collapse to their final state.
+func ExportHistoryText(chunks []TerminalDataChunk) string {
+	buffer := newExportLineBuffer()
+	for _, chunk := range chunks {
+		buffer.Write(chunk.Data)
+	}
+
+	var out strings.Builder
+	for _, line := range buffer.lines {
+		text := make([]rune, 0, len(line))
+		for _, cell := range line {
+			text = append(text, cell.r)
+		}
+		out.WriteString(strings.TrimRight(string(text), " "))
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+func renderHTMLLine(line []exportCell) string {
+	var out strings.Builder
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && line[end].style == line[start].style {
+			end++
+		}
+		text := make([]rune, 0, end-start)
+		for _, cell := range line[start:end] {
+			text = append(text, cell.r)
+		}
+		escaped := html.EscapeString(string(text))
+		if css := styleCSS(line[start].style); css != "" {
+			out.WriteString("<span style=\"" + css + "\">" + escaped + "</span>")
+		} else {
+			out.WriteString(escaped)
+		}
+		start = end
+	}
+	return out.String()
+}
+
+func styleCSS(style exportCellStyle) string {
+	if style.isDefault() {
+		return ""
+	}
+
+	fg := style.fg
+	bg := style.bg
+	if style.inverse {
+		if fg == "" {
+			fg = exportDefaultForeground
+		}
+		if bg == "" {
+			bg = exportDefaultBackground
+		}
+		fg, bg = bg, fg
+	}
+
+	var parts []string
+	if fg != "" {
+		parts = append(parts, "color:"+fg)
+	}
+	if bg != "" {
+		parts = append(parts, "background-color:"+bg)
+	}
+	if style.bold {
+		parts = append(parts, "font-weight:bold")
+	}
+	if style.faint {
+		parts = append(parts, "opacity:0.6")
+	}
+	if style.italic {
+		parts = append(parts, "font-style:italic")
+	}
+	switch {
+	case style.underline && style.strike:
+		parts = append(parts, "text-decoration:underline line-through")
+	case style.underline:
+		parts = append(parts, "text-decoration:underline")
+	case style.strike:
+		parts = append(parts, "text-decoration:line-through")
+	}
+	return strings.Join(parts, ";")
+}
+
+// ScreenStyleRun styles the rune range [Start, End) of a screen line. Colors
+// use the same "#rrggbb" form as the HTML exporter; empty means the default.
+type ScreenStyleRun struct {
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Foreground string `json:"fg,omitempty"`
+	Background string `json:"bg,omitempty"`
+	Bold       bool   `json:"bold,omitempty"`
+	Faint      bool   `json:"faint,omitempty"`
+	Italic     bool   `json:"italic,omitempty"`
+	Underline  bool   `json:"underline,omitempty"`
+	Strike     bool   `json:"strike,omitempty"`
+	Inverse    bool   `json:"inverse,omitempty"`
+}
+
+// ScreenLine is one rendered row of a screen snapshot. Runs only cover the
+// styled spans; unstyled text uses the default colors.
+type ScreenLine struct {
+	Text string           `json:"text"`
+	Runs []ScreenStyleRun `json:"runs,omitempty"`
+}
+
+// RenderScreen replays history chunks through the transcript emulator that
+// powers the exporters and returns the last rows of output as styled lines.
+// Like the exporters it resolves carriage-return overwrites and SGR styling
+// but ignores cursor addressing, so full-screen applications render as their
+// output transcript rather than their exact grid.
+func RenderScreen(chunks []TerminalDataChunk, rows, cols int) []ScreenLine {
+	buffer := newExportLineBuffer()
+	for _, chunk := range chunks {
+		buffer.Write(chunk.Data)
+	}
+
+	lines := buffer.lines
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if rows > 0 && len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+
+	out := make([]ScreenLine, 0, len(lines))
+	for _, line := range lines {
+		if cols > 0 && len(line) > cols {
+			line = line[:cols]
+		}
+		out = append(out, renderScreenLine(line))
+	}
+	return out
+}
+
+func renderScreenLine(line []exportCell) ScreenLine {
+	// Trim unstyled trailing blanks so run offsets stay valid for the text.
+	for len(line) > 0 {
+		last := line[len(line)-1]
+		if last.r != ' ' || !last.style.isDefault() {
+			break
+		}
+		line = line[:len(line)-1]
+	}
+
+	text := make([]rune, 0, len(line))
+	for _, cell := range line {
+		text = append(text, cell.r)
+	}
+
+	var runs []ScreenStyleRun
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && line[end].style == line[start].style {
+			end++
+		}
+		if style := line[start].style; !style.isDefault() {
+			runs = append(runs, ScreenStyleRun{
+				Start:      start,
+				End:        end,
+				Foreground: style.fg,
+				Background: style.bg,
+				Bold:       style.bold,
+				Faint:      style.faint,
+				Italic:     style.italic,
+				Underline:  style.underline,
+				Strike:     style.strike,
+				Inverse:    style.inverse,
+			})
+		}
+		start = end
+	}
+
+	return ScreenLine{Text: string(text), Runs: runs}
+}
@@ -0,0 +1,120 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func exportChunks(parts ...string) []TerminalDataChunk {
+	chunks := make([]TerminalDataChunk, 0, len(parts))
+	for i, part := range parts {
+		chunks = append(chunks, TerminalDataChunk{
+			Sequence: int64(i + 1),
+			Data:     []byte(part),
+			Size:     len(part),
+		})
+	}
+	return chunks
+}
+
+func TestExportHistoryHTMLRendersSGRColors(t *testing.T) {
+	doc := ExportHistoryHTML(exportChunks("\x1b[31mred\x1b[0m plain\r\n"), "demo")
+
+	if !strings.Contains(doc, "<span style=\"color:#cd3131\">red</span>") {
+		t.Fatalf("missing red span in %q", doc)
+	}
+	if !strings.Contains(doc, "red</span> plain") {
+		t.Fatalf("reset did not return to unstyled text in %q", doc)
+	}
+	if !strings.Contains(doc, "<title>demo</title>") {
+		t.Fatalf("missing title in %q", doc)
+	}
+}
+
+func TestExportHistoryHTMLEscapesMarkup(t *testing.T) {
+	doc := ExportHistoryHTML(exportChunks("<script>alert(1)</script>\n"), "t")
+	if strings.Contains(doc, "<script>") {
+		t.Fatalf("unescaped markup in %q", doc)
+	}
+	if !strings.Contains(doc, "&lt;script&gt;") {
+		t.Fatalf("missing escaped markup in %q", doc)
+	}
+}
+
+func TestExportHistoryHTMLResolvesCarriageReturnOverwrites(t *testing.T) {
+	doc := ExportHistoryHTML(exportChunks("progress 10%\rprogress 99%\r\ndone\n"), "t")
+	if strings.Contains(doc, "10%") {
+		t.Fatalf("overwritten progress retained in %q", doc)
+	}
+	if !strings.Contains(doc, "progress 99%") || !strings.Contains(doc, "done") {
+		t.Fatalf("final progress missing in %q", doc)
+	}
+}
+
+func TestExportHistoryHTMLHandlesChunkSplitEscape(t *testing.T) {
+	doc := ExportHistoryHTML(exportChunks("\x1b[3", "2mgreen\x1b[0m\n"), "t")
+	if !strings.Contains(doc, "<span style=\"color:#0dbc79\">green</span>") {
+		t.Fatalf("split escape sequence not reassembled in %q", doc)
+	}
+}
+
+func TestExportHistoryTextStripsSequencesAndResolvesEdits(t *testing.T) {
+	text := ExportHistoryText(exportChunks(
+		"\x1b[1;32mok\x1b[0m\r\n",
+		"typo\b\b\b\bfine\r\n",
+		"\x1b]0;window title\x07prompt $ ls\r\n",
+	))
+
+	if strings.Contains(text, "\x1b") {
+		t.Fatalf("escape bytes leaked into %q", text)
+	}
+	want := "ok\nfine\nprompt $ ls\n"
+	if text != want {
+		t.Fatalf("text = %q, want %q", text, want)
+	}
+}
+
+func TestExportHistoryHTMLTruecolorAnd256(t *testing.T) {
+	doc := ExportHistoryHTML(exportChunks("\x1b[38;2;1;2;3mrgb\x1b[0m \x1b[48;5;196mbg\x1b[0m\n"), "t")
+	if !strings.Contains(doc, "color:#010203") {
+		t.Fatalf("truecolor missing in %q", doc)
+	}
+	if !strings.Contains(doc, "background-color:#ff0000") {
+		t.Fatalf("256-color background missing in %q", doc)
+	}
+}
+
+func TestRenderScreenReturnsLastRowsWithStyleRuns(t *testing.T) {
+	var data strings.Builder
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&data, "line-%d\n", i)
+	}
+	data.WriteString("plain \x1b[31mred\x1b[0m tail\n")
+
+	lines := RenderScreen(exportChunks(data.String()), 3, 80)
+	if len(lines) != 3 {
+		t.Fatalf("len(lines)=%d, want 3", len(lines))
+	}
+	if lines[0].Text != "line-28" || lines[1].Text != "line-29" {
+		t.Fatalf("unexpected leading lines: %q, %q", lines[0].Text, lines[1].Text)
+	}
+	last := lines[2]
+	if last.Text != "plain red tail" {
+		t.Fatalf("text=%q", last.Text)
+	}
+	if len(last.Runs) != 1 {
+		t.Fatalf("runs=%+v, want one styled run", last.Runs)
+	}
+	run := last.Runs[0]
+	if run.Start != 6 || run.End != 9 || run.Foreground != "#cd3131" {
+		t.Fatalf("run=%+v", run)
+	}
+}
+
+func TestRenderScreenClipsColumns(t *testing.T) {
+	lines := RenderScreen(exportChunks("0123456789\n"), 10, 4)
+	if len(lines) != 1 || lines[0].Text != "0123" {
+		t.Fatalf("lines=%+v, want single clipped line", lines)
+	}
+}
@@ -13,11 +13,15 @@ type HistoryFilter interface {
 type DefaultHistoryFilter struct{}
 
 // Filter removes OSC/CSI responses that would render as garbage on replay.
+// Inline image regions (Sixel DCS, iTerm2 OSC 1337 File=) pass through
+// verbatim, even when they span several chunks: their payloads are opaque
+// binary that the byte-level filters below must never touch.
 func (DefaultHistoryFilter) Filter(chunks []TerminalDataChunk) []TerminalDataChunk {
 	if len(chunks) == 0 {
 		return chunks
 	}
 
+	var images imageScanState
 	filtered := make([]TerminalDataChunk, 0, len(chunks))
 	for _, chunk := range chunks {
 		data := chunk.Data
@@ -26,19 +30,18 @@ func (DefaultHistoryFilter) Filter(chunks []TerminalDataChunk) []TerminalDataChu
 			continue
 		}
 
-		newData := filterOSCColorSequences(data)
-		if len(newData) == 0 {
-			continue
-		}
-		newData = filterCSIDeviceAttributeSequences(newData)
-		if len(newData) == 0 {
-			continue
-		}
-		newData = filterCSICursorPositionReportSequences(newData)
-		if len(newData) == 0 {
-			continue
+		newData := make([]byte, 0, len(data))
+		for _, segment := range splitInlineImageSegments(data, &images) {
+			if segment.kind != imageRegionNone {
+				newData = append(newData, segment.data...)
+				continue
+			}
+			part := filterOSCColorSequences(segment.data)
+			part = filterCSIDeviceAttributeSequences(part)
+			part = filterCSICursorPositionReportSequences(part)
+			part = filterTerminalQuerySequences(part)
+			newData = append(newData, part...)
 		}
-		newData = filterTerminalQuerySequences(newData)
 		if len(newData) == 0 {
 			continue
 		}
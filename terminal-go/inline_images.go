@@ -0,0 +1,240 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+)
+
+// Inline terminal images arrive in two shapes: Sixel graphics as a DCS
+// sequence (ESC P <params> q <sixel data> ST) and iTerm2 inline images as
+// OSC 1337 (ESC ] 1337 ; File = <args> : <base64> BEL or ST). Both carry
+// payloads that must reach the frontend byte for byte — the history filter
+// treats the regions found here as opaque — and the web renderer wants the
+// images back out again, which ExtractInlineImages provides.
+
+// Inline image formats reported by ExtractInlineImages.
+const (
+	InlineImageFormatSixel  = "sixel"
+	InlineImageFormatITerm2 = "iterm2"
+)
+
+// maxInlineImageBytes bounds a single image region. A region that grows past
+// it (a missing terminator, usually) stops being treated as an image so the
+// rest of the history is filtered normally.
+const maxInlineImageBytes = 64 << 20
+
+type imageRegionKind int
+
+const (
+	imageRegionNone imageRegionKind = iota
+	imageRegionSixel
+	imageRegionITerm2
+)
+
+// imageScanState carries region detection across chunk boundaries: image
+// payloads routinely span many PTY reads.
+type imageScanState struct {
+	kind       imageRegionKind
+	regionSize int
+	// pendingEsc is set when a chunk ends on a bare ESC inside a region; the
+	// ST terminator may straddle the boundary.
+	pendingEsc bool
+}
+
+// imageSegment is one run of chunk data: either plain bytes (kind none) or
+// part of an inline image region.
+type imageSegment struct {
+	data   []byte
+	kind   imageRegionKind
+	opens  bool
+	closes bool
+}
+
+// scanRegion advances through region payload looking for the terminator and
+// returns the offset just past the region's end, or len(data) with
+// closed=false when the region continues into the next chunk.
+func (st *imageScanState) scanRegion(data []byte, from int) (end int, closed bool) {
+	i := from
+	if st.pendingEsc {
+		st.pendingEsc = false
+		if i < len(data) && data[i] == '\\' {
+			return i + 1, true
+		}
+	}
+	for i < len(data) {
+		b := data[i]
+		if b == 0x07 && st.kind == imageRegionITerm2 {
+			return i + 1, true
+		}
+		if b == 0x1b {
+			if i+1 >= len(data) {
+				st.pendingEsc = true
+				return len(data), false
+			}
+			if data[i+1] == '\\' {
+				return i + 2, true
+			}
+			i++
+			continue
+		}
+		i++
+	}
+	return len(data), false
+}
+
+// findImageOpener locates the earliest inline-image introducer at or after
+// from: ESC P <digits/;> q for Sixel, ESC ] 1337 ; File = for iTerm2. An
+// introducer split across a chunk boundary is not detected, matching how the
+// other history filters treat split sequences.
+func findImageOpener(data []byte, from int) (start, afterOpen int, kind imageRegionKind) {
+	for i := from; i+1 < len(data); i++ {
+		if data[i] != 0x1b {
+			continue
+		}
+		switch data[i+1] {
+		case 'P':
+			j := i + 2
+			for j < len(data) && (data[j] == ';' || (data[j] >= '0' && data[j] <= '9')) {
+				j++
+			}
+			if j < len(data) && data[j] == 'q' {
+				return i, j + 1, imageRegionSixel
+			}
+		case ']':
+			if bytes.HasPrefix(data[i+2:], []byte("1337;File=")) {
+				return i, i + 2 + len("1337;File="), imageRegionITerm2
+			}
+		}
+	}
+	return 0, 0, imageRegionNone
+}
+
+// splitInlineImageSegments carves one chunk's data into plain runs and image
+// regions, carrying in-progress regions across calls through st.
+func splitInlineImageSegments(data []byte, st *imageScanState) []imageSegment {
+	var segs []imageSegment
+	i := 0
+	for i < len(data) {
+		if st.kind != imageRegionNone {
+			kind := st.kind
+			end, closed := st.scanRegion(data, i)
+			st.regionSize += end - i
+			if closed || st.regionSize > maxInlineImageBytes {
+				st.kind = imageRegionNone
+				st.regionSize = 0
+				st.pendingEsc = false
+			}
+			segs = append(segs, imageSegment{data: data[i:end], kind: kind, closes: closed})
+			i = end
+			continue
+		}
+		start, afterOpen, kind := findImageOpener(data, i)
+		if kind == imageRegionNone {
+			segs = append(segs, imageSegment{data: data[i:]})
+			break
+		}
+		if start > i {
+			segs = append(segs, imageSegment{data: data[i:start]})
+		}
+		st.kind = kind
+		st.regionSize = afterOpen - start
+		end, closed := st.scanRegion(data, afterOpen)
+		st.regionSize += end - afterOpen
+		if closed || st.regionSize > maxInlineImageBytes {
+			st.kind = imageRegionNone
+			st.regionSize = 0
+			st.pendingEsc = false
+		}
+		segs = append(segs, imageSegment{data: data[start:end], kind: kind, opens: true, closes: closed})
+		i = end
+	}
+	return segs
+}
+
+// InlineImage is one image recovered from history. For Sixel, Data holds the
+// complete DCS sequence for the renderer's Sixel decoder; for iTerm2, Data
+// holds the decoded file contents and Name the decoded file name.
+type InlineImage struct {
+	StartSequence int64  `json:"startSequence"`
+	EndSequence   int64  `json:"endSequence"`
+	Format        string `json:"format"`
+	Name          string `json:"name,omitempty"`
+	Data          []byte `json:"dataBase64"`
+}
+
+// ExtractInlineImages scans history chunks for inline image sequences and
+// returns them decoded, each tagged with the sequence range it spanned.
+// Regions that turn out malformed (truncated payload, bad base64) are
+// dropped rather than reported.
+func ExtractInlineImages(chunks []TerminalDataChunk) []InlineImage {
+	var st imageScanState
+	var images []InlineImage
+	var raw []byte
+	var startSeq int64
+	var kind imageRegionKind
+	for _, chunk := range chunks {
+		for _, seg := range splitInlineImageSegments(chunk.Data, &st) {
+			if seg.kind == imageRegionNone {
+				continue
+			}
+			if seg.opens {
+				raw = nil
+				startSeq = chunk.Sequence
+				kind = seg.kind
+			}
+			raw = append(raw, seg.data...)
+			if seg.closes {
+				if img, ok := finalizeInlineImage(kind, raw); ok {
+					img.StartSequence = startSeq
+					img.EndSequence = chunk.Sequence
+					images = append(images, img)
+				}
+				raw = nil
+				kind = imageRegionNone
+			}
+		}
+	}
+	return images
+}
+
+func finalizeInlineImage(kind imageRegionKind, raw []byte) (InlineImage, bool) {
+	switch kind {
+	case imageRegionSixel:
+		if !bytes.HasPrefix(raw, []byte{0x1b, 'P'}) {
+			return InlineImage{}, false
+		}
+		return InlineImage{Format: InlineImageFormatSixel, Data: append([]byte(nil), raw...)}, true
+
+	case imageRegionITerm2:
+		intro := []byte("\x1b]1337;File=")
+		if !bytes.HasPrefix(raw, intro) {
+			return InlineImage{}, false
+		}
+		body := raw[len(intro):]
+		if n := len(body); n > 0 && body[n-1] == 0x07 {
+			body = body[:n-1]
+		} else if n > 1 && body[n-2] == 0x1b && body[n-1] == '\\' {
+			body = body[:n-2]
+		} else {
+			return InlineImage{}, false
+		}
+		colon := bytes.IndexByte(body, ':')
+		if colon < 0 {
+			return InlineImage{}, false
+		}
+		data, err := base64.StdEncoding.DecodeString(string(body[colon+1:]))
+		if err != nil {
+			return InlineImage{}, false
+		}
+		name := ""
+		for _, arg := range bytes.Split(body[:colon], []byte(";")) {
+			if value, ok := bytes.CutPrefix(arg, []byte("name=")); ok {
+				if decoded, err := base64.StdEncoding.DecodeString(string(value)); err == nil {
+					name = string(decoded)
+				}
+			}
+		}
+		return InlineImage{Format: InlineImageFormatITerm2, Name: name, Data: data}, true
+	}
+	return InlineImage{}, false
+}
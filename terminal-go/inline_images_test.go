@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestDefaultHistoryFilter_PassesSixelThrough(t *testing.T) {
+	filter := DefaultHistoryFilter{}
+
+	// The payload deliberately embeds byte runs the filters would otherwise
+	// strip ("\x1b[c" looks like a DA response).
+	sixel := "\x1bP0;0;8q\"1;1;4;4#0;2;0;0;0\x1b[c#0~~~~\x1b\\"
+	input := []TerminalDataChunk{{
+		Sequence:  1,
+		Timestamp: 1,
+		Data:      []byte("\x1b]10;rgb:1/2/3\x07" + sixel + "\x1b[6n"),
+	}}
+
+	output := filter.Filter(input)
+	if len(output) != 1 {
+		t.Fatalf("expected one chunk after filtering, got %d", len(output))
+	}
+	if got := string(output[0].Data); got != sixel {
+		t.Fatalf("unexpected filtered content: %q", got)
+	}
+}
+
+func TestDefaultHistoryFilter_PassesSplitITermImageThrough(t *testing.T) {
+	filter := DefaultHistoryFilter{}
+
+	payload := base64.StdEncoding.EncodeToString([]byte("fake image bytes"))
+	full := "\x1b]1337;File=name=" + base64.StdEncoding.EncodeToString([]byte("a.png")) +
+		";size=16;inline=1:" + payload + "\x1b\\"
+	// Split mid-payload and between the terminator's ESC and backslash.
+	cut := len(full) - 1
+	input := []TerminalDataChunk{
+		{Sequence: 1, Timestamp: 1, Data: []byte("before" + full[:20])},
+		{Sequence: 2, Timestamp: 2, Data: []byte(full[20:cut])},
+		{Sequence: 3, Timestamp: 3, Data: []byte(full[cut:] + "\x1b[>0c" + "after")},
+	}
+
+	output := filter.Filter(input)
+	var joined []byte
+	for _, chunk := range output {
+		joined = append(joined, chunk.Data...)
+	}
+	if got := string(joined); got != "before"+full+"after" {
+		t.Fatalf("unexpected filtered content: %q", got)
+	}
+}
+
+func TestExtractInlineImages(t *testing.T) {
+	sixel := "\x1bP9;1q\"1;1;2;2#1;2;100;0;0#1~~\x1b\\"
+	fileBytes := []byte{0x89, 'P', 'N', 'G', 0, 1, 2, 3}
+	iterm := "\x1b]1337;File=name=" + base64.StdEncoding.EncodeToString([]byte("chart.png")) +
+		";size=8;inline=1:" + base64.StdEncoding.EncodeToString(fileBytes) + "\x07"
+
+	chunks := []TerminalDataChunk{
+		{Sequence: 1, Data: []byte("ls output\r\n")},
+		{Sequence: 2, Data: []byte("img: " + sixel[:10])},
+		{Sequence: 3, Data: []byte(sixel[10:] + "\r\n")},
+		{Sequence: 4, Data: []byte(iterm + "done\r\n")},
+	}
+
+	images := ExtractInlineImages(chunks)
+	if len(images) != 2 {
+		t.Fatalf("expected two images, got %d: %+v", len(images), images)
+	}
+
+	if images[0].Format != InlineImageFormatSixel || images[0].StartSequence != 2 || images[0].EndSequence != 3 {
+		t.Fatalf("sixel image = %+v", images[0])
+	}
+	if !bytes.Equal(images[0].Data, []byte(sixel)) {
+		t.Fatalf("sixel data = %q", images[0].Data)
+	}
+
+	if images[1].Format != InlineImageFormatITerm2 || images[1].Name != "chart.png" {
+		t.Fatalf("iterm image = %+v", images[1])
+	}
+	if images[1].StartSequence != 4 || images[1].EndSequence != 4 {
+		t.Fatalf("iterm image range = %d..%d", images[1].StartSequence, images[1].EndSequence)
+	}
+	if !bytes.Equal(images[1].Data, fileBytes) {
+		t.Fatalf("iterm data = %v", images[1].Data)
+	}
+}
+
+func TestExtractInlineImagesDropsMalformed(t *testing.T) {
+	chunks := []TerminalDataChunk{
+		{Sequence: 1, Data: []byte("\x1b]1337;File=inline=1:not base64!!\x07")},
+	}
+	if images := ExtractInlineImages(chunks); len(images) != 0 {
+		t.Fatalf("expected no images, got %+v", images)
+	}
+}
@@ -0,0 +1,36 @@
+package terminal
+
+// SessionIsolation requests lightweight Linux namespace isolation for spawned
+// session processes, so terminals in shared environments cannot see each
+// other's mounts, processes or IPC objects. On other platforms the settings
+// are ignored.
+type SessionIsolation struct {
+	// NewMountNamespace starts the process in its own mount namespace; mount
+	// changes made inside the session do not propagate to the host.
+	NewMountNamespace bool
+	// NewPIDNamespace makes the shell PID 1 of a fresh PID namespace. Note
+	// that /proc is not remounted, so process listings still show the host
+	// view unless the session remounts it.
+	NewPIDNamespace bool
+	// NewIPCNamespace isolates System V IPC and POSIX message queues.
+	NewIPCNamespace bool
+	// PrivateTmp mounts a session-private tmpfs over /tmp (implies
+	// NewMountNamespace). Mounting requires the server to run with
+	// CAP_SYS_ADMIN; without it the session keeps the shared /tmp.
+	PrivateTmp bool
+	// ChrootDir confines the session to this directory tree via chroot(2),
+	// so browser-accessible shells on shared hosts cannot wander the whole
+	// filesystem. The tree must contain the shell and everything it needs
+	// (a static shell or a bind-mounted /usr, for example). Requires root.
+	ChrootDir string
+	// ChrootHome chroots each session into its user's home directory — the
+	// run-as account's home when one is set, otherwise the server's own.
+	// Ignored when ChrootDir is set.
+	ChrootHome bool
+}
+
+// enabled reports whether any namespace setting is active; chroot confinement
+// is applied separately (see applySessionChroot).
+func (i SessionIsolation) enabled() bool {
+	return i.NewMountNamespace || i.NewPIDNamespace || i.NewIPCNamespace || i.PrivateTmp
+}
@@ -0,0 +1,45 @@
+package terminal
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// applySessionIsolation configures the command's SysProcAttr clone flags for
+// the requested namespaces. creack/pty preserves a pre-set SysProcAttr, only
+// adding Setsid/Setctty on top.
+func applySessionIsolation(cmd *exec.Cmd, iso SessionIsolation) *exec.Cmd {
+	if !iso.enabled() {
+		return cmd
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if iso.NewMountNamespace || iso.PrivateTmp {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNS
+		// Mark the new namespace's mounts private so nothing leaks back even
+		// on shared-propagation hosts.
+		cmd.SysProcAttr.Unshareflags |= syscall.CLONE_NEWNS
+	}
+	if iso.NewPIDNamespace {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWPID
+	}
+	if iso.NewIPCNamespace {
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWIPC
+	}
+	if iso.PrivateTmp {
+		// There is no hook between clone and exec, so a /bin/sh shim mounts
+		// the private tmpfs and then execs the real command line. The mount
+		// is best-effort: without CAP_SYS_ADMIN the session keeps the shared
+		// /tmp rather than failing to start.
+		shim := exec.Command("/bin/sh", "-c",
+			`mount -t tmpfs -o mode=1777 tmpfs /tmp 2>/dev/null; exec "$0" "$@"`,
+			cmd.Path)
+		shim.Args = append(shim.Args, cmd.Args[1:]...)
+		shim.Dir = cmd.Dir
+		shim.Env = cmd.Env
+		shim.SysProcAttr = cmd.SysProcAttr
+		return shim
+	}
+	return cmd
+}
@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestApplySessionIsolationCloneFlags(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "true")
+	got := applySessionIsolation(cmd, SessionIsolation{
+		NewMountNamespace: true,
+		NewPIDNamespace:   true,
+		NewIPCNamespace:   true,
+	})
+	if got != cmd {
+		t.Fatal("namespace-only isolation must not replace the command")
+	}
+	flags := got.SysProcAttr.Cloneflags
+	for _, want := range []uintptr{syscall.CLONE_NEWNS, syscall.CLONE_NEWPID, syscall.CLONE_NEWIPC} {
+		if flags&want == 0 {
+			t.Fatalf("clone flags %#x missing %#x", flags, want)
+		}
+	}
+	if got.SysProcAttr.Unshareflags&syscall.CLONE_NEWNS == 0 {
+		t.Fatal("mount namespace must be marked private via unshare flags")
+	}
+}
+
+func TestApplySessionIsolationPrivateTmpShim(t *testing.T) {
+	cmd := exec.Command("/bin/bash", "-l")
+	cmd.Dir = "/srv"
+	cmd.Env = []string{"TERM=xterm"}
+	got := applySessionIsolation(cmd, SessionIsolation{PrivateTmp: true})
+	if got == cmd {
+		t.Fatal("private tmp must wrap the command in a mount shim")
+	}
+	if got.Path != "/bin/sh" {
+		t.Fatalf("shim path = %q", got.Path)
+	}
+	// The original command line rides along as "$0"/"$@".
+	last := got.Args[len(got.Args)-2:]
+	if last[0] != "/bin/bash" || last[1] != "-l" {
+		t.Fatalf("shim args = %v", got.Args)
+	}
+	if got.Dir != cmd.Dir || len(got.Env) != 1 {
+		t.Fatalf("shim must inherit dir and env, got dir=%q env=%v", got.Dir, got.Env)
+	}
+	if got.SysProcAttr.Cloneflags&syscall.CLONE_NEWNS == 0 {
+		t.Fatal("private tmp implies a new mount namespace")
+	}
+}
+
+func TestApplySessionIsolationDisabled(t *testing.T) {
+	cmd := exec.Command("/bin/sh")
+	if got := applySessionIsolation(cmd, SessionIsolation{}); got != cmd || got.SysProcAttr != nil {
+		t.Fatal("zero isolation must leave the command untouched")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package terminal
+
+import "os/exec"
+
+// applySessionIsolation is a no-op outside Linux; namespace isolation is not
+// available there.
+func applySessionIsolation(cmd *exec.Cmd, iso SessionIsolation) *exec.Cmd {
+	return cmd
+}
@@ -29,6 +29,14 @@ func (s *Session) broadcastGeometry(geometry TerminalGeometry, subscribers []Liv
 	}
 }
 
+func (s *Session) broadcastPresence(event TerminalPresenceEvent, subscribers []LiveSubscriber) {
+	for _, subscriber := range subscribers {
+		if subscriber.OnPresence != nil {
+			subscriber.OnPresence(event)
+		}
+	}
+}
+
 // AttachLiveConnection atomically registers a connection and its subscriber,
 // then returns the exact sequence covered by the initial history snapshot.
 func (s *Session) AttachLiveConnection(
@@ -97,6 +105,12 @@ func (s *Session) AttachLiveConnection(
 	if geometry.Generation != previousGeometryGeneration {
 		geometrySubscribers = s.liveSubscribersLocked()
 	}
+	presenceSubscribers := s.liveSubscribersLocked()
+	presence := TerminalPresenceEvent{
+		ConnID:          connectionID,
+		Joined:          true,
+		ConnectionCount: len(s.liveAttachments),
+	}
 	s.mu.Unlock()
 
 	if exists && previous.subscriber.OnSuperseded != nil {
@@ -105,6 +119,8 @@ func (s *Session) AttachLiveConnection(
 	if len(geometrySubscribers) > 0 {
 		s.broadcastGeometry(geometry, geometrySubscribers)
 	}
+	// Everyone, including the joiner, sees the same join event and count.
+	s.broadcastPresence(presence, presenceSubscribers)
 
 	var once sync.Once
 	detach := func() {
@@ -113,24 +129,34 @@ func (s *Session) AttachLiveConnection(
 			previousGeneration := s.geometryGeneration
 			var detachedGeometry TerminalGeometry
 			var detachedSubscribers []LiveSubscriber
+			var presenceSubscribers []LiveSubscriber
+			var presence TerminalPresenceEvent
 			current, ok := s.liveAttachments[connectionID]
 			if ok && current.generation == generation {
 				delete(s.liveAttachments, connectionID)
 				delete(s.connections, connectionID)
 				if s.isActive && len(s.connections) > 0 {
 					if err := s.reconcilePTYSizeLocked("live-connection-detached", false); err != nil {
-						s.config.logger.Warn("Failed to reconcile PTY after live detach", "sessionID", s.ID, "error", err)
+						s.log().Warn("Failed to reconcile PTY after live detach", "error", err)
 					}
 				}
 				detachedGeometry = s.effectiveGeometryLocked()
 				if detachedGeometry.Generation != previousGeneration {
 					detachedSubscribers = s.liveSubscribersLocked()
 				}
+				presenceSubscribers = s.liveSubscribersLocked()
+				presence = TerminalPresenceEvent{
+					ConnID:          connectionID,
+					ConnectionCount: len(s.liveAttachments),
+				}
 			}
 			s.mu.Unlock()
 			if len(detachedSubscribers) > 0 {
 				s.broadcastGeometry(detachedGeometry, detachedSubscribers)
 			}
+			if len(presenceSubscribers) > 0 {
+				s.broadcastPresence(presence, presenceSubscribers)
+			}
 		})
 	}
 
@@ -29,16 +29,21 @@ var (
 type FrameType uint8
 
 const (
-	FrameAttach FrameType = 0x01
-	FrameInput  FrameType = 0x02
-	FrameResize FrameType = 0x03
-	FrameDetach FrameType = 0x04
+	FrameAttach           FrameType = 0x01
+	FrameInput            FrameType = 0x02
+	FrameResize           FrameType = 0x03
+	FrameDetach           FrameType = 0x04
+	FrameAck              FrameType = 0x05
+	FrameInputSpeculative FrameType = 0x06
 
 	FrameAttached        FrameType = 0x81
 	FrameOutputBatch     FrameType = 0x82
 	FrameResizeApplied   FrameType = 0x83
 	FrameSessionClosed   FrameType = 0x84
 	FrameGeometryChanged FrameType = 0x85
+	FrameOutputDropped   FrameType = 0x86
+	FramePresenceChanged FrameType = 0x87
+	FrameInputApplied    FrameType = 0x88
 	FrameError           FrameType = 0xff
 )
 
@@ -54,11 +59,71 @@ type Attach struct {
 	Rows             uint32
 	SessionID        string
 	ConnectionID     string
+	// LastSequence is the newest output sequence the client already holds.
+	// When non-zero, output up to the history boundary is replayed on the
+	// live stream so reconnection needs no separate history fetch. Encoded
+	// as an optional trailer for compatibility with older peers.
+	LastSequence uint64
+	// AckWindowBytes opts in to windowed flow control: the client promises
+	// to ack received sequences (FrameAck) and the server keeps at most this
+	// many unacknowledged output bytes in flight, dropping with a resync
+	// marker (FrameOutputDropped) rather than stalling the session when the
+	// client falls a full queue behind. Zero leaves flow control off.
+	AckWindowBytes uint64
+	// SuppressOwnEcho asks the server to skip output attributed to this
+	// connection's own input, so clients that echo locally (predictive
+	// typing) do not render doubled characters. Suppressed records leave
+	// sequence gaps on the live stream; history retains everything.
+	SuppressOwnEcho bool
+	// SpeculativeInput negotiates mosh-style predictive echo: the client may
+	// tag input it has already echoed locally (FrameInputSpeculative) and the
+	// server confirms each tagged input with FrameInputApplied once it is
+	// written, so predictions can be retired or rolled back against the
+	// authoritative stream. Servers predating the flag reject the attach,
+	// which tells the client to retry without prediction.
+	SpeculativeInput bool
+}
+
+// Bits of the optional flags trailer word on the attach frame.
+const (
+	attachFlagSuppressOwnEcho  uint64 = 1 << 0
+	attachFlagSpeculativeInput uint64 = 1 << 1
+)
+
+// Ack reports the newest output sequence the client has received, opening
+// the flow-control window for more output.
+type Ack struct {
+	Sequence uint64
+}
+
+// OutputDropped tells a windowed client that records in [FromSequence,
+// ToSequence] were dropped while it was behind; it should refetch that range
+// from history before applying later output.
+type OutputDropped struct {
+	FromSequence uint64
+	ToSequence   uint64
 }
 
 type Input struct {
 	Sequence uint64
 	Data     []byte
+	// Speculative marks input the client has already echoed locally. It is
+	// carried by the frame type (FrameInputSpeculative rather than
+	// FrameInput), not by payload bytes, and is only valid on attachments
+	// that negotiated Attach.SpeculativeInput. The server confirms each
+	// speculative input with an InputApplied frame.
+	Speculative bool
+}
+
+// InputApplied confirms that the speculative input with the given sequence
+// has been written to the session. OutputSequenceBoundary is the newest
+// output sequence the server had produced for this connection when the input
+// was applied: authoritative echo of the input can only appear above it, so
+// the client knows exactly which part of the stream may confirm or contradict
+// its prediction.
+type InputApplied struct {
+	Sequence               uint64
+	OutputSequenceBoundary uint64
 }
 
 type Resize struct {
@@ -91,6 +156,10 @@ type OutputRecord struct {
 	Cols               uint32
 	Rows               uint32
 	Data               []byte
+	// EchoSourceConnID is server-side attribution of the record to the
+	// connection whose input produced it. It is never encoded on the wire;
+	// transports use it to honor Attach.SuppressOwnEcho.
+	EchoSourceConnID string
 }
 
 type OutputBatch struct {
@@ -107,6 +176,15 @@ type EffectiveGeometry struct {
 	Rows                   uint32
 }
 
+// PresenceChanged announces a connection joining or leaving the session's
+// live view. ConnectionCount is the number of attachments after the change,
+// so clients can render "N viewers connected" without tracking membership.
+type PresenceChanged struct {
+	ConnectionID    string
+	Joined          bool
+	ConnectionCount uint32
+}
+
 type ProtocolError struct {
 	Code    uint16
 	Message string
@@ -114,8 +192,8 @@ type ProtocolError struct {
 
 func validFrameType(frameType FrameType) bool {
 	switch frameType {
-	case FrameAttach, FrameInput, FrameResize, FrameDetach,
-		FrameAttached, FrameOutputBatch, FrameResizeApplied, FrameSessionClosed, FrameGeometryChanged, FrameError:
+	case FrameAttach, FrameInput, FrameResize, FrameDetach, FrameAck, FrameInputSpeculative,
+		FrameAttached, FrameOutputBatch, FrameResizeApplied, FrameSessionClosed, FrameGeometryChanged, FrameOutputDropped, FramePresenceChanged, FrameInputApplied, FrameError:
 		return true
 	default:
 		return false
@@ -245,6 +323,22 @@ func EncodeAttach(value Attach) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("connection id: %w", err)
 	}
+	var flags uint64
+	if value.SuppressOwnEcho {
+		flags |= attachFlagSuppressOwnEcho
+	}
+	if value.SpeculativeInput {
+		flags |= attachFlagSpeculativeInput
+	}
+	if value.LastSequence != 0 || value.AckWindowBytes != 0 || flags != 0 {
+		payload = binary.BigEndian.AppendUint64(payload, value.LastSequence)
+	}
+	if value.AckWindowBytes != 0 || flags != 0 {
+		payload = binary.BigEndian.AppendUint64(payload, value.AckWindowBytes)
+	}
+	if flags != 0 {
+		payload = binary.BigEndian.AppendUint64(payload, flags)
+	}
 	return EncodeFrame(Frame{Type: FrameAttach, Payload: payload})
 }
 
@@ -267,7 +361,27 @@ func DecodeAttach(frame Frame) (Attach, error) {
 		return Attach{}, err
 	}
 	value.ConnectionID, offset, err = readString(frame.Payload, offset)
-	if err != nil || offset != len(frame.Payload) {
+	if err != nil {
+		return Attach{}, ErrInvalidPayload
+	}
+	switch len(frame.Payload) - offset {
+	case 0:
+		// Older clients omit the trailer.
+	case 8:
+		value.LastSequence = binary.BigEndian.Uint64(frame.Payload[offset:])
+	case 16:
+		value.LastSequence = binary.BigEndian.Uint64(frame.Payload[offset : offset+8])
+		value.AckWindowBytes = binary.BigEndian.Uint64(frame.Payload[offset+8:])
+	case 24:
+		value.LastSequence = binary.BigEndian.Uint64(frame.Payload[offset : offset+8])
+		value.AckWindowBytes = binary.BigEndian.Uint64(frame.Payload[offset+8 : offset+16])
+		flags := binary.BigEndian.Uint64(frame.Payload[offset+16:])
+		if flags&^(attachFlagSuppressOwnEcho|attachFlagSpeculativeInput) != 0 {
+			return Attach{}, ErrInvalidPayload
+		}
+		value.SuppressOwnEcho = flags&attachFlagSuppressOwnEcho != 0
+		value.SpeculativeInput = flags&attachFlagSpeculativeInput != 0
+	default:
 		return Attach{}, ErrInvalidPayload
 	}
 	if value.AttachGeneration == 0 || value.Cols == 0 || value.Rows == 0 {
@@ -283,23 +397,58 @@ func EncodeInput(value Input) ([]byte, error) {
 	payload := make([]byte, 8+len(value.Data))
 	binary.BigEndian.PutUint64(payload[:8], value.Sequence)
 	copy(payload[8:], value.Data)
-	return EncodeFrame(Frame{Type: FrameInput, Payload: payload})
+	frameType := FrameInput
+	if value.Speculative {
+		frameType = FrameInputSpeculative
+	}
+	return EncodeFrame(Frame{Type: frameType, Payload: payload})
 }
 
 func DecodeInput(frame Frame) (Input, error) {
-	if frame.Type != FrameInput {
+	if frame.Type != FrameInput && frame.Type != FrameInputSpeculative {
 		return Input{}, ErrUnexpectedFrameType
 	}
 	if len(frame.Payload) <= 8 || len(frame.Payload)-8 > MaxInputBytes {
 		return Input{}, ErrInvalidPayload
 	}
-	value := Input{Sequence: binary.BigEndian.Uint64(frame.Payload[:8]), Data: append([]byte(nil), frame.Payload[8:]...)}
+	value := Input{
+		Sequence:    binary.BigEndian.Uint64(frame.Payload[:8]),
+		Data:        append([]byte(nil), frame.Payload[8:]...),
+		Speculative: frame.Type == FrameInputSpeculative,
+	}
 	if value.Sequence == 0 {
 		return Input{}, ErrInvalidPayload
 	}
 	return value, nil
 }
 
+func EncodeInputApplied(value InputApplied) ([]byte, error) {
+	if value.Sequence == 0 {
+		return nil, ErrInvalidPayload
+	}
+	payload := make([]byte, 16)
+	binary.BigEndian.PutUint64(payload[:8], value.Sequence)
+	binary.BigEndian.PutUint64(payload[8:16], value.OutputSequenceBoundary)
+	return EncodeFrame(Frame{Type: FrameInputApplied, Payload: payload})
+}
+
+func DecodeInputApplied(frame Frame) (InputApplied, error) {
+	if frame.Type != FrameInputApplied {
+		return InputApplied{}, ErrUnexpectedFrameType
+	}
+	if len(frame.Payload) != 16 {
+		return InputApplied{}, ErrInvalidPayload
+	}
+	value := InputApplied{
+		Sequence:               binary.BigEndian.Uint64(frame.Payload[:8]),
+		OutputSequenceBoundary: binary.BigEndian.Uint64(frame.Payload[8:16]),
+	}
+	if value.Sequence == 0 {
+		return InputApplied{}, ErrInvalidPayload
+	}
+	return value, nil
+}
+
 func EncodeResize(value Resize) ([]byte, error) {
 	if value.Sequence == 0 || value.Cols == 0 || value.Rows == 0 {
 		return nil, ErrInvalidPayload
@@ -329,6 +478,56 @@ func DecodeResize(frame Frame) (Resize, error) {
 	return value, nil
 }
 
+func EncodeAck(value Ack) ([]byte, error) {
+	if value.Sequence == 0 {
+		return nil, ErrInvalidPayload
+	}
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, value.Sequence)
+	return EncodeFrame(Frame{Type: FrameAck, Payload: payload})
+}
+
+func DecodeAck(frame Frame) (Ack, error) {
+	if frame.Type != FrameAck {
+		return Ack{}, ErrUnexpectedFrameType
+	}
+	if len(frame.Payload) != 8 {
+		return Ack{}, ErrInvalidPayload
+	}
+	value := Ack{Sequence: binary.BigEndian.Uint64(frame.Payload)}
+	if value.Sequence == 0 {
+		return Ack{}, ErrInvalidPayload
+	}
+	return value, nil
+}
+
+func EncodeOutputDropped(value OutputDropped) ([]byte, error) {
+	if value.FromSequence == 0 || value.ToSequence < value.FromSequence {
+		return nil, ErrInvalidPayload
+	}
+	payload := make([]byte, 16)
+	binary.BigEndian.PutUint64(payload[:8], value.FromSequence)
+	binary.BigEndian.PutUint64(payload[8:16], value.ToSequence)
+	return EncodeFrame(Frame{Type: FrameOutputDropped, Payload: payload})
+}
+
+func DecodeOutputDropped(frame Frame) (OutputDropped, error) {
+	if frame.Type != FrameOutputDropped {
+		return OutputDropped{}, ErrUnexpectedFrameType
+	}
+	if len(frame.Payload) != 16 {
+		return OutputDropped{}, ErrInvalidPayload
+	}
+	value := OutputDropped{
+		FromSequence: binary.BigEndian.Uint64(frame.Payload[:8]),
+		ToSequence:   binary.BigEndian.Uint64(frame.Payload[8:16]),
+	}
+	if value.FromSequence == 0 || value.ToSequence < value.FromSequence {
+		return OutputDropped{}, ErrInvalidPayload
+	}
+	return value, nil
+}
+
 func EncodeAttached(value Attached) ([]byte, error) {
 	if value.HistoryGeneration == 0 || value.HistoryStartSequence == 0 || value.HistoryStartSequence > value.HistoryBoundarySequence+1 ||
 		value.GeometryGeneration == 0 || value.Cols == 0 || value.Rows == 0 {
@@ -430,6 +629,46 @@ func DecodeGeometryChanged(frame Frame) (EffectiveGeometry, error) {
 	return value, nil
 }
 
+func EncodePresenceChanged(value PresenceChanged) ([]byte, error) {
+	payload := make([]byte, 5)
+	binary.BigEndian.PutUint32(payload[:4], value.ConnectionCount)
+	if value.Joined {
+		payload[4] = 1
+	}
+	var err error
+	payload, err = appendString(payload, value.ConnectionID)
+	if err != nil {
+		return nil, fmt.Errorf("connection id: %w", err)
+	}
+	return EncodeFrame(Frame{Type: FramePresenceChanged, Payload: payload})
+}
+
+func DecodePresenceChanged(frame Frame) (PresenceChanged, error) {
+	if frame.Type != FramePresenceChanged {
+		return PresenceChanged{}, ErrUnexpectedFrameType
+	}
+	if len(frame.Payload) < 5 {
+		return PresenceChanged{}, ErrInvalidPayload
+	}
+	if frame.Payload[4] > 1 {
+		return PresenceChanged{}, ErrInvalidPayload
+	}
+	value := PresenceChanged{
+		ConnectionCount: binary.BigEndian.Uint32(frame.Payload[:4]),
+		Joined:          frame.Payload[4] == 1,
+	}
+	var err error
+	var offset int
+	value.ConnectionID, offset, err = readString(frame.Payload, 5)
+	if err != nil {
+		return PresenceChanged{}, err
+	}
+	if offset != len(frame.Payload) {
+		return PresenceChanged{}, ErrInvalidPayload
+	}
+	return value, nil
+}
+
 func EncodeOutputBatch(value OutputBatch) ([]byte, error) {
 	if value.GeometryGeneration == 0 || value.Cols == 0 || value.Rows == 0 ||
 		len(value.Records) == 0 || len(value.Records) > MaxOutputBatchChunks {
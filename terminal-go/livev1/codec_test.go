@@ -98,6 +98,10 @@ func TestProtocolVectors(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	encoded["presence_changed"], err = EncodePresenceChanged(PresenceChanged{ConnectionID: "c1", Joined: true, ConnectionCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	for _, vector := range vectors.Vectors {
 		want, err := hex.DecodeString(vector.Hex)
@@ -0,0 +1,108 @@
+package livev1
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAttachSuppressOwnEchoRoundTrip(t *testing.T) {
+	encoded, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		LastSequence:     7,
+		AckWindowBytes:   1 << 20,
+		SuppressOwnEcho:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	decoded, err := DecodeAttach(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.SuppressOwnEcho || decoded.LastSequence != 7 || decoded.AckWindowBytes != 1<<20 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	// The flag alone still forces the full trailer so the layout stays
+	// unambiguous.
+	encoded, err = EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		SuppressOwnEcho:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err = NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	decoded, err = DecodeAttach(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.SuppressOwnEcho || decoded.LastSequence != 0 || decoded.AckWindowBytes != 0 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	// Unknown flag bits are a decode error, not silently ignored behavior.
+	frame := frames[0]
+	frame.Payload[len(frame.Payload)-1] |= 0x04
+	if _, err := DecodeAttach(frame); err == nil {
+		t.Fatal("unknown flag bit was accepted")
+	}
+}
+
+func attachWithEchoSuppression(t *testing.T, client net.Conn) {
+	t.Helper()
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		SuppressOwnEcho:  true,
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+	if _, err := DecodeAttached(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceSuppressesOwnEcho(t *testing.T) {
+	backend := &fakeBackend{}
+	client, _ := servePipe(t, backend)
+	attachWithEchoSuppression(t, client)
+
+	// Output attributed to this connection's own input is dropped; other
+	// output flows through untouched.
+	own := testOutputRecord(5, 5, []byte("own"))
+	own.EchoSourceConnID = "connection"
+	if !backend.emit(own) {
+		t.Fatal("emit failed")
+	}
+	other := testOutputRecord(6, 6, []byte("other"))
+	other.EchoSourceConnID = "someone-else"
+	if !backend.emit(other) {
+		t.Fatal("emit failed")
+	}
+
+	batch, err := DecodeOutputBatch(readFrameForTest(t, client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Records) != 1 || batch.Records[0].Sequence != 6 {
+		t.Fatalf("batch = %+v, want only the unsuppressed record", batch)
+	}
+}
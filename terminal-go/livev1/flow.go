@@ -0,0 +1,88 @@
+package livev1
+
+import "sync"
+
+// ackFlow implements the optional windowed flow control negotiated via
+// Attach.AckWindowBytes. The writer keeps at most window unacknowledged
+// output bytes in flight and pauses when the client stops acking; if the
+// client then falls a full output queue behind, records are dropped and a
+// resync marker is sent instead of stalling the session or closing the
+// socket as a slow consumer.
+type ackFlow struct {
+	mu            sync.Mutex
+	window        int
+	inFlight      []ackFlightEntry
+	inFlightBytes int
+	ackedSequence uint64
+	droppedFrom   uint64
+	droppedTo     uint64
+	signal        chan struct{}
+}
+
+type ackFlightEntry struct {
+	sequence uint64
+	bytes    int
+}
+
+func newAckFlow(window int) *ackFlow {
+	return &ackFlow{window: window, signal: make(chan struct{})}
+}
+
+// noteSent records output written to the client; lastSequence is the newest
+// sequence in the write and bytes its payload size.
+func (f *ackFlow) noteSent(lastSequence uint64, bytes int) {
+	f.mu.Lock()
+	f.inFlight = append(f.inFlight, ackFlightEntry{sequence: lastSequence, bytes: bytes})
+	f.inFlightBytes += bytes
+	f.mu.Unlock()
+}
+
+// ack releases all in-flight entries up to and including sequence and wakes
+// a writer waiting for the window to open. Stale or duplicate acks are
+// ignored.
+func (f *ackFlow) ack(sequence uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if sequence <= f.ackedSequence {
+		return
+	}
+	f.ackedSequence = sequence
+	for len(f.inFlight) > 0 && f.inFlight[0].sequence <= sequence {
+		f.inFlightBytes -= f.inFlight[0].bytes
+		f.inFlight = f.inFlight[1:]
+	}
+	close(f.signal)
+	f.signal = make(chan struct{})
+}
+
+// windowOpen reports whether more output may be written, and the channel to
+// wait on before re-checking when it may not.
+func (f *ackFlow) windowOpen() (bool, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inFlightBytes < f.window, f.signal
+}
+
+// noteDropped widens the pending resync range to include sequence.
+func (f *ackFlow) noteDropped(sequence uint64) {
+	f.mu.Lock()
+	if f.droppedFrom == 0 || sequence < f.droppedFrom {
+		f.droppedFrom = sequence
+	}
+	if sequence > f.droppedTo {
+		f.droppedTo = sequence
+	}
+	f.mu.Unlock()
+}
+
+// takeDropped returns and clears the pending resync range, if any.
+func (f *ackFlow) takeDropped() (OutputDropped, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.droppedFrom == 0 {
+		return OutputDropped{}, false
+	}
+	dropped := OutputDropped{FromSequence: f.droppedFrom, ToSequence: f.droppedTo}
+	f.droppedFrom, f.droppedTo = 0, 0
+	return dropped, true
+}
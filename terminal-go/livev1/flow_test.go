@@ -0,0 +1,177 @@
+package livev1
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAttachAckWindowRoundTrip(t *testing.T) {
+	encoded, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		AckWindowBytes:   1 << 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	decoded, err := DecodeAttach(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.AckWindowBytes != 1<<20 || decoded.LastSequence != 0 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	ackBytes, err := EncodeAck(Ack{Sequence: 9})
+	frames, err = NewDecoder().Push(mustEncode(t, ackBytes, err))
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	ack, err := DecodeAck(frames[0])
+	if err != nil || ack.Sequence != 9 {
+		t.Fatalf("ack = %+v err=%v", ack, err)
+	}
+
+	droppedBytes, err := EncodeOutputDropped(OutputDropped{FromSequence: 3, ToSequence: 7})
+	frames, err = NewDecoder().Push(mustEncode(t, droppedBytes, err))
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	dropped, err := DecodeOutputDropped(frames[0])
+	if err != nil || dropped.FromSequence != 3 || dropped.ToSequence != 7 {
+		t.Fatalf("dropped = %+v err=%v", dropped, err)
+	}
+	if _, err := DecodeOutputDropped(Frame{Type: FrameOutputDropped, Payload: make([]byte, 8)}); err == nil {
+		t.Fatal("short payload was accepted")
+	}
+}
+
+func attachWithAckWindow(t *testing.T, client net.Conn, window uint64) {
+	t.Helper()
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		AckWindowBytes:   window,
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+	if _, err := DecodeAttached(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServicePausesOutputUntilAck(t *testing.T) {
+	backend := &fakeBackend{}
+	client, _ := servePipe(t, backend)
+	attachWithAckWindow(t, client, 4)
+
+	// The first record goes out because nothing is in flight yet.
+	if !backend.emit(testOutputRecord(5, 5, []byte("hello"))) {
+		t.Fatal("emit failed")
+	}
+	batch, err := DecodeOutputBatch(readFrameForTest(t, client))
+	if err != nil || len(batch.Records) != 1 || batch.Records[0].Sequence != 5 {
+		t.Fatalf("batch = %+v err=%v", batch, err)
+	}
+
+	// Five unacked bytes exceed the four-byte window, so the next record is
+	// held back until the client acks.
+	if !backend.emit(testOutputRecord(6, 6, []byte("world"))) {
+		t.Fatal("emit failed")
+	}
+	if err := client.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFrame(client); err == nil {
+		t.Fatal("output was written while the window was closed")
+	} else if netErr := net.Error(nil); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("read error = %v, want timeout", err)
+	}
+	if err := client.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ackBytes, ackErr := EncodeAck(Ack{Sequence: 5})
+	writeBytes(t, client, mustEncode(t, ackBytes, ackErr))
+	batch, err = DecodeOutputBatch(readFrameForTest(t, client))
+	if err != nil || len(batch.Records) != 1 || batch.Records[0].Sequence != 6 {
+		t.Fatalf("batch after ack = %+v err=%v", batch, err)
+	}
+}
+
+func TestServiceDropsWithResyncMarkerWhenFarBehind(t *testing.T) {
+	backend := &fakeBackend{}
+	client, _ := servePipe(t, backend)
+	attachWithAckWindow(t, client, 4)
+
+	if !backend.emit(testOutputRecord(1, 1, []byte("hello"))) {
+		t.Fatal("emit failed")
+	}
+	if _, err := DecodeOutputBatch(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+
+	// With the window closed, flood past the queue capacity; the overflow is
+	// dropped instead of back-pressuring the session.
+	chunk := bytes.Repeat([]byte("x"), 32*1024)
+	lastSeq := uint64(1)
+	for emitted := 0; emitted <= MaxQueuedOutputBytes; emitted += len(chunk) {
+		lastSeq++
+		if !backend.emit(testOutputRecord(lastSeq, lastSeq, chunk)) {
+			t.Fatal("emit failed")
+		}
+	}
+
+	// Opening the window surfaces the resync marker before any later output.
+	ackBytes, ackErr := EncodeAck(Ack{Sequence: 1})
+	writeBytes(t, client, mustEncode(t, ackBytes, ackErr))
+	frame := readFrameForTest(t, client)
+	if frame.Type != FrameOutputDropped {
+		t.Fatalf("frame type = %#x, want output-dropped marker", frame.Type)
+	}
+	dropped, err := DecodeOutputDropped(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped.FromSequence < 2 || dropped.ToSequence > lastSeq || dropped.FromSequence > dropped.ToSequence {
+		t.Fatalf("dropped range = %+v, emitted through %d", dropped, lastSeq)
+	}
+
+	// Retained output still flows after the marker.
+	if _, err := DecodeOutputBatch(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceRejectsAckWithoutNegotiatedWindow(t *testing.T) {
+	backend := &fakeBackend{}
+	client, done := servePipe(t, backend)
+	attachWithAckWindow(t, client, 0)
+
+	ackBytes, ackErr := EncodeAck(Ack{Sequence: 1})
+	writeBytes(t, client, mustEncode(t, ackBytes, ackErr))
+	frame := readFrameForTest(t, client)
+	if frame.Type != FrameError {
+		t.Fatalf("frame type = %#x, want error", frame.Type)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrProtocolViolation) {
+			t.Fatalf("serve error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve did not stop")
+	}
+}
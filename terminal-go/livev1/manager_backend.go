@@ -64,6 +64,7 @@ func (b *ManagerBackend) Attach(ctx context.Context, request Attach, subscriber
 					Cols:               uint32(event.Geometry.Cols),
 					Rows:               uint32(event.Geometry.Rows),
 					Data:               event.Data,
+					EchoSourceConnID:   event.EchoSourceConnID,
 				})
 			},
 			OnGeometry: func(geometry terminal.TerminalGeometry) bool {
@@ -77,6 +78,17 @@ func (b *ManagerBackend) Attach(ctx context.Context, request Attach, subscriber
 					Rows:                   uint32(geometry.Rows),
 				})
 			},
+			OnPresence: func(event terminal.TerminalPresenceEvent) bool {
+				if subscriber.OnPresence == nil {
+					// Presence is optional; not listening is not a delivery failure.
+					return true
+				}
+				return subscriber.OnPresence(PresenceChanged{
+					ConnectionID:    event.ConnID,
+					Joined:          event.Joined,
+					ConnectionCount: uint32(event.ConnectionCount),
+				})
+			},
 			OnSessionClosed: subscriber.OnSessionClosed,
 			OnSuperseded:    subscriber.OnSuperseded,
 		},
@@ -107,6 +119,31 @@ func (b *ManagerBackend) Attach(ctx context.Context, request Attach, subscriber
 	}, attachment.Detach, nil
 }
 
+// ReplayOutput returns retained session output after the given sequence so
+// the service can catch a reconnecting client up over the live stream.
+func (b *ManagerBackend) ReplayOutput(_ context.Context, attachment Attach, afterSequence uint64) ([]OutputRecord, error) {
+	if b == nil || b.manager == nil {
+		return nil, errors.New("terminal manager is required")
+	}
+	session, ok := b.manager.GetSession(attachment.SessionID)
+	if !ok || session == nil {
+		return nil, ErrSessionNotFound
+	}
+	chunks, err := session.GetHistoryFromSequence(int64(afterSequence) + 1)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]OutputRecord, 0, len(chunks))
+	for _, chunk := range chunks {
+		records = append(records, OutputRecord{
+			Sequence:    uint64(chunk.Sequence),
+			TimestampMs: uint64(chunk.Timestamp),
+			Data:        chunk.Data,
+		})
+	}
+	return records, nil
+}
+
 func (b *ManagerBackend) WriteInput(_ context.Context, attachment Attach, input Input) error {
 	if b == nil || b.manager == nil {
 		return errors.New("terminal manager is required")
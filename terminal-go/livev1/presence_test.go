@@ -0,0 +1,81 @@
+package livev1
+
+import "testing"
+
+func TestPresenceChangedRoundTrip(t *testing.T) {
+	encoded, err := EncodePresenceChanged(PresenceChanged{ConnectionID: "conn-1", Joined: true, ConnectionCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames = %d, err = %v", len(frames), err)
+	}
+	decoded, err := DecodePresenceChanged(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ConnectionID != "conn-1" || !decoded.Joined || decoded.ConnectionCount != 3 {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	if _, err := EncodePresenceChanged(PresenceChanged{Joined: true, ConnectionCount: 1}); err == nil {
+		t.Fatal("missing connection id was accepted")
+	}
+
+	bad := Frame{Type: FramePresenceChanged, Payload: append([]byte(nil), frames[0].Payload...)}
+	bad.Payload[4] = 2
+	if _, err := DecodePresenceChanged(bad); err == nil {
+		t.Fatal("invalid joined byte was accepted")
+	}
+
+	trailing := Frame{Type: FramePresenceChanged, Payload: append(append([]byte(nil), frames[0].Payload...), 0)}
+	if _, err := DecodePresenceChanged(trailing); err == nil {
+		t.Fatal("trailing bytes were accepted")
+	}
+}
+
+func TestServiceForwardsPresenceChanges(t *testing.T) {
+	backend := &fakeBackend{}
+	client, _ := servePipe(t, backend)
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+	if _, err := DecodeAttached(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+
+	emitted := make(chan bool, 1)
+	go func() {
+		emitted <- backend.emitPresence(PresenceChanged{ConnectionID: "other", Joined: true, ConnectionCount: 2})
+	}()
+	joined, err := DecodePresenceChanged(readFrameForTest(t, client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.ConnectionID != "other" || !joined.Joined || joined.ConnectionCount != 2 {
+		t.Fatalf("joined = %+v", joined)
+	}
+	if !<-emitted {
+		t.Fatal("presence emit failed")
+	}
+
+	go func() {
+		emitted <- backend.emitPresence(PresenceChanged{ConnectionID: "other", ConnectionCount: 1})
+	}()
+	left, err := DecodePresenceChanged(readFrameForTest(t, client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left.ConnectionID != "other" || left.Joined || left.ConnectionCount != 1 {
+		t.Fatalf("left = %+v", left)
+	}
+	if !<-emitted {
+		t.Fatal("presence emit failed")
+	}
+}
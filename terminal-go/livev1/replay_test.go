@@ -0,0 +1,147 @@
+package livev1
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAttachLastSequenceRoundTrip(t *testing.T) {
+	encoded, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		LastSequence:     42,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	decoded, err := DecodeAttach(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.LastSequence != 42 || decoded.SessionID != "session" || decoded.ConnectionID != "connection" {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+
+	// Frames from older clients without the trailer decode with a zero
+	// LastSequence.
+	encoded, err = EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err = NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	decoded, err = DecodeAttach(frames[0])
+	if err != nil || decoded.LastSequence != 0 {
+		t.Fatalf("decoded = %+v err=%v", decoded, err)
+	}
+
+	// A partial trailer is invalid.
+	frame := frames[0]
+	frame.Payload = append(frame.Payload, 0, 0, 0)
+	if _, err := DecodeAttach(frame); err == nil {
+		t.Fatal("partial trailer was accepted")
+	}
+}
+
+// replayingBackend serves retained records 1..6 so the service can catch a
+// reconnecting client up.
+type replayingBackend struct {
+	fakeBackend
+	replayedAfter []uint64
+}
+
+func (b *replayingBackend) ReplayOutput(_ context.Context, _ Attach, afterSequence uint64) ([]OutputRecord, error) {
+	b.mu.Lock()
+	b.replayedAfter = append(b.replayedAfter, afterSequence)
+	b.mu.Unlock()
+	records := make([]OutputRecord, 0, 6)
+	for seq := uint64(1); seq <= 6; seq++ {
+		records = append(records, OutputRecord{Sequence: seq, TimestampMs: seq, Data: []byte{byte('a' + seq)}})
+	}
+	return records, nil
+}
+
+func TestServiceReplaysOutputAfterLastSequence(t *testing.T) {
+	backend := &replayingBackend{}
+	client, _ := servePipe(t, backend)
+
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		LastSequence:     2,
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+
+	attached, err := DecodeAttached(readFrameForTest(t, client))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake backend reports history boundary 4, so only sequences 3 and 4
+	// are replayed; newer records arrive through the live stream instead.
+	batch, err := DecodeOutputBatch(readFrameForTest(t, client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Records) != 2 || batch.Records[0].Sequence != 3 || batch.Records[1].Sequence != 4 {
+		t.Fatalf("replayed batch = %+v", batch.Records)
+	}
+	if batch.GeometryGeneration != attached.GeometryGeneration || batch.Cols != attached.Cols || batch.Rows != attached.Rows {
+		t.Fatalf("replayed batch geometry = %+v, attached = %+v", batch, attached)
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.replayedAfter) != 1 || backend.replayedAfter[0] != 2 {
+		t.Fatalf("replayedAfter = %v", backend.replayedAfter)
+	}
+}
+
+func TestServiceSkipsReplayWithoutLastSequence(t *testing.T) {
+	backend := &replayingBackend{}
+	client, _ := servePipe(t, backend)
+
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+	if _, err := DecodeAttached(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Live output still flows; nothing was replayed first.
+	if !backend.emit(testOutputRecord(5, 5, []byte("live"))) {
+		t.Fatal("emit failed")
+	}
+	batch, err := DecodeOutputBatch(readFrameForTest(t, client))
+	if err != nil || len(batch.Records) != 1 || batch.Records[0].Sequence != 5 {
+		t.Fatalf("batch = %+v err=%v", batch, err)
+	}
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.replayedAfter) != 0 {
+		t.Fatalf("replayedAfter = %v", backend.replayedAfter)
+	}
+}
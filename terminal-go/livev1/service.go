@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,6 +35,7 @@ var (
 type Subscriber struct {
 	OnOutput        func(OutputRecord) bool
 	OnGeometry      func(EffectiveGeometry) bool
+	OnPresence      func(PresenceChanged) bool
 	OnSessionClosed func()
 	OnSuperseded    func()
 }
@@ -44,6 +46,14 @@ type Backend interface {
 	Resize(ctx context.Context, attachment Attach, resize Resize) (EffectiveGeometry, error)
 }
 
+// OutputReplayer is an optional Backend capability: it returns retained
+// output with sequences above afterSequence so a reconnecting client that
+// sent a LastSequence in its attach frame catches up on the live stream
+// instead of fetching history out of band.
+type OutputReplayer interface {
+	ReplayOutput(ctx context.Context, attachment Attach, afterSequence uint64) ([]OutputRecord, error)
+}
+
 type Service struct {
 	backend        Backend
 	newOutputTimer func(time.Duration) outputTimer
@@ -61,6 +71,14 @@ type outputQueue struct {
 	closed    chan struct{}
 	isClosed  bool
 	closeOnce sync.Once
+	// flow, when set, drops records instead of blocking once the queue is
+	// full: a windowed client that far behind gets a resync marker rather
+	// than back-pressuring the whole session.
+	flow *ackFlow
+	// lastSequence tracks the newest output sequence handed to this queue;
+	// input confirmations read it to tell the client where authoritative
+	// echo of the confirmed input can start.
+	lastSequence atomic.Uint64
 }
 
 func newOutputQueue() *outputQueue {
@@ -76,6 +94,7 @@ func (q *outputQueue) enqueue(record OutputRecord) bool {
 		len(record.Data) == 0 || len(record.Data) > MaxQueuedOutputBytes {
 		return false
 	}
+	q.lastSequence.Store(record.Sequence)
 	owned := OutputRecord{
 		Sequence:           record.Sequence,
 		TimestampMs:        record.TimestampMs,
@@ -101,6 +120,11 @@ func (q *outputQueue) enqueue(record OutputRecord) bool {
 				return false
 			}
 		}
+		if q.flow != nil {
+			q.mu.Unlock()
+			q.flow.noteDropped(owned.Sequence)
+			return true
+		}
 		space := q.space
 		q.mu.Unlock()
 
@@ -164,6 +188,11 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 
 	queue := newOutputQueue()
 	defer queue.close()
+	var flow *ackFlow
+	if attachment.AckWindowBytes > 0 {
+		flow = newAckFlow(int(attachment.AckWindowBytes))
+		queue.flow = flow
+	}
 	sessionClosed := make(chan struct{})
 	superseded := make(chan struct{})
 	var sessionClosedOnce sync.Once
@@ -177,6 +206,7 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 	var geometryMu sync.Mutex
 	attachedWritten := false
 	var pendingGeometry *EffectiveGeometry
+	var pendingPresence []PresenceChanged
 	writeGeometry := func(geometry EffectiveGeometry) bool {
 		geometryMu.Lock()
 		if !attachedWritten {
@@ -194,9 +224,40 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 		}
 		return true
 	}
+	writePresence := func(presence PresenceChanged) bool {
+		geometryMu.Lock()
+		if !attachedWritten {
+			// Presence is buffered, not coalesced: join/leave events are
+			// individually meaningful, unlike geometry where only the latest
+			// state matters.
+			pendingPresence = append(pendingPresence, presence)
+			geometryMu.Unlock()
+			return true
+		}
+		geometryMu.Unlock()
+		encoded, encodeErr := EncodePresenceChanged(presence)
+		if encodeErr != nil || writeBytes(encoded) != nil {
+			cancel()
+			_ = stream.Close()
+			return false
+		}
+		return true
+	}
+	onOutput := queue.enqueue
+	if attachment.SuppressOwnEcho {
+		onOutput = func(record OutputRecord) bool {
+			if record.EchoSourceConnID == attachment.ConnectionID {
+				// The client already rendered this input locally; forwarding
+				// the echo would double the characters.
+				return true
+			}
+			return queue.enqueue(record)
+		}
+	}
 	attached, detach, err := s.backend.Attach(ctx, attachment, Subscriber{
-		OnOutput:   queue.enqueue,
+		OnOutput:   onOutput,
 		OnGeometry: writeGeometry,
+		OnPresence: writePresence,
 		OnSessionClosed: func() {
 			sessionClosedOnce.Do(func() { close(sessionClosed) })
 		},
@@ -233,16 +294,33 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 	attachedWritten = true
 	pending := pendingGeometry
 	pendingGeometry = nil
+	bufferedPresence := pendingPresence
+	pendingPresence = nil
 	geometryMu.Unlock()
 	if pending != nil && pending.Generation > attached.GeometryGeneration {
 		if !writeGeometry(*pending) {
 			return io.ErrClosedPipe
 		}
 	}
+	for _, presence := range bufferedPresence {
+		if !writePresence(presence) {
+			return io.ErrClosedPipe
+		}
+	}
+	// Replay retained output before draining live records: everything queued
+	// since the attach registration is above the history boundary, so the
+	// stream stays sequence-ordered.
+	if attachment.LastSequence > 0 && attachment.LastSequence < attached.HistoryBoundarySequence {
+		if replayer, ok := s.backend.(OutputReplayer); ok {
+			if err := s.replayOutput(ctx, writeBytes, replayer, attachment, attached); err != nil {
+				return s.protocolFailureLocked(stream, &writeMu, ErrorCodeInternal, "terminal replay failed", err)
+			}
+		}
+	}
 
 	writerDone := make(chan error, 1)
 	go func() {
-		writerDone <- s.writeOutputs(ctx, stream, &writeMu, queue, sessionClosed, superseded)
+		writerDone <- s.writeOutputs(ctx, stream, &writeMu, queue, flow, sessionClosed, superseded)
 	}()
 
 	var lastInputSequence uint64
@@ -263,15 +341,34 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 			return readErr
 		}
 		switch frame.Type {
-		case FrameInput:
+		case FrameInput, FrameInputSpeculative:
 			input, decodeErr := DecodeInput(frame)
 			if decodeErr != nil || input.Sequence <= lastInputSequence {
 				return s.protocolFailureLocked(stream, &writeMu, ErrorCodeProtocolViolation, "invalid input sequence", ErrProtocolViolation)
 			}
+			if input.Speculative && !attachment.SpeculativeInput {
+				return s.protocolFailureLocked(stream, &writeMu, ErrorCodeProtocolViolation, "speculative input was not negotiated", ErrProtocolViolation)
+			}
+			// Read the boundary before the write: any output produced by this
+			// input is enqueued after the write starts, so echo is guaranteed
+			// to land above the sequence reported in the confirmation.
+			outputBoundary := queue.lastSequence.Load()
 			if err := s.backend.WriteInput(ctx, attachment, input); err != nil {
 				return s.protocolFailureLocked(stream, &writeMu, ErrorCodeInternal, "terminal input failed", err)
 			}
 			lastInputSequence = input.Sequence
+			if input.Speculative {
+				confirmation, encodeErr := EncodeInputApplied(InputApplied{
+					Sequence:               input.Sequence,
+					OutputSequenceBoundary: outputBoundary,
+				})
+				if encodeErr != nil {
+					return encodeErr
+				}
+				if err := writeBytes(confirmation); err != nil {
+					return err
+				}
+			}
 		case FrameResize:
 			resize, decodeErr := DecodeResize(frame)
 			if decodeErr != nil || resize.Sequence <= lastResizeSequence {
@@ -295,6 +392,12 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 				return err
 			}
 			lastResizeSequence = resize.Sequence
+		case FrameAck:
+			ack, decodeErr := DecodeAck(frame)
+			if decodeErr != nil || flow == nil {
+				return s.protocolFailureLocked(stream, &writeMu, ErrorCodeProtocolViolation, "unexpected ack frame", ErrProtocolViolation)
+			}
+			flow.ack(ack.Sequence)
 		case FrameDetach:
 			if len(frame.Payload) != 0 {
 				return s.protocolFailureLocked(stream, &writeMu, ErrorCodeProtocolViolation, "invalid detach frame", ErrProtocolViolation)
@@ -306,11 +409,76 @@ func (s *Service) Serve(parent context.Context, stream io.ReadWriteCloser) error
 	}
 }
 
+// replayOutput streams retained records in (LastSequence, history boundary]
+// as regular output batches. Replayed records carry the attach-time geometry;
+// their original geometry is long gone and irrelevant for catch-up rendering.
+func (s *Service) replayOutput(
+	ctx context.Context,
+	writeBytes func([]byte) error,
+	replayer OutputReplayer,
+	attachment Attach,
+	attached Attached,
+) error {
+	records, err := replayer.ReplayOutput(ctx, attachment, attachment.LastSequence)
+	if err != nil {
+		return err
+	}
+
+	var batch []OutputRecord
+	batchBytes := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		encoded, err := EncodeOutputBatch(OutputBatch{
+			GeometryGeneration: attached.GeometryGeneration,
+			Cols:               attached.Cols,
+			Rows:               attached.Rows,
+			Records:            batch,
+		})
+		if err != nil {
+			return err
+		}
+		batch, batchBytes = nil, 0
+		return writeBytes(encoded)
+	}
+	for _, record := range records {
+		if record.Sequence <= attachment.LastSequence || record.Sequence > attached.HistoryBoundarySequence || len(record.Data) == 0 {
+			continue
+		}
+		// Oversized records are split; the shared sequence is fine because
+		// clients apply replay strictly in stream order.
+		for data := record.Data; len(data) > 0; {
+			piece := data
+			if len(piece) > MaxOutputBatchBytes {
+				piece = piece[:MaxOutputBatchBytes]
+			}
+			data = data[len(piece):]
+			if len(batch) == MaxOutputBatchChunks || batchBytes+len(piece) > MaxOutputBatchBytes {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			batch = append(batch, OutputRecord{
+				Sequence:           record.Sequence,
+				TimestampMs:        record.TimestampMs,
+				GeometryGeneration: attached.GeometryGeneration,
+				Cols:               attached.Cols,
+				Rows:               attached.Rows,
+				Data:               piece,
+			})
+			batchBytes += len(piece)
+		}
+	}
+	return flush()
+}
+
 func (s *Service) writeOutputs(
 	ctx context.Context,
 	stream io.ReadWriteCloser,
 	writeMu *sync.Mutex,
 	queue *outputQueue,
+	flow *ackFlow,
 	sessionClosed <-chan struct{},
 	superseded <-chan struct{},
 ) error {
@@ -409,6 +577,40 @@ func (s *Service) writeOutputs(
 			return nil
 		default:
 		}
+		if flow != nil {
+			// Pause until the client has acked enough of what is already in
+			// flight; dropped records get their resync marker first so the
+			// client knows to refetch before applying this write.
+			for {
+				open, signal := flow.windowOpen()
+				if open {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-sessionClosed:
+					return s.writeSessionClosed(stream, writeMu)
+				case <-superseded:
+					_ = s.protocolFailureLocked(stream, writeMu, ErrorCodeProtocolViolation, "terminal attachment superseded", ErrProtocolViolation)
+					_ = stream.Close()
+					return ErrProtocolViolation
+				case <-signal:
+				}
+			}
+			if dropped, ok := flow.takeDropped(); ok {
+				marker, encodeErr := EncodeOutputDropped(dropped)
+				if encodeErr != nil {
+					return encodeErr
+				}
+				writeMu.Lock()
+				err := writeAll(stream, marker)
+				writeMu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+		}
 		writeMu.Lock()
 		err := writeAll(stream, output.data)
 		writeMu.Unlock()
@@ -416,6 +618,9 @@ func (s *Service) writeOutputs(
 			return err
 		}
 		queue.takeBytes(output.queuedBytes)
+		if flow != nil {
+			flow.noteSent(output.lastSequence, output.queuedBytes)
+		}
 		idle = false
 		window = s.startOutputTimer(OutputCoalesceWindow)
 	}
@@ -441,13 +646,15 @@ func (s *Service) startOutputTimer(window time.Duration) outputTimer {
 }
 
 type encodedOutputFrame struct {
-	data        []byte
-	queuedBytes int
+	data         []byte
+	queuedBytes  int
+	lastSequence uint64
 }
 
 type encodedOutputWrite struct {
-	data        []byte
-	queuedBytes int
+	data         []byte
+	queuedBytes  int
+	lastSequence uint64
 }
 
 func encodeOutputFrames(ctx context.Context, queue *outputQueue, frames chan<- encodedOutputFrame) error {
@@ -475,8 +682,9 @@ func collectAvailableOutputFrames(
 	frames <-chan encodedOutputFrame,
 ) (encodedOutputWrite, *encodedOutputFrame) {
 	output := encodedOutputWrite{
-		data:        append([]byte(nil), first.data...),
-		queuedBytes: first.queuedBytes,
+		data:         append([]byte(nil), first.data...),
+		queuedBytes:  first.queuedBytes,
+		lastSequence: first.lastSequence,
 	}
 	for len(output.data) < maxOutputStreamBytes {
 		select {
@@ -489,6 +697,7 @@ func collectAvailableOutputFrames(
 			}
 			output.data = append(output.data, next.data...)
 			output.queuedBytes += next.queuedBytes
+			output.lastSequence = next.lastSequence
 		default:
 			return output, nil
 		}
@@ -503,8 +712,9 @@ func collectOutputFramesUntilDeadline(
 	deadline <-chan time.Time,
 ) (encodedOutputWrite, *encodedOutputFrame) {
 	output := encodedOutputWrite{
-		data:        append([]byte(nil), first.data...),
-		queuedBytes: first.queuedBytes,
+		data:         append([]byte(nil), first.data...),
+		queuedBytes:  first.queuedBytes,
+		lastSequence: first.lastSequence,
 	}
 	for len(output.data) < maxOutputStreamBytes {
 		select {
@@ -526,6 +736,7 @@ func collectOutputFramesUntilDeadline(
 			}
 			output.data = append(output.data, next.data...)
 			output.queuedBytes += next.queuedBytes
+			output.lastSequence = next.lastSequence
 		}
 	}
 	return output, nil
@@ -586,7 +797,11 @@ drain:
 	if err != nil {
 		return encodedOutputFrame{}, nil, err
 	}
-	return encodedOutputFrame{data: encoded, queuedBytes: dataBytes}, pending, nil
+	return encodedOutputFrame{
+		data:         encoded,
+		queuedBytes:  dataBytes,
+		lastSequence: records[len(records)-1].Sequence,
+	}, pending, nil
 }
 
 func (s *Service) writeSessionClosed(stream io.ReadWriteCloser, writeMu *sync.Mutex) error {
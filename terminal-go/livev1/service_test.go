@@ -77,6 +77,13 @@ func (b *fakeBackend) emitGeometry(geometry EffectiveGeometry) bool {
 	return subscriber.OnGeometry(geometry)
 }
 
+func (b *fakeBackend) emitPresence(presence PresenceChanged) bool {
+	b.mu.Lock()
+	subscriber := b.subscriber
+	b.mu.Unlock()
+	return subscriber.OnPresence(presence)
+}
+
 func servePipe(t *testing.T, backend Backend) (net.Conn, <-chan error) {
 	t.Helper()
 	client, server := net.Pipe()
@@ -412,6 +419,7 @@ func TestWriteOutputsPipelinesEncodingWhileStreamWriteIsBlocked(t *testing.T) {
 			stream,
 			&sync.Mutex{},
 			queue,
+			nil,
 			make(chan struct{}),
 			make(chan struct{}),
 		)
@@ -738,6 +746,7 @@ func TestWriteOutputsSendsFirstOutputBeforeStartingCoalescingWindow(t *testing.T
 			stream,
 			&sync.Mutex{},
 			queue,
+			nil,
 			make(chan struct{}),
 			make(chan struct{}),
 		)
@@ -804,6 +813,7 @@ func TestWriteOutputsPreservesOrderAcrossBatchByteBoundary(t *testing.T) {
 		stream,
 		&sync.Mutex{},
 		queue,
+		nil,
 		make(chan struct{}),
 		make(chan struct{}),
 	)
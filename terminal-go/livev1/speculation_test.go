@@ -0,0 +1,169 @@
+package livev1
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAttachSpeculativeInputRoundTrip(t *testing.T) {
+	encoded, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		SuppressOwnEcho:  true,
+		SpeculativeInput: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	decoded, err := DecodeAttach(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.SpeculativeInput || !decoded.SuppressOwnEcho {
+		t.Fatalf("decoded = %+v", decoded)
+	}
+}
+
+func TestSpeculativeInputAndConfirmationRoundTrip(t *testing.T) {
+	encoded, err := EncodeInput(Input{Sequence: 9, Data: []byte("a"), Speculative: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	if frames[0].Type != FrameInputSpeculative {
+		t.Fatalf("frame type=%x, want speculative input", frames[0].Type)
+	}
+	input, err := DecodeInput(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !input.Speculative || input.Sequence != 9 || string(input.Data) != "a" {
+		t.Fatalf("input = %+v", input)
+	}
+
+	encoded, err = EncodeInputApplied(InputApplied{Sequence: 9, OutputSequenceBoundary: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err = NewDecoder().Push(encoded)
+	if err != nil || len(frames) != 1 {
+		t.Fatalf("frames=%d err=%v", len(frames), err)
+	}
+	applied, err := DecodeInputApplied(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Sequence != 9 || applied.OutputSequenceBoundary != 42 {
+		t.Fatalf("applied = %+v", applied)
+	}
+	// A boundary of zero is legal: input may be applied before any output.
+	if _, err := EncodeInputApplied(InputApplied{Sequence: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncodeInputApplied(InputApplied{OutputSequenceBoundary: 5}); err == nil {
+		t.Fatal("zero input sequence was accepted")
+	}
+}
+
+func attachWithSpeculation(t *testing.T, client net.Conn) {
+	t.Helper()
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+		SpeculativeInput: true,
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+	if _, err := DecodeAttached(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceConfirmsSpeculativeInput(t *testing.T) {
+	backend := &fakeBackend{}
+	client, _ := servePipe(t, backend)
+	attachWithSpeculation(t, client)
+
+	// Establish an output position first so the confirmation has a boundary
+	// to report; drain the batch before writing input to keep the read order
+	// deterministic.
+	if !backend.emit(testOutputRecord(5, 5, []byte("before"))) {
+		t.Fatal("emit failed")
+	}
+	if _, err := DecodeOutputBatch(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+
+	inputBytes, err := EncodeInput(Input{Sequence: 1, Data: []byte("x"), Speculative: true})
+	writeBytes(t, client, mustEncode(t, inputBytes, err))
+	applied, err := DecodeInputApplied(readFrameForTest(t, client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Sequence != 1 || applied.OutputSequenceBoundary != 5 {
+		t.Fatalf("applied = %+v, want sequence 1 above boundary 5", applied)
+	}
+
+	backend.mu.Lock()
+	inputs := append([]Input(nil), backend.inputs...)
+	backend.mu.Unlock()
+	if len(inputs) != 1 || !inputs[0].Speculative || string(inputs[0].Data) != "x" {
+		t.Fatalf("backend inputs = %+v", inputs)
+	}
+
+	// Untagged input on the same attachment is written without confirmation:
+	// the next server frame is the echo output, not an InputApplied.
+	inputBytes, err = EncodeInput(Input{Sequence: 2, Data: []byte("y")})
+	writeBytes(t, client, mustEncode(t, inputBytes, err))
+	if !backend.emit(testOutputRecord(6, 6, []byte("after"))) {
+		t.Fatal("emit failed")
+	}
+	frame := readFrameForTest(t, client)
+	if frame.Type != FrameOutputBatch {
+		t.Fatalf("frame type=%x, want output batch", frame.Type)
+	}
+}
+
+func TestServiceRejectsUnnegotiatedSpeculativeInput(t *testing.T) {
+	backend := &fakeBackend{}
+	client, done := servePipe(t, backend)
+	attachBytes, err := EncodeAttach(Attach{
+		AttachGeneration: 1,
+		Cols:             80,
+		Rows:             24,
+		SessionID:        "session",
+		ConnectionID:     "connection",
+	})
+	writeBytes(t, client, mustEncode(t, attachBytes, err))
+	if _, err := DecodeAttached(readFrameForTest(t, client)); err != nil {
+		t.Fatal(err)
+	}
+
+	inputBytes, err := EncodeInput(Input{Sequence: 1, Data: []byte("x"), Speculative: true})
+	writeBytes(t, client, mustEncode(t, inputBytes, err))
+	frame := readFrameForTest(t, client)
+	if frame.Type != FrameError {
+		t.Fatalf("frame type=%x, want error", frame.Type)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("protocol violation returned nil")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("service did not close after protocol violation")
+	}
+}
@@ -0,0 +1,92 @@
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fallbackUTF8Locale = "C.UTF-8"
+
+var (
+	detectedLocaleOnce sync.Once
+	detectedLocale     string
+)
+
+// detectUTF8Locale returns a UTF-8 locale suitable for LANG/LC_ALL. It prefers
+// the host environment, then consults `locale -a`, and finally falls back to
+// C.UTF-8 so sessions never start with a locale the system cannot provide.
+// The result is cached for the process lifetime.
+func detectUTF8Locale() string {
+	detectedLocaleOnce.Do(func() {
+		detectedLocale = detectUTF8LocaleUncached(os.Getenv, listAvailableLocales)
+	})
+	return detectedLocale
+}
+
+func detectUTF8LocaleUncached(getenv func(string) string, listLocales func() []string) string {
+	if locale := utf8LocaleFromEnv(getenv); locale != "" {
+		return locale
+	}
+	if locale := chooseUTF8Locale(listLocales()); locale != "" {
+		return locale
+	}
+	return fallbackUTF8Locale
+}
+
+// utf8LocaleFromEnv returns the host locale when it already selects UTF-8,
+// checking variables in POSIX precedence order.
+func utf8LocaleFromEnv(getenv func(string) string) string {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := getenv(key); isUTF8Locale(locale) {
+			return locale
+		}
+	}
+	return ""
+}
+
+func isUTF8Locale(locale string) bool {
+	normalized := strings.ToLower(locale)
+	return strings.Contains(normalized, "utf-8") || strings.Contains(normalized, "utf8")
+}
+
+// chooseUTF8Locale picks the best UTF-8 entry from `locale -a` output,
+// preferring the neutral C/en_US locales over arbitrary ones.
+func chooseUTF8Locale(available []string) string {
+	preferred := []string{"C.UTF-8", "C.utf8", "en_US.UTF-8", "en_US.utf8"}
+	for _, want := range preferred {
+		for _, locale := range available {
+			if locale == want {
+				return locale
+			}
+		}
+	}
+	for _, locale := range available {
+		if isUTF8Locale(locale) {
+			return locale
+		}
+	}
+	return ""
+}
+
+func listAvailableLocales() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "locale", "-a").Output()
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(string(output), "\n")
+	locales := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			locales = append(locales, line)
+		}
+	}
+	return locales
+}
@@ -0,0 +1,49 @@
+package terminal
+
+import "testing"
+
+func TestDetectUTF8LocalePrefersHostEnv(t *testing.T) {
+	getenv := func(key string) string {
+		if key == "LANG" {
+			return "de_DE.UTF-8"
+		}
+		return ""
+	}
+	locale := detectUTF8LocaleUncached(getenv, func() []string { return nil })
+	if locale != "de_DE.UTF-8" {
+		t.Fatalf("locale = %q, want host LANG", locale)
+	}
+}
+
+func TestDetectUTF8LocaleIgnoresNonUTF8HostEnv(t *testing.T) {
+	getenv := func(key string) string {
+		if key == "LANG" {
+			return "POSIX"
+		}
+		return ""
+	}
+	locale := detectUTF8LocaleUncached(getenv, func() []string {
+		return []string{"POSIX", "fr_FR.utf8", "en_US.utf8"}
+	})
+	if locale != "en_US.utf8" {
+		t.Fatalf("locale = %q, want preferred entry from locale -a", locale)
+	}
+}
+
+func TestDetectUTF8LocaleFallsBackToCUTF8(t *testing.T) {
+	getenv := func(string) string { return "" }
+	locale := detectUTF8LocaleUncached(getenv, func() []string { return []string{"C", "POSIX"} })
+	if locale != fallbackUTF8Locale {
+		t.Fatalf("locale = %q, want %q", locale, fallbackUTF8Locale)
+	}
+}
+
+func TestApplyDefaultsFillsMissingLocale(t *testing.T) {
+	cfg := ManagerConfig{TerminalEnv: TerminalEnv{Term: "xterm"}}.applyDefaults()
+	if cfg.TerminalEnv.Lang == "" || cfg.TerminalEnv.LcAll == "" {
+		t.Fatalf("expected applyDefaults to fill locale, got %+v", cfg.TerminalEnv)
+	}
+	if !isUTF8Locale(cfg.TerminalEnv.Lang) {
+		t.Fatalf("expected a UTF-8 locale, got %q", cfg.TerminalEnv.Lang)
+	}
+}
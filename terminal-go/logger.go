@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -29,6 +30,40 @@ type Logger interface {
 	Error(msg string, kv ...any)
 }
 
+// WithLogFields returns a logger that prepends the given key/value pairs to
+// every entry, so correlation fields like sessionID appear consistently
+// without being repeated at each call site. Wrapping an already-wrapped
+// logger accumulates the fields; a nil base logs nowhere.
+func WithLogFields(base Logger, kv ...any) Logger {
+	if base == nil {
+		base = NopLogger{}
+	}
+	if len(kv) == 0 {
+		return base
+	}
+	if parent, ok := base.(fieldLogger); ok {
+		kv = append(append([]any(nil), parent.fields...), kv...)
+		base = parent.base
+	}
+	return fieldLogger{base: base, fields: kv}
+}
+
+type fieldLogger struct {
+	base   Logger
+	fields []any
+}
+
+func (l fieldLogger) Debug(msg string, kv ...any) { l.base.Debug(msg, l.merge(kv)...) }
+func (l fieldLogger) Info(msg string, kv ...any)  { l.base.Info(msg, l.merge(kv)...) }
+func (l fieldLogger) Warn(msg string, kv ...any)  { l.base.Warn(msg, l.merge(kv)...) }
+func (l fieldLogger) Error(msg string, kv ...any) { l.base.Error(msg, l.merge(kv)...) }
+
+func (l fieldLogger) merge(kv []any) []any {
+	merged := make([]any, 0, len(l.fields)+len(kv))
+	merged = append(merged, l.fields...)
+	return append(merged, kv...)
+}
+
 // NopLogger drops all log messages.
 type NopLogger struct{}
 
@@ -85,6 +120,62 @@ func (l *StdLogger) log(level LogLevel, label string, msg string, kv ...any) {
 	l.logger.Printf("%s [%s] %s", timestamp, labelOut, payloadOut)
 }
 
+// JSONLogger writes one JSON object per log line ("ts", "level", "msg" plus
+// the key/value pairs), so log shippers can ingest the output without parsing
+// the human-oriented StdLogger format.
+type JSONLogger struct {
+	logger   *log.Logger
+	minLevel LogLevel
+}
+
+// NewJSONLogger returns a logger that prints JSON lines to stdout.
+func NewJSONLogger(minLevel LogLevel) *JSONLogger {
+	return &JSONLogger{
+		logger:   log.New(os.Stdout, "", 0),
+		minLevel: minLevel,
+	}
+}
+
+func (l *JSONLogger) Debug(msg string, kv ...any) { l.log(LogDebug, "debug", msg, kv...) }
+func (l *JSONLogger) Info(msg string, kv ...any)  { l.log(LogInfo, "info", msg, kv...) }
+func (l *JSONLogger) Warn(msg string, kv ...any)  { l.log(LogWarn, "warn", msg, kv...) }
+func (l *JSONLogger) Error(msg string, kv ...any) { l.log(LogError, "error", msg, kv...) }
+
+func (l *JSONLogger) log(level LogLevel, label string, msg string, kv ...any) {
+	if l == nil || l.logger == nil {
+		return
+	}
+	if level < l.minLevel {
+		return
+	}
+
+	entry := make(map[string]any, 3+(len(kv)+1)/2)
+	entry["ts"] = time.Now().Format(time.RFC3339)
+	entry["level"] = label
+	entry["msg"] = msg
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprintf("%v", kv[i])
+		var value any
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		// Errors marshal as {}; anything else unmarshalable falls back to
+		// its string form rather than dropping the line.
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		} else if _, err := json.Marshal(value); err != nil {
+			value = fmt.Sprintf("%v", value)
+		}
+		entry[key] = value
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.logger.Print(string(data))
+}
+
 const (
 	ansiReset  = "\x1b[0m"
 	ansiRed    = "\x1b[31m"
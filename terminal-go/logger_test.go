@@ -0,0 +1,94 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestJSONLoggerWritesStructuredLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &JSONLogger{logger: log.New(&buf, "", 0), minLevel: LogInfo}
+
+	logger.Debug("filtered out")
+	logger.Info("session started", "sessionId", "s1", "cols", 80, "error", errors.New("boom"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d: %q", len(lines), buf.String())
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v (%q)", err, lines[0])
+	}
+	if entry["level"] != "info" || entry["msg"] != "session started" {
+		t.Fatalf("unexpected level/msg: %v", entry)
+	}
+	if entry["sessionId"] != "s1" || entry["cols"] != float64(80) {
+		t.Fatalf("kv pairs missing: %v", entry)
+	}
+	if entry["error"] != "boom" {
+		t.Fatalf("error value should be its message, got %v", entry["error"])
+	}
+	if _, ok := entry["ts"]; !ok {
+		t.Fatalf("timestamp missing: %v", entry)
+	}
+}
+
+type recordingLogger struct {
+	msgs []string
+	kvs  [][]any
+}
+
+func (l *recordingLogger) Debug(msg string, kv ...any) { l.record(msg, kv) }
+func (l *recordingLogger) Info(msg string, kv ...any)  { l.record(msg, kv) }
+func (l *recordingLogger) Warn(msg string, kv ...any)  { l.record(msg, kv) }
+func (l *recordingLogger) Error(msg string, kv ...any) { l.record(msg, kv) }
+
+func (l *recordingLogger) record(msg string, kv []any) {
+	l.msgs = append(l.msgs, msg)
+	l.kvs = append(l.kvs, kv)
+}
+
+func TestWithLogFieldsPrependsFields(t *testing.T) {
+	base := &recordingLogger{}
+	logger := WithLogFields(base, "sessionID", "s1")
+
+	logger.Info("started", "cols", 80)
+
+	if len(base.kvs) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(base.kvs))
+	}
+	got := base.kvs[0]
+	want := []any{"sessionID", "s1", "cols", 80}
+	if len(got) != len(want) {
+		t.Fatalf("kv = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("kv = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWithLogFieldsAccumulates(t *testing.T) {
+	base := &recordingLogger{}
+	logger := WithLogFields(WithLogFields(base, "sessionID", "s1"), "owner", "ada")
+
+	logger.Warn("slow")
+
+	got := base.kvs[0]
+	if len(got) != 4 || got[0] != "sessionID" || got[2] != "owner" || got[3] != "ada" {
+		t.Fatalf("kv = %v", got)
+	}
+}
+
+func TestWithLogFieldsNilBase(t *testing.T) {
+	logger := WithLogFields(nil, "sessionID", "s1")
+	// Must not panic.
+	logger.Error("dropped")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/user"
 	"strings"
 	"time"
 )
@@ -11,11 +12,55 @@ import (
 // NewManager creates a terminal manager with the provided configuration.
 func NewManager(cfg ManagerConfig) *Manager {
 	cfg = cfg.applyDefaults()
-	return &Manager{
+	m := &Manager{
 		sessions:     make(map[string]*Session),
 		sessionOrder: make([]string, 0),
 		config:       cfg,
 	}
+	if cfg.SessionIdleTimeout > 0 {
+		m.reaperStop = make(chan struct{})
+		go m.reapIdleSessions(cfg.SessionIdleTimeout)
+	}
+	return m
+}
+
+// reapIdleSessions periodically deletes sessions nobody is attached to whose
+// last activity is older than the timeout.
+func (m *Manager) reapIdleSessions(timeout time.Duration) {
+	interval := timeout / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.reaperStop:
+			return
+		case <-ticker.C:
+		}
+		cutoff := time.Now().Add(-timeout)
+		for _, session := range m.ListSessions() {
+			session.mu.RLock()
+			idle := len(session.connections) == 0 && len(session.liveAttachments) == 0 &&
+				session.LastActive.Before(cutoff)
+			session.mu.RUnlock()
+			if idle {
+				m.config.Logger.Info("Reaping idle session", "sessionID", session.ID, "idleTimeout", timeout)
+				_ = m.DeleteSession(session.ID)
+			}
+		}
+	}
+}
+
+func (m *Manager) stopReaper() {
+	if m.reaperStop == nil {
+		return
+	}
+	m.reaperStopOnce.Do(func() { close(m.reaperStop) })
 }
 
 // getDirectoryName derives a display name from a working directory path.
@@ -40,14 +85,63 @@ func getDirectoryName(path string) string {
 	return "home"
 }
 
+// CreateSessionOptions captures per-session creation parameters beyond the
+// basic name/working directory pair.
+type CreateSessionOptions struct {
+	Name       string
+	WorkingDir string
+	// PathPrepend is a colon-separated list of directories injected at the
+	// front of PATH for this session only, ahead of any prepend computed by
+	// the EnvProvider. Useful for per-terminal tool paths such as
+	// node_modules/.bin or toolchain shims.
+	PathPrepend string
+	// LoginShell overrides ManagerConfig.LoginShell for this session. Nil
+	// keeps the manager default.
+	LoginShell *bool
+	// Owner tags the session with the identity that created it so callers can
+	// enforce per-user access. Empty means unowned (single-tenant behaviour).
+	Owner string
+	// Shell overrides the ShellResolver for this session only. Empty keeps
+	// the resolver's choice.
+	Shell string
+	// ShellArgs overrides the ShellArgsProvider for this session only. Nil
+	// keeps the provider's choice; an empty slice runs the shell bare.
+	ShellArgs []string
+	// ExtraEnv is a list of KEY=value entries appended after all other
+	// environment sources, so these values win over provider defaults.
+	ExtraEnv []string
+	// Sandbox overrides the manager-wide sandbox profile for this session.
+	// Nil keeps the manager default.
+	Sandbox *SandboxProfile
+	// RunAsUser launches the session process as this local account instead
+	// of the manager's own user. Requires the embedding process to run with
+	// the privilege to change credentials (realistically: root).
+	RunAsUser string
+}
+
 // CreateSession creates a dormant logical terminal session.
 func (m *Manager) CreateSession(name, workingDir string) (*Session, error) {
+	return m.CreateSessionWithOptions(CreateSessionOptions{Name: name, WorkingDir: workingDir})
+}
+
+// CreateSessionWithOptions creates a dormant logical terminal session with
+// per-session parameters.
+func (m *Manager) CreateSessionWithOptions(opts CreateSessionOptions) (*Session, error) {
 	sessionID := generateSessionID()
 
+	name := opts.Name
+	workingDir := opts.WorkingDir
 	if name == "" {
 		name = getDirectoryName(workingDir)
 	}
 
+	if workingDir == "" && opts.RunAsUser != "" {
+		// The manager's own home is meaningless for a session running as
+		// another account; start in that account's home instead.
+		if account, err := user.Lookup(opts.RunAsUser); err == nil {
+			workingDir = account.HomeDir
+		}
+	}
 	if workingDir == "" {
 		if homeDir, err := os.UserHomeDir(); err == nil {
 			workingDir = homeDir
@@ -64,14 +158,35 @@ func (m *Manager) CreateSession(name, workingDir string) (*Session, error) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	sessionCfg := newSessionConfig(m.config)
+	if opts.LoginShell != nil {
+		sessionCfg.loginShell = *opts.LoginShell
+	}
 	createdDone := make(chan struct{})
 	// Ensure onExit never blocks forever even if CreateSession errors or panics.
 	defer close(createdDone)
 
+	// Every log line for this session carries the correlation fields, so call
+	// sites never repeat them.
+	logFields := []any{"sessionID", sessionID}
+	if opts.Owner != "" {
+		logFields = append(logFields, "owner", opts.Owner)
+	}
+	sessionLogger := WithLogFields(sessionCfg.logger, logFields...)
+
+	ringBuffer := NewTerminalRingBufferWithLimits(sessionCfg.historyBufferSize, sessionCfg.historyBufferMaxChunks, sessionCfg.historyBufferMaxBytes)
+	ringBuffer.setMetrics(sessionCfg.metrics)
+
 	session := &Session{
 		ID:                   sessionID,
 		Name:                 name,
 		WorkingDir:           workingDir,
+		Owner:                opts.Owner,
+		requestedPathPrepend: opts.PathPrepend,
+		requestedShell:       opts.Shell,
+		requestedShellArgs:   opts.ShellArgs,
+		requestedExtraEnv:    opts.ExtraEnv,
+		requestedSandbox:     opts.Sandbox,
+		requestedRunAsUser:   opts.RunAsUser,
 		CreatedAt:            time.Now(),
 		LastActive:           time.Now(),
 		isActive:             false,
@@ -79,7 +194,7 @@ func (m *Manager) CreateSession(name, workingDir string) (*Session, error) {
 		liveAttachments:      make(map[string]liveAttachment),
 		ctx:                  ctx,
 		cancel:               cancel,
-		ringBuffer:           NewTerminalRingBufferWithLimits(sessionCfg.historyBufferSize, sessionCfg.historyBufferMaxChunks, sessionCfg.historyBufferMaxBytes),
+		ringBuffer:           ringBuffer,
 		historyGeneration:    1,
 		historyStartSequence: 1,
 		currentWorkingDir:    workingDir,
@@ -95,14 +210,23 @@ func (m *Manager) CreateSession(name, workingDir string) (*Session, error) {
 			m.deleteSessionIfExists(sessionID)
 		},
 		config: sessionCfg,
+		logger: sessionLogger,
 	}
 
 	// Register the session before starting the PTY so the onExit callback can
 	// reliably remove it even if the process exits immediately.
 	m.mu.Lock()
+	if m.config.MaxSessions > 0 && len(m.sessions) >= m.config.MaxSessions {
+		m.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("session limit reached (%d)", m.config.MaxSessions)
+	}
 	m.sessions[sessionID] = session
 	m.sessionOrder = append(m.sessionOrder, sessionID)
+	openSessions := len(m.sessions)
 	m.mu.Unlock()
+	m.config.Metrics.IncCounter(MetricSessionsCreated, 1)
+	m.config.Metrics.SetGauge(MetricOpenSessions, float64(openSessions))
 
 	// Refresh the session handler after registration in case it changed during initialization.
 	m.mu.RLock()
@@ -123,7 +247,8 @@ func (m *Manager) CreateSession(name, workingDir string) (*Session, error) {
 }
 
 // GetDiagnostics returns a point-in-time view of retained history memory.
-// It is intentionally observational: Floeterm never limits session creation.
+// It is intentionally observational; the optional MaxSessions cap is the only
+// thing that limits session creation, and it is enforced at create time.
 func (m *Manager) GetDiagnostics() ManagerDiagnostics {
 	m.mu.RLock()
 	sessions := make([]*Session, 0, len(m.sessions))
@@ -159,6 +284,11 @@ func (m *Manager) GetDiagnostics() ManagerDiagnostics {
 	return diagnostics
 }
 
+// DefaultShell returns the shell path new sessions would launch with.
+func (m *Manager) DefaultShell() string {
+	return m.config.ShellResolver.ResolveShell(m.config.Logger)
+}
+
 // GetSession returns a session by ID.
 func (m *Manager) GetSession(sessionID string) (*Session, bool) {
 	m.mu.RLock()
@@ -216,6 +346,7 @@ func (m *Manager) detachSession(sessionID string) (*Session, TerminalEventHandle
 			break
 		}
 	}
+	m.config.Metrics.SetGauge(MetricOpenSessions, float64(len(m.sessions)))
 
 	return session, m.eventHandler, true
 }
@@ -259,6 +390,48 @@ func (m *Manager) RenameSession(sessionID, newName string) error {
 	return nil
 }
 
+// SessionMetadataUpdate describes a partial metadata change; nil fields are
+// left untouched.
+type SessionMetadataUpdate struct {
+	Name       *string
+	Tags       *[]string
+	Notes      *string
+	NameLocked *bool
+}
+
+// UpdateSessionMetadata applies the non-nil fields of the update to a session.
+// Setting the name through an update behaves like RenameSession; locking the
+// name only suppresses the automatic renames that follow working-directory
+// changes, explicit renames still apply.
+func (m *Manager) UpdateSessionMetadata(sessionID string, update SessionMetadataUpdate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	session.mu.Lock()
+	if update.Name != nil {
+		session.Name = *update.Name
+	}
+	if update.Tags != nil {
+		session.Tags = append([]string(nil), (*update.Tags)...)
+	}
+	if update.Notes != nil {
+		session.Notes = *update.Notes
+	}
+	if update.NameLocked != nil {
+		session.nameLocked = *update.NameLocked
+	}
+	session.LastActive = time.Now()
+	session.mu.Unlock()
+
+	m.config.Logger.Info("Updated terminal session metadata", "sessionID", sessionID)
+	return nil
+}
+
 // ActivateSession starts a PTY for a dormant session.
 func (m *Manager) ActivateSession(sessionID string, cols, rows int) error {
 	return m.ActivateSessionContext(context.Background(), sessionID, cols, rows)
@@ -284,14 +457,29 @@ func (m *Manager) ActivateSessionContext(ctx context.Context, sessionID string,
 	return nil
 }
 
+// CleanupShellInit removes the generated shell init files when the configured
+// writer supports cleanup. Writers without a Cleanup method make this a no-op.
+func (m *Manager) CleanupShellInit() error {
+	cleaner, ok := m.config.ShellInitWriter.(ShellInitCleaner)
+	if !ok {
+		return nil
+	}
+	if err := cleaner.Cleanup(); err != nil {
+		return fmt.Errorf("failed to clean up shell init files: %w", err)
+	}
+	m.config.Logger.Info("Removed generated shell init files")
+	return nil
+}
+
 // Cleanup stops and removes all sessions.
 func (m *Manager) Cleanup() {
+	m.stopReaper()
 	sessions := m.detachAllSessions()
 
 	m.config.Logger.Info("Cleaning up all terminal sessions", "count", len(sessions))
 	for _, session := range sessions {
 		m.config.Logger.Debug("Cleaning up session", "sessionID", session.ID)
-		session.cleanup()
+		session.cleanupWithReason(ExitReasonServerShutdown)
 	}
 }
 
@@ -326,6 +514,31 @@ func (m *Manager) SetEventHandler(handler TerminalEventHandler) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.userHandler = handler
+	m.installHandlerLocked()
+}
+
+// Events returns the manager's typed subscription registry, so embedders can
+// observe single event types without implementing TerminalEventHandler.
+func (m *Manager) Events() *ManagerEvents {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.events == nil {
+		m.events = newManagerEvents()
+		m.installHandlerLocked()
+	}
+	return m.events
+}
+
+// installHandlerLocked recomputes the effective handler from the
+// SetEventHandler handler and the typed subscriptions, and pushes it to
+// every live session. Callers hold m.mu.
+func (m *Manager) installHandlerLocked() {
+	handler := m.userHandler
+	if m.events != nil {
+		handler = eventTee{handler: m.userHandler, events: m.events}
+	}
 	m.eventHandler = handler
 	for _, session := range m.sessions {
 		session.mu.Lock()
@@ -343,10 +556,24 @@ func (s *Session) ToSessionInfo() TerminalSessionInfo {
 		ID:                s.ID,
 		Name:              s.Name,
 		WorkingDir:        s.WorkingDir,
+		Owner:             s.Owner,
+		Tags:              append([]string(nil), s.Tags...),
+		Notes:             s.Notes,
+		NameLocked:        s.nameLocked,
+		ResizePolicy:      s.resizePolicy.String(),
 		CreatedAt:         s.CreatedAt.UnixMilli(),
 		LastActive:        s.LastActive.UnixMilli(),
 		IsActive:          s.isActive,
 		ForegroundCommand: normalizeForegroundCommandInfo(s.foregroundCommand),
 		OutputActivity:    normalizeOutputActivityInfo(s.outputActivity),
+		LastExit:          copyExitInfoLocked(s.lastExit),
+	}
+}
+
+func copyExitInfoLocked(exit *TerminalExitInfo) *TerminalExitInfo {
+	if exit == nil {
+		return nil
 	}
+	out := *exit
+	return &out
 }
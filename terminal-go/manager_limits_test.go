@@ -0,0 +1,54 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxSessionsCapsCreation(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}, MaxSessions: 2})
+	defer manager.Cleanup()
+
+	first, err := manager.CreateSession("one", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.CreateSession("two", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.CreateSession("three", ""); err == nil {
+		t.Fatal("expected creation beyond the cap to fail")
+	} else if !strings.Contains(err.Error(), "session limit") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Deleting a session frees a slot.
+	if err := manager.DeleteSession(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.CreateSession("three", ""); err != nil {
+		t.Fatalf("creation after freeing a slot failed: %v", err)
+	}
+}
+
+func TestIdleSessionReaper(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}, SessionIdleTimeout: 100 * time.Millisecond})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSession("idle", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := manager.GetSession(session.ID); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("idle session was not reaped")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
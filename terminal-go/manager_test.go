@@ -3,7 +3,12 @@ package terminal
 import (
 	"context"
 	"errors"
+	"os"
+	"os/exec"
 	"testing"
+	"time"
+
+	"github.com/creack/pty"
 )
 
 func TestManagerListRenameDelete(t *testing.T) {
@@ -150,3 +155,205 @@ func TestManagerConfigGrowsHistorySlotsOnlyWhenConfigured(t *testing.T) {
 		t.Fatalf("fixed TotalChunks=%d, want 2", got)
 	}
 }
+
+type recordingShellArgsProvider struct {
+	pathPrepend chan string
+}
+
+func (p *recordingShellArgsProvider) GetShellArgs(_ string, pathPrepend string) ([]string, []string) {
+	select {
+	case p.pathPrepend <- pathPrepend:
+	default:
+	}
+	return []string{"-c", "cat"}, nil
+}
+
+func TestCreateSessionWithOptionsAppliesPathPrepend(t *testing.T) {
+	provider := &recordingShellArgsProvider{pathPrepend: make(chan string, 1)}
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		EnvProvider:       StaticEnvProvider{PathPrepend: "/provider/bin"},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: provider,
+	})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSessionWithOptions(CreateSessionOptions{
+		WorkingDir:  "/",
+		PathPrepend: "/session/bin",
+	})
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if err := manager.ActivateSession(session.ID, 80, 24); err != nil {
+		t.Fatalf("activate failed: %v", err)
+	}
+
+	got := <-provider.pathPrepend
+	if got != "/session/bin:/provider/bin" {
+		t.Fatalf("pathPrepend = %q, want session prepend ahead of provider prepend", got)
+	}
+}
+
+type sessionAwareShellArgsProvider struct {
+	launch chan ShellLaunchContext
+}
+
+func (p *sessionAwareShellArgsProvider) GetShellArgs(string, string) ([]string, []string) {
+	return nil, nil
+}
+
+func (p *sessionAwareShellArgsProvider) GetShellArgsForSession(launch ShellLaunchContext, _ string, _ string) ([]string, []string) {
+	select {
+	case p.launch <- launch:
+	default:
+	}
+	return []string{"-c", "cat"}, nil
+}
+
+func TestSessionShellArgsProviderReceivesLaunchContext(t *testing.T) {
+	provider := &sessionAwareShellArgsProvider{launch: make(chan ShellLaunchContext, 1)}
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: provider,
+	})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSession("ctx", "/")
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if err := manager.ActivateSession(session.ID, 120, 40); err != nil {
+		t.Fatalf("activate failed: %v", err)
+	}
+
+	launch := <-provider.launch
+	if launch.SessionID != session.ID || launch.WorkingDir != "/" || launch.Cols != 120 || launch.Rows != 40 {
+		t.Fatalf("unexpected launch context: %+v", launch)
+	}
+}
+
+type nilArgsShellArgsProvider struct{}
+
+func (nilArgsShellArgsProvider) GetShellArgs(string, string) ([]string, []string) { return nil, nil }
+
+func capturePTYLaunchArgs(t *testing.T, cfg ManagerConfig, opts CreateSessionOptions) []string {
+	t.Helper()
+	manager := NewManager(cfg)
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSessionWithOptions(opts)
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+
+	captured := make(chan []string, 1)
+	session.startPTYProcess = func(cmd *exec.Cmd, _ *pty.Winsize) (*os.File, error) {
+		captured <- cmd.Args
+		return nil, errors.New("capture only")
+	}
+	if err := manager.ActivateSession(session.ID, 80, 24); err == nil {
+		t.Fatalf("expected activation to fail with capture stub")
+	}
+	return <-captured
+}
+
+func TestLoginShellOptionControlsFallbackArgs(t *testing.T) {
+	baseCfg := ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/bash"},
+		ShellArgsProvider: nilArgsShellArgsProvider{},
+	}
+
+	args := capturePTYLaunchArgs(t, baseCfg, CreateSessionOptions{WorkingDir: "/"})
+	if len(args) != 2 || args[1] != "-l" {
+		t.Fatalf("default launch args = %v, want login shell", args)
+	}
+
+	noLogin := false
+	args = capturePTYLaunchArgs(t, baseCfg, CreateSessionOptions{WorkingDir: "/", LoginShell: &noLogin})
+	if len(args) != 1 {
+		t.Fatalf("launch args with LoginShell=false = %v, want bare shell", args)
+	}
+
+	cfg := baseCfg
+	cfg.LoginShell = &noLogin
+	args = capturePTYLaunchArgs(t, cfg, CreateSessionOptions{WorkingDir: "/"})
+	if len(args) != 1 {
+		t.Fatalf("launch args with manager LoginShell=false = %v, want bare shell", args)
+	}
+}
+
+type exitShellArgsProvider struct{ code string }
+
+func (p exitShellArgsProvider) GetShellArgs(string, string) ([]string, []string) {
+	return []string{"-c", "exit " + p.code}, nil
+}
+
+func waitForExitInfo(t *testing.T, session *Session) *TerminalExitInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if exit := session.LastExit(); exit != nil {
+			return exit
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for exit info")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionRecordsNaturalExitInfo(t *testing.T) {
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: exitShellArgsProvider{code: "7"},
+	})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSession("", "")
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if err := manager.ActivateSession(session.ID, 80, 24); err != nil {
+		t.Fatalf("activate failed: %v", err)
+	}
+
+	exit := waitForExitInfo(t, session)
+	if exit.Reason != ExitReasonExited {
+		t.Fatalf("reason=%q, want %q", exit.Reason, ExitReasonExited)
+	}
+	if exit.ExitCode != 7 {
+		t.Fatalf("exit code=%d, want 7", exit.ExitCode)
+	}
+	if exit.ExitedAt <= 0 {
+		t.Fatalf("exited at=%d, want > 0", exit.ExitedAt)
+	}
+}
+
+func TestSessionRecordsKilledExitInfo(t *testing.T) {
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: catShellArgsProvider{},
+	})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSession("", "")
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if err := manager.ActivateSession(session.ID, 80, 24); err != nil {
+		t.Fatalf("activate failed: %v", err)
+	}
+	if err := manager.DeleteSession(session.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	exit := waitForExitInfo(t, session)
+	if exit.Reason != ExitReasonKilled {
+		t.Fatalf("reason=%q, want %q", exit.Reason, ExitReasonKilled)
+	}
+}
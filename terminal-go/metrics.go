@@ -0,0 +1,38 @@
+package terminal
+
+// Metric names reported through the Metrics hook. Embedders exporting to
+// Prometheus or similar systems can use them verbatim.
+const (
+	// Counters.
+	MetricSessionsCreated = "sessions_created_total"
+	MetricSessionsFailed  = "session_starts_failed_total"
+	MetricPTYReadBytes    = "pty_read_bytes_total"
+	MetricPTYWrittenBytes = "pty_written_bytes_total"
+	MetricHistoryChunks   = "history_chunks_written_total"
+	MetricPTYResizes      = "pty_resizes_total"
+	MetricEchoDedupHits   = "echo_dedup_hits_total"
+	// Gauges.
+	MetricOpenSessions = "open_sessions"
+	// Histograms.
+	MetricPTYReadChunkBytes = "pty_read_chunk_bytes"
+)
+
+// Metrics receives operational measurements from the manager, its sessions
+// and their history ring buffers, so the library is observable regardless of
+// which process embeds it. Implementations must be safe for concurrent use;
+// the hooks run on hot paths (including the PTY read loop), so they should
+// be cheap. Counters accumulate, gauges report the latest value, and
+// histograms record individual observations (e.g. PTY read chunk sizes).
+type Metrics interface {
+	IncCounter(name string, delta float64)
+	SetGauge(name string, value float64)
+	ObserveHistogram(name string, value float64)
+}
+
+// NopMetrics discards all measurements. It is used when no Metrics is
+// configured.
+type NopMetrics struct{}
+
+func (NopMetrics) IncCounter(string, float64)       {}
+func (NopMetrics) SetGauge(string, float64)         {}
+func (NopMetrics) ObserveHistogram(string, float64) {}
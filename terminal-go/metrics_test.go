@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"sync"
+	"testing"
+)
+
+// recordingMetrics captures every measurement by name for assertions.
+type recordingMetrics struct {
+	mu         sync.Mutex
+	counters   map[string]float64
+	gauges     map[string]float64
+	histograms map[string][]float64
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		counters:   map[string]float64{},
+		gauges:     map[string]float64{},
+		histograms: map[string][]float64{},
+	}
+}
+
+func (m *recordingMetrics) IncCounter(name string, delta float64) {
+	m.mu.Lock()
+	m.counters[name] += delta
+	m.mu.Unlock()
+}
+
+func (m *recordingMetrics) SetGauge(name string, value float64) {
+	m.mu.Lock()
+	m.gauges[name] = value
+	m.mu.Unlock()
+}
+
+func (m *recordingMetrics) ObserveHistogram(name string, value float64) {
+	m.mu.Lock()
+	m.histograms[name] = append(m.histograms[name], value)
+	m.mu.Unlock()
+}
+
+func (m *recordingMetrics) counter(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+func (m *recordingMetrics) gauge(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gauges[name]
+}
+
+func TestRingBufferCountsChunkWrites(t *testing.T) {
+	metrics := newRecordingMetrics()
+	rb := NewTerminalRingBuffer(4)
+	rb.setMetrics(metrics)
+
+	if err := rb.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := rb.Write([]byte("world")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if got := metrics.counter(MetricHistoryChunks); got != 2 {
+		t.Fatalf("chunk counter = %g, want 2", got)
+	}
+}
+
+func TestManagerReportsOpenSessionsGauge(t *testing.T) {
+	metrics := newRecordingMetrics()
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		Metrics:           metrics,
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellArgsProvider: testShellArgsProvider{},
+	})
+
+	session, err := manager.CreateSession("metered", "")
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+	if got := metrics.gauge(MetricOpenSessions); got != 1 {
+		t.Fatalf("open sessions gauge = %g after create, want 1", got)
+	}
+	if got := metrics.counter(MetricSessionsCreated); got != 1 {
+		t.Fatalf("sessions created counter = %g, want 1", got)
+	}
+
+	if err := manager.DeleteSession(session.ID); err != nil {
+		t.Fatalf("delete session failed: %v", err)
+	}
+	if got := metrics.gauge(MetricOpenSessions); got != 0 {
+		t.Fatalf("open sessions gauge = %g after delete, want 0", got)
+	}
+}
@@ -0,0 +1,71 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+)
+
+func waitPresenceEvent(t *testing.T, events <-chan TerminalPresenceEvent) TerminalPresenceEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for presence event")
+		return TerminalPresenceEvent{}
+	}
+}
+
+func TestLiveAttachmentsBroadcastPresenceChanges(t *testing.T) {
+	session := &Session{
+		ID:                "presence-broadcast",
+		connections:       make(map[string]*ConnectionInfo),
+		liveAttachments:   make(map[string]liveAttachment),
+		ringBuffer:        NewTerminalRingBuffer(8),
+		historyGeneration: 1,
+		config:            newSessionConfig(ManagerConfig{Logger: NopLogger{}}),
+	}
+
+	firstEvents := make(chan TerminalPresenceEvent, 4)
+	first, err := session.AttachLiveConnection("first", 1, 80, 24, LiveSubscriber{
+		OnOutput: func(TerminalOutputEvent) bool { return true },
+		OnPresence: func(event TerminalPresenceEvent) bool {
+			firstEvents <- event
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Detach()
+
+	joined := waitPresenceEvent(t, firstEvents)
+	if joined.ConnID != "first" || !joined.Joined || joined.ConnectionCount != 1 {
+		t.Fatalf("own join event = %+v", joined)
+	}
+
+	secondEvents := make(chan TerminalPresenceEvent, 4)
+	second, err := session.AttachLiveConnection("second", 1, 80, 24, LiveSubscriber{
+		OnOutput: func(TerminalOutputEvent) bool { return true },
+		OnPresence: func(event TerminalPresenceEvent) bool {
+			secondEvents <- event
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, events := range []<-chan TerminalPresenceEvent{firstEvents, secondEvents} {
+		joined := waitPresenceEvent(t, events)
+		if joined.ConnID != "second" || !joined.Joined || joined.ConnectionCount != 2 {
+			t.Fatalf("second join event = %+v", joined)
+		}
+	}
+
+	second.Detach()
+	left := waitPresenceEvent(t, firstEvents)
+	if left.ConnID != "second" || left.Joined || left.ConnectionCount != 1 {
+		t.Fatalf("leave event = %+v", left)
+	}
+}
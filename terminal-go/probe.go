@@ -0,0 +1,140 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// shellProbePathMarker is the synthetic PATH prepend used during probing; it
+// only needs to be recognisable in the probed shell's $PATH.
+const shellProbePathMarker = "/floeterm-probe/bin"
+
+const shellProbeTimeout = 10 * time.Second
+
+// ShellProbeResult reports whether the generated shell integration actually
+// works for a given shell binary.
+type ShellProbeResult struct {
+	Shell     string
+	ShellType string
+	// OK is true when every applicable check passed.
+	OK bool
+	// RCSourced reports whether the generated rc file ran at all.
+	RCSourced bool
+	// PathPrependApplied reports whether the probe PATH prefix reached $PATH.
+	PathPrependApplied bool
+	// ZdotdirRestored reports whether ZDOTDIR was restored after rc sourcing.
+	// Always true for shells other than zsh.
+	ZdotdirRestored bool
+	Error           string
+}
+
+// ProbeShell spawns shellPath non-interactively with the generated init files
+// and reports whether PATH prepending, ZDOTDIR restoration and rc sourcing
+// actually work. It is a diagnostic entry point and never affects sessions.
+func (m *Manager) ProbeShell(shellPath string) ShellProbeResult {
+	return m.ProbeShellContext(context.Background(), shellPath)
+}
+
+func (m *Manager) ProbeShellContext(ctx context.Context, shellPath string) ShellProbeResult {
+	kind := detectShellType(shellPath)
+	result := ShellProbeResult{
+		Shell:           shellPath,
+		ShellType:       string(kind),
+		ZdotdirRestored: true,
+	}
+
+	if kind == shellTypeElvish || kind == shellTypeXonsh {
+		result.Error = "probing is not supported for this shell"
+		return result
+	}
+	if _, err := os.Stat(shellPath); err != nil {
+		result.Error = "shell not found: " + shellPath
+		return result
+	}
+
+	if writer := m.config.ShellInitWriter; writer != nil {
+		if err := ensureShellInitForActivation(ctx, writer, shellProbePathMarker); err != nil {
+			result.Error = "failed to write shell init files: " + err.Error()
+			return result
+		}
+	}
+
+	launch := ShellLaunchContext{WorkingDir: "/", Cols: 80, Rows: 24}
+	args, extraEnv, err := shellArgsForActivation(ctx, m.config.ShellArgsProvider, launch, shellPath, shellProbePathMarker)
+	if err != nil {
+		result.Error = "failed to build shell arguments: " + err.Error()
+		return result
+	}
+	if args == nil {
+		result.Error = "shell args provider produced no launch arguments"
+		return result
+	}
+
+	output, err := runShellProbe(ctx, shellPath, kind, args, extraEnv)
+	if err != nil {
+		result.Error = "probe shell failed: " + err.Error()
+		return result
+	}
+
+	result.RCSourced = output["RC"] == "1"
+	result.PathPrependApplied = strings.Contains(output["PATH"], shellProbePathMarker)
+	if kind == shellTypeZsh {
+		result.ZdotdirRestored = output["ZDOTDIR"] != envValue(extraEnv, "ZDOTDIR")
+	}
+	result.OK = result.RCSourced && result.PathPrependApplied && result.ZdotdirRestored
+	return result
+}
+
+// runShellProbe runs the shell interactively (so rc files are sourced) with a
+// command that echoes the probe markers, and parses them from stdout.
+func runShellProbe(ctx context.Context, shellPath string, kind shellType, args []string, extraEnv []string) (map[string]string, error) {
+	script := strings.Join([]string{
+		`printf 'FLOETERM_PROBE RC:%s\n' "${__floeterm_shell_init_sourced:-}"`,
+		`printf 'FLOETERM_PROBE PATH:%s\n' "$PATH"`,
+		`printf 'FLOETERM_PROBE ZDOTDIR:%s\n' "${ZDOTDIR:-}"`,
+	}, "; ")
+	if kind == shellTypeFish {
+		script = strings.Join([]string{
+			`echo "FLOETERM_PROBE RC:$__floeterm_shell_init_sourced"`,
+			`echo "FLOETERM_PROBE PATH:$PATH"`,
+		}, "; ")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shellProbeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, shellPath, append(append([]string{}, args...), "-i", "-c", script)...)
+	cmd.Env = append(os.Environ(), extraEnv...)
+	cmd.Dir = "/"
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	output := map[string]string{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		rest, ok := strings.CutPrefix(line, "FLOETERM_PROBE ")
+		if !ok {
+			continue
+		}
+		if key, value, ok := strings.Cut(rest, ":"); ok {
+			output[key] = value
+		}
+	}
+	return output, nil
+}
+
+func envValue(env []string, key string) string {
+	for _, entry := range env {
+		if value, ok := strings.CutPrefix(entry, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
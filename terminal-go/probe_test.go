@@ -0,0 +1,38 @@
+package terminal
+
+import "testing"
+
+func TestProbeShellReportsWorkingIntegration(t *testing.T) {
+	baseDir := t.TempDir()
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/sh"},
+		ShellInitWriter:   DefaultShellInitWriter{BaseDir: baseDir},
+		ShellArgsProvider: DefaultShellArgsProvider{ShellInitBaseDir: baseDir},
+	})
+	defer manager.Cleanup()
+
+	result := manager.ProbeShell("/bin/sh")
+	if result.Error != "" {
+		t.Fatalf("probe error: %s", result.Error)
+	}
+	if !result.RCSourced {
+		t.Fatalf("expected generated rc to be sourced: %+v", result)
+	}
+	if !result.PathPrependApplied {
+		t.Fatalf("expected PATH prepend to be applied: %+v", result)
+	}
+	if !result.OK {
+		t.Fatalf("expected probe to pass: %+v", result)
+	}
+}
+
+func TestProbeShellReportsMissingShell(t *testing.T) {
+	manager := NewManager(ManagerConfig{Logger: NopLogger{}})
+	defer manager.Cleanup()
+
+	result := manager.ProbeShell("/nonexistent/shell")
+	if result.OK || result.Error == "" {
+		t.Fatalf("expected probe failure for missing shell, got %+v", result)
+	}
+}
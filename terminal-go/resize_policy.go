@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResizePolicyMode selects how a session maps attached client viewports onto
+// the single shared PTY size.
+type ResizePolicyMode string
+
+const (
+	// ResizePolicyMin sizes the PTY to the smallest attached viewport so no
+	// client ever clips output. This is the default and historical behavior.
+	ResizePolicyMin ResizePolicyMode = "min"
+	// ResizePolicyMax sizes the PTY to the largest attached viewport.
+	ResizePolicyMax ResizePolicyMode = "max"
+	// ResizePolicyLastWriter follows the viewport of the connection that most
+	// recently wrote input, falling back to min while no writer is attached.
+	ResizePolicyLastWriter ResizePolicyMode = "lastWriter"
+	// ResizePolicyFixed pins the PTY to an explicit size regardless of the
+	// attached viewports.
+	ResizePolicyFixed ResizePolicyMode = "fixed"
+)
+
+// ResizePolicy describes one session's PTY sizing strategy. The zero value
+// behaves as ResizePolicyMin.
+type ResizePolicy struct {
+	Mode ResizePolicyMode
+	// Cols and Rows are only meaningful for ResizePolicyFixed.
+	Cols int
+	Rows int
+}
+
+// String renders the policy in the form accepted by ParseResizePolicy.
+func (p ResizePolicy) String() string {
+	switch p.Mode {
+	case ResizePolicyFixed:
+		return fmt.Sprintf("fixed:%dx%d", p.Cols, p.Rows)
+	case "":
+		return string(ResizePolicyMin)
+	default:
+		return string(p.Mode)
+	}
+}
+
+// ParseResizePolicy parses "min", "max", "lastWriter" or "fixed:WxH".
+func ParseResizePolicy(value string) (ResizePolicy, error) {
+	switch ResizePolicyMode(value) {
+	case ResizePolicyMin, ResizePolicyMax, ResizePolicyLastWriter:
+		return ResizePolicy{Mode: ResizePolicyMode(value)}, nil
+	}
+	if rest, ok := strings.CutPrefix(value, string(ResizePolicyFixed)+":"); ok {
+		colsText, rowsText, ok := strings.Cut(rest, "x")
+		if !ok {
+			return ResizePolicy{}, fmt.Errorf("invalid fixed resize policy %q: want fixed:WxH", value)
+		}
+		cols, err := strconv.Atoi(colsText)
+		if err != nil {
+			return ResizePolicy{}, fmt.Errorf("invalid fixed resize policy %q: %w", value, err)
+		}
+		rows, err := strconv.Atoi(rowsText)
+		if err != nil {
+			return ResizePolicy{}, fmt.Errorf("invalid fixed resize policy %q: %w", value, err)
+		}
+		if err := validateTerminalSize(cols, rows); err != nil {
+			return ResizePolicy{}, err
+		}
+		return ResizePolicy{Mode: ResizePolicyFixed, Cols: cols, Rows: rows}, nil
+	}
+	return ResizePolicy{}, fmt.Errorf("unknown resize policy %q", value)
+}
+
+// SetResizePolicy changes how the session reconciles the shared PTY size and
+// re-applies the size under the new policy.
+func (s *Session) SetResizePolicy(policy ResizePolicy) error {
+	switch policy.Mode {
+	case "", ResizePolicyMin, ResizePolicyMax, ResizePolicyLastWriter:
+	case ResizePolicyFixed:
+		if err := validateTerminalSize(policy.Cols, policy.Rows); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown resize policy %q", policy.Mode)
+	}
+
+	s.log().Debug("Setting resize policy", "policy", policy.String())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resizePolicy = policy
+	if s.isActive {
+		s.schedulePTYSizeReconcileLocked("policy-changed")
+	}
+	return nil
+}
+
+// GetResizePolicy returns the session's current resize policy.
+func (s *Session) GetResizePolicy() ResizePolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resizePolicy
+}
+
+// desiredTerminalSizeLocked computes the PTY size the session's resize policy
+// asks for given the currently attached viewports.
+func (s *Session) desiredTerminalSizeLocked() (int, int, bool) {
+	switch s.resizePolicy.Mode {
+	case ResizePolicyFixed:
+		return s.resizePolicy.Cols, s.resizePolicy.Rows, true
+	case ResizePolicyMax:
+		return s.getMaximumTerminalSizeLocked()
+	case ResizePolicyLastWriter:
+		if conn, ok := s.connections[s.lastWriterConnID]; ok {
+			cols, rows := clampTerminalSize(conn.Cols, conn.Rows)
+			return cols, rows, true
+		}
+		return s.getMinimumTerminalSizeLocked()
+	default:
+		return s.getMinimumTerminalSizeLocked()
+	}
+}
+
+func (s *Session) getMaximumTerminalSizeLocked() (int, int, bool) {
+	if len(s.connections) == 0 {
+		return 0, 0, false
+	}
+
+	maxCols := 0
+	maxRows := 0
+	for _, conn := range s.connections {
+		if conn.Cols > maxCols {
+			maxCols = conn.Cols
+		}
+		if conn.Rows > maxRows {
+			maxRows = conn.Rows
+		}
+	}
+
+	maxCols, maxRows = clampTerminalSize(maxCols, maxRows)
+	return maxCols, maxRows, true
+}
@@ -0,0 +1,152 @@
+package terminal
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+func TestParseResizePolicy(t *testing.T) {
+	for _, value := range []string{"min", "max", "lastWriter", "fixed:120x40"} {
+		policy, err := ParseResizePolicy(value)
+		if err != nil {
+			t.Fatalf("ParseResizePolicy(%q) failed: %v", value, err)
+		}
+		if policy.String() != value {
+			t.Fatalf("ParseResizePolicy(%q).String() = %q", value, policy.String())
+		}
+	}
+	fixed, err := ParseResizePolicy("fixed:120x40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed.Mode != ResizePolicyFixed || fixed.Cols != 120 || fixed.Rows != 40 {
+		t.Fatalf("fixed policy = %+v", fixed)
+	}
+
+	for _, value := range []string{"", "biggest", "fixed:", "fixed:axb", "fixed:120", "fixed:10x10"} {
+		if _, err := ParseResizePolicy(value); err == nil {
+			t.Fatalf("ParseResizePolicy(%q) accepted an invalid policy", value)
+		}
+	}
+}
+
+func TestResizePolicyControlsDesiredPTYSize(t *testing.T) {
+	var calls []*pty.Winsize
+	var callsMu sync.Mutex
+	session := &Session{
+		ID:       "resize-policy",
+		PTY:      &os.File{},
+		isActive: true,
+		connections: map[string]*ConnectionInfo{
+			"wide-short":  {ConnID: "wide-short", Cols: 140, Rows: 24},
+			"narrow-tall": {ConnID: "narrow-tall", Cols: 80, Rows: 48},
+		},
+		lastAppliedCols: 80,
+		lastAppliedRows: 24,
+		setPTYSize: func(_ *os.File, size *pty.Winsize) error {
+			callsMu.Lock()
+			defer callsMu.Unlock()
+			copySize := *size
+			calls = append(calls, &copySize)
+			return nil
+		},
+		config: newSessionConfig(ManagerConfig{Logger: NopLogger{}}),
+	}
+
+	applied := func() (int, int) {
+		session.mu.RLock()
+		defer session.mu.RUnlock()
+		return session.lastAppliedCols, session.lastAppliedRows
+	}
+
+	if err := session.SetResizePolicy(ResizePolicy{Mode: ResizePolicyMax}); err != nil {
+		t.Fatal(err)
+	}
+	waitForResizeIdle(t, session)
+	if cols, rows := applied(); cols != 140 || rows != 48 {
+		t.Fatalf("max policy applied %dx%d, want 140x48", cols, rows)
+	}
+
+	if err := session.SetResizePolicy(ResizePolicy{Mode: ResizePolicyFixed, Cols: 100, Rows: 40}); err != nil {
+		t.Fatal(err)
+	}
+	waitForResizeIdle(t, session)
+	if cols, rows := applied(); cols != 100 || rows != 40 {
+		t.Fatalf("fixed policy applied %dx%d, want 100x40", cols, rows)
+	}
+
+	if err := session.SetResizePolicy(ResizePolicy{Mode: ResizePolicyMin}); err != nil {
+		t.Fatal(err)
+	}
+	waitForResizeIdle(t, session)
+	if cols, rows := applied(); cols != 80 || rows != 24 {
+		t.Fatalf("min policy applied %dx%d, want 80x24", cols, rows)
+	}
+
+	if err := session.SetResizePolicy(ResizePolicy{Mode: "biggest"}); err == nil {
+		t.Fatal("invalid policy mode was accepted")
+	}
+	if err := session.SetResizePolicy(ResizePolicy{Mode: ResizePolicyFixed, Cols: 1, Rows: 1}); err == nil {
+		t.Fatal("fixed policy below the minimum terminal size was accepted")
+	}
+}
+
+func TestLastWriterResizePolicyFollowsInput(t *testing.T) {
+	ptyRead, ptyWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ptyRead.Close()
+		ptyWrite.Close()
+	})
+	session := &Session{
+		ID:       "resize-last-writer",
+		PTY:      ptyWrite,
+		isActive: true,
+		connections: map[string]*ConnectionInfo{
+			"phone":   {ConnID: "phone", Cols: 40, Rows: 20},
+			"desktop": {ConnID: "desktop", Cols: 200, Rows: 50},
+		},
+		setPTYSize: func(_ *os.File, _ *pty.Winsize) error { return nil },
+		config:     newSessionConfig(ManagerConfig{Logger: NopLogger{}}),
+	}
+
+	if err := session.SetResizePolicy(ResizePolicy{Mode: ResizePolicyLastWriter}); err != nil {
+		t.Fatal(err)
+	}
+	waitForResizeIdle(t, session)
+	if session.lastAppliedCols != 40 || session.lastAppliedRows != 20 {
+		t.Fatalf("no writer yet: applied %dx%d, want the 40x20 minimum", session.lastAppliedCols, session.lastAppliedRows)
+	}
+
+	if err := session.WriteDataWithSource([]byte("ls\n"), "desktop"); err != nil {
+		t.Fatal(err)
+	}
+	waitForAppliedSize(t, session, 200, 50)
+
+	// Losing the last writer falls back to the minimum across remaining views.
+	session.RemoveConnection("desktop")
+	waitForAppliedSize(t, session, 40, 20)
+}
+
+func waitForAppliedSize(t *testing.T, session *Session, cols, rows int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		session.mu.RLock()
+		done := session.lastAppliedCols == cols && session.lastAppliedRows == rows
+		session.mu.RUnlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	t.Fatalf("timed out waiting for %dx%d, have %dx%d", cols, rows, session.lastAppliedCols, session.lastAppliedRows)
+}
@@ -34,6 +34,8 @@ type TerminalRingBuffer struct {
 	nextSequence int64
 	maxBytes     int64
 
+	metrics Metrics
+
 	mutex sync.RWMutex
 }
 
@@ -71,9 +73,21 @@ func NewTerminalRingBufferWithLimits(size int, maxChunks int, maxBytes int64) *T
 		full:          false,
 		nextSequence:  1,
 		maxBytes:      maxBytes,
+		metrics:       NopMetrics{},
 	}
 }
 
+// setMetrics routes the buffer's measurements to the provided sink. The
+// manager calls it when wiring a session's history buffer.
+func (rb *TerminalRingBuffer) setMetrics(metrics Metrics) {
+	if metrics == nil {
+		return
+	}
+	rb.mutex.Lock()
+	rb.metrics = metrics
+	rb.mutex.Unlock()
+}
+
 // Write appends data to the ring buffer.
 func (rb *TerminalRingBuffer) Write(data []byte) error {
 	if len(data) == 0 {
@@ -134,6 +148,8 @@ func (rb *TerminalRingBuffer) writeOwnedWithSequence(data []byte, sequence int64
 	rb.head = (rb.head + 1) % rb.size
 	rb.full = rb.head == rb.tail
 
+	rb.metrics.IncCounter(MetricHistoryChunks, 1)
+
 	return nil
 }
 
@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// applyRunAsUser switches the session process to the given local account: the
+// process runs with the account's uid, gid and supplementary groups, and the
+// identity environment variables are rewritten so shells see the right home.
+// The embedding process needs the privilege to change credentials
+// (realistically: running as root); otherwise the PTY start fails with EPERM.
+func applyRunAsUser(cmd *exec.Cmd, username string) error {
+	account, err := user.Lookup(username)
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+	uid, err := strconv.ParseUint(account.Uid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q: %w", account.Uid, err)
+	}
+	gid, err := strconv.ParseUint(account.Gid, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid gid %q: %w", account.Gid, err)
+	}
+	groupIDs, err := account.GroupIds()
+	if err != nil {
+		return fmt.Errorf("failed to resolve groups: %w", err)
+	}
+	groups := make([]uint32, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		parsed, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid group id %q: %w", groupID, err)
+		}
+		groups = append(groups, uint32(parsed))
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    uint32(uid),
+		Gid:    uint32(gid),
+		Groups: groups,
+	}
+	if cmd.Dir == "" {
+		cmd.Dir = account.HomeDir
+	}
+	cmd.Env = setEnvValue(cmd.Env, "HOME", account.HomeDir)
+	cmd.Env = setEnvValue(cmd.Env, "USER", account.Username)
+	cmd.Env = setEnvValue(cmd.Env, "LOGNAME", account.Username)
+	return nil
+}
+
+// setEnvValue replaces the first KEY=value entry in env, or appends one. The
+// first occurrence is what getenv returns, so replacing in place keeps any
+// later duplicates harmless.
+func setEnvValue(env []string, key, value string) []string {
+	for i, entry := range env {
+		if strings.HasPrefix(entry, key+"=") {
+			env[i] = key + "=" + value
+			return env
+		}
+	}
+	return append(env, key+"="+value)
+}
@@ -0,0 +1,50 @@
+package terminal
+
+import (
+	"os/exec"
+	"os/user"
+	"strconv"
+	"testing"
+)
+
+func TestApplyRunAsUserSetsCredentialAndEnv(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Fatalf("user.Current failed: %v", err)
+	}
+	cmd := exec.Command("/bin/sh")
+	cmd.Env = []string{"HOME=/somewhere/else", "TERM=xterm"}
+	if err := applyRunAsUser(cmd, current.Username); err != nil {
+		t.Fatalf("applyRunAsUser failed: %v", err)
+	}
+	cred := cmd.SysProcAttr.Credential
+	if cred == nil {
+		t.Fatal("credential not set")
+	}
+	wantUID, _ := strconv.ParseUint(current.Uid, 10, 32)
+	if cred.Uid != uint32(wantUID) {
+		t.Fatalf("uid = %d, want %d", cred.Uid, wantUID)
+	}
+	if cmd.Dir != current.HomeDir {
+		t.Fatalf("empty dir must fall back to the account home, got %q", cmd.Dir)
+	}
+	if cmd.Env[0] != "HOME="+current.HomeDir {
+		t.Fatalf("HOME must be replaced in place, env = %v", cmd.Env)
+	}
+	found := 0
+	for _, entry := range cmd.Env {
+		if entry == "USER="+current.Username || entry == "LOGNAME="+current.Username {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("USER/LOGNAME not set, env = %v", cmd.Env)
+	}
+}
+
+func TestApplyRunAsUserUnknownAccount(t *testing.T) {
+	cmd := exec.Command("/bin/sh")
+	if err := applyRunAsUser(cmd, "no-such-user-floeterm"); err == nil {
+		t.Fatal("unknown account must fail")
+	}
+}
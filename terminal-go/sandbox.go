@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// SandboxProfile opts a spawned shell into kernel-enforced restrictions for
+// "untrusted playground terminal" deployments. Filesystem visibility shrinks
+// to the listed trees via Landlock, and a small seccomp denylist can block
+// syscalls a playground shell has no business making (ptrace, module loading,
+// mount, reboot, ...).
+//
+// Enforcement has to happen inside the session process between fork and exec,
+// which Go does not expose directly; the session is therefore launched
+// through a re-exec of the embedding binary. Binaries that enable sandbox
+// profiles must call MaybeRunSandboxHelper first thing in main. Linux only;
+// enabling a profile elsewhere fails the activation instead of silently
+// running unsandboxed.
+type SandboxProfile struct {
+	// ReadOnlyPaths are directory trees the session may read and execute
+	// from but not modify.
+	ReadOnlyPaths []string `json:"readOnlyPaths,omitempty"`
+	// ReadWritePaths are directory trees with full filesystem access.
+	ReadWritePaths []string `json:"readWritePaths,omitempty"`
+	// DenyDangerousSyscalls installs the seccomp denylist; blocked syscalls
+	// fail with EPERM.
+	DenyDangerousSyscalls bool `json:"denyDangerousSyscalls,omitempty"`
+}
+
+func (p *SandboxProfile) enabled() bool {
+	return p != nil && (len(p.ReadOnlyPaths) > 0 || len(p.ReadWritePaths) > 0 || p.DenyDangerousSyscalls)
+}
+
+// sandboxHelperArg is the hidden argv[1] marker for the sandbox re-exec step.
+const sandboxHelperArg = "__floeterm-sandbox-exec"
+
+// MaybeRunSandboxHelper handles the hidden sandbox re-exec invocation:
+// argv = [self, marker, profile-json, command, args...]. When the marker is
+// present it applies the profile and execs the command in place, never
+// returning; otherwise it does nothing. Call it at the top of main, before
+// flag parsing.
+func MaybeRunSandboxHelper() {
+	if len(os.Args) < 4 || os.Args[1] != sandboxHelperArg {
+		return
+	}
+	var profile SandboxProfile
+	if err := json.Unmarshal([]byte(os.Args[2]), &profile); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox helper: invalid profile: %v\n", err)
+		os.Exit(125)
+	}
+	if err := enforceSandbox(profile); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox helper: %v\n", err)
+		os.Exit(125)
+	}
+	if err := syscall.Exec(os.Args[3], os.Args[3:], os.Environ()); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox helper: exec %s: %v\n", os.Args[3], err)
+		os.Exit(126)
+	}
+}
+
+// applySandboxProfile wraps the command in the sandbox re-exec helper.
+func applySandboxProfile(cmd *exec.Cmd, profile *SandboxProfile) (*exec.Cmd, error) {
+	if !profile.enabled() {
+		return cmd, nil
+	}
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("sandbox profiles require Linux (Landlock/seccomp)")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate own binary for sandbox re-exec: %w", err)
+	}
+	spec, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode sandbox profile: %w", err)
+	}
+	wrapped := exec.Command(self, sandboxHelperArg, string(spec), cmd.Path)
+	wrapped.Args = append(wrapped.Args, cmd.Args[1:]...)
+	wrapped.Dir = cmd.Dir
+	wrapped.Env = cmd.Env
+	wrapped.SysProcAttr = cmd.SysProcAttr
+	return wrapped, nil
+}
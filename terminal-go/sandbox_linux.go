@@ -0,0 +1,97 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock syscall numbers and ABI v1 filesystem access bits (available since
+// Linux 5.13). The raw interface is used directly to avoid a dependency for
+// three syscalls.
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockRulePathBeneath = 1
+
+	landlockAccessFSExecute   = 1 << 0
+	landlockAccessFSWriteFile = 1 << 1
+	landlockAccessFSReadFile  = 1 << 2
+	landlockAccessFSReadDir   = 1 << 3
+	landlockAccessFSAllV1     = 1<<13 - 1
+
+	prSetNoNewPrivs = 38
+
+	linuxOPath = 0x200000
+)
+
+// enforceSandbox applies the profile to the calling process. It runs inside
+// the re-exec helper, immediately before the session command is exec'd.
+func enforceSandbox(profile SandboxProfile) error {
+	// Required for both Landlock and unprivileged seccomp, and sensible for
+	// a playground shell regardless: no setuid re-escalation.
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	if len(profile.ReadOnlyPaths)+len(profile.ReadWritePaths) > 0 {
+		if err := landlockRestrictPaths(profile.ReadOnlyPaths, profile.ReadWritePaths); err != nil {
+			return fmt.Errorf("landlock: %w", err)
+		}
+	}
+	if profile.DenyDangerousSyscalls {
+		if err := installSeccompDenylist(); err != nil {
+			return fmt.Errorf("seccomp: %w", err)
+		}
+	}
+	return nil
+}
+
+// landlockRestrictPaths confines filesystem access to the given trees:
+// read-only paths allow reading and executing, read-write paths allow
+// everything ABI v1 covers. Anything outside the listed trees is denied.
+func landlockRestrictPaths(readOnly, readWrite []string) error {
+	rulesetAttr := struct {
+		handledAccessFS uint64
+	}{handledAccessFS: landlockAccessFSAllV1}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset,
+		uintptr(unsafe.Pointer(&rulesetAttr)), unsafe.Sizeof(rulesetAttr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create ruleset (kernel without Landlock?): %w", errno)
+	}
+	defer syscall.Close(int(fd))
+
+	addRules := func(paths []string, access uint64) error {
+		for _, path := range paths {
+			pathFD, err := syscall.Open(path, linuxOPath|syscall.O_CLOEXEC, 0)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", path, err)
+			}
+			// struct landlock_path_beneath_attr is packed: u64 access + s32 fd.
+			var attr [12]byte
+			binary.LittleEndian.PutUint64(attr[0:8], access)
+			binary.LittleEndian.PutUint32(attr[8:12], uint32(pathFD))
+			_, _, errno := syscall.Syscall6(sysLandlockAddRule,
+				fd, landlockRulePathBeneath, uintptr(unsafe.Pointer(&attr[0])), 0, 0, 0)
+			syscall.Close(pathFD)
+			if errno != 0 {
+				return fmt.Errorf("failed to add rule for %s: %w", path, errno)
+			}
+		}
+		return nil
+	}
+	readAccess := uint64(landlockAccessFSExecute | landlockAccessFSReadFile | landlockAccessFSReadDir)
+	if err := addRules(readOnly, readAccess); err != nil {
+		return err
+	}
+	if err := addRules(readWrite, landlockAccessFSAllV1); err != nil {
+		return err
+	}
+
+	if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to restrict self: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package terminal
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestApplySandboxProfileWrapsCommand(t *testing.T) {
+	cmd := exec.Command("/bin/bash", "-l")
+	cmd.Dir = "/srv"
+	cmd.Env = []string{"TERM=xterm"}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	profile := &SandboxProfile{
+		ReadOnlyPaths:         []string{"/usr", "/lib"},
+		ReadWritePaths:        []string{"/tmp"},
+		DenyDangerousSyscalls: true,
+	}
+	got, err := applySandboxProfile(cmd, profile)
+	if err != nil {
+		t.Fatalf("applySandboxProfile failed: %v", err)
+	}
+	if got == cmd {
+		t.Fatal("enabled profile must wrap the command in the re-exec helper")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable failed: %v", err)
+	}
+	if got.Path != self {
+		t.Fatalf("helper path = %q, want %q", got.Path, self)
+	}
+	if got.Args[1] != sandboxHelperArg {
+		t.Fatalf("helper marker = %q", got.Args[1])
+	}
+	var decoded SandboxProfile
+	if err := json.Unmarshal([]byte(got.Args[2]), &decoded); err != nil {
+		t.Fatalf("profile payload does not decode: %v", err)
+	}
+	if len(decoded.ReadOnlyPaths) != 2 || len(decoded.ReadWritePaths) != 1 || !decoded.DenyDangerousSyscalls {
+		t.Fatalf("profile payload = %+v", decoded)
+	}
+	if got.Args[3] != "/bin/bash" || got.Args[4] != "-l" {
+		t.Fatalf("original command line missing from helper args: %v", got.Args)
+	}
+	if got.Dir != cmd.Dir || len(got.Env) != 1 || got.SysProcAttr != cmd.SysProcAttr {
+		t.Fatal("helper must inherit dir, env and SysProcAttr")
+	}
+}
+
+func TestApplySandboxProfileDisabled(t *testing.T) {
+	cmd := exec.Command("/bin/sh")
+	if got, err := applySandboxProfile(cmd, nil); err != nil || got != cmd {
+		t.Fatalf("nil profile must pass the command through, got %v err %v", got, err)
+	}
+	if got, err := applySandboxProfile(cmd, &SandboxProfile{}); err != nil || got != cmd {
+		t.Fatalf("zero profile must pass the command through, got %v err %v", got, err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package terminal
+
+import "errors"
+
+// Sandbox profiles rely on Landlock and seccomp; applySandboxProfile already
+// rejects enabled profiles off Linux, so this only runs if the helper
+// invocation is forged by hand.
+func enforceSandbox(SandboxProfile) error {
+	return errors.New("sandbox profiles require Linux")
+}
@@ -0,0 +1,85 @@
+//go:build linux && amd64
+
+package terminal
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	seccompModeFilter = 2
+	prSetSeccomp      = 22
+
+	seccompRetErrno = 0x00050000
+	seccompRetAllow = 0x7fff0000
+
+	auditArchX8664 = 0xc000003e
+
+	// Classic BPF opcodes used by the filter program.
+	bpfLdWAbs = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfJeqK   = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfRetK   = 0x06 // BPF_RET | BPF_K
+)
+
+type sockFilter struct {
+	code uint16
+	jt   uint8
+	jf   uint8
+	k    uint32
+}
+
+type sockFprog struct {
+	len    uint16
+	_      [6]byte
+	filter *sockFilter
+}
+
+// deniedSyscalls lists x86-64 syscall numbers a playground shell should never
+// need: tracing other processes, (un)mounting, kernel module and kexec
+// loading, swap control, reboot, and bpf.
+var deniedSyscalls = []uint32{
+	101, // ptrace
+	165, // mount
+	166, // umount2
+	167, // swapon
+	168, // swapoff
+	169, // reboot
+	175, // init_module
+	176, // delete_module
+	246, // kexec_load
+	304, // open_by_handle_at
+	313, // finit_module
+	320, // kexec_file_load
+	321, // bpf
+}
+
+// installSeccompDenylist installs a BPF filter that makes the denied syscalls
+// fail with EPERM while everything else passes through. A denylist (rather
+// than an allowlist) keeps arbitrary interactive workloads working.
+func installSeccompDenylist() error {
+	filter := []sockFilter{
+		// Non-native architectures (x32 etc.) bypass the denylist numbering;
+		// refuse them outright instead of matching the wrong table.
+		{bpfLdWAbs, 0, 0, 4}, // seccomp_data.arch
+		{bpfJeqK, 1, 0, auditArchX8664},
+		{bpfRetK, 0, 0, seccompRetErrno | uint32(syscall.ENOSYS)},
+		{bpfLdWAbs, 0, 0, 0}, // seccomp_data.nr
+	}
+	for i, nr := range deniedSyscalls {
+		// Jump over the remaining checks and the allow return to the final
+		// EPERM return.
+		filter = append(filter, sockFilter{bpfJeqK, uint8(len(deniedSyscalls) - i), 0, nr})
+	}
+	filter = append(filter,
+		sockFilter{bpfRetK, 0, 0, seccompRetAllow},
+		sockFilter{bpfRetK, 0, 0, seccompRetErrno | uint32(syscall.EPERM)},
+	)
+	prog := sockFprog{len: uint16(len(filter)), filter: &filter[0]}
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL,
+		prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to install filter: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build linux && !amd64
+
+package terminal
+
+import "errors"
+
+// The seccomp denylist is built from x86-64 syscall numbers; other
+// architectures would need their own table. Fail loudly rather than install
+// a filter that blocks the wrong syscalls.
+func installSeccompDenylist() error {
+	return errors.New("syscall denylist is only implemented on amd64")
+}
@@ -19,6 +19,13 @@ var errSessionClosed = errors.New("session is closed")
 
 const naturalExitPTYDrainTimeout = 500 * time.Millisecond
 
+// defaultEchoAttributionWindow bounds how long after an input write output is
+// still attributed to the writing connection. It only needs to cover the
+// terminal echo round trip; keeping it tight avoids mislabeling unrelated
+// output (a running program, another connection's typing) as someone's echo.
+// ManagerConfig.EchoAttributionWindow overrides it for slow PTY paths.
+const defaultEchoAttributionWindow = 50 * time.Millisecond
+
 type sessionActivation struct {
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -84,7 +91,7 @@ func (s *Session) startPTYContext(ctx context.Context, cols, rows int) error {
 	}
 	if s.isActive {
 		s.mu.Unlock()
-		s.config.logger.Warn("Attempted to start PTY for active session", "sessionID", s.ID)
+		s.log().Warn("Attempted to start PTY for active session")
 		return nil
 	}
 	activation := s.activation
@@ -139,7 +146,17 @@ func ensureShellInitForActivation(ctx context.Context, writer ShellInitWriter, p
 	return writer.EnsureShellInitFiles(pathPrepend)
 }
 
-func shellArgsForActivation(ctx context.Context, provider ShellArgsProvider, shell string, pathPrepend string) ([]string, []string, error) {
+func shellArgsForActivation(ctx context.Context, provider ShellArgsProvider, launch ShellLaunchContext, shell string, pathPrepend string) ([]string, []string, error) {
+	if contextual, ok := provider.(ContextSessionShellArgsProvider); ok {
+		return contextual.GetShellArgsForSessionContext(ctx, launch, shell, pathPrepend)
+	}
+	if sessionAware, ok := provider.(SessionShellArgsProvider); ok {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		args, env := sessionAware.GetShellArgsForSession(launch, shell, pathPrepend)
+		return args, env, nil
+	}
 	if contextual, ok := provider.(ContextShellArgsProvider); ok {
 		return contextual.GetShellArgsContext(ctx, shell, pathPrepend)
 	}
@@ -152,6 +169,9 @@ func shellArgsForActivation(ctx context.Context, provider ShellArgsProvider, she
 
 func (s *Session) runPTYActivation(activation *sessionActivation, cols, rows int) {
 	err := s.launchPTY(activation, cols, rows)
+	if err != nil {
+		s.config.metrics.IncCounter(MetricSessionsFailed, 1)
+	}
 	s.mu.Lock()
 	if s.activation == activation {
 		s.activation = nil
@@ -165,26 +185,31 @@ func (s *Session) launchPTY(activation *sessionActivation, cols, rows int) error
 		return errSessionClosed
 	}
 
-	shell, err := resolveShellForActivation(activation.ctx, s.config.shellResolver, s.config.logger)
-	if err != nil {
-		if sessionContextDone(activation.ctx) {
-			return errSessionClosed
+	shell := s.requestedShell
+	if shell == "" {
+		var err error
+		shell, err = resolveShellForActivation(activation.ctx, s.config.shellResolver, s.log())
+		if err != nil {
+			if sessionContextDone(activation.ctx) {
+				return errSessionClosed
+			}
+			return fmt.Errorf("failed to resolve shell: %w", err)
 		}
-		return fmt.Errorf("failed to resolve shell: %w", err)
 	}
-	s.config.logger.Info("Starting terminal", "shell", filepath.Base(shell), "workingDir", filepath.Base(s.WorkingDir))
+	s.log().Info("Starting terminal", "shell", filepath.Base(shell), "workingDir", filepath.Base(s.WorkingDir))
 
 	env, pathPrepend, envErr := buildEnvForActivation(activation.ctx, s.config.envProvider, shell, s.WorkingDir)
 	if envErr != nil {
 		if sessionContextDone(activation.ctx) {
 			return errSessionClosed
 		}
-		s.config.logger.Warn("Env provider failed", "error", envErr)
+		s.log().Warn("Env provider failed", "error", envErr)
 		env = os.Environ()
 	}
 	if len(env) == 0 {
 		env = os.Environ()
 	}
+	pathPrepend = combinePathPrepends(s.requestedPathPrepend, pathPrepend)
 
 	shouldEnsureShellInit := pathPrepend != ""
 	if requirement, ok := s.config.shellInitWriter.(ShellInitRequirement); ok {
@@ -195,26 +220,46 @@ func (s *Session) launchPTY(activation *sessionActivation, cols, rows int) error
 			if sessionContextDone(activation.ctx) {
 				return errSessionClosed
 			}
-			s.config.logger.Warn("Failed to ensure shell init files", "error", err)
+			s.log().Warn("Failed to ensure shell init files", "error", err)
 		}
 	}
 
-	shellArgs, shellEnv, err := shellArgsForActivation(activation.ctx, s.config.shellArgsProvider, shell, pathPrepend)
-	if err != nil {
-		if sessionContextDone(activation.ctx) {
-			return errSessionClosed
+	launch := ShellLaunchContext{
+		SessionID:  s.ID,
+		WorkingDir: s.WorkingDir,
+		Cols:       cols,
+		Rows:       rows,
+	}
+	shellArgs := s.requestedShellArgs
+	var shellEnv []string
+	if shellArgs == nil {
+		var err error
+		shellArgs, shellEnv, err = shellArgsForActivation(activation.ctx, s.config.shellArgsProvider, launch, shell, pathPrepend)
+		if err != nil {
+			if sessionContextDone(activation.ctx) {
+				return errSessionClosed
+			}
+			return fmt.Errorf("failed to build shell arguments: %w", err)
 		}
-		return fmt.Errorf("failed to build shell arguments: %w", err)
 	}
 
 	var cmd *exec.Cmd
 	// Distinguish nil vs empty slice:
-	// - nil means "no opinion" → fall back to a login shell for backwards behaviour.
+	// - nil means "no opinion" → the configured LoginShell option decides.
 	// - empty slice means "run the shell without extra args" (useful for ZDOTDIR-based zsh setup).
-	if shellArgs != nil {
+	switch {
+	case shellArgs != nil:
 		cmd = exec.Command(shell, shellArgs...)
-	} else {
-		cmd = exec.Command(shell, "-l")
+	case s.config.loginShell:
+		cmd = exec.Command(shell, loginShellArgs(detectShellType(shell))...)
+	default:
+		cmd = exec.Command(shell)
+	}
+
+	if s.config.commandPolicy != nil {
+		if err := s.config.commandPolicy.AuthorizeCommand(launch, shell, cmd.Args[1:]); err != nil {
+			return fmt.Errorf("command rejected by policy: %w", err)
+		}
 	}
 
 	cmd.Dir = s.WorkingDir
@@ -244,11 +289,35 @@ func (s *Session) launchPTY(activation *sessionActivation, cols, rows int) error
 		"COLUMNS="+fmt.Sprintf("%d", cols),
 		"LINES="+fmt.Sprintf("%d", rows),
 		"PROMPT_EOL_MARK=",
-		"TERMINFO="+s.config.terminalEnv.Terminfo,
 		"TERM_FEATURES="+s.config.terminalEnv.TermFeatures,
 	)
+	if s.config.terminalEnv.Terminfo != "" {
+		env = append(env, "TERMINFO="+s.config.terminalEnv.Terminfo)
+	}
+	// Caller-requested entries go last so they win over provider defaults.
+	env = append(env, s.requestedExtraEnv...)
 	cmd.Env = env
 
+	if s.requestedRunAsUser != "" {
+		if err := applyRunAsUser(cmd, s.requestedRunAsUser); err != nil {
+			return fmt.Errorf("failed to run session as %q: %w", s.requestedRunAsUser, err)
+		}
+	}
+
+	if err := applySessionChroot(cmd, s.config.isolation, s.requestedRunAsUser); err != nil {
+		return fmt.Errorf("failed to chroot session: %w", err)
+	}
+
+	sandbox := s.requestedSandbox
+	if sandbox == nil {
+		sandbox = s.config.sandbox
+	}
+	cmd, err := applySandboxProfile(cmd, sandbox)
+	if err != nil {
+		return fmt.Errorf("failed to apply sandbox profile: %w", err)
+	}
+	cmd = applySessionIsolation(cmd, s.config.isolation)
+
 	winsize := buildWinSize(cols, rows)
 	startPTYProcess := s.startPTYProcess
 	if startPTYProcess == nil {
@@ -302,7 +371,7 @@ func (s *Session) launchPTY(activation *sessionActivation, cols, rows int) error
 	go s.readPTYOutput(ptmx, outputMonitor, done, readerDone)
 	go s.waitProcessExit(cmd, ptmx, readerDone, done)
 
-	s.config.logger.Info("Started PTY session", "sessionID", s.ID, "cols", cols, "rows", rows)
+	s.log().Info("Started PTY session", "cols", cols, "rows", rows)
 	return nil
 }
 
@@ -374,12 +443,26 @@ func (s *Session) waitProcessExit(cmd *exec.Cmd, ptyFile *os.File, readerDone ch
 			select {
 			case <-readerDone:
 			case <-time.After(naturalExitPTYDrainTimeout):
-				s.config.logger.Warn("PTY output reader did not stop after close", "sessionID", s.ID)
+				s.log().Warn("PTY output reader did not stop after close")
 			}
 		}
 	}
 
 	s.mu.Lock()
+	// Cleanup detaches s.Cmd before signalling the process, so also record
+	// the exit when the session is closed; the closeReason set there tells a
+	// caller-initiated termination apart from a natural exit.
+	if s.Cmd == cmd || s.closed {
+		reason := ExitReasonExited
+		if s.closeReason != "" {
+			reason = s.closeReason
+		}
+		s.lastExit = &TerminalExitInfo{
+			Reason:   reason,
+			ExitCode: exitCodeFromWaitErr(err),
+			ExitedAt: time.Now().UnixMilli(),
+		}
+	}
 	if s.Cmd == cmd {
 		s.Cmd = nil
 		s.procWaitErr = err
@@ -401,6 +484,19 @@ func (s *Session) waitProcessExit(cmd *exec.Cmd, ptyFile *os.File, readerDone ch
 	}
 }
 
+// exitCodeFromWaitErr derives a process exit code from a Wait error. Signal
+// terminations and unexpected wait failures report -1.
+func exitCodeFromWaitErr(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
 // Close shuts down the session and releases resources.
 func (s *Session) Close() error {
 	s.cleanup()
@@ -408,6 +504,10 @@ func (s *Session) Close() error {
 }
 
 func (s *Session) cleanup() {
+	s.cleanupWithReason(ExitReasonKilled)
+}
+
+func (s *Session) cleanupWithReason(reason TerminalExitReason) {
 	s.mu.Lock()
 	if s.cleaned {
 		s.mu.Unlock()
@@ -415,6 +515,9 @@ func (s *Session) cleanup() {
 	}
 	s.cleaned = true
 	s.closed = true
+	if s.closeReason == "" {
+		s.closeReason = reason
+	}
 	s.resizeQueued = false
 	s.resizeRunning = false
 
@@ -448,13 +551,13 @@ func (s *Session) cleanup() {
 	}
 	if cmd != nil && cmd.Process != nil {
 		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
-			s.config.logger.Debug("Failed to send SIGTERM", "sessionID", s.ID, "error", err)
+			s.log().Debug("Failed to send SIGTERM", "error", err)
 		}
 		if waitDone != nil {
 			select {
 			case <-waitDone:
 			case <-time.After(2 * time.Second):
-				s.config.logger.Debug("Force killing process", "sessionID", s.ID)
+				s.log().Debug("Force killing process")
 				_ = cmd.Process.Kill()
 				select {
 				case <-waitDone:
@@ -464,7 +567,7 @@ func (s *Session) cleanup() {
 		}
 	}
 
-	s.config.logger.Info("Cleaned up session", "sessionID", s.ID)
+	s.log().Info("Cleaned up session")
 }
 
 // GetHistoryChunks returns raw chunks from the ring buffer.
@@ -554,6 +657,70 @@ func (s *Session) GetHistoryStats() (RingBufferStats, error) {
 	return ringBuffer.GetStats(), nil
 }
 
+// GetIOStats returns cumulative bytes written to (input) and read from
+// (output) the PTY over the session's lifetime.
+func (s *Session) GetIOStats() (inputBytes, outputBytes int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.inputBytes, s.outputBytes
+}
+
+// ConnectionCount returns the number of currently attached connections.
+func (s *Session) ConnectionCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.connections)
+}
+
+// ProcessID returns the PID of the running shell process, or 0 when the
+// session is not active.
+func (s *Session) ProcessID() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.Cmd == nil || s.Cmd.Process == nil {
+		return 0
+	}
+	return s.Cmd.Process.Pid
+}
+
+// ExitError returns the error from the most recent shell process exit; nil
+// both when the process exited cleanly and when it has not exited yet.
+func (s *Session) ExitError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.procWaitErr
+}
+
+// SignalProcessGroup delivers a signal to the shell's process group. The PTY
+// child runs in its own session (Setsid), so addressing the negative PID
+// reaches the foreground job as well as the shell itself, mirroring what the
+// kernel does for ^C on the controlling terminal.
+func (s *Session) SignalProcessGroup(sig syscall.Signal) error {
+	s.mu.RLock()
+	cmd := s.Cmd
+	s.mu.RUnlock()
+
+	if cmd == nil || cmd.Process == nil {
+		return fmt.Errorf("session has no running process")
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, sig); err != nil {
+		return fmt.Errorf("failed to signal process group: %w", err)
+	}
+	return nil
+}
+
+// LastExit returns a copy of the most recent PTY exit record, or nil when the
+// shell process has not exited yet.
+func (s *Session) LastExit() *TerminalExitInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.lastExit == nil {
+		return nil
+	}
+	exit := *s.lastExit
+	return &exit
+}
+
 // ClearHistory removes stored PTY output from the ring buffer.
 func (s *Session) ClearHistory() error {
 	s.mu.Lock()
@@ -568,7 +735,7 @@ func (s *Session) ClearHistory() error {
 	}
 	s.historyStartSequence = s.committedSequence + 1
 
-	s.config.logger.Info("Terminal history cleared", "sessionID", s.ID)
+	s.log().Info("Terminal history cleared")
 	return nil
 }
 
@@ -600,14 +767,14 @@ func (s *Session) readPTYOutput(
 	if done != nil {
 		defer close(done)
 	}
-	s.config.logger.Info("Starting PTY output reader", "sessionID", s.ID)
+	s.log().Info("Starting PTY output reader")
 
 	if ptyFile == nil {
-		s.config.logger.Warn("PTY is nil", "sessionID", s.ID)
+		s.log().Warn("PTY is nil")
 		return
 	}
 	if monitor == nil {
-		s.config.logger.Error("PTY output monitor is nil", "sessionID", s.ID)
+		s.log().Error("PTY output monitor is nil")
 		return
 	}
 	monitorWatcherDone := make(chan struct{})
@@ -647,7 +814,7 @@ func (s *Session) readPTYOutput(
 			s.processRawPTYData(raw)
 		}
 		if err != nil {
-			s.config.logger.Debug("PTY read finished", "sessionID", s.ID, "error", err)
+			s.log().Debug("PTY read finished", "error", err)
 			return
 		}
 	}
@@ -787,14 +954,22 @@ func collectAvailablePTYBurst(
 func (s *Session) processRawPTYData(data []byte) {
 	timestamp := time.Now().UnixMilli()
 
+	s.config.metrics.IncCounter(MetricPTYReadBytes, float64(len(data)))
+	s.config.metrics.ObserveHistogram(MetricPTYReadChunkBytes, float64(len(data)))
+
 	s.mu.Lock()
 	s.sequenceNumber++
 	seqNum := s.sequenceNumber
+	s.outputBytes += int64(len(data))
 	s.LastActive = time.Now()
 
-	if s.ringBuffer != nil {
+	skipHistory, transferChanged := s.applyTransferDetection(data, seqNum)
+	transferInfo := s.fileTransfer
+	handler := s.eventHandler
+
+	if s.ringBuffer != nil && !skipHistory {
 		if err := s.ringBuffer.writeOwnedWithSequence(data, seqNum, timestamp, false); err != nil {
-			s.config.logger.Error("Failed to write to ring buffer", "sessionID", s.ID, "error", err)
+			s.log().Error("Failed to write to ring buffer", "error", err)
 		} else {
 			s.committedSequence = seqNum
 		}
@@ -804,14 +979,29 @@ func (s *Session) processRawPTYData(data []byte) {
 		subscribers = append(subscribers, attachment.subscriber)
 	}
 	geometry := s.effectiveGeometryLocked()
+	echoSource := ""
+	if s.lastInputConnID != "" && time.Since(s.lastInputTime) <= s.config.echoAttributionWindow {
+		echoSource = s.lastInputConnID
+	}
 
 	s.mu.Unlock()
 
+	if echoSource != "" {
+		// Output attributed to a connection's own input lets that client drop
+		// its locally echoed copy; count how often the attribution fires.
+		s.config.metrics.IncCounter(MetricEchoDedupHits, 1)
+	}
+
+	if transferChanged {
+		notifyTerminalFileTransfer(handler, s.ID, transferInfo)
+	}
+
 	s.broadcastData(TerminalOutputEvent{
-		Data:        data,
-		Sequence:    seqNum,
-		TimestampMs: timestamp,
-		Geometry:    geometry,
+		Data:             data,
+		Sequence:         seqNum,
+		TimestampMs:      timestamp,
+		EchoSourceConnID: echoSource,
+		Geometry:         geometry,
 	}, subscribers)
 
 	s.checkShellIntegrationChange(data)
@@ -819,7 +1009,6 @@ func (s *Session) processRawPTYData(data []byte) {
 
 // WriteDataWithSource writes each accepted input exactly once to the PTY.
 func (s *Session) WriteDataWithSource(data []byte, sourceConnID string) error {
-	_ = sourceConnID
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -827,12 +1016,25 @@ func (s *Session) WriteDataWithSource(data []byte, sourceConnID string) error {
 		return fmt.Errorf("PTY not available")
 	}
 
+	if sourceConnID != "" && sourceConnID != s.lastWriterConnID {
+		s.lastWriterConnID = sourceConnID
+		if s.resizePolicy.Mode == ResizePolicyLastWriter && s.isActive {
+			s.schedulePTYSizeReconcileLocked("last-writer-changed")
+		}
+	}
+	if sourceConnID != "" {
+		s.lastInputConnID = sourceConnID
+		s.lastInputTime = time.Now()
+	}
+
 	if _, err := s.PTY.Write(data); err != nil {
-		s.config.logger.Error("Failed to write to PTY", "sessionID", s.ID, "error", err)
+		s.log().Error("Failed to write to PTY", "error", err)
 		return err
 	}
 
+	s.inputBytes += int64(len(data))
 	s.LastActive = time.Now()
+	s.config.metrics.IncCounter(MetricPTYWrittenBytes, float64(len(data)))
 
 	return nil
 }
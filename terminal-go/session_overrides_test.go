@@ -0,0 +1,91 @@
+package terminal
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/creack/pty"
+)
+
+// capturePTYLaunchCmd activates a session with a stub that records the full
+// command instead of starting a PTY, so launch overrides can be asserted.
+func capturePTYLaunchCmd(t *testing.T, opts CreateSessionOptions) *exec.Cmd {
+	t.Helper()
+	manager := NewManager(ManagerConfig{
+		Logger:            NopLogger{},
+		ShellResolver:     testShellResolver{shell: "/bin/bash"},
+		ShellArgsProvider: nilArgsShellArgsProvider{},
+	})
+	defer manager.Cleanup()
+
+	session, err := manager.CreateSessionWithOptions(opts)
+	if err != nil {
+		t.Fatalf("create session failed: %v", err)
+	}
+
+	captured := make(chan *exec.Cmd, 1)
+	session.startPTYProcess = func(cmd *exec.Cmd, _ *pty.Winsize) (*os.File, error) {
+		captured <- cmd
+		return nil, errors.New("capture only")
+	}
+	if err := manager.ActivateSession(session.ID, 80, 24); err == nil {
+		t.Fatalf("expected activation to fail with capture stub")
+	}
+	return <-captured
+}
+
+func TestCreateSessionShellOverrides(t *testing.T) {
+	cmd := capturePTYLaunchCmd(t, CreateSessionOptions{
+		WorkingDir: "/",
+		Shell:      "/usr/bin/python3",
+		ShellArgs:  []string{"-i"},
+	})
+	if len(cmd.Args) != 2 || cmd.Args[0] != "/usr/bin/python3" || cmd.Args[1] != "-i" {
+		t.Fatalf("launch args = %v, want [/usr/bin/python3 -i]", cmd.Args)
+	}
+
+	// An empty (non-nil) args slice runs the override shell bare, skipping
+	// the login-shell fallback.
+	cmd = capturePTYLaunchCmd(t, CreateSessionOptions{
+		WorkingDir: "/",
+		Shell:      "/bin/dash",
+		ShellArgs:  []string{},
+	})
+	if len(cmd.Args) != 1 || cmd.Args[0] != "/bin/dash" {
+		t.Fatalf("launch args = %v, want bare /bin/dash", cmd.Args)
+	}
+
+	// Without overrides the resolver and login fallback still apply.
+	cmd = capturePTYLaunchCmd(t, CreateSessionOptions{WorkingDir: "/"})
+	if len(cmd.Args) != 2 || cmd.Args[0] != "/bin/bash" || cmd.Args[1] != "-l" {
+		t.Fatalf("launch args = %v, want [/bin/bash -l]", cmd.Args)
+	}
+}
+
+func TestCreateSessionExtraEnvWinsOverDefaults(t *testing.T) {
+	cmd := capturePTYLaunchCmd(t, CreateSessionOptions{
+		WorkingDir: "/",
+		ExtraEnv:   []string{"PROFILE_VAR=hello", "TERM=vt100"},
+	})
+
+	lastValue := func(key string) string {
+		value := ""
+		prefix := key + "="
+		for _, entry := range cmd.Env {
+			if rest, ok := strings.CutPrefix(entry, prefix); ok {
+				value = rest
+			}
+		}
+		return value
+	}
+	if got := lastValue("PROFILE_VAR"); got != "hello" {
+		t.Fatalf("PROFILE_VAR = %q, want hello", got)
+	}
+	// Extra env entries come last, so they shadow the built-in defaults.
+	if got := lastValue("TERM"); got != "vt100" {
+		t.Fatalf("effective TERM = %q, want vt100", got)
+	}
+}
@@ -32,6 +32,28 @@ type ContextShellArgsProvider interface {
 	GetShellArgsContext(ctx context.Context, shellPath string, pathPrepend string) (args []string, env []string, err error)
 }
 
+// ShellLaunchContext describes the session a shell is being launched for.
+type ShellLaunchContext struct {
+	SessionID  string
+	WorkingDir string
+	Cols       int
+	Rows       int
+}
+
+// SessionShellArgsProvider optionally receives the session launch details so
+// providers can generate per-project arguments (e.g. an --rcfile per
+// workspace) instead of only global ones. When implemented it takes precedence
+// over the plain ShellArgsProvider methods.
+type SessionShellArgsProvider interface {
+	GetShellArgsForSession(launch ShellLaunchContext, shellPath string, pathPrepend string) (args []string, env []string)
+}
+
+// ContextSessionShellArgsProvider combines SessionShellArgsProvider with
+// activation cancellation.
+type ContextSessionShellArgsProvider interface {
+	GetShellArgsForSessionContext(ctx context.Context, launch ShellLaunchContext, shellPath string, pathPrepend string) (args []string, env []string, err error)
+}
+
 // ShellInitWriter allows writing shell init files for PATH injection when needed.
 type ShellInitWriter interface {
 	EnsureShellInitFiles(pathPrepend string) error
@@ -49,6 +71,45 @@ type ShellInitRequirement interface {
 	ShouldEnsureShellInit(pathPrepend string) bool
 }
 
+// ShellInitCleaner lets a writer remove its generated init files, e.g. on
+// uninstall or when the base directory changes.
+type ShellInitCleaner interface {
+	Cleanup() error
+}
+
+// loginShellArgs returns the flag that starts the given shell as a login
+// shell. Most shells accept `-l`; xonsh spells it `--login` and elvish has no
+// login mode at all.
+func loginShellArgs(shell shellType) []string {
+	switch shell {
+	case shellTypeXonsh:
+		return []string{"--login"}
+	case shellTypeElvish:
+		return nil
+	default:
+		return []string{"-l"}
+	}
+}
+
+// FixedShellResolver always resolves to one shell path, so containers and
+// kiosk deployments control exactly what every session launches.
+type FixedShellResolver struct {
+	Shell string
+}
+
+func (r FixedShellResolver) ResolveShell(Logger) string { return r.Shell }
+
+// FixedShellArgsProvider always starts the shell with the same argv (not
+// including the shell path itself). A nil Args still yields an empty slice so
+// the shell runs bare instead of falling back to login-shell defaults.
+type FixedShellArgsProvider struct {
+	Args []string
+}
+
+func (p FixedShellArgsProvider) GetShellArgs(string, string) ([]string, []string) {
+	return append([]string{}, p.Args...), nil
+}
+
 // DefaultShellResolver implements the shell lookup strategy used by the original agent.
 type DefaultShellResolver struct{}
 
@@ -134,10 +195,14 @@ func resolveShellFromPasswd(logger Logger) string {
 type DefaultShellArgsProvider struct {
 	ShellInitBaseDir       string
 	EnableCommandLifecycle bool
+
+	// EnablePromptMarkers mirrors DefaultShellInitWriter.EnablePromptMarkers:
+	// the generated init files must be used even without a PATH prepend.
+	EnablePromptMarkers bool
 }
 
 func (p DefaultShellArgsProvider) GetShellArgs(shellPath string, pathPrepend string) ([]string, []string) {
-	if strings.TrimSpace(pathPrepend) == "" && !p.EnableCommandLifecycle {
+	if strings.TrimSpace(pathPrepend) == "" && !p.EnableCommandLifecycle && !p.EnablePromptMarkers {
 		return nil, nil
 	}
 
@@ -173,6 +238,28 @@ func (p DefaultShellArgsProvider) GetShellArgs(shellPath string, pathPrepend str
 		}
 		return []string{"--init-command", "source " + cfgFile}, env
 
+	case shellTypeElvish:
+		rcFile := initPaths.ElvishRC()
+		if _, err := os.Stat(rcFile); err != nil {
+			return nil, nil
+		}
+		return []string{"-rc", rcFile}, env
+
+	case shellTypeXonsh:
+		rcFile := initPaths.XonshRC()
+		if _, err := os.Stat(rcFile); err != nil {
+			return nil, nil
+		}
+		return []string{"--rc", rcFile}, env
+
+	case shellTypeKsh:
+		rcFile := initPaths.KshRC()
+		if _, err := os.Stat(rcFile); err != nil {
+			return nil, nil
+		}
+		env = append(env, "ENV="+rcFile)
+		return []string{}, env
+
 	default:
 		rcFile := initPaths.PosixRC()
 		if _, err := os.Stat(rcFile); err != nil {
@@ -2,6 +2,8 @@ package terminal
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -17,10 +19,13 @@ const (
 type shellType string
 
 const (
-	shellTypeBash  shellType = "bash"
-	shellTypeZsh   shellType = "zsh"
-	shellTypeFish  shellType = "fish"
-	shellTypePosix shellType = "posix"
+	shellTypeBash   shellType = "bash"
+	shellTypeZsh    shellType = "zsh"
+	shellTypeFish   shellType = "fish"
+	shellTypeKsh    shellType = "ksh"
+	shellTypeElvish shellType = "elvish"
+	shellTypeXonsh  shellType = "xonsh"
+	shellTypePosix  shellType = "posix"
 )
 
 func detectShellType(shellPath string) shellType {
@@ -32,11 +37,26 @@ func detectShellType(shellPath string) shellType {
 		return shellTypeBash
 	case strings.Contains(name, "fish"):
 		return shellTypeFish
+	case strings.Contains(name, "elvish"):
+		return shellTypeElvish
+	case strings.Contains(name, "xonsh"):
+		return shellTypeXonsh
+	case strings.Contains(name, "ksh"):
+		// Covers ksh, ksh93 and mksh, which all read $ENV but need their own
+		// rc sourcing rules.
+		return shellTypeKsh
 	default:
 		return shellTypePosix
 	}
 }
 
+// DefaultShellInitBaseDir reports where DefaultShellInitWriter places its
+// generated init files when no BaseDir is configured, so embedders and
+// diagnostics can point at the right directory.
+func DefaultShellInitBaseDir() string {
+	return defaultShellInitBaseDir()
+}
+
 func defaultShellInitBaseDir() string {
 	if dir, err := os.UserCacheDir(); err == nil && dir != "" {
 		return filepath.Join(dir, "floeterm", defaultShellInitFolder)
@@ -66,6 +86,19 @@ func (p shellInitPaths) FishConfig() string {
 	return filepath.Join(p.baseDir, "config.fish")
 }
 func (p shellInitPaths) PosixRC() string { return filepath.Join(p.baseDir, "shrc") }
+func (p shellInitPaths) KshRC() string   { return filepath.Join(p.baseDir, "kshrc") }
+func (p shellInitPaths) ElvishRC() string {
+	return filepath.Join(p.baseDir, "rc.elv")
+}
+func (p shellInitPaths) XonshRC() string {
+	return filepath.Join(p.baseDir, "rc.xsh")
+}
+
+// ShellInitCustomizer adjusts a generated init script before it is written.
+// The shell argument is the generated script flavor ("bash", "zsh", "fish",
+// "posix", "ksh", "elvish" or "xonsh"); the returned content replaces the
+// generated script, so customizers typically append to it.
+type ShellInitCustomizer func(shell string, content string) string
 
 // DefaultShellInitWriter generates shell init files used for PATH injection.
 //
@@ -74,12 +107,28 @@ func (p shellInitPaths) PosixRC() string { return filepath.Join(p.baseDir, "shrc
 type DefaultShellInitWriter struct {
 	BaseDir                string
 	EnableCommandLifecycle bool
+
+	// EnablePromptMarkers installs OSC 133 A/B/C/D prompt markers (with exit
+	// codes) into the generated bash/zsh/fish init files.
+	EnablePromptMarkers bool
+
+	// Customize, when set, post-processes each generated script (e.g. to
+	// append aliases or source an org-wide snippet) without reimplementing
+	// the whole writer.
+	Customize ShellInitCustomizer
+}
+
+func (w DefaultShellInitWriter) renderScript(shell string, content string) string {
+	if w.Customize == nil {
+		return content
+	}
+	return w.Customize(shell, content)
 }
 
 // ShouldEnsureShellInit reports whether the generated integration files are
 // needed for PATH injection or command lifecycle hooks.
 func (w DefaultShellInitWriter) ShouldEnsureShellInit(pathPrepend string) bool {
-	return strings.TrimSpace(pathPrepend) != "" || w.EnableCommandLifecycle
+	return strings.TrimSpace(pathPrepend) != "" || w.EnableCommandLifecycle || w.EnablePromptMarkers
 }
 
 func (w DefaultShellInitWriter) EnsureShellInitFiles(pathPrepend string) error {
@@ -103,37 +152,127 @@ func (w DefaultShellInitWriter) EnsureShellInitFilesContext(ctx context.Context,
 		return fmt.Errorf("failed to create zsh init directory: %w", err)
 	}
 
-	if err := writeFileContext(ctx, paths.BashRC(), bashInitScript(w.EnableCommandLifecycle)); err != nil {
+	if err := writeFileContext(ctx, paths.BashRC(), w.renderScript("bash", bashInitScript(w.EnableCommandLifecycle, w.EnablePromptMarkers))); err != nil {
 		return err
 	}
-	if err := writeFileContext(ctx, paths.ZshRC(), zshInitScript(w.EnableCommandLifecycle)); err != nil {
+	if err := writeFileContext(ctx, paths.ZshRC(), w.renderScript("zsh", zshInitScript(w.EnableCommandLifecycle, w.EnablePromptMarkers))); err != nil {
 		return err
 	}
-	if err := writeFileContext(ctx, paths.FishConfig(), fishInitScript(w.EnableCommandLifecycle)); err != nil {
+	if err := writeFileContext(ctx, paths.FishConfig(), w.renderScript("fish", fishInitScript(w.EnableCommandLifecycle, w.EnablePromptMarkers))); err != nil {
 		return err
 	}
-	if err := writeFileContext(ctx, paths.PosixRC(), posixInitScript()); err != nil {
+	if err := writeFileContext(ctx, paths.PosixRC(), w.renderScript("posix", posixInitScript())); err != nil {
+		return err
+	}
+	if err := writeFileContext(ctx, paths.KshRC(), w.renderScript("ksh", kshInitScript())); err != nil {
+		return err
+	}
+	if err := writeFileContext(ctx, paths.ElvishRC(), w.renderScript("elvish", elvishInitScript())); err != nil {
+		return err
+	}
+	if err := writeFileContext(ctx, paths.XonshRC(), w.renderScript("xonsh", xonshInitScript())); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// Cleanup removes the generated init files and, when nothing else lives in
+// them, the shell-init directories. Files without the generated version marker
+// are left alone so a misconfigured BaseDir cannot destroy user data.
+func (w DefaultShellInitWriter) Cleanup() error {
+	paths := newShellInitPaths(w.BaseDir)
+	generated := []string{
+		paths.BashRC(),
+		paths.ZshRC(),
+		paths.FishConfig(),
+		paths.PosixRC(),
+		paths.KshRC(),
+		paths.ElvishRC(),
+		paths.XonshRC(),
+	}
+
+	var firstErr error
+	for _, path := range generated {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) && firstErr == nil {
+				firstErr = fmt.Errorf("failed to inspect %s: %w", filepath.Base(path), err)
+			}
+			continue
+		}
+		if !strings.Contains(string(content), shellInitVersionPrefix) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	// Remove now-empty directories; ENOTEMPTY means foreign files remain.
+	for _, dir := range []string{paths.ZshDir(), paths.BaseDir()} {
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+	}
+
+	return firstErr
+}
+
+// shellInitVersionPrefix introduces the trailing marker line embedded in every
+// generated file. The marker carries a hash of the generated content, so a
+// file is only rewritten when the template (or customization) changed.
+const shellInitVersionPrefix = "# floeterm-shell-init-version: "
+
+func shellInitContentWithMarker(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + shellInitVersionPrefix + hex.EncodeToString(hash[:8]) + "\n"
+}
+
 func writeFile(path string, content string) error {
 	return writeFileContext(context.Background(), path, content)
 }
 
+// writeFileContext writes a generated init file idempotently: when the file
+// already holds the desired content (compared via the embedded version marker)
+// it is left untouched, otherwise the new content lands via a temp file and
+// rename so concurrent session activations never observe a partial script.
 func writeFileContext(ctx context.Context, path string, content string) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+
+	desired := shellInitContentWithMarker(content)
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == desired {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
 		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
 	}
+	tmpPath := tmp.Name()
+	_, writeErr := tmp.WriteString(desired)
+	if chmodErr := tmp.Chmod(0o644); writeErr == nil {
+		writeErr = chmodErr
+	}
+	if closeErr := tmp.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tmpPath, path)
+	}
+	if writeErr != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), writeErr)
+	}
 	return nil
 }
 
-func bashInitScript(enableCommandLifecycle bool) string {
+func bashInitScript(enableCommandLifecycle bool, enablePromptMarkers bool) string {
 	script := `#!/bin/bash
 # floeterm shell integration - auto-generated, do not edit.
 
@@ -150,14 +289,17 @@ fi
 if [ -n "$` + pathPrependEnvKey + `" ]; then
     export PATH="$` + pathPrependEnvKey + `:$PATH"
 fi
+
+# Sentinel for ProbeShell diagnostics.
+__floeterm_shell_init_sourced=1
 `
-	if !enableCommandLifecycle {
+	if !enableCommandLifecycle && !enablePromptMarkers {
 		return script
 	}
-	return script + bashCommandLifecycleScript()
+	return script + commandLifecycleWithPromptMarkers(bashCommandLifecycleScript(), enablePromptMarkers, false)
 }
 
-func zshInitScript(enableCommandLifecycle bool) string {
+func zshInitScript(enableCommandLifecycle bool, enablePromptMarkers bool) string {
 	homeDir, _ := os.UserHomeDir()
 	if homeDir == "" {
 		homeDir = "$HOME"
@@ -191,14 +333,17 @@ fi
 if [ -n "$%s" ]; then
     export PATH="$%s:$PATH"
 fi
+
+# Sentinel for ProbeShell diagnostics.
+__floeterm_shell_init_sourced=1
 `, originalZdotdirEnvKey, originalZdotdirEnvKey, homeDir, homeDir, homeDir, homeDir, pathPrependEnvKey, pathPrependEnvKey)
-	if !enableCommandLifecycle {
+	if !enableCommandLifecycle && !enablePromptMarkers {
 		return script
 	}
-	return script + zshCommandLifecycleScript()
+	return script + commandLifecycleWithPromptMarkers(zshCommandLifecycleScript(), enablePromptMarkers, false)
 }
 
-func fishInitScript(enableCommandLifecycle bool) string {
+func fishInitScript(enableCommandLifecycle bool, enablePromptMarkers bool) string {
 	homeDir, _ := os.UserHomeDir()
 	if homeDir == "" {
 		homeDir = "$HOME"
@@ -220,11 +365,14 @@ if set -q %s
         end
     end
 end
+
+# Sentinel for ProbeShell diagnostics.
+set -g __floeterm_shell_init_sourced 1
 `, homeDir, homeDir, pathPrependEnvKey, pathPrependEnvKey)
-	if !enableCommandLifecycle {
+	if !enableCommandLifecycle && !enablePromptMarkers {
 		return script
 	}
-	return script + fishCommandLifecycleScript()
+	return script + commandLifecycleWithPromptMarkers(fishCommandLifecycleScript(), enablePromptMarkers, true)
 }
 
 func posixInitScript() string {
@@ -240,9 +388,110 @@ fi
 if [ -n "$` + pathPrependEnvKey + `" ]; then
     export PATH="$` + pathPrependEnvKey + `:$PATH"
 fi
+
+# Sentinel for ProbeShell diagnostics.
+__floeterm_shell_init_sourced=1
+`
+}
+
+func kshInitScript() string {
+	return `# floeterm shell integration - auto-generated, do not edit.
+
+# Source user's original ksh configuration. ksh93 and mksh both honor $ENV,
+# so reaching this file means the user's rc was not read yet.
+if [ -f "$HOME/.kshrc" ]; then
+    . "$HOME/.kshrc"
+elif [ -f "$HOME/.mkshrc" ]; then
+    . "$HOME/.mkshrc"
+elif [ -f "$HOME/.profile" ]; then
+    . "$HOME/.profile"
+fi
+
+# Inject floeterm paths (after user's rc to take priority).
+if [ -n "$` + pathPrependEnvKey + `" ]; then
+    export PATH="$` + pathPrependEnvKey + `:$PATH"
+fi
+
+# Sentinel for ProbeShell diagnostics.
+__floeterm_shell_init_sourced=1
+`
+}
+
+func elvishInitScript() string {
+	return `# floeterm shell integration - auto-generated, do not edit.
+use path
+use str
+
+# Source user's original elvish configuration (-rc replaces it entirely).
+var user-rc = $E:HOME/.config/elvish/rc.elv
+if (path:is-regular &follow-symlink=$true $user-rc) {
+    eval (slurp < $user-rc)
+}
+
+# Inject floeterm paths (after user's rc to take priority).
+if (has-env ` + pathPrependEnvKey + `) {
+    set paths = [(str:split : $E:` + pathPrependEnvKey + `) $@paths]
+}
 `
 }
 
+func xonshInitScript() string {
+	return `# floeterm shell integration - auto-generated, do not edit.
+import os as _floeterm_os
+
+# Source user's original xonsh configuration (--rc replaces it entirely).
+_floeterm_user_rc = _floeterm_os.path.expanduser('~/.xonshrc')
+if _floeterm_os.path.isfile(_floeterm_user_rc):
+    source @(_floeterm_user_rc)
+
+# Inject floeterm paths (after user's rc to take priority).
+if ${...}.get('` + pathPrependEnvKey + `'):
+    for _floeterm_path in reversed($` + pathPrependEnvKey + `.split(':')):
+        $PATH.insert(0, _floeterm_path)
+`
+}
+
+// commandLifecycleWithPromptMarkers injects OSC 133 prompt-marker emissions
+// into a generated command lifecycle script. The markers reuse the lifecycle
+// hook points: B/C at command start, D with the exit code and A at the prompt.
+func commandLifecycleWithPromptMarkers(script string, enablePromptMarkers bool, fish bool) string {
+	if !enablePromptMarkers {
+		return script
+	}
+
+	helper := `
+__floeterm_terminal_osc133() {
+    printf '\033]133;%s\a' "$1"
+}
+`
+	if fish {
+		helper = `
+function __floeterm_terminal_osc133 --argument payload
+    printf '\e]133;%s\a' $payload
+end
+`
+	}
+
+	replacements := [][2]string{
+		{`__floeterm_terminal_osc "B"`, `__floeterm_terminal_osc "B"` + "\n" + `        __floeterm_terminal_osc133 "B"`},
+		{`__floeterm_terminal_osc "C"`, `__floeterm_terminal_osc "C"` + "\n" + `        __floeterm_terminal_osc133 "C"`},
+		{`__floeterm_terminal_osc "D;$exit_code"`, `__floeterm_terminal_osc "D;$exit_code"` + "\n" + `        __floeterm_terminal_osc133 "D;$exit_code"`},
+		{`__floeterm_terminal_osc "A"`, `__floeterm_terminal_osc "A"` + "\n" + `    __floeterm_terminal_osc133 "A"`},
+	}
+	if fish {
+		replacements = [][2]string{
+			{`__floeterm_terminal_osc B`, `__floeterm_terminal_osc B` + "\n" + `    __floeterm_terminal_osc133 B`},
+			{`__floeterm_terminal_osc C`, `__floeterm_terminal_osc C` + "\n" + `    __floeterm_terminal_osc133 C`},
+			{`__floeterm_terminal_osc "D;$status"`, `__floeterm_terminal_osc "D;$status"` + "\n" + `        __floeterm_terminal_osc133 "D;$status"`},
+			{`__floeterm_terminal_osc A`, `__floeterm_terminal_osc A` + "\n" + `        __floeterm_terminal_osc133 A`},
+		}
+	}
+	for _, replacement := range replacements {
+		script = strings.Replace(script, replacement[0], replacement[1], 1)
+	}
+	return helper + script
+}
+
 func bashCommandLifecycleScript() string {
 	return `
 # Emit OSC 633 command lifecycle and working-directory markers.
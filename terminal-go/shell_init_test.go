@@ -141,6 +141,136 @@ func TestDefaultShellInitWriterAndArgsProvider(t *testing.T) {
 	}
 }
 
+func TestKshVariantsUseGeneratedENVScript(t *testing.T) {
+	for _, shell := range []string{"/bin/ksh", "/usr/bin/ksh93", "/usr/bin/mksh"} {
+		if got := detectShellType(shell); got != shellTypeKsh {
+			t.Fatalf("detectShellType(%q) = %q, want ksh", shell, got)
+		}
+	}
+
+	baseDir := t.TempDir()
+	writer := DefaultShellInitWriter{BaseDir: baseDir}
+	if err := writer.EnsureShellInitFiles("/example/prepend"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	paths := newShellInitPaths(baseDir)
+	content, err := os.ReadFile(paths.KshRC())
+	if err != nil {
+		t.Fatalf("expected ksh init file to exist: %v", err)
+	}
+	for _, needle := range []string{pathPrependEnvKey, ".kshrc", ".mkshrc"} {
+		if !strings.Contains(string(content), needle) {
+			t.Fatalf("ksh init file missing %q", needle)
+		}
+	}
+
+	provider := DefaultShellArgsProvider{ShellInitBaseDir: baseDir}
+	args, env := provider.GetShellArgs("/usr/bin/mksh", "/tmp/prepend")
+	if args == nil || len(args) != 0 {
+		t.Fatalf("expected non-nil empty args for ksh, got %v", args)
+	}
+	if !contains(env, "ENV="+paths.KshRC()) {
+		t.Fatalf("expected ENV in env, got %v", env)
+	}
+}
+
+func TestElvishUsesGeneratedRCLaunchArgs(t *testing.T) {
+	if got := detectShellType("/usr/bin/elvish"); got != shellTypeElvish {
+		t.Fatalf("detectShellType(elvish) = %q, want elvish", got)
+	}
+
+	baseDir := t.TempDir()
+	writer := DefaultShellInitWriter{BaseDir: baseDir}
+	if err := writer.EnsureShellInitFiles("/example/prepend"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	paths := newShellInitPaths(baseDir)
+	content, err := os.ReadFile(paths.ElvishRC())
+	if err != nil {
+		t.Fatalf("expected elvish init file to exist: %v", err)
+	}
+	for _, needle := range []string{pathPrependEnvKey, "rc.elv", "set paths"} {
+		if !strings.Contains(string(content), needle) {
+			t.Fatalf("elvish init file missing %q", needle)
+		}
+	}
+
+	provider := DefaultShellArgsProvider{ShellInitBaseDir: baseDir}
+	args, env := provider.GetShellArgs("/usr/bin/elvish", "/tmp/prepend")
+	if len(args) != 2 || args[0] != "-rc" || args[1] != paths.ElvishRC() {
+		t.Fatalf("unexpected elvish args: %v", args)
+	}
+	if !contains(env, pathPrependEnvKey+"=/tmp/prepend") {
+		t.Fatalf("expected %s in env, got %v", pathPrependEnvKey, env)
+	}
+}
+
+func TestXonshUsesGeneratedRCLaunchArgs(t *testing.T) {
+	if got := detectShellType("/usr/bin/xonsh"); got != shellTypeXonsh {
+		t.Fatalf("detectShellType(xonsh) = %q, want xonsh", got)
+	}
+
+	baseDir := t.TempDir()
+	writer := DefaultShellInitWriter{BaseDir: baseDir}
+	if err := writer.EnsureShellInitFiles("/example/prepend"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	paths := newShellInitPaths(baseDir)
+	content, err := os.ReadFile(paths.XonshRC())
+	if err != nil {
+		t.Fatalf("expected xonsh init file to exist: %v", err)
+	}
+	for _, needle := range []string{pathPrependEnvKey, ".xonshrc", "$PATH.insert"} {
+		if !strings.Contains(string(content), needle) {
+			t.Fatalf("xonsh init file missing %q", needle)
+		}
+	}
+
+	provider := DefaultShellArgsProvider{ShellInitBaseDir: baseDir}
+	args, env := provider.GetShellArgs("/usr/bin/xonsh", "/tmp/prepend")
+	if len(args) != 2 || args[0] != "--rc" || args[1] != paths.XonshRC() {
+		t.Fatalf("unexpected xonsh args: %v", args)
+	}
+	if !contains(env, pathPrependEnvKey+"=/tmp/prepend") {
+		t.Fatalf("expected %s in env, got %v", pathPrependEnvKey, env)
+	}
+}
+
+func TestShellInitWriterCustomizeHookAppendsContent(t *testing.T) {
+	baseDir := t.TempDir()
+	var shells []string
+	writer := DefaultShellInitWriter{
+		BaseDir: baseDir,
+		Customize: func(shell string, content string) string {
+			shells = append(shells, shell)
+			return content + "\n# org-wide extras for " + shell + "\n"
+		},
+	}
+	if err := writer.EnsureShellInitFiles("/example/prepend"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	paths := newShellInitPaths(baseDir)
+	content, err := os.ReadFile(paths.BashRC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "# org-wide extras for bash") {
+		t.Fatalf("customized bash content missing hook output: %q", content)
+	}
+	if !strings.Contains(string(content), pathPrependEnvKey) {
+		t.Fatal("customizer must receive the generated script, not replace it")
+	}
+	for _, shell := range []string{"bash", "zsh", "fish", "posix", "ksh", "elvish", "xonsh"} {
+		if !contains(shells, shell) {
+			t.Fatalf("customizer not invoked for %q (got %v)", shell, shells)
+		}
+	}
+}
+
 func TestDefaultShellIntegrationCanEnableCommandLifecycleWithoutPathPrepend(t *testing.T) {
 	baseDir := t.TempDir()
 	writer := DefaultShellInitWriter{BaseDir: baseDir, EnableCommandLifecycle: true}
@@ -172,6 +302,50 @@ func TestDefaultShellIntegrationCanEnableCommandLifecycleWithoutPathPrepend(t *t
 	}
 }
 
+func TestPromptMarkersInstallOSC133Hooks(t *testing.T) {
+	baseDir := t.TempDir()
+	writer := DefaultShellInitWriter{BaseDir: baseDir, EnablePromptMarkers: true}
+	if !writer.ShouldEnsureShellInit("") {
+		t.Fatal("prompt markers must force shell init generation")
+	}
+	if err := writer.EnsureShellInitFiles(""); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	paths := newShellInitPaths(baseDir)
+	for _, path := range []string{paths.BashRC(), paths.ZshRC(), paths.FishConfig()} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if !strings.Contains(string(content), "]133;") {
+			t.Fatalf("%s missing OSC 133 emitter", path)
+		}
+		// Helper definition plus one emission for each of A, B, C and D.
+		if got := strings.Count(string(content), "__floeterm_terminal_osc133"); got < 5 {
+			t.Fatalf("%s has %d osc133 references, want at least 5", path, got)
+		}
+	}
+
+	provider := DefaultShellArgsProvider{ShellInitBaseDir: baseDir, EnablePromptMarkers: true}
+	args, _ := provider.GetShellArgs("/bin/bash", "")
+	if len(args) != 2 || args[0] != "--rcfile" {
+		t.Fatalf("prompt markers must route bash through the generated rc, got %v", args)
+	}
+}
+
+func TestManagerConfigEnablePromptMarkersPropagatesToDefaults(t *testing.T) {
+	cfg := ManagerConfig{EnablePromptMarkers: true}.applyDefaults()
+	writer, ok := cfg.ShellInitWriter.(DefaultShellInitWriter)
+	if !ok || !writer.EnablePromptMarkers {
+		t.Fatalf("writer = %#v", cfg.ShellInitWriter)
+	}
+	provider, ok := cfg.ShellArgsProvider.(DefaultShellArgsProvider)
+	if !ok || !provider.EnablePromptMarkers {
+		t.Fatalf("provider = %#v", cfg.ShellArgsProvider)
+	}
+}
+
 func TestBashCommandLifecyclePublishesExecutedProgramAndFinalPromptState(t *testing.T) {
 	script := bashCommandLifecycleScript()
 
@@ -591,3 +765,110 @@ func contains(items []string, needle string) bool {
 	}
 	return false
 }
+
+func TestEnsureShellInitFilesSkipsRewriteWhenUnchanged(t *testing.T) {
+	baseDir := t.TempDir()
+	writer := DefaultShellInitWriter{BaseDir: baseDir}
+
+	if err := writer.EnsureShellInitFiles("/custom/bin"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	rcFile := newShellInitPaths(baseDir).BashRC()
+	content, err := os.ReadFile(rcFile)
+	if err != nil {
+		t.Fatalf("failed to read bashrc: %v", err)
+	}
+	if !strings.Contains(string(content), shellInitVersionPrefix) {
+		t.Fatalf("expected version marker in generated file")
+	}
+	before, err := os.Stat(rcFile)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+
+	// A second run with the same template must not touch the file.
+	time.Sleep(10 * time.Millisecond)
+	if err := writer.EnsureShellInitFiles("/custom/bin"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+	after, err := os.Stat(rcFile)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Fatalf("expected unchanged file to keep its mtime")
+	}
+
+	// Changing the template must rewrite the file.
+	changed := DefaultShellInitWriter{BaseDir: baseDir, EnableCommandLifecycle: true}
+	if err := changed.EnsureShellInitFiles("/custom/bin"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+	rewritten, err := os.ReadFile(rcFile)
+	if err != nil {
+		t.Fatalf("failed to read bashrc: %v", err)
+	}
+	if string(rewritten) == string(content) {
+		t.Fatalf("expected changed template to rewrite the file")
+	}
+
+	// No temp files may be left behind.
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Fatalf("leftover temp file: %s", entry.Name())
+		}
+	}
+}
+
+func TestCleanupRemovesGeneratedFilesButKeepsForeignOnes(t *testing.T) {
+	baseDir := t.TempDir()
+	writer := DefaultShellInitWriter{BaseDir: baseDir}
+	if err := writer.EnsureShellInitFiles("/custom/bin"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+
+	// A user file without the version marker must survive cleanup.
+	foreign := filepath.Join(baseDir, "bashrc")
+	if err := os.WriteFile(foreign, []byte("# hand edited\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if err := writer.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	if _, err := os.Stat(foreign); err != nil {
+		t.Fatalf("expected foreign bashrc to survive cleanup: %v", err)
+	}
+	paths := newShellInitPaths(baseDir)
+	for _, path := range []string{paths.ZshRC(), paths.FishConfig(), paths.PosixRC()} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed", path)
+		}
+	}
+	if _, err := os.Stat(paths.ZshDir()); !os.IsNotExist(err) {
+		t.Fatalf("expected empty zsh dir to be removed")
+	}
+}
+
+func TestManagerCleanupShellInitUsesWriter(t *testing.T) {
+	baseDir := t.TempDir()
+	manager := NewManager(ManagerConfig{
+		Logger:          NopLogger{},
+		ShellInitWriter: DefaultShellInitWriter{BaseDir: baseDir},
+	})
+	if err := (DefaultShellInitWriter{BaseDir: baseDir}).EnsureShellInitFiles("/custom/bin"); err != nil {
+		t.Fatalf("EnsureShellInitFiles failed: %v", err)
+	}
+	if err := manager.CleanupShellInit(); err != nil {
+		t.Fatalf("CleanupShellInit failed: %v", err)
+	}
+	if _, err := os.Stat(baseDir); !os.IsNotExist(err) {
+		t.Fatalf("expected base dir to be removed after cleanup")
+	}
+}
@@ -216,7 +216,7 @@ func (s *Session) checkShellIntegrationChange(chunk []byte) {
 	s.mu.Unlock()
 
 	for _, source := range malformed {
-		s.config.logger.Debug("Discarded malformed shell integration sequence", "sessionID", s.ID, "source", source)
+		s.log().Debug("Discarded malformed shell integration sequence", "source", source)
 	}
 	for _, token := range tokens {
 		if token.kind == shellIntegrationDisplay {
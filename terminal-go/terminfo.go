@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	detectedTerminfoOnce sync.Once
+	detectedTerminfoDir  string
+)
+
+// detectTerminfoDir locates a terminfo database that actually describes the
+// given TERM, instead of assuming /usr/share/terminfo exists. On hosts where
+// no database can be found it returns "" so TERMINFO is left unset and the
+// shell's curses library uses its own compiled-in search path. The result is
+// cached for the process lifetime.
+func detectTerminfoDir(term string) string {
+	detectedTerminfoOnce.Do(func() {
+		detectedTerminfoDir = detectTerminfoDirUncached(term, os.Getenv)
+	})
+	return detectedTerminfoDir
+}
+
+func detectTerminfoDirUncached(term string, getenv func(string) string) string {
+	candidates := terminfoCandidateDirs(getenv)
+
+	for _, dir := range candidates {
+		if terminfoDirHasEntry(dir, term) {
+			return dir
+		}
+	}
+	// No database describes TERM; settle for any existing database so basic
+	// entries still resolve.
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+// terminfoCandidateDirs lists terminfo locations in search priority order:
+// explicit env overrides, the user database, then distro-specific paths
+// (Debian, Nix, Alpine/musl, BSD) and the classic /usr/share location.
+func terminfoCandidateDirs(getenv func(string) string) []string {
+	dirs := make([]string, 0, 12)
+	if dir := getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range strings.Split(getenv("TERMINFO_DIRS"), ":") {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	return append(dirs,
+		"/etc/terminfo",
+		"/lib/terminfo",
+		"/usr/share/terminfo",
+		"/usr/lib/terminfo",
+		"/usr/local/share/terminfo",
+		"/run/current-system/sw/share/terminfo",
+		"/usr/share/misc/terminfo",
+	)
+}
+
+// terminfoDirHasEntry reports whether dir contains a compiled entry for term,
+// checking both the single-letter layout used by ncurses and the hex layout
+// used on macOS.
+func terminfoDirHasEntry(dir string, term string) bool {
+	if dir == "" || term == "" {
+		return false
+	}
+	letterPath := filepath.Join(dir, term[:1], term)
+	if _, err := os.Stat(letterPath); err == nil {
+		return true
+	}
+	hexPath := filepath.Join(dir, fmt.Sprintf("%02x", term[0]), term)
+	if _, err := os.Stat(hexPath); err == nil {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTerminfoEntry(t *testing.T, dir string, term string) {
+	t.Helper()
+	entryDir := filepath.Join(dir, term[:1])
+	if err := os.MkdirAll(entryDir, 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(entryDir, term), []byte{0x1a, 0x01}, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestDetectTerminfoDirPrefersEnvOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeTerminfoEntry(t, dir, "xterm-256color")
+
+	getenv := func(key string) string {
+		if key == "TERMINFO" {
+			return dir
+		}
+		return ""
+	}
+	if got := detectTerminfoDirUncached("xterm-256color", getenv); got != dir {
+		t.Fatalf("detected %q, want env override %q", got, dir)
+	}
+}
+
+func TestDetectTerminfoDirSkipsDirsWithoutEntry(t *testing.T) {
+	empty := t.TempDir()
+	withEntry := t.TempDir()
+	writeTerminfoEntry(t, withEntry, "xterm-256color")
+
+	getenv := func(key string) string {
+		if key == "TERMINFO_DIRS" {
+			return empty + ":" + withEntry
+		}
+		return ""
+	}
+	if got := detectTerminfoDirUncached("xterm-256color", getenv); got != withEntry {
+		t.Fatalf("detected %q, want %q", got, withEntry)
+	}
+}
+
+func TestDetectTerminfoDirFallsBackToExistingDir(t *testing.T) {
+	empty := t.TempDir()
+	getenv := func(key string) string {
+		if key == "TERMINFO" {
+			return empty
+		}
+		return ""
+	}
+	got := detectTerminfoDirUncached("no-such-term", getenv)
+	if got != empty {
+		t.Fatalf("detected %q, want existing dir %q", got, empty)
+	}
+}
@@ -50,9 +50,15 @@ type TerminalSessionInfo struct {
 	ID                string
 	Name              string
 	WorkingDir        string
+	Owner             string
+	Tags              []string
+	Notes             string
+	NameLocked        bool
+	ResizePolicy      string
 	CreatedAt         int64
 	LastActive        int64
 	IsActive          bool
+	LastExit          *TerminalExitInfo
 	ForegroundCommand TerminalForegroundCommandInfo
 	OutputActivity    TerminalOutputActivityInfo
 }
@@ -93,6 +99,28 @@ type TerminalOutputActivityInfo struct {
 	UpdatedAt int64
 }
 
+// TerminalExitReason classifies why a session's PTY process stopped.
+type TerminalExitReason string
+
+const (
+	// ExitReasonExited means the process ended on its own.
+	ExitReasonExited TerminalExitReason = "exited"
+	// ExitReasonKilled means the session was closed or deleted by a caller.
+	ExitReasonKilled TerminalExitReason = "killed"
+	// ExitReasonServerShutdown means the manager was cleaned up as a whole.
+	ExitReasonServerShutdown TerminalExitReason = "server-shutdown"
+)
+
+// TerminalExitInfo records the most recent PTY process exit.
+type TerminalExitInfo struct {
+	Reason TerminalExitReason
+	// ExitCode is the process exit status; -1 when the process was terminated
+	// by a signal or the status is unknown.
+	ExitCode int
+	// ExitedAt is the exit time in Unix milliseconds.
+	ExitedAt int64
+}
+
 // ManagerDiagnostics reports terminal history memory without imposing a
 // session-count limit or changing session lifecycle behavior.
 type ManagerDiagnostics struct {
@@ -145,13 +173,30 @@ type TerminalOutputEvent struct {
 	Data        []byte
 	Sequence    int64
 	TimestampMs int64
-	Geometry    TerminalGeometry
+	// EchoSourceConnID names the connection whose input most plausibly
+	// produced this output (written within the echo attribution window).
+	// Subscribers doing local echo can skip their own reflections; empty
+	// means the output was not attributed to any connection.
+	EchoSourceConnID string
+	Geometry         TerminalGeometry
+}
+
+// TerminalPresenceEvent reports a connection joining or leaving a session's
+// live view, so collaborating clients can show who else is attached.
+type TerminalPresenceEvent struct {
+	ConnID string
+	Joined bool
+	// ConnectionCount is the number of live attachments after the change.
+	ConnectionCount int
 }
 
 // LiveSubscriber receives exact output for one attached connection.
+// OnPresence is optional; the other output-path callbacks report delivery
+// with their return value.
 type LiveSubscriber struct {
 	OnOutput        func(TerminalOutputEvent) bool
 	OnGeometry      func(TerminalGeometry) bool
+	OnPresence      func(TerminalPresenceEvent) bool
 	OnSessionClosed func()
 	OnSuperseded    func()
 }
@@ -214,17 +259,47 @@ type Session struct {
 	ID         string
 	Name       string
 	WorkingDir string
+	// Owner is the identity that created the session; empty means unowned.
+	// Immutable after creation.
+	Owner string
+	// Tags and Notes are caller-managed labels with no behavioral effect.
+	Tags       []string
+	Notes      string
 	CreatedAt  time.Time
 	LastActive time.Time
 	PTY        *os.File
 	Cmd        *exec.Cmd
 
+	// requestedPathPrepend is a caller-supplied PATH prefix applied ahead of
+	// any prepend computed by the EnvProvider. Immutable after creation.
+	requestedPathPrepend string
+	// requestedShell, requestedShellArgs and requestedExtraEnv are
+	// per-session launch overrides (see CreateSessionOptions). Immutable
+	// after creation.
+	requestedShell     string
+	requestedShellArgs []string
+	requestedExtraEnv  []string
+	// requestedSandbox overrides the manager-wide sandbox profile for this
+	// session. Immutable after creation.
+	requestedSandbox *SandboxProfile
+	// requestedRunAsUser launches the session as this local account (see
+	// CreateSessionOptions.RunAsUser). Immutable after creation.
+	requestedRunAsUser string
+
 	isActive bool
 	closed   bool
 	cleaned  bool
-	mu       sync.RWMutex
-	ctx      context.Context
-	cancel   context.CancelFunc
+	// nameLocked pins the display name against automatic renames that
+	// normally follow working-directory changes.
+	nameLocked bool
+	// closeReason is set before the PTY process is signalled during cleanup
+	// so the exit observer can distinguish caller-initiated terminations
+	// from natural exits.
+	closeReason TerminalExitReason
+	lastExit    *TerminalExitInfo
+	mu          sync.RWMutex
+	ctx         context.Context
+	cancel      context.CancelFunc
 
 	connections     map[string]*ConnectionInfo
 	ringBuffer      *TerminalRingBuffer
@@ -235,6 +310,11 @@ type Session struct {
 	historyGeneration    int64
 	historyStartSequence int64
 
+	// Cumulative PTY byte counts over the session's lifetime, independent of
+	// what the ring buffer currently retains.
+	inputBytes  int64
+	outputBytes int64
+
 	currentWorkingDir             string
 	workdirPending                []byte
 	shellIntegrationPending       []byte
@@ -255,6 +335,23 @@ type Session struct {
 	resizeQueued       bool
 	resizeRunning      bool
 	resizeReason       string
+	// resizePolicy selects how connection viewports map onto the shared PTY
+	// size; the zero value keeps smallest-view-wins behavior.
+	resizePolicy     ResizePolicy
+	lastWriterConnID string
+	// lastInputConnID/lastInputTime attribute prompt output that follows an
+	// input write, so subscribers can suppress the echo of their own typing.
+	lastInputConnID string
+	lastInputTime   time.Time
+
+	// transferDetector watches output for file-transfer handshakes;
+	// fileTransfer holds the resulting state. While a transfer is active the
+	// binary payload is withheld from the ring buffer; transferLastData
+	// timestamps the most recent withheld chunk so a stalled or falsely
+	// detected transfer ends on its own.
+	transferDetector transferDetector
+	fileTransfer     FileTransferInfo
+	transferLastData time.Time
 
 	eventHandler TerminalEventHandler
 
@@ -266,6 +363,18 @@ type Session struct {
 	onExit func(sessionID string)
 
 	config sessionConfig
+	// logger is the session-scoped child logger carrying the correlation
+	// fields (sessionID, owner) on every entry; use log() to read it.
+	logger Logger
+}
+
+// log returns the session's child logger. Sessions assembled without one
+// (tests) fall back to the shared config logger.
+func (s *Session) log() Logger {
+	if s.logger != nil {
+		return s.logger
+	}
+	return s.config.logger
 }
 
 // Manager manages multiple sessions.
@@ -275,5 +384,15 @@ type Manager struct {
 	mu           sync.RWMutex
 
 	eventHandler TerminalEventHandler
-	config       ManagerConfig
+	// userHandler is the handler passed to SetEventHandler; eventHandler is
+	// either it directly or an eventTee combining it with typed
+	// subscriptions once Events() has been called.
+	userHandler TerminalEventHandler
+	events      *ManagerEvents
+	config      ManagerConfig
+
+	// reaperStop terminates the idle-session reaper; nil when
+	// SessionIdleTimeout is unset.
+	reaperStop     chan struct{}
+	reaperStopOnce sync.Once
 }
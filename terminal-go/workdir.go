@@ -34,7 +34,7 @@ func (s *Session) shouldCheckDirectoryChange(output string) bool {
 func (s *Session) checkWorkingDirectoryChange(chunk []byte) {
 	signals, malformedSources := s.consumeWorkingDirectorySignals(chunk)
 	for _, source := range malformedSources {
-		s.config.logger.Debug("Discarded malformed working directory sequence", "sessionID", s.ID, "source", source)
+		s.log().Debug("Discarded malformed working directory sequence", "source", source)
 	}
 	for _, signal := range signals {
 		s.applyWorkingDirectoryChange(signal.path)
@@ -283,7 +283,7 @@ func (s *Session) applyWorkingDirectoryChange(currentDir string) {
 		return
 	}
 	oldDir := s.currentWorkingDir
-	shouldRename := newName != s.Name
+	shouldRename := newName != s.Name && !s.nameLocked
 	if currentDir == oldDir {
 		s.mu.Unlock()
 		return
@@ -292,7 +292,7 @@ func (s *Session) applyWorkingDirectoryChange(currentDir string) {
 	s.WorkingDir = currentDir
 	s.mu.Unlock()
 
-	s.config.logger.Info("Working directory changed", "sessionID", s.ID, "from", filepath.Base(oldDir), "to", filepath.Base(currentDir))
+	s.log().Info("Working directory changed", "from", filepath.Base(oldDir), "to", filepath.Base(currentDir))
 
 	if shouldRename {
 		s.onSessionNameChange(newName, currentDir)
@@ -310,7 +310,7 @@ func (s *Session) onSessionNameChange(newName, workingDir string) {
 	s.Name = newName
 	s.mu.Unlock()
 
-	s.config.logger.Info("Updated session name", "sessionID", sessionID, "oldName", oldName, "newName", newName)
+	s.log().Info("Updated session name", "oldName", oldName, "newName", newName)
 
 	// Never call external handlers while holding locks.
 	if handler != nil {